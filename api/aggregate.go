@@ -0,0 +1,276 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/rollup"
+	"github.com/mainflux/mainflux-mongodb-reader/units"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	// AggregateMaxScan caps the number of messages a single aggregation
+	// reads from each collection. Zero removes the cap.
+	AggregateMaxScan = 1000000
+	// AggregateMaxBuckets caps the number of buckets of an aggregation,
+	// which bounds the memory its $group stage needs.
+	AggregateMaxBuckets = 10000
+
+	errInterval       = errors.New("interval must be a positive number of seconds")
+	errFunction       = errors.New("fn must be one of avg, min, max, sum or count")
+	errTooManyBuckets = errors.New("too many buckets, use a longer interval or a shorter time range")
+
+	aggregateFns = map[string]bool{"avg": true, "min": true, "max": true, "sum": true, "count": true}
+)
+
+type (
+	bucket struct {
+		Time  float64 `json:"time" bson:"_id"`
+		Value float64 `json:"value"`
+		Count int     `json:"count" bson:"count"`
+		Sum   float64 `json:"-" bson:"sum"`
+		Min   float64 `json:"-" bson:"min"`
+		Max   float64 `json:"-" bson:"max"`
+	}
+
+	aggregation struct {
+		Channel   string   `json:"channel"`
+		Interval  float64  `json:"interval"`
+		Fn        string   `json:"fn"`
+		Truncated bool     `json:"truncated"`
+		Buckets   []bucket `json:"buckets"`
+	}
+)
+
+type byTime []bucket
+
+func (s byTime) Len() int           { return len(s) }
+func (s byTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byTime) Less(i, j int) bool { return s[i].Time < s[j].Time }
+
+// getAggregate function reduces numeric values of the channel messages to
+// fixed time buckets. Parameters, besides the time range of getMessage,
+// which by default spans the last AggregateMaxBuckets intervals:
+// - interval = bucket length in seconds, an hour by default.
+// - fn = avg (default), min, max, sum or count.
+// - name = only messages with this name.
+// Ranges starting on a whole minute, hour or day, in buckets of whole
+// minutes, hours or days, are aggregated from rollup summaries as far as
+// those are complete.
+func getAggregate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	cid := bone.GetValue(r, "channel_id")
+
+	if err := Db.FindChannel(cid); err != nil {
+		channelError(w, r, cid, err)
+		return
+	}
+
+	st, et, err := timeRange(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	q := r.URL.Query()
+	agg := aggregation{Channel: cid, Interval: 3600, Fn: "avg"}
+	if s := q.Get("interval"); s != "" {
+		if agg.Interval, err = strconv.ParseFloat(s, 64); err != nil || agg.Interval <= 0 {
+			err = errInterval
+		}
+	}
+	if s := q.Get("fn"); s != "" {
+		if agg.Fn = s; !aggregateFns[s] {
+			err = errFunction
+		}
+	}
+	if err == nil && AggregateMaxBuckets > 0 && q.Get("start_time") == "" {
+		// Aligned on the interval, so that rollups can serve the range
+		st = math.Max(math.Ceil(et/agg.Interval-float64(AggregateMaxBuckets))*agg.Interval, 0)
+	}
+	if err == nil && AggregateMaxBuckets > 0 && (et-st)/agg.Interval > float64(AggregateMaxBuckets) {
+		err = errTooManyBuckets
+	}
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	name := q.Get("name")
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	// Values of different units don't add up
+	if units.Enabled {
+		mixed, err := mixedUnits(ctx, Db, cid, name, st, et)
+		if db.IsTimeout(err) {
+			timedOut(w, r)
+			return
+		}
+		if err != nil {
+			logger(r).Error(err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to read units", map[string]interface{}{"id": cid})
+			return
+		}
+		if mixed != nil {
+			writeError(w, r, http.StatusUnprocessableEntity, CodeInvalidFilter, "values of different units can't be aggregated, select a name", map[string]interface{}{"units": mixed})
+			return
+		}
+	}
+
+	err = Db.Read(ctx, func() error {
+		merged := map[float64]*bucket{}
+		match := bson.M{"channel": cid, "time": bson.M{"$gt": st, "$lt": et}, "value": bson.M{"$exists": true}}
+		if name != "" {
+			match["name"] = name
+		}
+
+		// Complete summaries serve the start of the range, and messages
+		// the rest of it.
+		res, until, ok, err := rollup.Plan(&Db, agg.Interval, st)
+		if err != nil {
+			return err
+		}
+		if ok && (until < et || math.Mod(et, res.Seconds) == 0) {
+			until = math.Min(until, et)
+			part := []bucket{}
+			if err := Db.Aggregate(ctx, res.Collection(), rollup.Pipeline(cid, name, st, until, agg.Interval)).All(&part); err != nil {
+				return err
+			}
+			for i := range part {
+				mergeBucket(merged, part[i])
+			}
+			if until == et {
+				agg.Buckets = collect(merged)
+				return nil
+			}
+			match["time"] = bson.M{"$gte": until, "$lt": et}
+		}
+
+		// Scans cut short keep the earliest messages, so that truncated
+		// results are the same whatever order documents are stored in
+		pipeline := []bson.M{{"$match": match}}
+		if AggregateMaxScan > 0 {
+			pipeline = append(pipeline, bson.M{"$sort": bson.M{"time": 1}}, bson.M{"$limit": AggregateMaxScan})
+		}
+		pipeline = append(pipeline, bson.M{"$group": bson.M{
+			"_id":   bson.M{"$subtract": []interface{}{"$time", bson.M{"$mod": []interface{}{"$time", agg.Interval}}}},
+			"count": bson.M{"$sum": 1},
+			"sum":   bson.M{"$sum": "$value"},
+			"min":   bson.M{"$min": "$value"},
+			"max":   bson.M{"$max": "$value"},
+		}})
+
+		collections, err := Db.MessageCollections(cid, st, et)
+		if err != nil {
+			return err
+		}
+
+		agg.Truncated = false
+		for _, c := range collections {
+			part := []bucket{}
+			if err := Db.Aggregate(ctx, c, pipeline).All(&part); err != nil {
+				return err
+			}
+
+			scanned := 0
+			for i := range part {
+				scanned += part[i].Count
+				mergeBucket(merged, part[i])
+			}
+			if AggregateMaxScan > 0 && scanned >= AggregateMaxScan {
+				agg.Truncated = true
+			}
+		}
+
+		agg.Buckets = collect(merged)
+		return nil
+	})
+	if db.IsTimeout(err) {
+		timedOut(w, r)
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to aggregate messages", map[string]interface{}{"id": cid})
+		return
+	}
+
+	sort.Sort(byTime(agg.Buckets))
+	for i := range agg.Buckets {
+		agg.Buckets[i].Value = bucketValue(agg.Buckets[i], agg.Fn)
+	}
+
+	res, err := json.Marshal(agg)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// mergeBucket combines partial results of the same bucket computed on
+// different collections
+func mergeBucket(merged map[float64]*bucket, b bucket) {
+	m, ok := merged[b.Time]
+	if !ok {
+		merged[b.Time] = &b
+		return
+	}
+
+	m.Count += b.Count
+	m.Sum += b.Sum
+	if b.Min < m.Min {
+		m.Min = b.Min
+	}
+	if b.Max > m.Max {
+		m.Max = b.Max
+	}
+}
+
+// collect function lists merged buckets
+func collect(merged map[float64]*bucket) []bucket {
+	buckets := []bucket{}
+	for _, b := range merged {
+		buckets = append(buckets, *b)
+	}
+	return buckets
+}
+
+func bucketValue(b bucket, fn string) float64 {
+	switch fn {
+	case "min":
+		return b.Min
+	case "max":
+		return b.Max
+	case "sum":
+		return b.Sum
+	case "count":
+		return float64(b.Count)
+	}
+	return b.Sum / float64(b.Count)
+}
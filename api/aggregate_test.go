@@ -0,0 +1,49 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestGetAggregate(t *testing.T) {
+	cases := []struct {
+		query string
+		body  string
+		code  int
+	}{
+		{"", `{"code":"not_found","message":"Channel not found","details":{"id":"unknown"},"response":"Channel not found","id":"unknown"}`, 404},
+		{"?fn=median", `{"code":"invalid_filter","message":"invalid query parameters: fn must be one of avg, min, max, sum, count","details":{"fields":[{"field":"fn","message":"must be one of avg, min, max, sum, count"}]},"response":"invalid query parameters: fn must be one of avg, min, max, sum, count"}`, 400},
+	}
+
+	url := ts.URL + "/channels/unknown/messages/aggregate"
+
+	for i, c := range cases {
+		res, err := http.Get(url + c.query)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if c.body != withoutRequestID(body) {
+			t.Errorf("case %d: expected response %s got %s", i+1, c.body, string(body))
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// APIKeyHeader names the request header carrying an API key.
+const APIKeyHeader = "X-API-Key"
+
+// Scope granting access to every channel.
+const allChannels = "*"
+
+var (
+	keysMu  sync.RWMutex
+	apiKeys = map[string]APIKey{}
+)
+
+// APIKey struct is a service-level key of the local keystore. Only the
+// hex encoded SHA-256 hash of the key is stored. Owner names the owner of
+// the channels the key reads when data is scoped by owner.
+type APIKey struct {
+	ID       string   `json:"id"`
+	Hash     string   `json:"sha256"`
+	Channels []string `json:"channels"`
+	Owner    string   `json:"owner,omitempty"`
+}
+
+// LoadAPIKeys function replaces the API keys by those of the JSON array
+// in file. An empty file name disables API keys.
+func LoadAPIKeys(file string) error {
+	keys := []APIKey{}
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &keys); err != nil {
+			return fmt.Errorf("malformed API keystore: %v", err)
+		}
+	}
+
+	return SetAPIKeys(keys)
+}
+
+// SetAPIKeys function replaces the API keys by keys
+func SetAPIKeys(keys []APIKey) error {
+	m := map[string]APIKey{}
+	for _, k := range keys {
+		h := strings.ToLower(k.Hash)
+		if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size || k.ID == "" {
+			return fmt.Errorf("API key %q must have an id and a SHA-256 hash", k.ID)
+		}
+		if len(k.Channels) == 0 {
+			return fmt.Errorf("API key %q has no channel scope", k.ID)
+		}
+		m[h] = k
+	}
+
+	keysMu.Lock()
+	apiKeys = m
+	keysMu.Unlock()
+
+	return nil
+}
+
+// apiKey function returns the API key r carries, and whether it carries
+// one. A key missing from the keystore is returned with an empty ID.
+func apiKey(r *http.Request) (APIKey, bool) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return APIKey{}, false
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	keysMu.RLock()
+	k := apiKeys[hex.EncodeToString(sum[:])]
+	keysMu.RUnlock()
+
+	return k, true
+}
+
+// allows function reports whether k grants r. Keys only read data: they
+// reach the channels in their scope, and the Grafana and export endpoints
+// with the "*" scope only.
+func (k APIKey) allows(r *http.Request) bool {
+	if k.ID == "" || r.Method == "PUT" || r.Method == "DELETE" {
+		return false
+	}
+
+	ch := channelID(r.URL.Path)
+	for _, c := range k.Channels {
+		if c == allChannels || ch != "" && c == ch {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizeKey function answers 403 to data requests carrying an API key
+// that is unknown or out of its scope. Requests without a key, and those
+// to endpoints serving no stored data, are passed on unchanged.
+func authorizeKey(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	k, ok := apiKey(r)
+	if !ok || !audited(r) || k.allows(r) {
+		next(w, r)
+		return
+	}
+
+	writeError(w, r, http.StatusForbidden, CodeForbidden, "API key not allowed", nil)
+}
@@ -0,0 +1,122 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAPIKeys(t *testing.T) {
+	if err := api.SetAPIKeys([]api.APIKey{{ID: "broken", Hash: "00", Channels: []string{"*"}}}); err == nil {
+		t.Error("expected an error for a malformed hash")
+	}
+	if err := api.SetAPIKeys([]api.APIKey{{ID: "unscoped", Hash: keyHash("k")}}); err == nil {
+		t.Error("expected an error for a key without scope")
+	}
+
+	err := api.SetAPIKeys([]api.APIKey{
+		{ID: "analytics", Hash: keyHash("analytics-key"), Channels: []string{"*"}},
+		{ID: "line-7", Hash: keyHash("line-key"), Channels: []string{"7"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.SetAPIKeys(nil)
+
+	srv := httptest.NewServer(api.HTTPServer())
+	defer srv.Close()
+
+	cases := []struct {
+		method    string
+		path      string
+		key       string
+		forbidden bool
+	}{
+		{"GET", "/channels/7/messages", "line-key", false},
+		{"GET", "/channels/8/messages", "line-key", true},
+		{"GET", "/channels/8/messages", "analytics-key", false},
+		{"GET", "/channels/8/messages", "unknown-key", true},
+		{"DELETE", "/channels/7/messages", "line-key", true},
+		{"POST", "/grafana/query", "line-key", true},
+		{"POST", "/grafana/query", "analytics-key", false},
+		{"GET", "/version", "unknown-key", false},
+	}
+
+	for i, c := range cases {
+		req, err := http.NewRequest(c.method, srv.URL+c.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(api.APIKeyHeader, c.key)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		if forbidden := res.StatusCode == http.StatusForbidden; forbidden != c.forbidden {
+			t.Errorf("case %d: expected forbidden %v got status %d", i+1, c.forbidden, res.StatusCode)
+		}
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	err := api.SetAPIKeys([]api.APIKey{{ID: "line-7", Hash: keyHash("line-key"), Channels: []string{"7"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.SetAPIKeys(nil)
+	api.RequireAuth = true
+	defer func() { api.RequireAuth = false }()
+
+	cases := []struct {
+		path  string
+		key   string
+		token string
+		code  int
+	}{
+		{"/channels/7/messages", "", "", http.StatusUnauthorized},
+		{"/channels/7/messages", "", "random", http.StatusUnauthorized},
+		{"/channels/7/messages", "line-key", "", http.StatusNotFound},
+		{"/version", "", "", http.StatusOK},
+	}
+	for i, c := range cases {
+		req, err := http.NewRequest("GET", ts.URL+c.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.key != "" {
+			req.Header.Set(api.APIKeyHeader, c.key)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoo/bone"
+)
+
+// APIVersion is the version of the data endpoints, served under the /v1
+// prefix.
+const APIVersion = "1"
+
+// VersionHeader names the request header asking for a version of the
+// API, and the response header telling the version served.
+const VersionHeader = "API-Version"
+
+const apiPrefix = "/v" + APIVersion
+
+var (
+	// LegacyPaths keeps serving the data endpoints at their former,
+	// unversioned paths, answering with Deprecation headers.
+	LegacyPaths = true
+	// Sunset is the date announced for the removal of the legacy paths,
+	// omitted if zero.
+	Sunset time.Time
+
+	legacyMu sync.RWMutex
+	// Legacy routes, by method and bone path
+	legacy = map[string]bool{}
+)
+
+// versioned function registers h on mux at the versioned path of method
+// and path, and at path itself while legacy paths are served
+func versioned(mux *bone.Mux, method, path string, h http.Handler) {
+	mux.Register(method, apiPrefix+path, h)
+	if !LegacyPaths {
+		return
+	}
+
+	mux.Register(method, path, deprecated(h))
+	legacyMu.Lock()
+	defer legacyMu.Unlock()
+	legacy[method+" "+path] = true
+}
+
+// isLegacy function reports whether the route of method and path is the
+// alias of a versioned one
+func isLegacy(method, path string) bool {
+	legacyMu.RLock()
+	defer legacyMu.RUnlock()
+	return legacy[method+" "+path]
+}
+
+// deprecated function marks the responses of h, served at a legacy path,
+// as deprecated
+func deprecated(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if !Sunset.IsZero() {
+			w.Header().Set("Sunset", Sunset.UTC().Format(http.TimeFormat))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// negotiateVersion function answers 406 to requests asking for a version
+// of the API other than the one served, and tells the version served to
+// the others
+func negotiateVersion(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if v := r.Header.Get(VersionHeader); v != "" && v != APIVersion {
+		details := map[string]interface{}{"requested": v, "supported": []string{APIVersion}}
+		writeError(w, r, http.StatusNotAcceptable, CodeNotSupported, "unsupported API version", details)
+		return
+	}
+
+	w.Header().Set(VersionHeader, APIVersion)
+	next(w, r)
+}
+
+// unversioned function returns path without its version prefix
+func unversioned(path string) string {
+	if path == apiPrefix || strings.HasPrefix(path, apiPrefix+"/") {
+		return path[len(apiPrefix):]
+	}
+	return path
+}
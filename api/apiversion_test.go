@@ -0,0 +1,72 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+func TestAPIVersion(t *testing.T) {
+	api.Sunset = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { api.Sunset = time.Time{} }()
+
+	cases := []struct {
+		path    string
+		version string
+		code    int
+		headers map[string]string
+	}{
+		{"/v1/version", "", 200, map[string]string{
+			"API-Version": "1",
+			"Deprecation": "",
+			"Sunset":      "",
+		}},
+		{"/v1/version", "1", 200, map[string]string{
+			"API-Version": "1",
+		}},
+		{"/version", "", 200, map[string]string{
+			"API-Version": "1",
+			"Deprecation": "true",
+			"Sunset":      "Fri, 01 Jan 2027 00:00:00 GMT",
+		}},
+		{"/v1/version", "2", 406, map[string]string{
+			"API-Version": "",
+		}},
+		{"/v2/version", "", 404, nil},
+	}
+
+	for i, c := range cases {
+		req, err := http.NewRequest("GET", ts.URL+c.path, nil)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		if c.version != "" {
+			req.Header.Set(api.VersionHeader, c.version)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+		for k, v := range c.headers {
+			if h := res.Header.Get(k); h != v {
+				t.Errorf("case %d: expected %s %q got %q", i+1, k, v, h)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/mainflux/mainflux-mongodb-reader/audit"
+)
+
+// Path prefixes of endpoints reading or removing stored data.
+var auditedPaths = []string{"/channels/", "/grafana/", "/exports"}
+
+// audited function reports whether r accesses stored data
+func audited(r *http.Request) bool {
+	for _, p := range auditedPaths {
+		if strings.HasPrefix(unversioned(r.URL.Path), p) {
+			return true
+		}
+	}
+	return false
+}
+
+// subject function identifies who made r, by the subject of its JWT, the
+// identity of its client certificate, its API key or its token. Tokens
+// are not logged, only a fingerprint telling their holders apart.
+func subject(r *http.Request) string {
+	if s, ok := verifiedSubject(r); ok {
+		return s
+	}
+
+	token := bearer(r)
+	if token == "" {
+		return "anonymous"
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:6])
+}
+
+// auditRequest function records the data access made by r
+func auditRequest(r *http.Request, id string, status, docs int, bytes int64) {
+	if !audit.Enabled() {
+		return
+	}
+
+	filters := map[string]string{}
+	for k, v := range r.URL.Query() {
+		filters[k] = strings.Join(v, ",")
+	}
+
+	audit.Log(audit.Record{
+		RequestID: id,
+		Subject:   subject(r),
+		Admin:     isAdmin(r),
+		Tenant:    tenant(r),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Channel:   channelID(r.URL.Path),
+		Filters:   filters,
+		Status:    status,
+		Documents: docs,
+		Bytes:     bytes,
+	})
+}
@@ -0,0 +1,121 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+var (
+	// AdminToken grants access to administrative endpoints. Administrative
+	// endpoints are disabled while it is empty.
+	AdminToken string
+
+	// AdminRole is the JWT role claim granting the access of the admin
+	// token. JWT roles grant nothing while it is empty.
+	AdminRole = "admin"
+
+	// RequireAuth makes data requests without verified credentials be
+	// answered 401 instead of reaching every channel.
+	RequireAuth bool
+)
+
+// bearer returns the token of the Authorization header, with or without
+// the "Bearer " scheme prefix.
+func bearer(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimPrefix(h, "Bearer ")
+	}
+	return h
+}
+
+// admin returns the identity r authenticates as an administrator with:
+// a client certificate mapped to the admin identity, the admin token or
+// a JWT carrying the admin role.
+func admin(r *http.Request) (string, bool) {
+	if id, ok := certIdentity(r); ok && id == adminIdentity {
+		return adminIdentity, true
+	}
+
+	token := bearer(r)
+	if AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(AdminToken)) == 1 {
+		return adminIdentity, true
+	}
+
+	if JWTVerifier != nil && AdminRole != "" && isJWT(token) {
+		if c, err := JWTVerifier.Verify(token); err == nil && c.Role == AdminRole {
+			return "jwt:" + c.Subject, true
+		}
+	}
+
+	return "", false
+}
+
+// isAdmin reports whether r authenticates as an administrator
+func isAdmin(r *http.Request) bool {
+	_, ok := admin(r)
+	return ok
+}
+
+// authorizeAdmin writes a 403 response and returns false unless r
+// authenticates as an administrator. Administrative requests are always
+// audited.
+func authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if id, ok := admin(r); ok {
+		setAdmin(r, id)
+		return true
+	}
+
+	writeError(w, r, http.StatusForbidden, CodeForbidden, "admin access required", nil)
+	return false
+}
+
+// adminOnly function serves the requests of administrators with h, and
+// answers 403 to the others
+func adminOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeAdmin(w, r) {
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// verifiedSubject function returns who the verified credentials of r
+// authenticate: a JWT or signature the middlewares verified, a client
+// certificate, a known API key or the admin token. It reports false for
+// requests carrying none of these.
+func verifiedSubject(r *http.Request) (string, bool) {
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok && info.subject != "" {
+		return info.subject, true
+	}
+	if id, ok := certIdentity(r); ok {
+		return id, true
+	}
+	if k, ok := apiKey(r); ok && k.ID != "" {
+		return "apikey:" + k.ID, true
+	}
+	return admin(r)
+}
+
+// requireAuth function answers 401 to data requests without verified
+// credentials while RequireAuth is set, signed download links aside
+func requireAuth(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if RequireAuth && audited(r) && !signedDownload(r) {
+		if _, ok := verifiedSubject(r); !ok {
+			writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "credentials required", nil)
+			return
+		}
+	}
+
+	next(w, r)
+}
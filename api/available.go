@@ -0,0 +1,41 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+)
+
+// Paths served while the reader is not connected to MongoDB.
+var offline = map[string]bool{
+	"/status":  true,
+	"/version": true,
+	"/health":  true,
+	"/live":    true,
+	"/ready":   true,
+	"/metrics": true,
+
+	"/swagger.json": true,
+	"/swagger/":     true,
+}
+
+// available function answers 503 to every request but status and health
+// checks, scrapes and the API specification while the reader is not connected to MongoDB yet
+func available(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !db.Connected() && !offline[unversioned(r.URL.Path)] {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Retry-After", "5")
+		writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "database unavailable", nil)
+		return
+	}
+
+	next(w, r)
+}
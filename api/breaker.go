@@ -0,0 +1,102 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/breaker"
+	"github.com/mainflux/mainflux-mongodb-reader/ratelimit"
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.code = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Flush sends buffered data of streaming responses to the client.
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over to WebSocket handlers.
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection cannot be hijacked")
+	}
+	return h.Hijack()
+}
+
+// guard function puts the database-backed handler h behind the circuit
+// breaker of endpoint and the global query slots. Server errors,
+// timeouts and panics count as failures, and calls are rejected with 503 while the
+// breaker is open or when no query slot frees up in time.
+func guard(endpoint string, h http.HandlerFunc) http.Handler {
+	b := breaker.Get(endpoint)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, ok := ratelimit.Enter()
+		if !ok {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("Retry-After", "1")
+			writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "server overloaded", nil)
+			return
+		}
+		defer release()
+
+		if !b.Allow() {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("Retry-After", "5")
+			writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "database unavailable", nil)
+			return
+		}
+
+		// The outcome is recorded even if h panics, which would otherwise
+		// leave a half-open breaker waiting for its probe forever
+		sr := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		done := false
+		defer func() {
+			if !done || sr.code >= http.StatusInternalServerError {
+				b.Failure()
+				return
+			}
+			b.Success()
+		}()
+		h(sr, r)
+		done = true
+	})
+}
+
+// getBreakers function reports the circuit breaker state of each endpoint
+func getBreakers(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	res, err := json.Marshal(breaker.All())
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
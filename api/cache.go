@@ -0,0 +1,115 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/mainflux/mainflux-mongodb-reader/cache"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+)
+
+// Largest response kept in the query cache.
+const maxCachedBody = 1 << 20
+
+// cacheWriter copies the body of a response until it grows too large
+type cacheWriter struct {
+	statusRecorder
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if !cw.overflow {
+		if cw.buf.Len()+len(b) > maxCachedBody {
+			cw.overflow = true
+			cw.buf = bytes.Buffer{}
+		} else {
+			cw.buf.Write(b)
+		}
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// cached function serves repeated queries of h on a channel from the
+// query cache. Successful responses are cached, and redacted responses
+// are cached apart for administrators, as are those of every owner under
+// owner scoping.
+func cached(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Long polls read again once messages are stored, which a
+		// cached response would hide
+		if !cache.Enabled() || r.URL.Query().Get("wait") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		variant := ""
+		if redact.Enabled() && isAdmin(r) {
+			variant = "admin"
+		}
+		// Owners only hit the responses of their own reads
+		if o, ok := scopedOwner(r); ok {
+			variant += "owner:" + o
+		}
+		key := cache.Key(tenant(r), channelID(r.URL.Path), r.URL.Path, r.URL.Query(), variant)
+
+		if body, docs, ok := decodeEntry(cache.Get(key)); ok {
+			setDocCount(r, docs)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("X-Cache", "HIT")
+			w.WriteHeader(http.StatusOK)
+			w.Write(body)
+			return
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+		cw := &cacheWriter{statusRecorder: statusRecorder{ResponseWriter: w, code: http.StatusOK}}
+		h.ServeHTTP(cw, r)
+
+		// Totals are cached on their own, and totals and links would be
+		// lost on a hit.
+		if cw.code == http.StatusOK && !cw.overflow && w.Header().Get(TotalCountHeader) == "" && w.Header().Get("Link") == "" {
+			cache.Set(key, encodeEntry(docCount(r), cw.buf.Bytes()))
+		}
+	})
+}
+
+// encodeEntry function prefixes body with the number of documents it
+// holds, so hits count against audit, usage and quotas as misses do
+func encodeEntry(docs int, body []byte) []byte {
+	b := strconv.AppendInt(nil, int64(docs), 10)
+	b = append(b, '\n')
+	return append(b, body...)
+}
+
+// decodeEntry function splits a cache entry into the response body and
+// its number of documents. Entries without a count are misses.
+func decodeEntry(entry []byte, ok bool) ([]byte, int, bool) {
+	if !ok {
+		return nil, 0, false
+	}
+	i := bytes.IndexByte(entry, '\n')
+	if i < 0 {
+		return nil, 0, false
+	}
+	docs, err := strconv.Atoi(string(entry[:i]))
+	if err != nil || docs < 0 {
+		return nil, 0, false
+	}
+	return entry[i+1:], docs, true
+}
+
+// invalidate function discards the cached queries of channel cid of the
+// tenant of r
+func invalidate(r *http.Request, cid string) {
+	cache.Invalidate(tenant(r), cid)
+}
@@ -0,0 +1,97 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/audit"
+	"github.com/mainflux/mainflux-mongodb-reader/cache"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/quota"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+type chanSink chan audit.Record
+
+func (s chanSink) Write(rec audit.Record) error {
+	s <- rec
+	return nil
+}
+
+func TestCachedDocCount(t *testing.T) {
+	const cid = "cached"
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": cid})
+
+	v := 1.0
+	for i := 0; i < 3; i++ {
+		m := models.Message{Channel: cid, Name: "temperature", Time: float64(1500000000 + i), Value: &v}
+		coll := mfdb.MessageCollection(cid, m.Time)
+		if err := Db.C(coll).Insert(m); err != nil {
+			t.Fatal(err)
+		}
+		defer Db.C(coll).RemoveAll(bson.M{"channel": cid})
+	}
+
+	cache.Configure(cache.NewMemory(1<<20), time.Minute)
+	defer cache.Configure(nil, 0)
+
+	records := make(chanSink, 10)
+	audit.Start(records, 10)
+	defer audit.Stop()
+
+	if err := quota.SetQuotas(quota.Quotas{DailyDocs: 1000}, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer quota.SetQuotas(quota.Quotas{}, "")
+	before, _ := quota.Check("default")
+
+	for i, expected := range []string{"MISS", "HIT"} {
+		res, err := http.Get(ts.URL + "/channels/" + cid + "/messages?start_time=1499999999&end_time=1500000010")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if c := res.Header.Get("X-Cache"); c != expected {
+			t.Fatalf("request %d: expected X-Cache %s got %s", i+1, expected, c)
+		}
+
+		select {
+		case rec := <-records:
+			if rec.Documents != 3 {
+				t.Errorf("request %d: expected 3 audited documents got %d", i+1, rec.Documents)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("request %d: expected an audit record", i+1)
+		}
+	}
+
+	// Egress is recorded once the response is written
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		after, _ := quota.Check("default")
+		if used := before.Remaining - after.Remaining; used == 6 {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected 6 documents counted against the quota got %d", used)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
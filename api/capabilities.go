@@ -0,0 +1,50 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+)
+
+// requires function answers 501 instead of calling h when the server
+// does not support feature
+func requires(feature string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c := db.Supports(feature); !c.Supported {
+			details := map[string]interface{}{"feature": feature, "reason": c.Reason}
+			writeError(w, r, http.StatusNotImplemented, CodeNotSupported, "not supported by the database", details)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// getCapabilities function reports the server version and the features
+// endpoints depend on
+func getCapabilities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	c, ok := db.Probed()
+	if !ok {
+		writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "database not probed yet", nil)
+		return
+	}
+
+	res, err := json.Marshal(c)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
@@ -0,0 +1,73 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Identity granting administrative access.
+const adminIdentity = "admin"
+
+var (
+	certMu         sync.RWMutex
+	certIdentities = map[string]string{}
+)
+
+// SetCertIdentities function maps names of verified client certificates
+// to the identities they authenticate as, from a spec such as
+// "ops.example.com=admin;gateway-7=thing:7". A certificate matches by its
+// common name or any of its DNS or email subject alternative names.
+// The admin identity grants access to administrative endpoints.
+func SetCertIdentities(spec string) error {
+	ids := map[string]string{}
+	for _, entry := range strings.Split(spec, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return fmt.Errorf("malformed certificate identity %q", entry)
+		}
+		ids[kv[0]] = kv[1]
+	}
+
+	certMu.Lock()
+	certIdentities = ids
+	certMu.Unlock()
+
+	return nil
+}
+
+// certIdentity function returns the identity the verified client
+// certificate of r is mapped to, if any
+func certIdentity(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+		return "", false
+	}
+	cert := r.TLS.PeerCertificates[0]
+
+	names := []string{cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+
+	certMu.RLock()
+	defer certMu.RUnlock()
+
+	for _, n := range names {
+		if id, ok := certIdentities[n]; ok && n != "" {
+			return id, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,105 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+// clientCert returns a self-signed client certificate for name
+func clientCert(t *testing.T, name string) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert
+}
+
+func TestCertIdentities(t *testing.T) {
+	if err := api.SetCertIdentities("ops=admin;broken"); err == nil {
+		t.Error("expected an error for a malformed identity")
+	}
+	if err := api.SetCertIdentities("ops=admin;gateway=thing:7"); err != nil {
+		t.Fatal(err)
+	}
+	defer api.SetCertIdentities("")
+
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+
+	ops, opsCert := clientCert(t, "ops")
+	gw, gwCert := clientCert(t, "gateway")
+	pool := x509.NewCertPool()
+	pool.AddCert(opsCert)
+	pool.AddCert(gwCert)
+
+	srv := httptest.NewUnstartedServer(api.HTTPServer())
+	srv.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+
+	cases := []struct {
+		cert *tls.Certificate
+		code int
+	}{
+		{&ops, 200},
+		{&gw, 403},
+		{nil, 403},
+	}
+
+	for i, c := range cases {
+		cfg := &tls.Config{InsecureSkipVerify: true}
+		if c.cert != nil {
+			cfg.Certificates = []tls.Certificate{*c.cert}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+
+		res, err := client.Get(srv.URL + "/pool")
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The contract of the Mainflux readers this reader replaces, which their
+// SDKs are written against:
+//
+//	GET /status                        {"running": true}
+//	GET /channels/:id/messages         an array of messages stored within
+//	                                   the exclusive start_time and
+//	                                   end_time, in UNIX seconds
+//
+// Messages keep the SenML short names and the Mainflux fields, and
+// failures answer {"response": message} bodies, with the id of the
+// channel if it is unknown. Both the legacy and the versioned paths honor
+// it.
+//
+// The reader deliberately departs from it in answering 400, not an empty
+// array, to a start_time after end_time.
+
+// Channel of the contract fixtures
+const contractChannel = "contract"
+
+// Messages of the contract channel, as encoded by the Mainflux readers
+const contractMessages = `[
+	{
+		"bn": "sensor:", "bu": "C", "bver": 5, "l": "/sensors/1",
+		"n": "temperature", "u": "C", "t": 1500000000.5, "ut": 1,
+		"v": 21.5, "s": 43,
+		"publisher": "thing-1", "protocol": "http", "created": "2017-07-14T02:40:00Z",
+		"content_type": "application/senml+json", "channel": "contract"
+	},
+	{
+		"n": "switch", "t": 1500000001, "vs": "on", "vd": "ZGF0YQ==", "vb": true,
+		"publisher": "thing-2", "protocol": "mqtt", "created": "2017-07-14T02:40:01Z",
+		"content_type": "application/senml+json", "channel": "contract",
+		"payload": "cmF3"
+	}
+]`
+
+// anyString matches every string value of an expected body
+const anyString = "*"
+
+func TestContract(t *testing.T) {
+	var msgs []models.Message
+	if err := json.Unmarshal([]byte(contractMessages), &msgs); err != nil {
+		t.Fatal(err)
+	}
+	var all []interface{}
+	json.Unmarshal([]byte(contractMessages), &all)
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": contractChannel}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": contractChannel})
+	for _, m := range msgs {
+		coll := mfdb.MessageCollection(m.Channel, m.Time)
+		if err := Db.C(coll).Insert(m); err != nil {
+			t.Fatal(err)
+		}
+		defer Db.C(coll).RemoveAll(bson.M{"channel": contractChannel})
+	}
+
+	messages := "/channels/" + contractChannel + "/messages"
+	cases := []struct {
+		path string
+		code int
+		body interface{}
+	}{
+		{"/status", 200, map[string]interface{}{"running": true}},
+		{messages, 200, all},
+		{messages + "?start_time=1500000000.5", 200, all[1:]},
+		{messages + "?end_time=1500000001", 200, all[:1]},
+		{messages + "?start_time=1400000000&end_time=1500000002", 200, all},
+		{messages + "?start_time=1600000000", 200, []interface{}{}},
+		{messages + "?start_time=now", 400, map[string]interface{}{"response": anyString}},
+		{messages + "?end_time=now", 400, map[string]interface{}{"response": anyString}},
+		{"/channels/unknown/messages", 404, map[string]interface{}{"response": "Channel not found", "id": "unknown"}},
+	}
+
+	for _, prefix := range []string{"", "/v1"} {
+		for i, c := range cases {
+			res, err := http.Get(ts.URL + prefix + c.path)
+			if err != nil {
+				t.Fatalf("%s case %d: %s", prefix, i+1, err.Error())
+			}
+
+			body, err := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				t.Fatalf("%s case %d: %s", prefix, i+1, err.Error())
+			}
+
+			if res.StatusCode != c.code {
+				t.Errorf("%s case %d: expected status %d got %d", prefix, i+1, c.code, res.StatusCode)
+			}
+			if ct := res.Header.Get("Content-Type"); ct != "application/json; charset=utf-8" {
+				t.Errorf("%s case %d: expected a JSON content type got %s", prefix, i+1, ct)
+			}
+
+			var got interface{}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("%s case %d: %s", prefix, i+1, err.Error())
+			}
+			if !conforms(got, c.body) {
+				t.Errorf("%s case %d: expected response %v got %s", prefix, i+1, c.body, string(body))
+			}
+		}
+	}
+}
+
+// conforms reports whether got carries want: the same messages in the
+// same order, and at least the fields of objects
+func conforms(got, want interface{}) bool {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range w {
+			if !conforms(g[k], v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			// Messages carry exactly the fields of the contract
+			if m, ok := w[i].(map[string]interface{}); ok {
+				if gm, ok := g[i].(map[string]interface{}); !ok || len(gm) != len(m) {
+					return false
+				}
+			}
+			if !conforms(g[i], w[i]) {
+				return false
+			}
+		}
+		return true
+	case string:
+		if w == anyString {
+			_, ok := got.(string)
+			return ok
+		}
+	}
+	return reflect.DeepEqual(got, want)
+}
@@ -0,0 +1,133 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CORS struct configures cross-origin requests of browser applications
+type CORS struct {
+	// Origins allowed to read responses, "*" for any. Cross-origin
+	// requests are refused while it is empty.
+	Origins []string
+	Methods []string
+	// Request headers allowed, those a preflight asks for when empty.
+	Headers     []string
+	MaxAge      time.Duration
+	Credentials bool
+}
+
+// Response headers readable by cross-origin requests.
+var exposedHeaders = strings.Join([]string{TotalCountHeader, "Link", RequestIDHeader, VersionHeader, "Deprecation", "Sunset",
+	QuotaNameHeader, QuotaLimitHeader, QuotaRemainingHeader, QuotaResetHeader}, ", ")
+
+var (
+	corsMu  sync.RWMutex
+	corsCfg CORS
+
+	// ErrCORSCredentials is returned by configurations letting any origin
+	// send credentials, which would let every site read the responses to
+	// the requests of logged in users.
+	ErrCORSCredentials = errors.New("credentials can't be allowed to any origin")
+)
+
+// Validate function checks that c doesn't allow credentials to any origin
+func (c CORS) Validate() error {
+	if c.Credentials && c.allowed("*") {
+		return ErrCORSCredentials
+	}
+	return nil
+}
+
+// SetCORS function replaces the cross-origin configuration, filling in the
+// methods the API serves when none are given. Invalid configurations are
+// refused, keeping the current one.
+func SetCORS(c CORS) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if len(c.Methods) == 0 {
+		c.Methods = []string{"GET", "POST", "PUT", "DELETE"}
+	}
+
+	corsMu.Lock()
+	defer corsMu.Unlock()
+	corsCfg = c
+	return nil
+}
+
+func (c CORS) allowed(origin string) bool {
+	for _, o := range c.Origins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// cors function sets the CORS headers of requests from allowed origins and
+// answers their preflight requests, which carry no credentials, before
+// authorization
+func cors(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	corsMu.RLock()
+	c := corsCfg
+	corsMu.RUnlock()
+
+	origin := r.Header.Get("Origin")
+	if len(c.Origins) == 0 || origin == "" {
+		next(w, r)
+		return
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	preflight := r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != ""
+	if !c.allowed(origin) {
+		if preflight {
+			writeError(w, r, http.StatusForbidden, CodeForbidden, "origin not allowed", nil)
+			return
+		}
+		next(w, r)
+		return
+	}
+
+	if len(c.Origins) == 1 && c.Origins[0] == "*" {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.Credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		h.Set("Access-Control-Expose-Headers", exposedHeaders)
+		next(w, r)
+		return
+	}
+
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	h.Set("Access-Control-Allow-Methods", strings.Join(c.Methods, ", "))
+	if len(c.Headers) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(c.Headers, ", "))
+	} else if req := r.Header.Get("Access-Control-Request-Headers"); req != "" {
+		h.Set("Access-Control-Allow-Headers", req)
+	}
+	if c.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
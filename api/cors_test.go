@@ -0,0 +1,107 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+func TestCORS(t *testing.T) {
+	defer api.SetCORS(api.CORS{})
+
+	cases := []struct {
+		cfg     api.CORS
+		method  string
+		origin  string
+		headers map[string]string
+		code    int
+	}{
+		// Disabled
+		{api.CORS{}, "GET", "https://dash.example.com", map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}, 200},
+		// Any origin
+		{api.CORS{Origins: []string{"*"}}, "GET", "https://dash.example.com", map[string]string{
+			"Access-Control-Allow-Origin":   "*",
+			"Access-Control-Expose-Headers": "X-Total-Count, Link, X-Request-ID, API-Version, Deprecation, Sunset, X-Quota-Name, X-Quota-Limit, X-Quota-Remaining, X-Quota-Reset",
+		}, 200},
+		// Credentials
+		{api.CORS{Origins: []string{"https://dash.example.com"}, Credentials: true}, "GET", "https://dash.example.com", map[string]string{
+			"Access-Control-Allow-Origin":      "https://dash.example.com",
+			"Access-Control-Allow-Credentials": "true",
+		}, 200},
+		// Other origin
+		{api.CORS{Origins: []string{"https://ops.example.com"}}, "GET", "https://dash.example.com", map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}, 200},
+		// Preflight
+		{api.CORS{Origins: []string{"https://dash.example.com"}, MaxAge: time.Hour}, "OPTIONS", "https://dash.example.com", map[string]string{
+			"Access-Control-Allow-Origin":  "https://dash.example.com",
+			"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE",
+			"Access-Control-Allow-Headers": "Authorization",
+			"Access-Control-Max-Age":       "3600",
+		}, 204},
+		// Preflight with configured headers
+		{api.CORS{Origins: []string{"https://dash.example.com"}, Methods: []string{"GET"}, Headers: []string{"Authorization", "X-Api-Key"}}, "OPTIONS", "https://dash.example.com", map[string]string{
+			"Access-Control-Allow-Methods": "GET",
+			"Access-Control-Allow-Headers": "Authorization, X-Api-Key",
+			"Access-Control-Max-Age":       "",
+		}, 204},
+		// Preflight of other origin
+		{api.CORS{Origins: []string{"https://ops.example.com"}}, "OPTIONS", "https://dash.example.com", map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}, 403},
+	}
+
+	for i, c := range cases {
+		if err := api.SetCORS(c.cfg); err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		req, err := http.NewRequest(c.method, ts.URL+"/version", nil)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		req.Header.Set("Origin", c.origin)
+		if c.method == "OPTIONS" {
+			req.Header.Set("Access-Control-Request-Method", "GET")
+			req.Header.Set("Access-Control-Request-Headers", "Authorization")
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+		for k, v := range c.headers {
+			if h := res.Header.Get(k); h != v {
+				t.Errorf("case %d: expected %s %q got %q", i+1, k, v, h)
+			}
+		}
+	}
+}
+
+func TestCORSValidate(t *testing.T) {
+	defer api.SetCORS(api.CORS{})
+
+	if err := api.SetCORS(api.CORS{Origins: []string{"https://dash.example.com", "*"}, Credentials: true}); err != api.ErrCORSCredentials {
+		t.Errorf("expected error %v got %v", api.ErrCORSCredentials, err)
+	}
+	if err := api.SetCORS(api.CORS{Origins: []string{"*"}}); err != nil {
+		t.Errorf("expected no error got %v", err)
+	}
+}
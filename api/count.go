@@ -0,0 +1,83 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mainflux/mainflux-mongodb-reader/cache"
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+)
+
+// Count modes of message reads
+const (
+	countNone     = "none"
+	countExact    = "exact"
+	countEstimate = "estimate"
+)
+
+// TotalCountHeader names the response header carrying the number of
+// messages matching a read.
+const TotalCountHeader = "X-Total-Count"
+
+// Granularity, in seconds, of the time range of estimated counts.
+const estimateStep = 60
+
+var errCountMode = errors.New("count must be exact, estimate or none")
+
+// countMode function returns the count mode requested by r, none by
+// default
+func countMode(r *http.Request) (string, error) {
+	switch m := r.URL.Query().Get("count"); m {
+	case "", countNone:
+		return countNone, nil
+	case countExact, countEstimate:
+		return m, nil
+	}
+	return "", errCountMode
+}
+
+// countMessages function counts the messages q selects from repo.
+// Estimates widen the range to whole minutes, so that the count of a
+// range is computed once and then served from the query cache until the
+// cache entry expires.
+func countMessages(ctx context.Context, r *http.Request, repo repository.MessageRepository, mode string, q repository.Query) (int, error) {
+	if mode == countExact {
+		return repo.Count(ctx, q)
+	}
+
+	q.Start = math.Floor(q.Start/estimateStep) * estimateStep
+	q.End = math.Ceil(q.End/estimateStep) * estimateStep
+	params := url.Values{
+		"st": {strconv.FormatFloat(q.Start, 'f', -1, 64)},
+		"et": {strconv.FormatFloat(q.End, 'f', -1, 64)},
+	}
+	if len(q.Distinct) > 0 {
+		params.Set("distinct_on", strings.Join(q.Distinct, ","))
+	}
+	key := cache.Key(tenant(r), q.Channel, "count", params, "")
+	if b, ok := cache.Get(key); ok {
+		if n, err := strconv.Atoi(string(b)); err == nil {
+			return n, nil
+		}
+	}
+
+	n, err := repo.Count(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	cache.Set(key, []byte(strconv.Itoa(n)))
+	return n, nil
+}
@@ -0,0 +1,25 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	// Register /debug/vars on the default mux.
+	_ "expvar"
+	"net/http"
+	// Register /debug/pprof/ on the default mux.
+	_ "net/http/pprof"
+)
+
+// DebugServer function returns the handler of runtime diagnostics:
+// profiles under /debug/pprof/ and exported variables, including memory
+// statistics, under /debug/vars. It must only be served on an internal
+// address.
+func DebugServer() http.Handler {
+	return http.DefaultServeMux
+}
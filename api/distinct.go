@@ -0,0 +1,247 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// Window of duplication reports without start_time.
+	defaultDuplicatesWindow = 24 * 3600
+	// Number of channels listed in duplication reports by default.
+	defaultDuplicatesLimit = 100
+)
+
+// DistinctMaxRange caps the time range of message reads collapsing
+// duplicates, whose messages are all grouped before the page is cut.
+// Zero removes the cap.
+var DistinctMaxRange = 24 * time.Hour
+
+// Message fields duplicates may be told apart by
+var distinctFields = map[string]bool{
+	"time":      true,
+	"name":      true,
+	"publisher": true,
+	"protocol":  true,
+	"unit":      true,
+	"value":     true,
+}
+
+// Fields duplication reports tell duplicates by without distinct_on,
+// those a QoS 1 redelivery repeats
+var defaultDistinct = []string{"time", "name", "publisher"}
+
+var errDistinct = errors.New("distinct_on must list time and any of name, publisher, protocol, unit, value")
+
+type (
+	// duplication is the share of duplicate messages of a channel
+	duplication struct {
+		Channel    string  `json:"channel" bson:"_id"`
+		Messages   int     `json:"messages" bson:"messages"`
+		Duplicates int     `json:"duplicates" bson:"duplicates"`
+		Rate       float64 `json:"rate"`
+	}
+
+	// byRate sorts duplications by decreasing rate, then channel
+	byRate []duplication
+)
+
+func (d byRate) Len() int      { return len(d) }
+func (d byRate) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d byRate) Less(i, j int) bool {
+	if d[i].Rate != d[j].Rate {
+		return d[i].Rate > d[j].Rate
+	}
+	return d[i].Channel < d[j].Channel
+}
+
+// parseDistinct function returns the fields listed in s, which must
+// include the time: duplicates then share a collection, so that they are
+// collapsed by each collection read.
+func parseDistinct(s string) ([]string, error) {
+	var keys []string
+	seen := map[string]bool{}
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(k)
+		if !distinctFields[k] {
+			return nil, errDistinct
+		}
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if !seen["time"] {
+		return nil, errDistinct
+	}
+	return keys, nil
+}
+
+// checkDistinct function checks that s lists fields to collapse
+// duplicates by
+func checkDistinct(s string) string {
+	if _, err := parseDistinct(s); err != nil {
+		return "must list time and any of name, publisher, protocol, unit, value"
+	}
+	return ""
+}
+
+// distinctOn function returns the fields r asks duplicate messages
+// between st and et to be collapsed by, answering 400 if the range
+// exceeds DistinctMaxRange and 501 if the database can't collapse them
+func distinctOn(w http.ResponseWriter, r *http.Request, st, et float64) ([]string, bool) {
+	s := r.URL.Query().Get("distinct_on")
+	if s == "" {
+		return nil, true
+	}
+	keys, err := parseDistinct(s)
+	if err != nil {
+		badFilter(w, r, err)
+		return nil, false
+	}
+	if DistinctMaxRange > 0 && et-st > DistinctMaxRange.Seconds() {
+		badFilter(w, r, fmt.Errorf("distinct_on requires a time range no longer than %s", DistinctMaxRange))
+		return nil, false
+	}
+	if c := db.Supports(db.FeatureReplaceRoot); !c.Supported {
+		details := map[string]interface{}{"feature": db.FeatureReplaceRoot, "reason": c.Reason}
+		writeError(w, r, http.StatusNotImplemented, CodeNotSupported, "not supported by the database", details)
+		return nil, false
+	}
+	return keys, true
+}
+
+// getDuplicates function reports the channels storing duplicate
+// messages, such as MQTT QoS 1 redeliveries, by decreasing share of
+// duplicates. Parameters, besides the time range of getMessage, by
+// default the last day:
+// - distinct_on = fields duplicates agree on, time,name,publisher by default.
+// - limit = number of channels listed, 100 by default.
+func getDuplicates(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	st, et, err := timeRange(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("start_time") == "" {
+		st = math.Max(et-defaultDuplicatesWindow, 0)
+	}
+	keys := defaultDistinct
+	if s := q.Get("distinct_on"); s != "" {
+		if keys, err = parseDistinct(s); err != nil {
+			badFilter(w, r, err)
+			return
+		}
+	}
+	limit := defaultDuplicatesLimit
+	if s := q.Get("limit"); s != "" {
+		limit, _ = strconv.Atoi(s)
+	}
+
+	key := db.DistinctKey(keys)
+	key["channel"] = "$channel"
+	pipeline := []bson.M{
+		{"$match": bson.M{"time": bson.M{"$gt": st, "$lt": et}}},
+		{"$group": bson.M{"_id": key, "n": bson.M{"$sum": 1}}},
+		{"$group": bson.M{
+			"_id":        "$_id.channel",
+			"messages":   bson.M{"$sum": "$n"},
+			"duplicates": bson.M{"$sum": bson.M{"$subtract": []interface{}{"$n", 1}}},
+		}},
+	}
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	channels := map[string]*duplication{}
+	err = Db.Read(ctx, func() error {
+		collections, err := Db.MessageCollections("", st, et)
+		if err != nil {
+			return err
+		}
+
+		channels = map[string]*duplication{}
+		for _, c := range collections {
+			var d duplication
+			iter := Db.Aggregate(ctx, c, pipeline)
+			for iter.Next(&d) {
+				if cd, ok := channels[d.Channel]; ok {
+					cd.Messages += d.Messages
+					cd.Duplicates += d.Duplicates
+				} else {
+					cd := d
+					channels[d.Channel] = &cd
+				}
+			}
+			if err := iter.Close(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if db.IsTimeout(err) {
+		timedOut(w, r)
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to count duplicates", nil)
+		return
+	}
+
+	rep := []duplication{}
+	for _, d := range channels {
+		if d.Duplicates == 0 {
+			continue
+		}
+		d.Rate = float64(d.Duplicates) / float64(d.Messages)
+		rep = append(rep, *d)
+	}
+	sort.Sort(byRate(rep))
+	if len(rep) > limit {
+		rep = rep[:limit]
+	}
+
+	res, err := json.Marshal(struct {
+		StartTime  float64       `json:"start_time"`
+		EndTime    float64       `json:"end_time"`
+		DistinctOn []string      `json:"distinct_on"`
+		Channels   []duplication `json:"channels"`
+	}{st, et, keys, rep})
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
@@ -0,0 +1,136 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestDistinct(t *testing.T) {
+	const cid = "distinct"
+
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": cid})
+
+	// Four readings, the second redelivered twice and the third sent by
+	// two publishers
+	v := 1.0
+	msgs := []models.Message{
+		{Channel: cid, Publisher: "p1", Name: "temperature", Time: 1500000000, Value: &v},
+		{Channel: cid, Publisher: "p1", Name: "temperature", Time: 1500000001, Value: &v},
+		{Channel: cid, Publisher: "p1", Name: "temperature", Time: 1500000001, Value: &v},
+		{Channel: cid, Publisher: "p1", Name: "temperature", Time: 1500000001, Value: &v},
+		{Channel: cid, Publisher: "p1", Name: "temperature", Time: 1500000002, Value: &v},
+		{Channel: cid, Publisher: "p2", Name: "temperature", Time: 1500000002, Value: &v},
+	}
+	for _, m := range msgs {
+		coll := mfdb.MessageCollection(cid, m.Time)
+		if err := Db.C(coll).Insert(m); err != nil {
+			t.Fatal(err)
+		}
+		defer Db.C(coll).RemoveAll(bson.M{"channel": cid})
+	}
+
+	read := ts.URL + "/channels/" + cid + "/messages?start_time=1499999999&end_time=1500000010"
+	cases := []struct {
+		query string
+		count string
+		n     int
+	}{
+		{"&count=exact", "6", 6},
+		{"&count=exact&distinct_on=time,name,publisher", "4", 4},
+		{"&count=exact&distinct_on=time,name", "3", 3},
+		{"&count=exact&distinct_on=time,name,publisher&offset=1&limit=2", "4", 2},
+	}
+	for i, c := range cases {
+		res, err := http.Get(read + c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body []models.Message
+		err = json.NewDecoder(res.Body).Decode(&body)
+		res.Body.Close()
+		if err != nil || res.StatusCode != http.StatusOK {
+			t.Fatalf("case %d: expected messages got status %d, %v", i+1, res.StatusCode, err)
+		}
+		if len(body) != c.n || res.Header.Get(api.TotalCountHeader) != c.count {
+			t.Errorf("case %d: expected %d of %s messages got %d of %s", i+1, c.n, c.count, len(body), res.Header.Get(api.TotalCountHeader))
+		}
+	}
+
+	bad := []string{
+		read + "&distinct_on=name,publisher",
+		read + "&distinct_on=time,payload",
+		ts.URL + "/channels/" + cid + "/messages?distinct_on=time,name",
+	}
+	for _, query := range bad {
+		res, err := http.Get(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("%q: expected status %d got %d", query, http.StatusBadRequest, res.StatusCode)
+		}
+	}
+
+	duplicates := ts.URL + "/duplicates?start_time=1499999999&end_time=1500000010"
+	res, err := http.Get(duplicates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status %d got %d", http.StatusForbidden, res.StatusCode)
+	}
+
+	req, _ := http.NewRequest("GET", duplicates, nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		Channels []map[string]interface{}
+	}
+	err = json.NewDecoder(res.Body).Decode(&rep)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("expected report got status %d, %v", res.StatusCode, err)
+	}
+	var found bool
+	for _, c := range rep.Channels {
+		if c["channel"] != cid {
+			continue
+		}
+		found = true
+		if c["messages"] != 6.0 || c["duplicates"] != 2.0 || c["rate"] != 2.0/6 {
+			t.Errorf("expected two duplicates of six messages got %v", c)
+		}
+	}
+	if !found {
+		t.Errorf("expected channel %s in report got %v", cid, rep.Channels)
+	}
+}
@@ -0,0 +1,112 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/things"
+	"github.com/mainflux/mainflux-mongodb-reader/units"
+)
+
+// enrichedMessage is a message labelled with the names and metadata of
+// its channel and publisher, and annotated with the unit registry entry
+// of its name
+type enrichedMessage struct {
+	models.Message
+	ChannelName       string      `json:"channel_name,omitempty"`
+	ChannelMetadata   interface{} `json:"channel_metadata,omitempty"`
+	PublisherName     string      `json:"publisher_name,omitempty"`
+	PublisherMetadata interface{} `json:"publisher_metadata,omitempty"`
+	DisplayName       string      `json:"display_name,omitempty"`
+	Violations        []string    `json:"violations,omitempty"`
+}
+
+// enricher labels the messages of a response, looking every channel and
+// thing up once
+type enricher struct {
+	r      *http.Request
+	labels map[string]things.Entity
+	failed bool
+}
+
+// enrichment function tells whether r asks for labelled messages,
+// answering 501 if no things service is configured
+func enrichment(w http.ResponseWriter, r *http.Request) (*enricher, bool) {
+	if r.URL.Query().Get("enrich") != "true" {
+		return nil, true
+	}
+	if !things.Enabled() {
+		writeError(w, r, http.StatusNotImplemented, CodeNotSupported, "enrichment not configured", nil)
+		return nil, false
+	}
+	return &enricher{r: r, labels: map[string]things.Entity{}}, true
+}
+
+// label returns the entity of kind id. Failed lookups leave messages
+// unlabelled, and are logged once per response.
+func (en *enricher) label(kind, id string) things.Entity {
+	key := kind + "/" + id
+	if e, ok := en.labels[key]; ok {
+		return e
+	}
+
+	lookup := things.Channel
+	if kind == things.KindThing {
+		lookup = things.Thing
+	}
+	e, _, err := lookup(id)
+	if err != nil && !en.failed {
+		en.failed = true
+		logger(en.r).WithField("error", err.Error()).Warn("Can't label messages")
+	}
+	en.labels[key] = e
+	return e
+}
+
+// enrich function labels em
+func (en *enricher) enrich(em *enrichedMessage) {
+	if em.Channel != "" {
+		c := en.label(things.KindChannel, em.Channel)
+		em.ChannelName, em.ChannelMetadata = c.Name, c.Metadata
+	}
+	if em.Publisher != "" {
+		p := en.label(things.KindThing, em.Publisher)
+		em.PublisherName, em.PublisherMetadata = p.Name, p.Metadata
+	}
+}
+
+// decorate function returns m labelled by en and annotated from c, or m
+// itself if neither is given
+func decorate(m models.Message, en *enricher, c units.Catalog) interface{} {
+	if en == nil && c == nil {
+		return m
+	}
+	em := enrichedMessage{Message: m}
+	if en != nil {
+		en.enrich(&em)
+	}
+	if c != nil {
+		annotate(&em, c)
+	}
+	return em
+}
+
+// decorateAll function returns msgs labelled by en and annotated from c
+func decorateAll(msgs []models.Message, en *enricher, c units.Catalog) interface{} {
+	if en == nil && c == nil {
+		return msgs
+	}
+	res := make([]interface{}, len(msgs))
+	for i, m := range msgs {
+		res[i] = decorate(m, en, c)
+	}
+	return res
+}
@@ -0,0 +1,95 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/latest"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/things"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestEnrich(t *testing.T) {
+	const cid = "enriched"
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": cid})
+	v := 21.5
+	m := models.Message{Channel: cid, Publisher: "thing-1", Name: "temperature", Time: 1500000000, Value: &v}
+	coll := mfdb.MessageCollection(cid, m.Time)
+	if err := Db.C(coll).Insert(m); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(coll).RemoveAll(bson.M{"channel": cid})
+	if err := Db.C(latest.Collection).Insert(bson.M{"channel": cid, "name": m.Name, "time": m.Time, "message": m}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(latest.Collection).RemoveAll(bson.M{"channel": cid})
+
+	// Without a things service, labels can't be had
+	res, err := http.Get(ts.URL + "/channels/" + cid + "/messages?enrich=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotImplemented {
+		t.Errorf("expected status %d got %d", http.StatusNotImplemented, res.StatusCode)
+	}
+
+	svc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/channels/" + cid:
+			w.Write([]byte(`{"id": "enriched", "name": "Greenhouse", "metadata": {"site": "north"}}`))
+		case "/things/thing-1":
+			w.Write([]byte(`{"id": "thing-1", "name": "Thermometer", "key": "secret"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer svc.Close()
+	if err := things.Init(svc.URL, "token"); err != nil {
+		t.Fatal(err)
+	}
+	defer things.Init("", "")
+
+	for _, path := range []string{"/messages", "/messages/latest"} {
+		res, err := http.Get(ts.URL + "/channels/" + cid + path + "?enrich=true")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body []map[string]interface{}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		res.Body.Close()
+		if err != nil || len(body) != 1 {
+			t.Fatalf("%s: expected one message got %v, %v", path, body, err)
+		}
+
+		if body[0]["channel_name"] != "Greenhouse" || body[0]["publisher_name"] != "Thermometer" {
+			t.Errorf("%s: expected labelled message got %v", path, body[0])
+		}
+		if md, _ := body[0]["channel_metadata"].(map[string]interface{}); md["site"] != "north" {
+			t.Errorf("%s: expected channel metadata got %v", path, body[0]["channel_metadata"])
+		}
+		if _, ok := body[0]["key"]; ok {
+			t.Errorf("%s: expected no thing key got %v", path, body[0])
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+	"gopkg.in/mgo.v2"
+)
+
+// Codes of failed requests, telling clients apart failures sharing a
+// status.
+const (
+	CodeInvalidFilter  = "invalid_filter"
+	CodeInvalidRequest = "invalid_request"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeLimitExceeded  = "limit_exceeded"
+	CodeQuotaExceeded  = "quota_exceeded"
+	CodeTimeout        = "timeout"
+	CodeUnavailable    = "backend_unavailable"
+	CodeNotSupported   = "not_supported"
+	CodeInternal       = "internal_error"
+)
+
+// Error struct is the body of every failed request. Response repeats
+// Message, and ID the id detail, for clients of the former
+// {"response": ..., "id": ...} bodies.
+type Error struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Response  string                 `json:"response"`
+	ID        string                 `json:"id,omitempty"`
+}
+
+// writeError function answers r with status and the error body of code
+// and message. details may be nil.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string, details map[string]interface{}) {
+	e := Error{Code: code, Message: message, Details: details, Response: message}
+	if id, ok := details["id"].(string); ok {
+		e.ID = id
+	}
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		e.RequestID = info.id
+	}
+
+	res, err := json.Marshal(e)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(res)
+}
+
+// badFilter function answers 400 to a request with an invalid parameter
+func badFilter(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, http.StatusBadRequest, CodeInvalidFilter, err.Error(), nil)
+}
+
+// channelNotFound function answers 404 to a request on an unknown channel
+func channelNotFound(w http.ResponseWriter, r *http.Request, cid string) {
+	writeError(w, r, http.StatusNotFound, CodeNotFound, "Channel not found", map[string]interface{}{"id": cid})
+}
+
+// channelError function answers a request whose channel lookup failed
+// with err: 404 if the channel doesn't exist, and a server error if the
+// database failed, so that outages count against the circuit breaker
+func channelError(w http.ResponseWriter, r *http.Request, cid string, err error) {
+	if err == mgo.ErrNotFound {
+		channelNotFound(w, r, cid)
+		return
+	}
+	if db.IsTimeout(err) {
+		timedOut(w, r)
+		return
+	}
+	logger(r).Error(err)
+	writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to read channel", map[string]interface{}{"id": cid})
+}
+
+// readError function answers a request whose read of the messages of
+// channel cid failed with err: 404 for unknown channels, 504 when the
+// read timed out, and otherwise 500 with msg
+func readError(w http.ResponseWriter, r *http.Request, cid, msg string, err error) {
+	if err == repository.ErrChannelNotFound || err == db.ErrNotOwned {
+		channelNotFound(w, r, cid)
+		return
+	}
+	if db.IsTimeout(err) {
+		timedOut(w, r)
+		return
+	}
+	logger(r).Error(err)
+	writeError(w, r, http.StatusInternalServerError, CodeInternal, msg, map[string]interface{}{"id": cid})
+}
+
+// timedOut function answers 504 to a request whose query ran out of time
+func timedOut(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusGatewayTimeout, CodeTimeout, "query timed out", nil)
+}
+
+// routeNotFound function answers 404 to a request no route matches
+func routeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodeNotFound, "route not found", map[string]interface{}{"path": r.URL.Path})
+}
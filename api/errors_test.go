@@ -0,0 +1,77 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+var requestIDField = regexp.MustCompile(`,"request_id":"[^"]*"`)
+
+// withoutRequestID returns body with the generated request ID of an error
+// removed, so that tests can compare it
+func withoutRequestID(body []byte) string {
+	return requestIDField.ReplaceAllString(string(body), "")
+}
+
+func TestErrorBody(t *testing.T) {
+	cases := []struct {
+		path    string
+		code    int
+		errCode string
+		details map[string]interface{}
+	}{
+		{"/channels/unknown/messages", 404, api.CodeNotFound, map[string]interface{}{"id": "unknown"}},
+		{"/channels/unknown/messages/aggregate", 404, api.CodeNotFound, map[string]interface{}{"id": "unknown"}},
+		{"/unknown", 404, api.CodeNotFound, map[string]interface{}{"path": "/unknown"}},
+	}
+
+	for i, c := range cases {
+		req, err := http.NewRequest("GET", ts.URL+c.path, nil)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		req.Header.Set(api.RequestIDHeader, "errors-test")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		var e api.Error
+		err = json.NewDecoder(res.Body).Decode(&e)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+		if e.Code != c.errCode {
+			t.Errorf("case %d: expected code %s got %s", i+1, c.errCode, e.Code)
+		}
+		if e.Message == "" || e.Response != e.Message {
+			t.Errorf("case %d: expected response to repeat message %q got %q", i+1, e.Message, e.Response)
+		}
+		if e.RequestID != "errors-test" {
+			t.Errorf("case %d: expected request ID errors-test got %s", i+1, e.RequestID)
+		}
+		for k, v := range c.details {
+			if e.Details[k] != v {
+				t.Errorf("case %d: expected detail %s %v got %v", i+1, k, v, e.Details[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+)
+
+// ETags enables validators of message reads, at the cost of counting the
+// matching messages and finding the latest of them on every read.
+var ETags bool
+
+// validated function answers 304 to conditional message reads whose
+// result did not change. The weak ETag of a read is derived from the
+// request, the number of matching messages and the time of the latest,
+// which is also its Last-Modified time.
+func validated(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ETags {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		st, et, err := timeRange(r)
+		if err != nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		Db, ok := openDb(w, r)
+		if !ok {
+			return
+		}
+		cid := bone.GetValue(r, "channel_id")
+
+		// Unknown channels are answered by h, without validators
+		if err := Db.FindChannel(cid); err != nil {
+			Db.Close()
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := db.Context(r.Context())
+		filter := messageFilter(cid, st, et)
+		n, err := Db.CountAll(ctx, cid, st, et, filter)
+		last := 0.0
+		if err == nil {
+			last, err = Db.LastTime(ctx, cid, st, et, filter)
+		}
+		cancel()
+		Db.Close()
+		if err != nil {
+			// Serve the read unvalidated, it reports its own failures
+			logger(r).Warnf("Can't compute validators: %v", err)
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		etag := messagesETag(r, n, last)
+		w.Header().Set("ETag", etag)
+		var modified time.Time
+		if last > 0 {
+			modified = time.Unix(int64(last), 0).UTC()
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		}
+
+		if notModified(r, etag, modified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// messagesETag function returns the weak ETag of the read r matching n
+// messages, the latest of which is stored at last
+func messagesETag(r *http.Request, n int, last float64) string {
+	variant := ""
+	if redact.Enabled() && isAdmin(r) {
+		variant = "admin"
+	}
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%d\n%v", tenant(r), r.URL.Path, r.URL.Query().Encode(), variant, n, last)
+	return fmt.Sprintf(`W/"%016x"`, h.Sum64())
+}
+
+// notModified function evaluates the preconditions of r against the
+// validators of the current result. If-Modified-Since is only considered
+// without If-None-Match.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	return err == nil && !modified.After(t)
+}
@@ -0,0 +1,40 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+func TestMessagesETag(t *testing.T) {
+	api.ETags = true
+	defer func() { api.ETags = false }()
+
+	req, err := http.NewRequest("GET", ts.URL+"/channels/unknown/messages", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("If-None-Match", "*")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %d got %d", http.StatusNotFound, res.StatusCode)
+	}
+	if etag := res.Header.Get("ETag"); etag != "" {
+		t.Errorf("expected no ETag for an unknown channel got %s", etag)
+	}
+}
@@ -0,0 +1,112 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var verbosities = map[string]bool{
+	"queryPlanner":      true,
+	"executionStats":    true,
+	"allPlansExecution": true,
+}
+
+// explainMessages function returns the plans of the reads getMessage
+// runs for the same parameters, including its page and distinct_on. The
+// verbosity parameter selects queryPlanner (default), executionStats or
+// allPlansExecution.
+func explainMessages(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	st, et, err := timeRange(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	limit, err := pageLimit(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	offset, err := pageOffset(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	verbosity := r.URL.Query().Get("verbosity")
+	if verbosity == "" {
+		verbosity = "queryPlanner"
+	}
+	if !verbosities[verbosity] {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidFilter, "unknown verbosity", nil)
+		return
+	}
+
+	distinct, ok := distinctOn(w, r, st, et)
+	if !ok {
+		return
+	}
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	cid := bone.GetValue(r, "channel_id")
+	q := repository.Query{Channel: cid, Start: st, End: et, Offset: offset, Limit: limit, Distinct: distinct}
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	plans := []db.Plan{}
+	err = Db.Run(ctx, func() error {
+		var err error
+		plans, err = repository.MongoIter(ctx, &Db, q).Explain(verbosity)
+		return err
+	})
+	if db.IsTimeout(err) {
+		timedOut(w, r)
+		return
+	}
+	if err == db.ErrNotOwned {
+		channelNotFound(w, r, cid)
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to explain query", map[string]interface{}{"id": cid})
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Channel string    `json:"channel"`
+		Filter  bson.M    `json:"filter"`
+		Plans   []db.Plan `json:"plans"`
+	}{cid, messageFilter(cid, st, et), plans})
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
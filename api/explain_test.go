@@ -0,0 +1,146 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestExplainMessages(t *testing.T) {
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+
+	cases := []struct {
+		token string
+		query string
+		body  string
+		code  int
+	}{
+		{"", "", `{"code":"forbidden","message":"admin access required","response":"admin access required"}`, 403},
+		{"admin", "?start_time=x", `{"code":"invalid_filter","message":"invalid query parameters: start_time must be a number","details":{"fields":[{"field":"start_time","message":"must be a number"}]},"response":"invalid query parameters: start_time must be a number"}`, 400},
+		{"admin", "?verbosity=all", `{"code":"invalid_filter","message":"invalid query parameters: verbosity must be one of queryPlanner, executionStats, allPlansExecution","details":{"fields":[{"field":"verbosity","message":"must be one of queryPlanner, executionStats, allPlansExecution"}]},"response":"invalid query parameters: verbosity must be one of queryPlanner, executionStats, allPlansExecution"}`, 400},
+	}
+
+	url := ts.URL + "/channels/1/messages/explain"
+
+	for i, c := range cases {
+		req, err := http.NewRequest("GET", url+c.query, nil)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		req.Header.Set("Authorization", c.token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if c.body != withoutRequestID(body) {
+			t.Errorf("case %d: expected response %s got %s", i+1, c.body, string(body))
+		}
+	}
+}
+
+func TestExplainMatchesRead(t *testing.T) {
+	const cid = "explained"
+
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": cid})
+
+	// Five readings, the last redelivered
+	v := 1.0
+	for _, tm := range []float64{1500000000, 1500000001, 1500000002, 1500000003, 1500000003} {
+		m := models.Message{Channel: cid, Name: "temperature", Time: tm, Value: &v}
+		coll := mfdb.MessageCollection(cid, m.Time)
+		if err := Db.C(coll).Insert(m); err != nil {
+			t.Fatal(err)
+		}
+		defer Db.C(coll).RemoveAll(bson.M{"channel": cid})
+	}
+
+	get := func(path string, result interface{}) {
+		req, err := http.NewRequest("GET", ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "admin")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected status %d got %d", path, http.StatusOK, res.StatusCode)
+		}
+		if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+	}
+
+	// Explained finds return as many documents as the reads
+	params := "?start_time=1499999999&end_time=1500000010"
+	for _, query := range []string{"", "&limit=2", "&limit=2&offset=3", "&offset=4"} {
+		var msgs []models.Message
+		get("/channels/"+cid+"/messages"+params+query, &msgs)
+
+		var explained struct {
+			Plans []struct {
+				Plan struct {
+					ExecutionStats struct {
+						NReturned int `json:"nReturned"`
+					} `json:"executionStats"`
+				} `json:"plan"`
+			} `json:"plans"`
+		}
+		get("/channels/"+cid+"/messages/explain"+params+query+"&verbosity=executionStats", &explained)
+
+		n := 0
+		for _, p := range explained.Plans {
+			n += p.Plan.ExecutionStats.NReturned
+		}
+		if n != len(msgs) {
+			t.Errorf("%q: expected the explained read to return %d messages got %d", query, len(msgs), n)
+		}
+	}
+
+	// Reads collapsing duplicates are explained as aggregations
+	var explained map[string]interface{}
+	get("/channels/"+cid+"/messages/explain"+params+"&distinct_on=time,name", &explained)
+	if b, _ := json.Marshal(explained); !strings.Contains(string(b), "$replaceRoot") {
+		t.Errorf("expected the plan of the distinct aggregation got %s", b)
+	}
+}
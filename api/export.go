@@ -0,0 +1,129 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/export"
+)
+
+// createExport function enqueues a background export job
+func createExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	var req export.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, "malformed request body", nil)
+		return
+	}
+
+	// Replays onto the live channel subjects are reserved to
+	// administrators, as consumers ingest them again
+	if req.Destination.Type == "nats" && !authorizeAdmin(w, r) {
+		return
+	}
+
+	// Results leave the reader only for administrators and owners
+	if t := req.Destination.Type; t != "" && t != "file" && !isAdmin(r) && owner(r) == "" {
+		writeError(w, r, http.StatusForbidden, CodeForbidden, "credentials required for destination", map[string]interface{}{"type": t})
+		return
+	}
+
+	req.Tenant = tenant(r)
+	req.Owner, _ = scopedOwner(r)
+
+	j, err := export.Create(req)
+	switch err {
+	case nil:
+	case export.ErrQueueFull:
+		writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, err.Error(), nil)
+		return
+	case export.ErrDestinationNotAllowed:
+		writeError(w, r, http.StatusForbidden, CodeForbidden, err.Error(), nil)
+		return
+	default:
+		badFilter(w, r, err)
+		return
+	}
+
+	res, err := json.Marshal(j)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.Header().Set("Location", "/exports/"+j.ID)
+	w.WriteHeader(http.StatusAccepted)
+	io.WriteString(w, string(res))
+}
+
+// getExport function returns the status of an export job
+func getExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	id := bone.GetValue(r, "export_id")
+	j, err := export.Get(id)
+	if o, ok := scopedOwner(r); err != nil || ok && j.Owner != o {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Export not found", map[string]interface{}{"id": id})
+		return
+	}
+
+	res, err := json.Marshal(j)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// signedDownload function reports whether r follows a valid signed
+// download link, which needs no credentials
+func signedDownload(r *http.Request) bool {
+	p := unversioned(r.URL.Path)
+	if r.Method != "GET" || !strings.HasPrefix(p, "/exports/") || !strings.HasSuffix(p, "/download") {
+		return false
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(p, "/exports/"), "/download")
+	q := r.URL.Query()
+	return export.VerifyLink(id, q.Get("expires"), q.Get("sig"))
+}
+
+// downloadExport function serves the file produced by a finished export
+// job to requests following its signed link, or made by administrators or
+// by the owner of the job
+func downloadExport(w http.ResponseWriter, r *http.Request) {
+	id := bone.GetValue(r, "export_id")
+	j, err := export.Get(id)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Export not found", map[string]interface{}{"id": id})
+		return
+	}
+	if !signedDownload(r) && !isAdmin(r) {
+		if o, ok := scopedOwner(r); !ok || j.Owner != o {
+			writeError(w, r, http.StatusForbidden, CodeForbidden, "signed download link required", map[string]interface{}{"id": id})
+			return
+		}
+	}
+
+	if !export.Downloadable(j) {
+		writeError(w, r, http.StatusConflict, CodeConflict, "Export not available for download", map[string]interface{}{"status": j.Status})
+		return
+	}
+
+	contentType := j.ContentType()
+	if j.Compression == "" {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+j.Filename()+`"`)
+	http.ServeFile(w, r, export.Path(j.ID))
+}
@@ -0,0 +1,75 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/export"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestDownloadExport(t *testing.T) {
+	const cid = "export"
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": cid})
+
+	export.Start(1, 10)
+	defer export.Stop(context.Background())
+
+	res, err := http.Post(ts.URL+"/exports", "application/json", strings.NewReader(`{"channel": "export", "end_time": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var j export.Job
+	err = json.NewDecoder(res.Body).Decode(&j)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected job got status %d, %v", res.StatusCode, err)
+	}
+
+	for deadline := time.Now().Add(10 * time.Second); j.Status != export.Done; time.Sleep(50 * time.Millisecond) {
+		if j.Status == export.Failed || time.Now().After(deadline) {
+			t.Fatalf("expected job to finish got %+v", j)
+		}
+		j, _ = export.Get(j.ID)
+	}
+
+	cases := []struct {
+		path string
+		code int
+	}{
+		{j.Location, http.StatusOK},
+		{"/exports/" + j.ID + "/download", http.StatusForbidden},
+		{strings.Replace(j.Location, "sig=", "sig=0", 1), http.StatusForbidden},
+	}
+	for i, c := range cases {
+		res, err := http.Get(ts.URL + c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+	}
+}
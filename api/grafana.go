@@ -0,0 +1,509 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// GrafanaSearchLimit caps the number of targets a search lists.
+var GrafanaSearchLimit = 1000
+
+// Grafana SimpleJSON datasource contract.
+// Targets are expressed as `<channel_id>:<name>`, where the name part
+// is optional and, when omitted, selects all names on the channel.
+type (
+	grafanaRange struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	}
+
+	grafanaTarget struct {
+		Target string `json:"target"`
+		RefID  string `json:"refId"`
+		Type   string `json:"type"`
+	}
+
+	grafanaSearchReq struct {
+		Target string `json:"target"`
+	}
+
+	grafanaQueryReq struct {
+		Range         grafanaRange    `json:"range"`
+		IntervalMs    int64           `json:"intervalMs"`
+		MaxDataPoints int             `json:"maxDataPoints"`
+		Targets       []grafanaTarget `json:"targets"`
+	}
+
+	grafanaAnnotation struct {
+		Name   string `json:"name"`
+		Query  string `json:"query"`
+		Enable bool   `json:"enable"`
+	}
+
+	grafanaAnnotationsReq struct {
+		Range      grafanaRange      `json:"range"`
+		Annotation grafanaAnnotation `json:"annotation"`
+	}
+
+	grafanaSeries struct {
+		Target     string       `json:"target"`
+		Datapoints [][2]float64 `json:"datapoints"`
+		Truncated  bool         `json:"truncated,omitempty"`
+	}
+
+	grafanaColumn struct {
+		Text string `json:"text"`
+		Type string `json:"type"`
+	}
+
+	grafanaTable struct {
+		Type    string          `json:"type"`
+		Columns []grafanaColumn `json:"columns"`
+		Rows    [][]interface{} `json:"rows"`
+	}
+
+	grafanaAnnotationRes struct {
+		Annotation grafanaAnnotation `json:"annotation"`
+		Time       int64             `json:"time"`
+		Title      string            `json:"title"`
+		Text       string            `json:"text"`
+		Tags       []string          `json:"tags"`
+	}
+)
+
+// grafanaTest answers the datasource "Save & Test" probe
+func grafanaTest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, `{"response": "ok"}`)
+}
+
+// grafanaSearch function lists available `<channel_id>:<name>` targets
+// containing the requested one, no more than GrafanaSearchLimit
+func grafanaSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	var req grafanaSearchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, "malformed request body", nil)
+		return
+	}
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{}},
+		{"$group": bson.M{"_id": bson.M{"channel": "$channel", "name": "$name"}}},
+		{"$sort": bson.D{{Name: "_id.channel", Value: 1}, {Name: "_id.name", Value: 1}}},
+		{"$limit": GrafanaSearchLimit},
+	}
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	type group struct {
+		ID struct {
+			Channel string `bson:"channel"`
+			Name    string `bson:"name"`
+		} `bson:"_id"`
+	}
+	groups := []group{}
+	err := Db.Read(ctx, func() error {
+		names, match, err := searchCollections(&Db)
+		if err != nil {
+			return err
+		}
+		if req.Target != "" {
+			match = bson.M{"$and": []bson.M{match, searchMatch(req.Target)}}
+		}
+		pipeline[0]["$match"] = match
+		groups = []group{}
+		for _, name := range names {
+			part := []group{}
+			if err := Db.Aggregate(ctx, name, pipeline).All(&part); err != nil {
+				return err
+			}
+			groups = append(groups, part...)
+		}
+		return nil
+	})
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to list targets", nil)
+		return
+	}
+
+	targets := []string{}
+	seen := map[string]bool{}
+	for _, g := range groups {
+		t := g.ID.Channel + ":" + g.ID.Name
+		if strings.Contains(t, req.Target) && !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+	sort.Strings(targets)
+	if len(targets) > GrafanaSearchLimit {
+		targets = targets[:GrafanaSearchLimit]
+	}
+
+	res, err := json.Marshal(targets)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// grafanaQuery function returns time series or tables for requested
+// targets. Series average the values of buckets of intervalMs, widened so
+// that there are no more than maxDataPoints, and tables list the latest
+// maxDataPoints messages. Series cut short by AggregateMaxScan keep the
+// latest messages and are flagged truncated.
+func grafanaQuery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	var req grafanaQueryReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, "malformed request body", nil)
+		return
+	}
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	results := []interface{}{}
+	for _, t := range req.Targets {
+		msgs := []models.Message{}
+		buckets := []bucket{}
+		truncated := false
+		channel, st, et := grafanaScope(t.Target, req.Range)
+		filter := grafanaFilter(t.Target, req.Range)
+		err := Db.Read(ctx, func() error {
+			if t.Type == "table" {
+				msgs = []models.Message{}
+				return Db.FindAll(ctx, channel, st, et, filter, "-time", req.MaxDataPoints, &msgs)
+			}
+			var err error
+			buckets, truncated, err = grafanaBuckets(ctx, r, &Db, channel, st, et, filter, grafanaInterval(req, st, et))
+			return err
+		})
+		if err == db.ErrNotOwned {
+			writeError(w, r, http.StatusForbidden, CodeForbidden, "target not allowed", map[string]interface{}{"target": t.Target})
+			return
+		}
+		if err != nil {
+			logger(r).Error(err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to query target", map[string]interface{}{"target": t.Target})
+			return
+		}
+		if t.Type == "table" {
+			redactAll(r, msgs)
+			results = append(results, grafanaToTable(msgs))
+			continue
+		}
+		results = append(results, grafanaToSeries(t.Target, buckets, truncated))
+	}
+
+	res, err := json.Marshal(results)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// grafanaAnnotations function turns messages of the queried target into annotations
+func grafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	var req grafanaAnnotationsReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, "malformed request body", nil)
+		return
+	}
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	msgs := []models.Message{}
+	channel, st, et := grafanaScope(req.Annotation.Query, req.Range)
+	err := Db.Read(ctx, func() error {
+		msgs = []models.Message{}
+		return Db.FindAll(ctx, channel, st, et, grafanaFilter(req.Annotation.Query, req.Range), "time", 0, &msgs)
+	})
+	if err == db.ErrNotOwned {
+		writeError(w, r, http.StatusForbidden, CodeForbidden, "target not allowed", map[string]interface{}{"target": req.Annotation.Query})
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to query annotations", nil)
+		return
+	}
+	redactAll(r, msgs)
+
+	annotations := []grafanaAnnotationRes{}
+	for _, m := range msgs {
+		annotations = append(annotations, grafanaAnnotationRes{
+			Annotation: req.Annotation,
+			Time:       int64(m.Time * 1000),
+			Title:      m.Name,
+			Text:       m.StringValue,
+			Tags:       []string{m.Channel, m.Publisher},
+		})
+	}
+
+	res, err := json.Marshal(annotations)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// grafanaFilter maps a `<channel_id>:<name>` target and a time range to a Mongo filter
+func grafanaFilter(target string, rng grafanaRange) bson.M {
+	parts := strings.SplitN(target, ":", 2)
+	filter := bson.M{"channel": parts[0]}
+	if len(parts) == 2 && parts[1] != "" {
+		filter["name"] = parts[1]
+	}
+
+	t := bson.M{}
+	if !rng.From.IsZero() {
+		t["$gte"] = unixSeconds(rng.From)
+	}
+	if !rng.To.IsZero() {
+		t["$lte"] = unixSeconds(rng.To)
+	}
+	if len(t) > 0 {
+		filter["time"] = t
+	}
+
+	return filter
+}
+
+// grafanaInterval returns the bucket length in seconds of the series of
+// req between st and et: its interval, widened so that there are no more
+// buckets than its maximum number of points, or than AggregateMaxBuckets
+// if it sets neither
+func grafanaInterval(req grafanaQueryReq, st, et float64) float64 {
+	interval := float64(req.IntervalMs) / 1000
+	if req.MaxDataPoints > 0 {
+		interval = math.Max(interval, (et-st)/float64(req.MaxDataPoints))
+	}
+	if interval <= 0 && AggregateMaxBuckets > 0 {
+		interval = (et - st) / float64(AggregateMaxBuckets)
+	}
+	if interval <= 0 {
+		interval = 1
+	}
+	return interval
+}
+
+// grafanaBuckets returns, in time order, the buckets of interval seconds
+// of the numeric and boolean values of the messages of channel filter
+// selects, booleans counting as 1 or 0. Messages are bucketed as they are
+// read while redaction hooks are registered, so that the hooks see them.
+// No more than AggregateMaxScan messages are read, the latest ones, and
+// whether the scan was cut short is reported.
+func grafanaBuckets(ctx context.Context, r *http.Request, Db *db.MgoDb, channel string, st, et float64, filter bson.M, interval float64) ([]bucket, bool, error) {
+	match := bson.M{"$or": []bson.M{{"value": bson.M{"$exists": true}}, {"boolvalue": bson.M{"$exists": true}}}}
+	for k, v := range filter {
+		match[k] = v
+	}
+	merged := map[float64]*bucket{}
+
+	if redact.Enabled() {
+		msgs := []models.Message{}
+		if err := Db.FindAll(ctx, channel, st, et, match, "-time", AggregateMaxScan, &msgs); err != nil {
+			return nil, false, err
+		}
+		redactAll(r, msgs)
+		for _, m := range msgs {
+			var v float64
+			switch {
+			case m.Value != nil:
+				v = *m.Value
+			case m.BoolValue != nil && *m.BoolValue:
+				v = 1
+			case m.BoolValue != nil:
+				v = 0
+			default:
+				continue
+			}
+			mergeBucket(merged, bucket{Time: m.Time - math.Mod(m.Time, interval), Count: 1, Sum: v, Min: v, Max: v})
+		}
+		buckets := collect(merged)
+		sort.Sort(byTime(buckets))
+		return buckets, AggregateMaxScan > 0 && len(msgs) >= AggregateMaxScan, nil
+	}
+	value := bson.M{"$ifNull": []interface{}{"$value", bson.M{"$cond": []interface{}{"$boolvalue", 1, 0}}}}
+	group := bson.M{"$group": bson.M{
+		"_id":   bson.M{"$subtract": []interface{}{"$time", bson.M{"$mod": []interface{}{"$time", interval}}}},
+		"count": bson.M{"$sum": 1},
+		"sum":   bson.M{"$sum": value},
+	}}
+
+	collections, err := Db.MessageCollections(channel, st, et)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The scan cap is shared by the collections, read from the latest,
+	// so that dashboards cut short show the latest messages
+	left, truncated := AggregateMaxScan, false
+	for i := len(collections) - 1; i >= 0 && !truncated; i-- {
+		pipeline := []bson.M{{"$match": match}}
+		if AggregateMaxScan > 0 {
+			pipeline = append(pipeline, bson.M{"$sort": bson.M{"time": -1}}, bson.M{"$limit": left})
+		}
+		pipeline = append(pipeline, group)
+
+		part := []bucket{}
+		if err := Db.Aggregate(ctx, collections[i], pipeline).All(&part); err != nil {
+			return nil, false, err
+		}
+		for j := range part {
+			left -= part[j].Count
+			mergeBucket(merged, part[j])
+		}
+		truncated = AggregateMaxScan > 0 && left <= 0
+	}
+
+	buckets := collect(merged)
+	sort.Sort(byTime(buckets))
+	return buckets, truncated, nil
+}
+
+// searchMatch returns the query selecting the messages whose
+// `<channel_id>:<name>` target contains target
+func searchMatch(target string) bson.M {
+	parts := strings.SplitN(target, ":", 2)
+	if len(parts) == 2 {
+		return bson.M{
+			"channel": bson.M{"$regex": regexp.QuoteMeta(parts[0]) + "$"},
+			"name":    bson.M{"$regex": "^" + regexp.QuoteMeta(parts[1])},
+		}
+	}
+	quoted := regexp.QuoteMeta(target)
+	return bson.M{"$or": []bson.M{
+		{"channel": bson.M{"$regex": quoted}},
+		{"name": bson.M{"$regex": quoted}},
+	}}
+}
+
+// grafanaScope returns the channel and time span of a target and range
+func grafanaScope(target string, rng grafanaRange) (string, float64, float64) {
+	st, et := float64(db.Earliest), float64(db.Latest)
+	if !rng.From.IsZero() {
+		st = unixSeconds(rng.From)
+	}
+	if !rng.To.IsZero() {
+		et = unixSeconds(rng.To)
+	}
+
+	return strings.SplitN(target, ":", 2)[0], st, et
+}
+
+// searchCollections returns the collections holding the messages Db may
+// read, and the query selecting them: all of them, or those of the
+// channels of its owner
+func searchCollections(Db *db.MgoDb) ([]string, bson.M, error) {
+	if Db.Owner() == "" {
+		names, err := Db.MessageCollections("", db.Earliest, db.Latest)
+		return names, bson.M{}, err
+	}
+
+	owned, err := Db.OwnedChannels()
+	if err != nil {
+		return nil, nil, err
+	}
+	names := []string{}
+	seen := map[string]bool{}
+	for _, ch := range owned {
+		parts, err := Db.MessageCollections(ch, db.Earliest, db.Latest)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, name := range parts {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names, bson.M{"channel": bson.M{"$in": owned}}, nil
+}
+
+func grafanaToSeries(target string, buckets []bucket, truncated bool) grafanaSeries {
+	s := grafanaSeries{Target: target, Datapoints: [][2]float64{}, Truncated: truncated}
+	for _, b := range buckets {
+		s.Datapoints = append(s.Datapoints, [2]float64{b.Sum / float64(b.Count), b.Time * 1000})
+	}
+
+	return s
+}
+
+func grafanaToTable(msgs []models.Message) grafanaTable {
+	t := grafanaTable{
+		Type: "table",
+		Columns: []grafanaColumn{
+			{"Time", "time"},
+			{"Name", "string"},
+			{"Publisher", "string"},
+			{"Value", "number"},
+			{"String value", "string"},
+			{"Unit", "string"},
+		},
+		Rows: [][]interface{}{},
+	}
+	for _, m := range msgs {
+		t.Rows = append(t.Rows, []interface{}{
+			int64(m.Time * 1000), m.Name, m.Publisher, m.Value, m.StringValue, m.Unit,
+		})
+	}
+
+	return t
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
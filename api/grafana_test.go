@@ -0,0 +1,122 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestGrafanaSearch(t *testing.T) {
+	cases := []struct {
+		req  string
+		body string
+		code int
+	}{
+		{`{"target": ""}`, `[]`, 200},
+		{`{"target"`, `{"code":"invalid_request","message":"malformed request body","response":"malformed request body"}`, 400},
+	}
+
+	url := ts.URL + "/grafana/search"
+
+	for i, c := range cases {
+		res, err := http.Post(url, "application/json", strings.NewReader(c.req))
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if c.body != withoutRequestID(body) {
+			t.Errorf("case %d: expected response %s got %s", i+1, c.body, string(body))
+		}
+	}
+}
+
+func TestGrafanaQuery(t *testing.T) {
+	const cid = "grafana"
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": cid})
+
+	// Ten readings, one per second, of values 1 to 10
+	for i := 0; i < 10; i++ {
+		v := float64(i + 1)
+		m := models.Message{Channel: cid, Name: "temperature", Time: 1500000000 + float64(i), Value: &v}
+		coll := mfdb.MessageCollection(cid, m.Time)
+		if err := Db.C(coll).Insert(m); err != nil {
+			t.Fatal(err)
+		}
+		defer Db.C(coll).RemoveAll(bson.M{"channel": cid})
+	}
+
+	body := `{"range": {"from": "2017-07-14T02:39:59Z", "to": "2017-07-14T02:40:10Z"}, "intervalMs": 5000, "maxDataPoints": 100,
+		"targets": [{"target": "grafana:temperature", "type": "timeserie"}]}`
+	res, err := http.Post(ts.URL+"/grafana/query", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var series []struct {
+		Datapoints [][2]float64
+		Truncated  bool
+	}
+	err = json.NewDecoder(res.Body).Decode(&series)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("expected series got status %d, %v", res.StatusCode, err)
+	}
+
+	expected := [][2]float64{{3, 1500000000000}, {8, 1500000005000}}
+	if len(series) != 1 || !reflect.DeepEqual(series[0].Datapoints, expected) || series[0].Truncated {
+		t.Errorf("expected datapoints %v got %v", expected, series)
+	}
+
+	// Scans cut short keep the latest four readings, of values 7 to 10
+	defer func(n int) { api.AggregateMaxScan = n }(api.AggregateMaxScan)
+	api.AggregateMaxScan = 4
+
+	res, err = http.Post(ts.URL+"/grafana/query", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	series = nil
+	err = json.NewDecoder(res.Body).Decode(&series)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("expected series got status %d, %v", res.StatusCode, err)
+	}
+
+	expected = [][2]float64{{8.5, 1500000005000}}
+	if len(series) != 1 || !reflect.DeepEqual(series[0].Datapoints, expected) || !series[0].Truncated {
+		t.Errorf("expected truncated datapoints %v got %v", expected, series)
+	}
+}
@@ -0,0 +1,108 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	// MaxResponseBytes caps the size of responses of message reads and
+	// aggregations, which are then buffered until complete. Zero removes
+	// the cap and lets responses stream.
+	MaxResponseBytes = 0
+
+	// Largest limit parameter of message reads, zero if uncapped
+	maxLimit int64 = 10000
+
+	errLimit         = errors.New("limit must be a positive number")
+	errResponseLarge = errors.New("response too large")
+)
+
+// pageLimit function reads the limit parameter of r, zero if absent
+func pageLimit(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("limit")
+	if s == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, errLimit
+	}
+	if max := MaxLimit(); max > 0 && n > max {
+		return 0, fmt.Errorf("limit exceeds the maximum of %d", max)
+	}
+	return n, nil
+}
+
+// MaxLimit function returns the cap of the limit parameter of message
+// reads, zero if there is none
+func MaxLimit() int {
+	return int(atomic.LoadInt64(&maxLimit))
+}
+
+// SetMaxLimit function caps the limit parameter of message reads. Zero
+// removes the cap. It may be called while requests are served.
+func SetMaxLimit(n int) {
+	atomic.StoreInt64(&maxLimit, int64(n))
+}
+
+// cappedWriter holds a response back until it is complete, or until it
+// grows past MaxResponseBytes
+type cappedWriter struct {
+	statusRecorder
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (cw *cappedWriter) WriteHeader(code int) {
+	cw.code = code
+}
+
+func (cw *cappedWriter) Write(b []byte) (int, error) {
+	if cw.overflow || cw.buf.Len()+len(b) > MaxResponseBytes {
+		cw.overflow = true
+		cw.buf = bytes.Buffer{}
+		return 0, errResponseLarge
+	}
+	return cw.buf.Write(b)
+}
+
+// Flush keeps capped responses buffered.
+func (cw *cappedWriter) Flush() {}
+
+// capped function answers 413 instead of the response of h when it
+// exceeds MaxResponseBytes
+func capped(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if MaxResponseBytes <= 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &cappedWriter{statusRecorder: statusRecorder{ResponseWriter: w, code: http.StatusOK}}
+		h.ServeHTTP(cw, r)
+
+		if cw.overflow {
+			w.Header().Del(TotalCountHeader)
+			w.Header().Del("Link")
+			msg := fmt.Sprintf("response exceeds %d bytes, narrow the query or set a limit", MaxResponseBytes)
+			writeError(w, r, http.StatusRequestEntityTooLarge, CodeLimitExceeded, msg, map[string]interface{}{"max_bytes": MaxResponseBytes})
+			return
+		}
+		w.WriteHeader(cw.code)
+		w.Write(cw.buf.Bytes())
+	})
+}
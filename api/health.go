@@ -0,0 +1,105 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+)
+
+// Time limit of dependency checks.
+const healthTimeout = 2 * time.Second
+
+// Overall health statuses
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+)
+
+type health struct {
+	Status string                 `json:"status"`
+	Checks map[string]interface{} `json:"checks"`
+}
+
+type natsHealth struct {
+	Status string `json:"status"`
+}
+
+// getHealth function reports the state of every dependency. It answers
+// 503 when MongoDB is unreachable, and reports a degraded status when an
+// optional dependency, such as NATS, is.
+func getHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	mongo := db.Check(ctx)
+	h := health{Status: healthOK, Checks: map[string]interface{}{"mongodb": mongo}}
+	if NatsConn != nil {
+		n := natsHealth{db.Up}
+		if !NatsConn.IsConnected() {
+			n.Status = db.Down
+			h.Status = healthDegraded
+		}
+		h.Checks["nats"] = n
+	}
+
+	code := http.StatusOK
+	if mongo.Status != db.Up {
+		h.Status = healthDown
+		code = http.StatusServiceUnavailable
+	}
+
+	res, err := json.Marshal(h)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(code)
+	io.WriteString(w, string(res))
+}
+
+// getLive function answers as long as the process serves requests, for
+// liveness probes. It does not depend on MongoDB, so that an unreachable
+// database does not get the reader restarted.
+func getLive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, `{"status": "ok"}`)
+}
+
+// getReady function answers 503 while MongoDB cannot be reached or the
+// reader shuts down, for readiness probes taking the reader out of load
+// balancing
+func getReady(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if Draining() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{"status": "draining"}`)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	if h := db.Check(ctx); h.Status != db.Up {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{"status": "down"}`)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, `{"status": "ok"}`)
+}
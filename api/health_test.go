@@ -0,0 +1,50 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHealthProbes(t *testing.T) {
+	cases := []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/live", `{"status": "ok"}`, 200},
+		{"/ready", `{"status": "ok"}`, 200},
+		{"/health", `"status":"ok"`, 200},
+		{"/health", `"mongodb":{"status":"up"`, 200},
+	}
+
+	for i, c := range cases {
+		res, err := http.Get(ts.URL + c.path)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if !strings.Contains(string(body), c.body) {
+			t.Errorf("case %d: expected response containing %s got %s", i+1, c.body, string(body))
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+func TestHMAC(t *testing.T) {
+	if err := api.SetHMACKeys([]api.HMACKey{{ID: "short", Secret: "s", Channels: []string{"*"}}}); err == nil {
+		t.Error("expected an error for a short secret")
+	}
+
+	secret := "0123456789abcdef0123"
+	if err := api.SetHMACKeys([]api.HMACKey{{ID: "batch", Secret: secret, Channels: []string{"7"}}}); err != nil {
+		t.Fatal(err)
+	}
+	defer api.SetHMACKeys(nil)
+
+	srv := httptest.NewServer(api.HTTPServer())
+	defer srv.Close()
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	body := []byte(`{"targets": []}`)
+
+	cases := []struct {
+		method string
+		path   string
+		key    string
+		ts     string
+		sig    string
+		status int
+	}{
+		{"GET", "/channels/7/messages?start_time=1", "batch", now, api.Sign(secret, "GET", "/channels/7/messages?start_time=1", now, nil), 0},
+		{"GET", "/channels/7/messages?start_time=2", "batch", now, api.Sign(secret, "GET", "/channels/7/messages?start_time=1", now, nil), http.StatusUnauthorized},
+		{"GET", "/channels/7/messages", "batch", old, api.Sign(secret, "GET", "/channels/7/messages", old, nil), http.StatusUnauthorized},
+		{"GET", "/channels/7/messages", "other", now, api.Sign(secret, "GET", "/channels/7/messages", now, nil), http.StatusUnauthorized},
+		{"GET", "/channels/8/messages", "batch", now, api.Sign(secret, "GET", "/channels/8/messages", now, nil), http.StatusForbidden},
+		{"POST", "/grafana/query", "batch", now, api.Sign(secret, "POST", "/grafana/query", now, body), http.StatusForbidden},
+		{"POST", "/grafana/query", "batch", now, api.Sign(secret, "POST", "/grafana/query", now, nil), http.StatusUnauthorized},
+	}
+
+	for i, c := range cases {
+		var b []byte
+		if c.method == "POST" {
+			b = body
+		}
+		req, err := http.NewRequest(c.method, srv.URL+c.path, bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(api.SignatureKeyHeader, c.key)
+		req.Header.Set(api.SignatureTimestampHeader, c.ts)
+		req.Header.Set(api.SignatureHeader, c.sig)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		rejected := res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnauthorized
+		if c.status != 0 && res.StatusCode != c.status || c.status == 0 && rejected {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.status, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/ipfilter"
+)
+
+// filterIP function answers 403 to clients whose address the IP rules do
+// not allow
+func filterIP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !ipfilter.Allowed(ip) {
+		writeError(w, r, http.StatusForbidden, CodeForbidden, "address not allowed", nil)
+		return
+	}
+
+	next(w, r)
+}
@@ -0,0 +1,107 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	"github.com/mainflux/mainflux-mongodb-reader/jwt"
+)
+
+// signJWT returns an ES256 token of claims signed with key
+func signJWT(t *testing.T, key *ecdsa.PrivateKey, claims map[string]interface{}) string {
+	enc := base64.RawURLEncoding
+	h, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "k"})
+	c, _ := json.Marshal(claims)
+	signed := enc.EncodeToString(h) + "." + enc.EncodeToString(c)
+
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	rb, sb := r.Bytes(), s.Bytes()
+	copy(sig[32-len(rb):], rb)
+	copy(sig[64-len(sb):], sb)
+
+	return signed + "." + enc.EncodeToString(sig)
+}
+
+func TestJWT(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := base64.RawURLEncoding
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "EC", "kid": "k", "crv": "P-256",
+			"x": enc.EncodeToString(key.X.Bytes()),
+			"y": enc.EncodeToString(key.Y.Bytes()),
+		}}})
+	}))
+	defer jwks.Close()
+
+	v, err := jwt.NewVerifier(jwks.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.JWTVerifier = v
+	defer func() { api.JWTVerifier = nil }()
+
+	srv := httptest.NewServer(api.HTTPServer())
+	defer srv.Close()
+
+	exp := time.Now().Unix() + 60
+	line := signJWT(t, key, map[string]interface{}{"sub": "line", "exp": exp, "channels": []string{"7"}})
+	support := signJWT(t, key, map[string]interface{}{"sub": "support", "exp": exp, "role": "admin"})
+	expired := signJWT(t, key, map[string]interface{}{"sub": "line", "exp": exp - 3600, "channels": []string{"7"}})
+
+	cases := []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{"/channels/7/messages", line, 0},
+		{"/channels/8/messages", line, http.StatusForbidden},
+		{"/channels/8/messages", support, 0},
+		{"/channels/7/messages", expired, http.StatusUnauthorized},
+		{"/channels/7/messages", "opaque-thing-token", 0},
+	}
+
+	for i, c := range cases {
+		req, err := http.NewRequest("GET", srv.URL+c.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		rejected := res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnauthorized
+		if c.status != 0 && res.StatusCode != c.status || c.status == 0 && rejected {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.status, res.StatusCode)
+		}
+	}
+}
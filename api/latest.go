@@ -0,0 +1,59 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+)
+
+// getLatest function returns the latest message of each name of the
+// channel. Parameters:
+// - name = only the latest message with this name.
+func getLatest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	repo, release, ok := openMessages(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	cid := bone.GetValue(r, "channel_id")
+
+	en, ok := enrichment(w, r)
+	if !ok {
+		return
+	}
+	catalog, ok := annotation(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	msgs, err := repo.Latest(ctx, cid, r.URL.Query().Get("name"))
+	if err != nil {
+		readError(w, r, cid, "failed to read latest values", err)
+		return
+	}
+	setDocCount(r, len(msgs))
+	redactAll(r, msgs)
+
+	res, err := json.Marshal(decorateAll(msgs, en, catalog))
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
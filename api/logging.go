@@ -0,0 +1,187 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// RequestIDHeader names the header carrying the request correlation ID.
+const RequestIDHeader = "X-Request-ID"
+
+// Request IDs accepted from clients; others are replaced by a new one.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type requestKey struct{}
+
+// requestInfo holds what handlers report about the request being served
+type requestInfo struct {
+	id      string
+	docs    int
+	subject string
+	owner   string
+	tenant  string
+	admin   bool
+}
+
+// requestWriter records the response status and adds the request ID to
+// the JSON body of server errors lacking it
+type requestWriter struct {
+	statusRecorder
+	id    string
+	wrote bool
+	bytes int64
+}
+
+func (rw *requestWriter) Write(b []byte) (int, error) {
+	rw.bytes += int64(len(b))
+	if rw.wrote || rw.code < http.StatusInternalServerError {
+		rw.wrote = true
+		return rw.ResponseWriter.Write(b)
+	}
+	rw.wrote = true
+
+	body := bytes.TrimSpace(b)
+	if len(body) < 2 || body[0] != '{' || body[len(body)-1] != '}' || bytes.Contains(body, []byte(`"request_id"`)) {
+		return rw.ResponseWriter.Write(b)
+	}
+
+	field := `"request_id": "` + rw.id + `"`
+	if len(bytes.TrimSpace(body[1:len(body)-1])) > 0 {
+		field = ", " + field
+	}
+	out := append(append(body[:len(body)-1:len(body)-1], field...), '}')
+	if _, err := rw.ResponseWriter.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// requestLogger function assigns every request a correlation ID, taken
+// from the X-Request-ID header or generated, returns it to the client
+// and logs the request once served
+func requestLogger(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+
+	id := r.Header.Get(RequestIDHeader)
+	if !validRequestID.MatchString(id) {
+		id = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+
+	info := &requestInfo{id: id}
+	rw := &requestWriter{statusRecorder: statusRecorder{ResponseWriter: w, code: http.StatusOK}, id: id}
+	r = r.WithContext(context.WithValue(r.Context(), requestKey{}, info))
+	next(rw, r)
+
+	fields := log.Fields{
+		"request_id": id,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"status":     rw.code,
+		"duration":   time.Since(start).Seconds(),
+		"doc_count":  info.docs,
+	}
+	if cid := channelID(r.URL.Path); cid != "" {
+		fields["channel_id"] = cid
+	}
+
+	if audited(r) || info.admin {
+		auditRequest(r, id, rw.code, info.docs, rw.bytes)
+	}
+	// Failed requests, such as those on unknown channels, and unverified
+	// tokens would fill the usage label caps
+	if audited(r) && rw.code >= http.StatusOK && rw.code < http.StatusMultipleChoices {
+		owner, ok := verifiedSubject(r)
+		if !ok {
+			owner = "anonymous"
+		}
+		recordUsage(channelID(r.URL.Path), owner, info.docs, rw.bytes)
+	}
+	if audited(r) {
+		recordEgress(r, info.docs, rw.bytes)
+	}
+
+	entry := log.WithFields(fields)
+	if rw.code >= http.StatusInternalServerError {
+		entry.Warn("request failed")
+		return
+	}
+	entry.Info("request served")
+}
+
+// logger function returns the logger of messages about request r
+func logger(r *http.Request) *log.Entry {
+	fields := log.Fields{"method": r.Method, "path": r.URL.Path}
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		fields["request_id"] = info.id
+	}
+	if cid := channelID(r.URL.Path); cid != "" {
+		fields["channel_id"] = cid
+	}
+	return log.WithFields(fields)
+}
+
+// setDocCount function records the number of documents returned to r
+func setDocCount(r *http.Request, n int) {
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		info.docs = n
+	}
+}
+
+// docCount function returns the number of documents recorded as
+// returned to r
+func docCount(r *http.Request) int {
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		return info.docs
+	}
+	return 0
+}
+
+// setSubject function records who made r, once authenticated
+func setSubject(r *http.Request, s string) {
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		info.subject = s
+	}
+}
+
+// setAdmin function records that r was granted administrative access as
+// subject s
+func setAdmin(r *http.Request, s string) {
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		info.subject = s
+		info.admin = true
+	}
+}
+
+// channelID returns the channel a /channels/:channel_id path refers to
+func channelID(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(unversioned(path), "/"), "/", 3)
+	if len(parts) < 2 || parts[0] != "channels" {
+		return ""
+	}
+	return parts[1]
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strings.Replace(time.Now().Format("20060102150405.000000000"), ".", "", 1)
+	}
+	return hex.EncodeToString(b)
+}
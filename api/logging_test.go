@@ -0,0 +1,51 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	cases := []struct {
+		id       string
+		expected string
+	}{
+		{"abc-123", "abc-123"},
+		{"bad id", ""},
+		{"", ""},
+	}
+
+	url := ts.URL + "/status"
+
+	for i, c := range cases {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		if c.id != "" {
+			req.Header.Set("X-Request-ID", c.id)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		id := res.Header.Get("X-Request-ID")
+		if c.expected != "" && id != c.expected {
+			t.Errorf("case %d: expected request ID %s got %s", i+1, c.expected, id)
+		}
+		if id == "" || id == c.id && c.expected == "" {
+			t.Errorf("case %d: expected a generated request ID got %q", i+1, id)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/logging"
+)
+
+// getLogLevel function reports the levels of the reader and driver logs
+func getLogLevel(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	res, err := json.Marshal(logging.Get())
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// setLogLevel function changes the levels of the reader and driver logs.
+// Omitted levels are left unchanged.
+func setLogLevel(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	var l logging.Levels
+	if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, "malformed request body", nil)
+		return
+	}
+
+	if l.Level != "" {
+		if err := logging.SetLevel(l.Level); err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidFilter, "unknown log level", nil)
+			return
+		}
+	}
+	if l.Mongo != "" {
+		if err := logging.SetMongoLevel(l.Mongo); err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidFilter, "unknown mongo log level", nil)
+			return
+		}
+	}
+	logger(r).Warnf("Log levels set to %+v", logging.Get())
+
+	res, err := json.Marshal(logging.Get())
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
@@ -0,0 +1,157 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/stream"
+)
+
+// MaxWait caps the wait parameter of message reads. Zero disables long
+// polling.
+var MaxWait = 60 * time.Second
+
+// heldWriter holds a response back until the handler decides whether to
+// send it
+type heldWriter struct {
+	statusRecorder
+	buf bytes.Buffer
+}
+
+func (hw *heldWriter) WriteHeader(code int) {
+	hw.code = code
+}
+
+func (hw *heldWriter) Write(b []byte) (int, error) {
+	return hw.buf.Write(b)
+}
+
+// Flush keeps held responses buffered.
+func (hw *heldWriter) Flush() {}
+
+// send function writes the held response to the client
+func (hw *heldWriter) send() {
+	hw.ResponseWriter.WriteHeader(hw.code)
+	hw.ResponseWriter.Write(hw.buf.Bytes())
+}
+
+// parseWait function reads a wait parameter, a duration such as 30s or a
+// number of seconds
+func parseWait(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// checkWait function checks that s is a wait within MaxWait
+func checkWait(s string) string {
+	if MaxWait <= 0 {
+		return "is not supported, long polling is disabled"
+	}
+	d, err := parseWait(s)
+	if err != nil || d < 0 {
+		return "must be a duration such as 30s"
+	}
+	if d > MaxWait {
+		return fmt.Sprintf("must not exceed %s", MaxWait)
+	}
+	return ""
+}
+
+// longPoll function holds reads of h that find no message, or none the
+// client hasn't seen, until a message within their time range is stored
+// or their wait parameter expires, and then answers them
+func longPoll(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wait, err := parseWait(r.URL.Query().Get("wait"))
+		if err != nil || wait <= 0 || MaxWait <= 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if !tailable(w, r) {
+			return
+		}
+
+		// Subscribe before reading, so that nothing stored in between
+		// is missed
+		sub := stream.Subscribe(bone.GetValue(r, "channel_id"))
+		defer sub.Close()
+
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		for {
+			hw := &heldWriter{statusRecorder: statusRecorder{ResponseWriter: w, code: http.StatusOK}}
+			h.ServeHTTP(hw, r)
+			if !unchanged(r, hw.code) {
+				hw.send()
+				return
+			}
+
+			if !awaitMessage(r, sub, timer.C) {
+				hw.send()
+				return
+			}
+		}
+	})
+}
+
+// unchanged function reports whether a read answered with code found
+// nothing new for the client
+func unchanged(r *http.Request, code int) bool {
+	if code == http.StatusNotModified {
+		return true
+	}
+	if code != http.StatusOK {
+		return false
+	}
+	info, ok := r.Context().Value(requestKey{}).(*requestInfo)
+	return ok && info.docs == 0
+}
+
+// awaitMessage function waits for a message within the time range of r,
+// and reports whether one was stored before expired fires, the client
+// left or the reader drains
+func awaitMessage(r *http.Request, sub *stream.Subscription, expired <-chan time.Time) bool {
+	st, et, err := timeRange(r)
+	if err != nil {
+		return false
+	}
+	// The range is read again after the wait, so that a default end
+	// moves along with the stored messages
+	open := r.URL.Query().Get("end_time") == ""
+
+	for {
+		select {
+		case <-expired:
+			return false
+		case <-r.Context().Done():
+			return false
+		case <-draining:
+			return false
+		case m, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			if m.Time > st && (open || m.Time < et) {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLongPoll(t *testing.T) {
+	cases := []struct {
+		query string
+		code  int
+	}{
+		{"?wait=5s", http.StatusNotFound},
+		{"?wait=5", http.StatusNotFound},
+	}
+
+	for i, c := range cases {
+		start := time.Now()
+		res, err := http.Get(ts.URL + "/channels/unknown/messages" + c.query)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+		// Failed reads are answered at once
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("case %d: expected an immediate answer got one after %s", i+1, d)
+		}
+	}
+}
@@ -10,8 +10,8 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -19,29 +19,166 @@ import (
 	"github.com/go-zoo/bone"
 	"github.com/mainflux/mainflux-mongodb-reader/db"
 	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
 	"gopkg.in/mgo.v2/bson"
 )
 
+var (
+	errStartTime = errors.New("wrong start_time format")
+	errEndTime   = errors.New("wrong end_time format")
+)
+
 // getMessage function
 func getMessage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
-	Db := db.MgoDb{}
-	Db.Init()
-	defer Db.Close()
+	repo, release, ok := openMessages(w, r)
+	if !ok {
+		return
+	}
+	defer release()
 
 	cid := bone.GetValue(r, "channel_id")
 
-	if err := Db.C("channels").Find(bson.M{"id": cid}).One(nil); err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		str := `{"response": "Channel not found", "id": "` + cid + `"}`
-		io.WriteString(w, str)
+	st, et, err := timeRange(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	limit, err := pageLimit(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	offset, err := pageOffset(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	mode, err := countMode(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	en, ok := enrichment(w, r)
+	if !ok {
+		return
+	}
+	catalog, ok := annotation(w, r)
+	if !ok {
+		return
+	}
+	distinct, ok := distinctOn(w, r, st, et)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	q := repository.Query{Channel: cid, Start: st, End: et, Offset: offset, Limit: limit, Distinct: distinct}
+	p := &page{Offset: offset, Limit: limit}
+	if mode != countNone {
+		total, err := countMessages(ctx, r, repo, mode, q)
+		if err != nil {
+			readError(w, r, cid, "can't count messages", err)
+			return
+		}
+		w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+		p.Total = &total
+	}
+
+	if limit > 0 {
+		if p.Total != nil && mode == countExact {
+			p.HasMore = offset+limit < *p.Total
+		} else if p.HasMore, err = hasMore(ctx, repo, q, offset+limit); err != nil {
+			readError(w, r, cid, "can't read messages", err)
+			return
+		}
+	}
+	setLinks(w, r, p)
+	meta := r.URL.Query().Get("meta") == "true"
+
+	// Messages are encoded as they are read from the cursor, so only the
+	// first batch is read before the response is committed.
+	iter, err := repo.Iter(ctx, q)
+	if err != nil {
+		readError(w, r, cid, "can't read messages", err)
 		return
 	}
+	defer iter.Close()
+
+	var raw bson.Raw
+	more := iter.Next(&raw)
+
+	n := 0
+	defer func() { setDocCount(r, n) }()
+	redacted := redact.Enabled()
+	admin := redacted && isAdmin(r)
+
+	// Documents are transcoded straight to JSON unless hooks or labels
+	// need them decoded, or they don't fit the fast path.
+	buf := make([]byte, 0, 1024)
+	w.WriteHeader(http.StatusOK)
+	if meta {
+		io.WriteString(w, `{"messages": `)
+	}
+	io.WriteString(w, "[")
+	for sep := ""; more; more = iter.Next(&raw) {
+		res, ok := buf[:0], false
+		if !redacted && en == nil && catalog == nil {
+			res, ok = models.AppendMessageJSON(res, raw.Data)
+		}
+		if ok {
+			buf = res
+		} else {
+			var m models.Message
+			if err := raw.Unmarshal(&m); err != nil {
+				logger(r).Error(err)
+				return
+			}
+			redact.Apply(&m, admin)
+			if res, err = json.Marshal(decorate(m, en, catalog)); err != nil {
+				logger(r).Error(err)
+				return
+			}
+		}
+		io.WriteString(w, sep)
+		if _, err := w.Write(res); err != nil {
+			// The client is gone or the response outgrew its cap.
+			return
+		}
+		n++
 
-	// Get fileter values from parameters:
-	// - start_time = messages from this moment. UNIX time format.
-	// - end_time = messages to this moment. UNIX time format.
+		sep = ","
+	}
+	if err := iter.Close(); err != nil {
+		// The status is already sent; the truncated body signals the failure.
+		logger(r).Error(err)
+		return
+	}
+	io.WriteString(w, "]")
+	if meta {
+		b, _ := json.Marshal(p)
+		io.WriteString(w, `, "meta": `+string(b)+"}")
+	}
+}
+
+// messageFilter function returns the query selecting channel messages
+// stored within (st, et)
+func messageFilter(cid string, st, et float64) bson.M {
+	return bson.M{"channel": cid, "time": bson.M{"$gt": st, "$lt": et}}
+}
+
+// timeRange function reads filter values from parameters:
+// - start_time = messages from this moment. UNIX time format.
+// - end_time = messages to this moment. UNIX time format.
+func timeRange(r *http.Request) (float64, float64, error) {
 	var st float64
 	var et float64
 	var err error
@@ -52,10 +189,7 @@ func getMessage(w http.ResponseWriter, r *http.Request) {
 	} else {
 		st, err = strconv.ParseFloat(s, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			str := `{"response": "wrong start_time format"}`
-			io.WriteString(w, str)
-			return
+			return 0, 0, errStartTime
 		}
 	}
 	s = r.URL.Query().Get("end_time")
@@ -64,27 +198,20 @@ func getMessage(w http.ResponseWriter, r *http.Request) {
 	} else {
 		et, err = strconv.ParseFloat(s, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			str := `{"response": "wrong end_time format"}`
-			io.WriteString(w, str)
-			return
+			return 0, 0, errEndTime
 		}
 	}
 
-	results := []models.Message{}
-	if err := Db.C("messages").Find(bson.M{"channel": cid, "time": bson.M{"$gt": st, "$lt": et}}).
-		All(&results); err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusNotFound)
-		str := `{"response": "not found", "id": "` + cid + `"}`
-		io.WriteString(w, str)
+	return st, et, nil
+}
+
+// redactAll function runs the redaction hooks on msgs, returned to r
+func redactAll(r *http.Request, msgs []models.Message) {
+	if !redact.Enabled() {
 		return
 	}
-
-	w.WriteHeader(http.StatusOK)
-	res, err := json.Marshal(results)
-	if err != nil {
-		log.Print(err)
+	admin := isAdmin(r)
+	for i := range msgs {
+		redact.Apply(&msgs[i], admin)
 	}
-	io.WriteString(w, string(res))
 }
@@ -0,0 +1,44 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec("mongo_reader_http_requests_total",
+		"HTTP requests served.", "method", "endpoint", "status")
+	httpErrors = metrics.NewCounterVec("mongo_reader_http_request_errors_total",
+		"HTTP requests answered with a server error.", "method", "endpoint", "status")
+	httpDuration = metrics.NewHistogramVec("mongo_reader_http_request_duration_seconds",
+		"Time spent serving HTTP requests.", metrics.DefBuckets, "method", "endpoint", "status")
+)
+
+// instrument function counts and times requests served by h. Requests are
+// labeled by the route pattern rather than the path, which would create
+// a series per channel.
+func instrument(method, endpoint string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		h.ServeHTTP(sr, r)
+
+		status := strconv.Itoa(sr.code)
+		httpRequests.Inc(method, endpoint, status)
+		if sr.code >= http.StatusInternalServerError {
+			httpErrors.Inc(method, endpoint, status)
+		}
+		httpDuration.Observe(time.Since(start).Seconds(), method, endpoint, status)
+	})
+}
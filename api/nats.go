@@ -9,8 +9,8 @@
 package api
 
 import (
+	log "github.com/Sirupsen/logrus"
 	"github.com/nats-io/go-nats"
-	"log"
 )
 
 type (
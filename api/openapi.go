@@ -0,0 +1,390 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/export"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/retention"
+	"github.com/mainflux/mainflux-mongodb-reader/units"
+	"github.com/mainflux/mainflux-mongodb-reader/version"
+)
+
+// SwaggerUI enables the Swagger UI page at /swagger/, browsing the
+// specification served at /swagger.json.
+var SwaggerUI bool
+
+// Summary of routes missing from the operations table, which the tests
+// look for.
+const undocumented = "Undocumented"
+
+type (
+	// param describes a query parameter of an operation, which requests
+	// are checked against. Check returns why a value of the parameter's
+	// type is invalid, and Excludes names a parameter it can't be
+	// combined with.
+	param struct {
+		Name        string
+		Description string
+		Type        string
+		Required    bool
+		Enum        []string
+		Check       func(string) string
+		Excludes    string
+	}
+
+	// operation documents a route. Request and response schemas are
+	// derived from the Go values the handler reads and writes.
+	operation struct {
+		Summary  string
+		Tag      string
+		Params   []param
+		Body     interface{}
+		Status   int
+		Response interface{}
+	}
+)
+
+var (
+	timeParams = []param{
+		{Name: "start_time", Description: "Messages stored after this UNIX time.", Type: "number", Check: atLeast(0)},
+		{Name: "end_time", Description: "Messages stored before this UNIX time, now by default.", Type: "number", Check: atLeast(0)},
+	}
+
+	messages = []models.Message{}
+	object   = map[string]interface{}{}
+)
+
+// operations documents the routes, by method and bone path
+var operations = map[string]operation{
+	"GET /status":       {Summary: "Service status", Tag: "status", Response: object},
+	"GET /capabilities": {Summary: "Features supported by the MongoDB server", Tag: "status", Response: object},
+	"GET /version":      {Summary: "Build and enabled features", Tag: "status", Response: version.Info{}},
+	"GET /health":       {Summary: "Health of the service and its dependencies", Tag: "health", Response: object},
+	"GET /live":         {Summary: "Liveness probe", Tag: "health", Response: object},
+	"GET /ready":        {Summary: "Readiness probe", Tag: "health", Response: object},
+
+	"GET /channels/:channel_id/messages": {Summary: "Read messages", Tag: "messages", Response: messages, Params: append([]param{
+		{Name: "limit", Description: "Largest number of messages returned; pages are read in time order.", Type: "integer", Check: checkLimit},
+		{Name: "offset", Description: "Number of messages skipped.", Type: "integer", Check: atLeast(0)},
+		{Name: "cursor", Description: "Opaque position of the page, from next_cursor.", Type: "string", Check: checkCursor, Excludes: "offset"},
+		{Name: "count", Description: "Total returned in the X-Total-Count header.", Type: "string", Enum: []string{countNone, countExact, countEstimate}},
+		{Name: "meta", Description: "Wrap messages in an object with page metadata.", Type: "boolean"},
+		{Name: "enrich", Description: "Label messages with the names and metadata of their channel and publisher.", Type: "boolean"},
+		{Name: "annotate", Description: "Annotate messages with the unit registry entry of their name and their violations of it.", Type: "boolean"},
+		{Name: "distinct_on", Description: "Collapse messages agreeing on these fields, e.g. time,name,publisher, into the first stored; must include time. The time range may span a day by default.", Type: "string", Check: checkDistinct},
+		{Name: "wait", Description: "Hold a read finding no new message until one is stored, up to this duration, e.g. 30s.", Type: "string", Check: checkWait},
+	}, timeParams...)},
+	"DELETE /channels/:channel_id/messages": {Summary: "Purge messages", Tag: "messages", Response: object, Params: []param{
+		{Name: "start_time", Description: "Purge messages stored after this UNIX time.", Type: "number", Required: true, Check: atLeast(0)},
+		{Name: "end_time", Description: "Purge messages stored before this UNIX time.", Type: "number", Required: true, Check: atLeast(0)},
+		{Name: "dry_run", Description: "Count instead of deleting.", Type: "boolean"},
+	}},
+	"GET /channels/:channel_id/messages/ws":     {Summary: "Stream messages over a WebSocket", Tag: "messages", Status: http.StatusSwitchingProtocols, Params: timeParams},
+	"GET /channels/:channel_id/messages/stream": {Summary: "Stream messages as server-sent events", Tag: "messages", Params: append([]param{{Name: "last_event_id", Description: "Resume after this message id.", Type: "string"}}, timeParams...)},
+	"GET /channels/:channel_id/messages/aggregate": {Summary: "Aggregate message values in time buckets", Tag: "messages", Response: aggregation{}, Params: append([]param{
+		{Name: "interval", Description: "Bucket width in seconds, 3600 by default.", Type: "number", Check: positive},
+		{Name: "fn", Description: "Aggregation function, avg by default.", Type: "string", Enum: []string{"avg", "min", "max", "sum", "count"}},
+		{Name: "name", Description: "Aggregate only messages of this name.", Type: "string"},
+	}, timeParams...)},
+	"GET /channels/:channel_id/messages/latest": {Summary: "Latest message of every name", Tag: "messages", Response: messages, Params: []param{
+		{Name: "name", Description: "Only the latest message of this name.", Type: "string"},
+		{Name: "enrich", Description: "Label messages with the names and metadata of their channel and publisher.", Type: "boolean"},
+		{Name: "annotate", Description: "Annotate messages with the unit registry entry of their name and their violations of it.", Type: "boolean"},
+	}},
+	"GET /channels/:channel_id/messages/explain": {Summary: "Query plan of a message read", Tag: "messages", Response: object, Params: append([]param{
+		{Name: "verbosity", Description: "Explain verbosity.", Type: "string", Enum: []string{"queryPlanner", "executionStats", "allPlansExecution"}},
+		{Name: "limit", Description: "Largest number of messages of the explained read.", Type: "integer", Check: checkLimit},
+		{Name: "offset", Description: "Number of messages the explained read skips.", Type: "integer", Check: atLeast(0)},
+		{Name: "cursor", Description: "Opaque position of the explained page, from next_cursor.", Type: "string", Check: checkCursor, Excludes: "offset"},
+		{Name: "distinct_on", Description: "Fields the explained read collapses duplicates by, as for message reads.", Type: "string", Check: checkDistinct},
+	}, timeParams...)},
+	"GET /channels/:channel_id/stats": {Summary: "Message count, time span and size of a channel", Tag: "statistics", Response: channelStats{}, Params: timeParams},
+	"GET /channels/:channel_id/quality": {Summary: "Gaps, duplicates and out of range values of a channel, over the last day by default", Tag: "statistics", Response: quality{}, Params: append([]param{
+		{Name: "interval", Description: "Declared reporting interval in seconds.", Type: "number", Required: true, Check: positive},
+		{Name: "name", Description: "Analyze only messages of this name.", Type: "string"},
+		{Name: "gaps", Description: "Number of longest gaps listed, 10 by default, at most 1000.", Type: "integer", Check: atLeast(0)},
+		{Name: "min", Description: "Values below are out of range, instead of the unit registry range.", Type: "number"},
+		{Name: "max", Description: "Values above are out of range, instead of the unit registry range.", Type: "number"},
+	}, timeParams...)},
+
+	"GET /pool":      {Summary: "MongoDB connection pool", Tag: "admin", Response: object},
+	"GET /breakers":  {Summary: "Circuit breakers", Tag: "admin", Response: object},
+	"GET /log-level": {Summary: "Log levels", Tag: "admin", Response: object},
+	"PUT /log-level": {Summary: "Set log levels", Tag: "admin", Body: object, Response: object},
+	"GET /retention": {Summary: "Retention periods", Tag: "admin", Response: struct {
+		Default  int64              `json:"default"`
+		Channels []retention.Policy `json:"channels"`
+	}{}},
+	"PUT /channels/:channel_id/retention":    {Summary: "Override the retention period of a channel", Tag: "admin", Body: retention.Policy{}, Response: object},
+	"DELETE /channels/:channel_id/retention": {Summary: "Remove the retention override of a channel", Tag: "admin", Response: object},
+	"GET /duplicates": {Summary: "Channels storing duplicate messages, by decreasing share of duplicates", Tag: "admin", Response: object, Params: append([]param{
+		{Name: "distinct_on", Description: "Fields duplicates agree on, time,name,publisher by default; must include time.", Type: "string", Check: checkDistinct},
+		{Name: "limit", Description: "Number of channels listed, 100 by default.", Type: "integer", Check: atLeast(1)},
+	}, timeParams...)},
+
+	"GET /units":          {Summary: "Unit registry", Tag: "units", Response: []units.Entry{}},
+	"GET /units/:name":    {Summary: "Unit registry entry of a SenML name", Tag: "units", Response: units.Entry{}},
+	"PUT /units/:name":    {Summary: "Register the unit, display name and range of a SenML name", Tag: "admin", Body: units.Entry{}, Response: units.Entry{}},
+	"DELETE /units/:name": {Summary: "Remove the unit registry entry of a SenML name", Tag: "admin", Status: http.StatusNoContent},
+
+	"POST /exports":           {Summary: "Start an export", Tag: "exports", Body: export.Request{}, Status: http.StatusAccepted, Response: export.Job{}},
+	"GET /exports/:export_id": {Summary: "Export progress", Tag: "exports", Response: export.Job{}},
+	"GET /exports/:export_id/download": {Summary: "Download an export", Tag: "exports", Params: []param{
+		{Name: "expires", Description: "Expiry of a signed download link, as a UNIX time.", Type: "integer"},
+		{Name: "sig", Description: "Signature of a signed download link, which needs no credentials.", Type: "string"},
+	}},
+	"GET /grafana":              {Summary: "Grafana datasource test", Tag: "grafana"},
+	"GET /grafana/":             {Summary: "Grafana datasource test", Tag: "grafana"},
+	"POST /grafana/search":      {Summary: "Grafana metric search", Tag: "grafana", Body: object, Response: []string{}},
+	"POST /grafana/query":       {Summary: "Grafana time series query", Tag: "grafana", Body: object, Response: []interface{}{}},
+	"POST /grafana/annotations": {Summary: "Grafana annotations", Tag: "grafana", Body: object, Response: []interface{}{}},
+	"GET /metrics":              {Summary: "Prometheus metrics", Tag: "admin"},
+	"GET /swagger.json":         {Summary: "This specification", Tag: "status", Response: object},
+	"GET /swagger/":             {Summary: "Swagger UI", Tag: "status"},
+}
+
+// openAPI function returns the OpenAPI 3 specification of the routes of mux
+func openAPI(mux *bone.Mux) map[string]interface{} {
+	paths := map[string]interface{}{}
+	for method, routes := range mux.Routes {
+		// Paths ending with a slash are kept apart as static routes,
+		// served for any method and documented as GET
+		if method == "static" {
+			method = "GET"
+		}
+		for _, r := range routes {
+			op, ok := operations[method+" "+unversioned(r.Path)]
+			if !ok {
+				op = operation{Summary: undocumented}
+			}
+
+			path, item := specPath(r.Path)
+			if _, ok := paths[path]; !ok {
+				paths[path] = map[string]interface{}{}
+			}
+			spec := specOperation(op, item)
+			if isLegacy(method, r.Path) {
+				spec["deprecated"] = true
+			}
+			paths[path].(map[string]interface{})[strings.ToLower(method)] = spec
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":   "Mainflux MongoDB Reader",
+			"version": version.Get().Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+			"schemas": map[string]interface{}{
+				"Error": schema(reflect.TypeOf(Error{})),
+			},
+		},
+		"security": []interface{}{map[string]interface{}{"bearer": []string{}}, map[string]interface{}{}},
+	}
+}
+
+// specPath function converts a bone path to an OpenAPI path, and returns
+// its path parameters
+func specPath(p string) (string, []string) {
+	params := []string{}
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+func specOperation(op operation, pathParams []string) map[string]interface{} {
+	params := []interface{}{}
+	for _, p := range pathParams {
+		params = append(params, map[string]interface{}{
+			"name": p, "in": "path", "required": true,
+			"schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, p := range op.Params {
+		s := map[string]interface{}{"type": p.Type}
+		if len(p.Enum) > 0 {
+			s["enum"] = p.Enum
+		}
+		params = append(params, map[string]interface{}{
+			"name": p.Name, "in": "query", "required": p.Required,
+			"description": p.Description, "schema": s,
+		})
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ok := map[string]interface{}{"description": http.StatusText(status)}
+	if op.Response != nil {
+		ok["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema(reflect.TypeOf(op.Response))},
+		}
+	}
+	failure := map[string]interface{}{
+		"description": "Error",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+			},
+		},
+	}
+
+	res := map[string]interface{}{
+		"summary":    op.Summary,
+		"parameters": params,
+		"responses": map[string]interface{}{
+			strconv.Itoa(status): ok,
+			"default":            failure,
+		},
+	}
+	if op.Tag != "" {
+		res["tags"] = []string{op.Tag}
+	}
+	if op.Body != nil {
+		res["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schema(reflect.TypeOf(op.Body))},
+			},
+		}
+	}
+	return res
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema function derives the JSON schema of the JSON encoding of values
+// of type t
+func schema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schema(t.Elem())}
+	case reflect.Struct:
+		props := map[string]interface{}{}
+		required := []string{}
+		structFields(t, props, &required)
+		s := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			sort.Strings(required)
+			s["required"] = required
+		}
+		return s
+	}
+	return map[string]interface{}{}
+}
+
+// structFields adds the JSON fields of struct type t to props, inlining
+// embedded structs as encoding/json does
+func structFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i:]
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			structFields(f.Type, props, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		props[name] = schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// specHandler function serves the specification of the routes of mux
+func specHandler(mux *bone.Mux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := json.Marshal(openAPI(mux))
+		if err != nil {
+			logger(r).Error(err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "can't build the specification", nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(res)
+	})
+}
+
+const swaggerPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>Mainflux MongoDB Reader API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "../swagger.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+// getSwaggerUI function serves the Swagger UI page, which loads its
+// assets from a CDN
+func getSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, swaggerPage)
+}
@@ -0,0 +1,57 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	res, err := http.Get(ts.URL + "/swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, res.StatusCode)
+	}
+
+	var spec struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.OpenAPI != "3.0.0" {
+		t.Errorf("expected OpenAPI 3.0.0 got %s", spec.OpenAPI)
+	}
+	for _, path := range []string{"/v1/channels/{channel_id}/messages", "/v1/exports", "/v1/swagger.json", "/health"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("expected path %s to be described", path)
+		}
+	}
+	if op := spec.Paths["/channels/{channel_id}/messages"]["get"]; op["deprecated"] != true {
+		t.Errorf("expected legacy path to be deprecated")
+	}
+	if op := spec.Paths["/v1/channels/{channel_id}/messages"]["get"]; op["deprecated"] != nil {
+		t.Errorf("expected versioned path not to be deprecated")
+	}
+	for path, ops := range spec.Paths {
+		for method, op := range ops {
+			if op["summary"] == "Undocumented" {
+				t.Errorf("%s %s is not documented", method, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+)
+
+// OwnerScoping restricts the data requests of clients other than
+// administrators to the channels of the owner their credentials name: the
+// owner claim, or else the subject, of their JWT, or the owner of their
+// API or signing key.
+var OwnerScoping bool
+
+// setOwner function records the owner the credentials of r name
+func setOwner(r *http.Request, o string) {
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		info.owner = o
+	}
+}
+
+// owner function returns the owner the credentials of r name, if any
+func owner(r *http.Request) string {
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok && info.owner != "" {
+		return info.owner
+	}
+	if k, ok := apiKey(r); ok {
+		return k.Owner
+	}
+	return ""
+}
+
+// scopedOwner function returns the owner the data r reads is restricted
+// to, and whether it is restricted at all
+func scopedOwner(r *http.Request) (string, bool) {
+	if !OwnerScoping || isAdmin(r) {
+		return "", false
+	}
+	return owner(r), true
+}
+
+// scopeOwner function answers 403 to data requests whose credentials name
+// no owner while data is scoped by owner
+func scopeOwner(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if o, ok := scopedOwner(r); ok && o == "" && audited(r) {
+		writeError(w, r, http.StatusForbidden, CodeForbidden, "credentials name no owner", nil)
+		return
+	}
+
+	next(w, r)
+}
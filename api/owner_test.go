@@ -0,0 +1,80 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestOwnerScoping(t *testing.T) {
+	err := api.SetAPIKeys([]api.APIKey{
+		{ID: "acme", Hash: keyHash("acme-key"), Channels: []string{"*"}, Owner: "acme"},
+		{ID: "beta", Hash: keyHash("beta-key"), Channels: []string{"*"}, Owner: "beta"},
+		{ID: "nobody", Hash: keyHash("nobody-key"), Channels: []string{"*"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.SetAPIKeys(nil)
+
+	api.OwnerScoping = true
+	defer func() { api.OwnerScoping = false }()
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": "acme-1", "owner": "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": "acme-1"})
+
+	srv := httptest.NewServer(api.HTTPServer())
+	defer srv.Close()
+
+	cases := []struct {
+		method string
+		path   string
+		key    string
+		code   int
+	}{
+		{"GET", "/channels/acme-1/messages", "acme-key", http.StatusOK},
+		{"GET", "/channels/acme-1/messages/latest", "acme-key", http.StatusOK},
+		{"GET", "/channels/acme-1/messages", "beta-key", http.StatusNotFound},
+		{"GET", "/channels/acme-1/messages/latest", "beta-key", http.StatusNotFound},
+		{"GET", "/channels/acme-1/stats", "beta-key", http.StatusNotFound},
+		{"GET", "/channels/acme-1/messages", "nobody-key", http.StatusForbidden},
+		{"POST", "/grafana/query", "nobody-key", http.StatusForbidden},
+		{"GET", "/version", "nobody-key", http.StatusOK},
+	}
+
+	for i, c := range cases {
+		req, err := http.NewRequest(c.method, srv.URL+c.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(api.APIKeyHeader, c.key)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+	}
+}
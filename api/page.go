@@ -0,0 +1,122 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	errOffset = errors.New("offset must be a non-negative number")
+	errCursor = errors.New("malformed cursor")
+)
+
+// page struct describes the part of a message read returned, and is sent
+// as the meta object of reads asking for it
+type page struct {
+	Total      *int   `json:"total,omitempty"`
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pageOffset function reads the position of the first message of a read
+// from the offset or cursor parameter of r, zero if absent
+func pageOffset(r *http.Request) (int, error) {
+	q := r.URL.Query()
+	if c := q.Get("cursor"); c != "" {
+		return parseCursor(c)
+	}
+
+	s := q.Get("offset")
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, errOffset
+	}
+	return n, nil
+}
+
+// parseCursor function returns the offset of cursor c
+func parseCursor(c string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil || !strings.HasPrefix(string(b), "o:") {
+		return 0, errCursor
+	}
+	n, err := strconv.Atoi(string(b[2:]))
+	if err != nil || n < 0 {
+		return 0, errCursor
+	}
+	return n, nil
+}
+
+// cursor function returns the opaque cursor of the read starting at offset
+func cursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+// hasMore function reports whether messages q selects from repo follow
+// the first n
+func hasMore(ctx context.Context, repo repository.MessageRepository, q repository.Query, n int) (bool, error) {
+	q.Offset, q.Limit = n, 1
+	it, err := repo.Iter(ctx, q)
+	if err != nil {
+		return false, err
+	}
+	var raw bson.Raw
+	more := it.Next(&raw)
+	return more, it.Close()
+}
+
+// setLinks function sets the RFC 5988 Link header of the pages around p
+// and fills in its next cursor
+func setLinks(w http.ResponseWriter, r *http.Request, p *page) {
+	if p.Limit <= 0 {
+		return
+	}
+
+	link := func(offset int, rel string) string {
+		q := r.URL.Query()
+		q.Del("cursor")
+		q.Set("offset", strconv.Itoa(offset))
+		u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+
+	links := []string{link(0, "first")}
+	if p.Offset > 0 {
+		prev := p.Offset - p.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, link(prev, "prev"))
+	}
+	if p.HasMore {
+		p.NextCursor = cursor(p.Offset + p.Limit)
+		links = append(links, link(p.Offset+p.Limit, "next"))
+	}
+	if p.Total != nil && *p.Total > 0 {
+		links = append(links, link((*p.Total-1)/p.Limit*p.Limit, "last"))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
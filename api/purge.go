@@ -0,0 +1,93 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/latest"
+	"github.com/mainflux/mainflux-mongodb-reader/rollup"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// deleteMessages function removes channel messages within a mandatory time
+// range. With dry_run=true only the number of matching messages is returned.
+func deleteMessages(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	q := r.URL.Query()
+	if q.Get("start_time") == "" || q.Get("end_time") == "" {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidFilter, "start_time and end_time are required", nil)
+		return
+	}
+
+	st, et, err := timeRange(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	cid := bone.GetValue(r, "channel_id")
+	filter := bson.M{"channel": cid, "time": bson.M{"$gt": st, "$lt": et}}
+
+	if q.Get("dry_run") == "true" {
+		ctx, cancel := db.Context(r.Context())
+		defer cancel()
+
+		var n int
+		err := Db.Read(ctx, func() error {
+			var err error
+			n, err = Db.CountAll(ctx, cid, st, et, filter)
+			return err
+		})
+		if db.IsTimeout(err) {
+			timedOut(w, r)
+			return
+		}
+		if err != nil {
+			logger(r).Error(err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to count messages", nil)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, fmt.Sprintf(`{"dry_run": true, "count": %d}`, n))
+		return
+	}
+
+	removed, err := Db.RemoveMessages(cid, st, et, filter)
+	invalidate(r, cid)
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to delete messages", nil)
+		return
+	}
+	if err := rollup.Rebuild(&Db, cid, st, et); err != nil {
+		logger(r).Errorf("Can't rebuild rollups of channel %s: %v", cid, err)
+	}
+	if err := latest.Rebuild(&Db, cid); err != nil {
+		logger(r).Errorf("Can't rebuild latest values of channel %s: %v", cid, err)
+	}
+
+	logger(r).Infof("Purged %d messages of channel %s in (%v, %v)", removed, cid, st, et)
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, fmt.Sprintf(`{"dry_run": false, "deleted": %d}`, removed))
+}
@@ -0,0 +1,64 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+func TestDeleteMessages(t *testing.T) {
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+
+	cases := []struct {
+		token string
+		query string
+		body  string
+		code  int
+	}{
+		{"", "?start_time=0&end_time=10", `{"code":"forbidden","message":"admin access required","response":"admin access required"}`, 403},
+		{"admin", "", `{"code":"invalid_filter","message":"invalid query parameters: start_time is required; end_time is required","details":{"fields":[{"field":"start_time","message":"is required"},{"field":"end_time","message":"is required"}]},"response":"invalid query parameters: start_time is required; end_time is required"}`, 400},
+		{"admin", "?start_time=x&end_time=10", `{"code":"invalid_filter","message":"invalid query parameters: start_time must be a number","details":{"fields":[{"field":"start_time","message":"must be a number"}]},"response":"invalid query parameters: start_time must be a number"}`, 400},
+		{"admin", "?start_time=0&end_time=10&dry_run=true", `{"dry_run": true, "count": 0}`, 200},
+		{"admin", "?start_time=0&end_time=10", `{"dry_run": false, "deleted": 0}`, 200},
+	}
+
+	url := ts.URL + "/channels/1/messages"
+
+	for i, c := range cases {
+		req, err := http.NewRequest("DELETE", url+c.query, nil)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		req.Header.Set("Authorization", c.token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if c.body != withoutRequestID(body) {
+			t.Errorf("case %d: expected response %s got %s", i+1, c.body, string(body))
+		}
+	}
+}
@@ -0,0 +1,382 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/units"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// Window of quality reports without start_time.
+	defaultQualityWindow = 24 * 3600
+	// Number of longest gaps reported by default.
+	defaultQualityGaps = 10
+	// Largest number of longest gaps reported.
+	maxQualityGaps = 1000
+	// Silences longer than this many reporting intervals are gaps, so
+	// that late reports don't count as missed.
+	gapTolerance = 1.5
+)
+
+// QualityMaxScan caps the number of messages a quality report reads from
+// each collection, which bounds the timestamps its pipeline gathers in a
+// single document. Zero removes the cap.
+var QualityMaxScan = 100000
+
+var (
+	errRange     = errors.New("min must not exceed max")
+	errGapsCount = errors.New("gaps must not exceed 1000")
+)
+
+type (
+	// gap is a silence of a channel longer than its reporting interval
+	gap struct {
+		Start    float64 `json:"start" bson:"start"`
+		End      float64 `json:"end" bson:"end"`
+		Duration float64 `json:"duration"`
+		Missing  int     `json:"missing"`
+	}
+
+	// quality is the data quality report of a channel over a window
+	quality struct {
+		Channel        string  `json:"channel"`
+		Name           string  `json:"name,omitempty"`
+		StartTime      float64 `json:"start_time"`
+		EndTime        float64 `json:"end_time"`
+		Interval       float64 `json:"interval"`
+		Expected       int     `json:"expected"`
+		Actual         int     `json:"actual"`
+		Completeness   float64 `json:"completeness"`
+		Messages       int     `json:"messages"`
+		Duplicates     int     `json:"duplicates"`
+		DuplicateTimes int     `json:"duplicate_timestamps"`
+		OutOfRange     int     `json:"out_of_range"`
+		GapCount       int     `json:"gap_count"`
+		Gaps           []gap   `json:"gaps"`
+		Truncated      bool    `json:"truncated"`
+	}
+
+	// qualityPart is the outcome of the quality pipeline on a collection
+	qualityPart struct {
+		Messages       int     `bson:"messages"`
+		Reports        int     `bson:"reports"`
+		Duplicates     int     `bson:"duplicates"`
+		DuplicateTimes int     `bson:"duplicate_times"`
+		OutOfRange     int     `bson:"out_of_range"`
+		First          float64 `bson:"first"`
+		Last           float64 `bson:"last"`
+		Gaps           []gap   `bson:"gaps"`
+	}
+)
+
+// getQuality function reports the data quality of the channel over a
+// window, for fleet health monitoring: reports expected at the declared
+// reporting interval against those stored, the longest gaps between
+// them, messages repeating the name and time of another, and values out
+// of range. Parameters, besides the time range of getMessage, by default
+// the last day:
+// - interval = declared reporting interval in seconds, required.
+// - name = only messages with this name.
+// - gaps = number of longest gaps listed, 10 by default.
+// - min, max = range of values, by default the one the unit registry
+// gives their name, if any.
+func getQuality(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	cid := bone.GetValue(r, "channel_id")
+
+	if err := Db.FindChannel(cid); err != nil {
+		channelError(w, r, cid, err)
+		return
+	}
+
+	st, et, err := timeRange(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("start_time") == "" {
+		st = math.Max(et-defaultQualityWindow, 0)
+	}
+
+	rep := quality{Channel: cid, Name: q.Get("name"), StartTime: st, EndTime: et, Gaps: []gap{}}
+	rep.Interval, _ = strconv.ParseFloat(q.Get("interval"), 64)
+	n := defaultQualityGaps
+	if s := q.Get("gaps"); s != "" {
+		if n, _ = strconv.Atoi(s); n > maxQualityGaps {
+			badFilter(w, r, errGapsCount)
+			return
+		}
+	}
+	outOfRange, err := rangeCheck(q.Get("name"), q.Get("min"), q.Get("max"))
+	if err == errRange {
+		badFilter(w, r, err)
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to read units", nil)
+		return
+	}
+
+	match := bson.M{"channel": cid, "time": bson.M{"$gt": st, "$lt": et}}
+	if rep.Name != "" {
+		match["name"] = rep.Name
+	}
+	pipeline := qualityPipeline(match, outOfRange, rep.Interval*gapTolerance)
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	var parts []qualityPart
+	err = Db.Read(ctx, func() error {
+		collections, err := Db.MessageCollections(cid, st, et)
+		if err != nil {
+			return err
+		}
+
+		parts = nil
+		for _, c := range collections {
+			var part qualityPart
+			iter := Db.Aggregate(ctx, c, pipeline)
+			found := iter.Next(&part)
+			if err := iter.Close(); err != nil {
+				return err
+			}
+			if found {
+				parts = append(parts, part)
+			}
+		}
+		return nil
+	})
+	if db.IsTimeout(err) {
+		timedOut(w, r)
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to analyze messages", map[string]interface{}{"id": cid})
+		return
+	}
+
+	rep.merge(parts, n)
+
+	res, err := json.Marshal(rep)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// rangeCheck function returns the expression telling whether the value
+// of a message is out of range: out of [min, max] if either is given,
+// or else out of the range the unit registry gives its name. It returns
+// nil if no range applies.
+func rangeCheck(name, min, max string) (interface{}, error) {
+	numeric := bson.M{"$in": []interface{}{bson.M{"$type": "$value"}, []string{"double", "int", "long", "decimal"}}}
+	outside := func(e units.Entry) interface{} {
+		var or []interface{}
+		if e.Min != nil {
+			or = append(or, bson.M{"$lt": []interface{}{"$value", *e.Min}})
+		}
+		if e.Max != nil {
+			or = append(or, bson.M{"$gt": []interface{}{"$value", *e.Max}})
+		}
+		if or == nil {
+			return nil
+		}
+		return bson.M{"$or": or}
+	}
+
+	if min != "" || max != "" {
+		var e units.Entry
+		if min != "" {
+			v, _ := strconv.ParseFloat(min, 64)
+			e.Min = &v
+		}
+		if max != "" {
+			v, _ := strconv.ParseFloat(max, 64)
+			e.Max = &v
+		}
+		if e.Min != nil && e.Max != nil && *e.Min > *e.Max {
+			return nil, errRange
+		}
+		return bson.M{"$and": []interface{}{numeric, outside(e)}}, nil
+	}
+
+	if !units.Enabled {
+		return nil, nil
+	}
+	c, err := units.Load()
+	if err != nil {
+		return nil, err
+	}
+	var or []interface{}
+	for _, e := range c {
+		if (name != "" && e.Name != name) || outside(e) == nil {
+			continue
+		}
+		or = append(or, bson.M{"$and": []interface{}{bson.M{"$eq": []interface{}{"$name", e.Name}}, outside(e)}})
+	}
+	if or == nil {
+		return nil, nil
+	}
+	return bson.M{"$and": []interface{}{numeric, bson.M{"$or": or}}}, nil
+}
+
+// qualityPipeline function returns the pipeline reporting on the
+// messages matched by match: their number and those of their distinct
+// timestamps, of the messages repeating the name and time of another and
+// of those whose value outOfRange tells are out of range, if given, and
+// the silences between timestamps longer than threshold
+func qualityPipeline(match bson.M, outOfRange interface{}, threshold float64) []bson.M {
+	if outOfRange == nil {
+		outOfRange = false
+	}
+
+	pipeline := []bson.M{{"$match": match}, {"$sort": bson.M{"time": 1}}}
+	if QualityMaxScan > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": QualityMaxScan})
+	}
+	times := []interface{}{"$times", bson.M{"$subtract": []interface{}{"$$i", 1}}}
+	return append(pipeline,
+		// Messages of a name at a time
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"time": "$time", "name": "$name"},
+			"count": bson.M{"$sum": 1},
+			"out":   bson.M{"$sum": bson.M{"$cond": []interface{}{outOfRange, 1, 0}}},
+		}},
+		// Reports at a time
+		bson.M{"$group": bson.M{
+			"_id":        "$_id.time",
+			"messages":   bson.M{"$sum": "$count"},
+			"duplicates": bson.M{"$sum": bson.M{"$subtract": []interface{}{"$count", 1}}},
+			"out":        bson.M{"$sum": "$out"},
+		}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$group": bson.M{
+			"_id":             nil,
+			"messages":        bson.M{"$sum": "$messages"},
+			"reports":         bson.M{"$sum": 1},
+			"duplicates":      bson.M{"$sum": "$duplicates"},
+			"duplicate_times": bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$gt": []interface{}{"$duplicates", 0}}, 1, 0}}},
+			"out_of_range":    bson.M{"$sum": "$out"},
+			"first":           bson.M{"$first": "$_id"},
+			"last":            bson.M{"$last": "$_id"},
+			"times":           bson.M{"$push": "$_id"},
+		}},
+		// Silences between consecutive reports
+		bson.M{"$project": bson.M{
+			"messages":        1,
+			"reports":         1,
+			"duplicates":      1,
+			"duplicate_times": 1,
+			"out_of_range":    1,
+			"first":           1,
+			"last":            1,
+			"gaps": bson.M{"$filter": bson.M{
+				"input": bson.M{"$map": bson.M{
+					"input": bson.M{"$range": []interface{}{1, bson.M{"$size": "$times"}}},
+					"as":    "i",
+					"in": bson.M{
+						"start": bson.M{"$arrayElemAt": times},
+						"end":   bson.M{"$arrayElemAt": []interface{}{"$times", "$$i"}},
+					},
+				}},
+				"as":   "g",
+				"cond": bson.M{"$gt": []interface{}{bson.M{"$subtract": []interface{}{"$$g.end", "$$g.start"}}, threshold}},
+			}},
+		}},
+	)
+}
+
+// merge function completes the report from the outcomes of its pipeline
+// on the collections of the window, listing its n longest gaps. Silences
+// across collections and at the ends of the window count as gaps too.
+func (rep *quality) merge(parts []qualityPart, n int) {
+	threshold := rep.Interval * gapTolerance
+	sort.Sort(byFirst(parts))
+
+	var gaps []gap
+	prev := rep.StartTime
+	for _, p := range parts {
+		rep.Messages += p.Messages
+		rep.Actual += p.Reports
+		rep.Duplicates += p.Duplicates
+		rep.DuplicateTimes += p.DuplicateTimes
+		rep.OutOfRange += p.OutOfRange
+		if QualityMaxScan > 0 && p.Messages >= QualityMaxScan {
+			rep.Truncated = true
+		}
+
+		if p.First-prev > threshold {
+			gaps = append(gaps, gap{Start: prev, End: p.First})
+		}
+		gaps = append(gaps, p.Gaps...)
+		prev = p.Last
+	}
+	if rep.EndTime-prev > threshold {
+		gaps = append(gaps, gap{Start: prev, End: rep.EndTime})
+	}
+
+	for i := range gaps {
+		g := &gaps[i]
+		g.Duration = g.End - g.Start
+		g.Missing = int(math.Max(math.Floor(g.Duration/rep.Interval+0.5)-1, 1))
+	}
+	sort.Sort(byDuration(gaps))
+	rep.GapCount = len(gaps)
+	if len(gaps) > n {
+		gaps = gaps[:n]
+	}
+	rep.Gaps = append(rep.Gaps, gaps...)
+
+	rep.Expected = int(math.Floor((rep.EndTime - rep.StartTime) / rep.Interval))
+	rep.Completeness = 1
+	if rep.Expected > 0 && rep.Actual < rep.Expected {
+		rep.Completeness = float64(rep.Actual) / float64(rep.Expected)
+	}
+}
+
+type byFirst []qualityPart
+
+func (s byFirst) Len() int           { return len(s) }
+func (s byFirst) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byFirst) Less(i, j int) bool { return s[i].First < s[j].First }
+
+// byDuration sorts gaps longest first, and then in time order
+type byDuration []gap
+
+func (s byDuration) Len() int      { return len(s) }
+func (s byDuration) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byDuration) Less(i, j int) bool {
+	if s[i].Duration != s[j].Duration {
+		return s[i].Duration > s[j].Duration
+	}
+	return s[i].Start < s[j].Start
+}
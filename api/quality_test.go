@@ -0,0 +1,105 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestQuality(t *testing.T) {
+	const cid = "quality"
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": cid})
+
+	// Reports every minute, three of them missed, one sent twice and
+	// one out of range
+	normal, high := 20.0, 200.0
+	var msgs []models.Message
+	for k := 0; k < 10; k++ {
+		if k >= 4 && k <= 6 {
+			continue
+		}
+		m := models.Message{Channel: cid, Name: "temperature", Time: float64(1500000000 + 60*k), Value: &normal}
+		if k == 8 {
+			m.Value = &high
+		}
+		msgs = append(msgs, m)
+		if k == 2 {
+			msgs = append(msgs, m)
+		}
+	}
+	for _, m := range msgs {
+		coll := mfdb.MessageCollection(cid, m.Time)
+		if err := Db.C(coll).Insert(m); err != nil {
+			t.Fatal(err)
+		}
+		defer Db.C(coll).RemoveAll(bson.M{"channel": cid})
+	}
+
+	quality := ts.URL + "/channels/" + cid + "/quality"
+	res, err := http.Get(quality + "?interval=60&max=100&start_time=1499999999&end_time=1500000600")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&rep)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("expected report got status %d, %v", res.StatusCode, err)
+	}
+
+	expected := map[string]interface{}{
+		"expected":             10.0,
+		"actual":               7.0,
+		"completeness":         0.7,
+		"messages":             8.0,
+		"duplicates":           1.0,
+		"duplicate_timestamps": 1.0,
+		"out_of_range":         1.0,
+		"gap_count":            1.0,
+		"truncated":            false,
+	}
+	for k, v := range expected {
+		if rep[k] != v {
+			t.Errorf("expected %s %v got %v", k, v, rep[k])
+		}
+	}
+	gaps, _ := rep["gaps"].([]interface{})
+	if len(gaps) != 1 {
+		t.Fatalf("expected one gap got %v", rep["gaps"])
+	}
+	g := gaps[0].(map[string]interface{})
+	if g["start"] != 1500000180.0 || g["end"] != 1500000420.0 || g["missing"] != 3.0 {
+		t.Errorf("expected three reports missed after 1500000180 got %v", g)
+	}
+
+	for _, query := range []string{"", "?interval=0", "?interval=60&min=10&max=5", "?interval=60&gaps=1001"} {
+		res, err := http.Get(quality + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("%q: expected status %d got %d", query, http.StatusBadRequest, res.StatusCode)
+		}
+	}
+}
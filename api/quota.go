@@ -0,0 +1,82 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+	"github.com/mainflux/mainflux-mongodb-reader/quota"
+)
+
+// Headers describing the egress quota of the tenant of a data request.
+const (
+	QuotaNameHeader      = "X-Quota-Name"
+	QuotaLimitHeader     = "X-Quota-Limit"
+	QuotaRemainingHeader = "X-Quota-Remaining"
+	QuotaResetHeader     = "X-Quota-Reset"
+)
+
+// Tenant of requests selecting no tenant database and owning no channels.
+const defaultTenant = "default"
+
+var quotaExceeded = metrics.NewCounterVec("mongo_reader_quota_exceeded_total",
+	"Requests rejected by egress quotas, by quota.", "quota")
+
+// quotaTenant function returns the tenant the egress of r counts
+// against: its owner under owner scoping, or else the tenant whose
+// database it selects
+func quotaTenant(r *http.Request) string {
+	if o, ok := scopedOwner(r); ok {
+		return "owner:" + o
+	}
+	if t := tenant(r); t != "" {
+		return t
+	}
+	return defaultTenant
+}
+
+// enforceQuota function answers 429 to the data requests of tenants over
+// an egress quota, and tells the others the quota they are closest to
+// exhausting. Administrators are not held to quotas.
+func enforceQuota(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !quota.Enabled() || !audited(r) || isAdmin(r) {
+		next(w, r)
+		return
+	}
+
+	st, ok := quota.Check(quotaTenant(r))
+	if st.Name != "" {
+		reset := int(math.Ceil(time.Until(st.Reset).Seconds()))
+		w.Header().Set(QuotaNameHeader, st.Name)
+		w.Header().Set(QuotaLimitHeader, strconv.FormatInt(st.Limit, 10))
+		w.Header().Set(QuotaRemainingHeader, strconv.FormatInt(st.Remaining, 10))
+		w.Header().Set(QuotaResetHeader, strconv.Itoa(reset))
+		if !ok {
+			quotaExceeded.Inc(st.Name)
+			w.Header().Set("Retry-After", strconv.Itoa(reset))
+			writeError(w, r, http.StatusTooManyRequests, CodeQuotaExceeded, "egress quota exceeded", map[string]interface{}{"quota": st.Name})
+			return
+		}
+	}
+
+	next(w, r)
+}
+
+// recordEgress function counts the documents and bytes served to r
+// against the quotas of its tenant
+func recordEgress(r *http.Request, docs int, bytes int64) {
+	if !quota.Enabled() || isAdmin(r) {
+		return
+	}
+	quota.Record(quotaTenant(r), docs, bytes)
+}
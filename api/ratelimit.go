@@ -0,0 +1,71 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+	"github.com/mainflux/mainflux-mongodb-reader/ratelimit"
+)
+
+var rateLimited = metrics.NewCounterVec("mongo_reader_rate_limited_total",
+	"Requests rejected by the per-caller rate limiter.")
+
+// caller function returns the key r is rate limited under: the subject
+// of its verified credentials, or else its client address, so that
+// unverified tokens don't open new buckets
+func caller(r *http.Request) string {
+	if s, ok := verifiedSubject(r); ok {
+		return s
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// streaming reports whether r opens a long-lived stream or long poll,
+// which would hold a slot of its caller for as long as it stays open
+func streaming(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.Path, "/messages/ws") || strings.HasSuffix(r.URL.Path, "/messages/stream") ||
+		r.URL.Query().Get("wait") != ""
+}
+
+// limit function answers 429 to data requests of callers exceeding their
+// request rate or their number of concurrent requests. Streams count
+// against the rate only.
+func limit(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !audited(r) {
+		next(w, r)
+		return
+	}
+
+	release, wait, ok := ratelimit.Acquire(caller(r))
+	if !ok {
+		rateLimited.Inc()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, r, http.StatusTooManyRequests, CodeLimitExceeded, "too many requests", nil)
+		return
+	}
+	if streaming(r) {
+		release()
+		next(w, r)
+		return
+	}
+
+	defer release()
+	next(w, r)
+}
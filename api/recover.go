@@ -0,0 +1,28 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// recoverer function answers 500 to requests whose handler panicked, and
+// logs the panic with its stack and the request it happened on
+func recoverer(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	defer func() {
+		if err := recover(); err != nil {
+			logger(r).WithField("stack", string(debug.Stack())).Errorf("panic: %v", err)
+
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "internal server error", nil)
+		}
+	}()
+
+	next(w, r)
+}
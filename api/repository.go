@@ -0,0 +1,35 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+)
+
+// Messages is the repository the message handlers read when set, such
+// as the in-memory one of package mocks in tests. They otherwise read the
+// database of the tenant of each request.
+var Messages repository.MessageRepository
+
+// openMessages function returns the repository of the messages r reads,
+// and the function releasing it. When it can't be opened, the request is
+// answered and false is returned.
+func openMessages(w http.ResponseWriter, r *http.Request) (repository.MessageRepository, func(), bool) {
+	if Messages != nil {
+		return Messages, func() {}, true
+	}
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	return repository.NewMongo(&Db), func() { Db.Close() }, true
+}
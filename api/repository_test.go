@@ -0,0 +1,78 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	"github.com/mainflux/mainflux-mongodb-reader/mocks"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+func TestMessagesRepository(t *testing.T) {
+	const cid = "mocked"
+
+	repo := mocks.NewMessageRepository()
+	v := 1.0
+	repo.Save(
+		models.Message{Channel: cid, Publisher: "p1", Name: "temperature", Time: 1500000003, Value: &v},
+		models.Message{Channel: cid, Publisher: "p1", Name: "temperature", Time: 1500000001, Value: &v},
+		models.Message{Channel: cid, Publisher: "p1", Name: "temperature", Time: 1500000001, Value: &v},
+		models.Message{Channel: cid, Publisher: "p1", Name: "humidity", Time: 1500000002, Value: &v},
+	)
+	api.Messages = repo
+	defer func() { api.Messages = nil }()
+
+	read := ts.URL + "/channels/" + cid + "/messages?start_time=1499999999&end_time=1500000010"
+	cases := []struct {
+		url    string
+		status int
+		count  string
+		times  []float64
+	}{
+		{read + "&limit=2&count=exact", http.StatusOK, "4", []float64{1500000001, 1500000001}},
+		{read + "&limit=2&offset=2&count=exact", http.StatusOK, "4", []float64{1500000002, 1500000003}},
+		{read + "&limit=2&distinct_on=time,name&count=exact", http.StatusOK, "3", []float64{1500000001, 1500000002}},
+		{ts.URL + "/channels/" + cid + "/messages/latest?name=temperature", http.StatusOK, "", []float64{1500000003}},
+		{ts.URL + "/channels/unknown/messages?limit=2&count=exact", http.StatusNotFound, "", nil},
+	}
+	for i, c := range cases {
+		res, err := http.Get(c.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msgs []models.Message
+		if c.status == http.StatusOK {
+			err = json.NewDecoder(res.Body).Decode(&msgs)
+		}
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err)
+		}
+
+		if res.StatusCode != c.status {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.status, res.StatusCode)
+			continue
+		}
+		if n := res.Header.Get(api.TotalCountHeader); n != c.count {
+			t.Errorf("case %d: expected total %q got %q", i+1, c.count, n)
+		}
+		var times []float64
+		for _, m := range msgs {
+			times = append(times, m.Time)
+		}
+		if !reflect.DeepEqual(times, c.times) {
+			t.Errorf("case %d: expected times %v got %v", i+1, c.times, times)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/retention"
+)
+
+// getRetention function lists the default retention period and all
+// per-channel overrides, in seconds
+func getRetention(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	ps, err := retention.Policies()
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to list retention policies", nil)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Default  int64              `json:"default"`
+		Channels []retention.Policy `json:"channels"`
+	}{int64(retention.Default().Seconds()), ps})
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// setRetention function overrides the retention period of a channel
+func setRetention(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	var p retention.Policy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil || p.Period < 0 {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, "malformed request body", nil)
+		return
+	}
+	p.Channel = bone.GetValue(r, "channel_id")
+
+	if err := retention.SetPolicy(p); err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to save retention policy", nil)
+		return
+	}
+
+	res, err := json.Marshal(p)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// removeRetention function reverts a channel to the default retention period
+func removeRetention(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	if err := retention.RemovePolicy(bone.GetValue(r, "channel_id")); err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to remove retention policy", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
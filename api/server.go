@@ -13,19 +13,148 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
 )
 
+// SeparateAdmin moves the operational endpoints from HTTPServer to
+// AdminServer, so that the public port only serves data.
+var SeparateAdmin bool
+
 // HTTPServer function
 func HTTPServer() http.Handler {
 	mux := bone.New()
+	mux.NotFoundFunc(routeNotFound)
 
 	// Status
-	mux.Get("/status", http.HandlerFunc(getStatus))
+	versioned(mux, "GET", "/status", http.HandlerFunc(getStatus))
+	versioned(mux, "GET", "/capabilities", http.HandlerFunc(getCapabilities))
+	versioned(mux, "GET", "/version", http.HandlerFunc(getVersion))
 
 	// Messages
-	mux.Get("/channels/:channel_id/messages", http.HandlerFunc(getMessage))
+	versioned(mux, "GET", "/channels/:channel_id/messages", longPoll(validated(cached(capped(guard("messages", getMessage))))))
+	versioned(mux, "DELETE", "/channels/:channel_id/messages", guard("purge", deleteMessages))
+	versioned(mux, "GET", "/channels/:channel_id/messages/ws", http.HandlerFunc(getMessageWS))
+	versioned(mux, "GET", "/channels/:channel_id/messages/stream", http.HandlerFunc(getMessageSSE))
+	versioned(mux, "GET", "/channels/:channel_id/messages/aggregate", requires(db.FeatureAggregationCursor, cached(capped(guard("aggregate", getAggregate)))))
+	versioned(mux, "GET", "/channels/:channel_id/messages/latest", guard("latest", getLatest))
+	versioned(mux, "GET", "/channels/:channel_id/messages/explain", requires(db.FeatureExplainCommand, http.HandlerFunc(explainMessages)))
+
+	// Statistics
+	versioned(mux, "GET", "/channels/:channel_id/stats", requires(db.FeatureAggregationCursor, cached(guard("stats", getStats))))
+	versioned(mux, "GET", "/channels/:channel_id/quality", requires(db.FeatureArrayExpressions, cached(guard("quality", getQuality))))
+
+	// Unit registry
+	versioned(mux, "GET", "/units", http.HandlerFunc(getUnits))
+	versioned(mux, "GET", "/units/:name", http.HandlerFunc(getUnit))
+
+	// Exports
+	versioned(mux, "POST", "/exports", http.HandlerFunc(createExport))
+	versioned(mux, "GET", "/exports/:export_id", http.HandlerFunc(getExport))
+	versioned(mux, "GET", "/exports/:export_id/download", http.HandlerFunc(downloadExport))
+
+	// Grafana SimpleJSON datasource
+	versioned(mux, "GET", "/grafana", http.HandlerFunc(grafanaTest))
+	versioned(mux, "GET", "/grafana/", http.HandlerFunc(grafanaTest))
+	versioned(mux, "POST", "/grafana/search", requires(db.FeatureAggregationCursor, guard("grafana_search", grafanaSearch)))
+	versioned(mux, "POST", "/grafana/query", capped(guard("grafana_query", grafanaQuery)))
+	versioned(mux, "POST", "/grafana/annotations", guard("grafana_annotations", grafanaAnnotations))
+
+	if !SeparateAdmin {
+		adminRoutes(mux, true)
+	}
 
-	n := negroni.Classic()
+	// Specification
+	versioned(mux, "GET", "/swagger.json", specHandler(mux))
+	if SwaggerUI {
+		versioned(mux, "GET", "/swagger/", http.HandlerFunc(getSwaggerUI))
+	}
+	validateRoutes(mux)
+	instrumentRoutes(mux)
+
+	n := negroni.New()
+	n.UseFunc(requestLogger)
+	n.UseFunc(filterIP)
+	n.UseFunc(recoverer)
+	n.UseFunc(cors)
+	n.UseFunc(negotiateVersion)
+	n.UseFunc(authorizeKey)
+	n.UseFunc(authorizeJWT)
+	n.UseFunc(authorizeHMAC)
+	n.UseFunc(requireAuth)
+	n.UseFunc(selectTenant)
+	n.UseFunc(scopeOwner)
+	n.UseFunc(limit)
+	n.UseFunc(enforceQuota)
+	n.UseFunc(available)
 	n.UseHandler(mux)
 	return n
 }
+
+// AdminServer function returns the handler of the operational endpoints
+// and runtime diagnostics, served on an internal address when
+// SeparateAdmin is set
+func AdminServer() http.Handler {
+	mux := bone.New()
+	mux.NotFoundFunc(routeNotFound)
+	adminRoutes(mux, false)
+	validateRoutes(mux)
+	instrumentRoutes(mux)
+
+	sm := http.NewServeMux()
+	sm.Handle("/debug/", DebugServer())
+	sm.Handle("/", mux)
+
+	n := negroni.New()
+	n.UseFunc(requestLogger)
+	n.UseFunc(recoverer)
+	n.UseFunc(selectTenant)
+	n.UseHandler(sm)
+	return n
+}
+
+// adminRoutes registers the operational endpoints on mux, public when it
+// serves the public API too
+func adminRoutes(mux *bone.Mux, public bool) {
+	// Health
+	mux.Get("/health", http.HandlerFunc(getHealth))
+	mux.Get("/live", http.HandlerFunc(getLive))
+	mux.Get("/ready", http.HandlerFunc(getReady))
+
+	// Database
+	mux.Get("/pool", http.HandlerFunc(getPool))
+	mux.Get("/breakers", http.HandlerFunc(getBreakers))
+
+	// Logging
+	mux.Get("/log-level", http.HandlerFunc(getLogLevel))
+	mux.Put("/log-level", http.HandlerFunc(setLogLevel))
+
+	// Retention
+	mux.Get("/retention", http.HandlerFunc(getRetention))
+	mux.Put("/channels/:channel_id/retention", http.HandlerFunc(setRetention))
+	mux.Delete("/channels/:channel_id/retention", http.HandlerFunc(removeRetention))
+
+	// Duplicates
+	mux.Get("/duplicates", http.HandlerFunc(getDuplicates))
+
+	// Unit registry
+	mux.Put("/units/:name", http.HandlerFunc(setUnit))
+	mux.Delete("/units/:name", http.HandlerFunc(removeUnit))
+
+	// Metrics label usage by channel and by caller, so the public port
+	// only serves them to administrators
+	if public {
+		mux.Get("/metrics", adminOnly(metrics.Handler()))
+	} else {
+		mux.Get("/metrics", metrics.Handler())
+	}
+}
+
+// instrumentRoutes wraps every route of mux in request metrics
+func instrumentRoutes(mux *bone.Mux) {
+	for method, routes := range mux.Routes {
+		for _, r := range routes {
+			r.Handler = instrument(method, r.Path, r.Handler)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	draining  = make(chan struct{})
+	drainOnce sync.Once
+
+	// Streaming sessions, which outlive the requests the server tracks
+	// once their connection is hijacked
+	streams sync.WaitGroup
+)
+
+// Drain function ends streaming sessions and reports the reader as not
+// ready, so that load balancers stop routing to it
+func Drain() {
+	drainOnce.Do(func() { close(draining) })
+}
+
+// Draining function reports whether Drain was called
+func Draining() bool {
+	select {
+	case <-draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitStreams function waits until all streaming sessions have ended, or
+// ctx is done
+func WaitStreams(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		streams.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
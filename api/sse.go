@@ -0,0 +1,86 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// getMessageSSE function streams channel messages as Server-Sent Events.
+// Every event carries the message ObjectId as its id, so a reconnecting
+// client sending Last-Event-ID resumes right after the last received message.
+func getMessageSSE(w http.ResponseWriter, r *http.Request) {
+	cid := bone.GetValue(r, "channel_id")
+
+	if !tailable(w, r) {
+		return
+	}
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	if err := Db.FindChannel(cid); err != nil {
+		channelError(w, r, cid, err)
+		return
+	}
+
+	st, et, err := timeRange(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var resume bson.ObjectId
+	if lastID != "" {
+		if !bson.IsObjectIdHex(lastID) {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidFilter, "wrong Last-Event-ID format", nil)
+			return
+		}
+		resume = bson.ObjectIdHex(lastID)
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "streaming unsupported", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	admin := redact.Enabled() && isAdmin(r)
+	tailMessages(Db, cid, st, et, resume, r.Context().Done(), func(m models.StoredMessage) error {
+		redact.Apply(&m.Message, admin)
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", m.ID.Hex(), data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type channelStats struct {
+	Channel string  `json:"channel"`
+	Count   int     `json:"count" bson:"count"`
+	Oldest  float64 `json:"oldest" bson:"oldest"`
+	Newest  float64 `json:"newest" bson:"newest"`
+	Bytes   int64   `json:"bytes"`
+}
+
+// getStats function returns message count, time span and approximate
+// storage size of a channel. The size is estimated from the average
+// document size of the collections holding its messages.
+func getStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	cid := bone.GetValue(r, "channel_id")
+
+	if err := Db.FindChannel(cid); err != nil {
+		channelError(w, r, cid, err)
+		return
+	}
+
+	ctx, cancel := db.Context(r.Context())
+	defer cancel()
+
+	stats := channelStats{Channel: cid}
+	pipeline := []bson.M{
+		{"$match": bson.M{"channel": cid}},
+		{"$group": bson.M{
+			"_id":    nil,
+			"count":  bson.M{"$sum": 1},
+			"oldest": bson.M{"$min": "$time"},
+			"newest": bson.M{"$max": "$time"},
+		}},
+	}
+	err := Db.Read(ctx, func() error {
+		names, err := Db.MessageCollections(cid, db.Earliest, db.Latest)
+		if err != nil {
+			return err
+		}
+
+		stats = channelStats{Channel: cid}
+		for _, name := range names {
+			part := channelStats{}
+			iter := Db.Aggregate(ctx, name, pipeline)
+			iter.Next(&part)
+			if err := iter.Close(); err != nil {
+				return err
+			}
+			if part.Count == 0 {
+				continue
+			}
+
+			if stats.Count == 0 || part.Oldest < stats.Oldest {
+				stats.Oldest = part.Oldest
+			}
+			if part.Newest > stats.Newest {
+				stats.Newest = part.Newest
+			}
+			stats.Count += part.Count
+			stats.Bytes += collectionBytes(Db, name, part.Count)
+		}
+		return nil
+	})
+	if db.IsTimeout(err) {
+		timedOut(w, r)
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to compute stats", map[string]interface{}{"id": cid})
+		return
+	}
+
+	res, err := json.Marshal(stats)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// collectionBytes estimates the size of count documents of a collection
+// from its average document size
+func collectionBytes(Db db.MgoDb, collection string, count int) int64 {
+	coll := struct {
+		AvgObjSize float64 `bson:"avgObjSize"`
+	}{}
+	if err := Db.Db.Run(bson.D{{Name: "collStats", Value: collection}}, &coll); err != nil {
+		log.Print(err)
+	}
+	return int64(coll.AvgObjSize * float64(count))
+}
@@ -11,10 +11,19 @@ package api
 import (
 	"io"
 	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
 )
 
+// getStatus function reports a degraded status while the reader is not
+// connected to MongoDB
 func getStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !db.Connected() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{"running": true, "status": "degraded"}`)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 	str := `{"running": true}`
 	io.WriteString(w, str)
@@ -0,0 +1,74 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"context"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/stream"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// tailMessages sends the channel messages within [st, et] and then keeps
+// sending newly stored ones until closed is signalled or send fails.
+// When resume is a valid ObjectId, the backlog is replaced by all messages
+// stored after the message with that id.
+func tailMessages(Db db.MgoDb, cid string, st, et float64, resume bson.ObjectId,
+	closed <-chan struct{}, send func(models.StoredMessage) error) {
+	// Subscribe before reading the backlog so nothing stored in between is
+	// missed; messages seen in both are filtered out by their id.
+	streams.Add(1)
+	defer streams.Done()
+
+	sub := stream.Subscribe(cid)
+	defer sub.Close()
+
+	filter := bson.M{"channel": cid, "time": bson.M{"$gt": st, "$lt": et}}
+	if resume.Valid() {
+		filter = bson.M{"channel": cid, "_id": bson.M{"$gt": resume}}
+		st, et = db.Earliest, db.Latest
+	}
+
+	backlog := []models.StoredMessage{}
+	if err := Db.FindAll(context.Background(), cid, st, et, filter, "_id", 0, &backlog); err != nil {
+		log.Print(err)
+		return
+	}
+
+	var last bson.ObjectId
+	for _, m := range backlog {
+		if err := send(m); err != nil {
+			return
+		}
+		last = m.ID
+	}
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-draining:
+			return
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if m.ID <= last {
+				continue
+			}
+			if err := send(m); err != nil {
+				return
+			}
+			last = m.ID
+		}
+	}
+}
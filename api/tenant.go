@@ -0,0 +1,97 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+)
+
+// TenantHeader names the request header selecting the tenant database.
+// Only administrators select any tenant; other clients may only name the
+// tenant of their credentials.
+var TenantHeader = "X-Tenant-ID"
+
+// tenant function returns the tenant a request is made for, as
+// selectTenant found it
+func tenant(r *http.Request) string {
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		return info.tenant
+	}
+	return ""
+}
+
+// credentialTenant function returns the tenant the verified credentials
+// of r name: the owner of their JWT, API or signing key, or else the
+// identity of their client certificate, if it has a tenant database
+func credentialTenant(r *http.Request) string {
+	if o := owner(r); o != "" && db.IsTenant(o) {
+		return o
+	}
+	if id, ok := certIdentity(r); ok && db.IsTenant(id) {
+		return id
+	}
+	return ""
+}
+
+// selectTenant function records the tenant of r: the one TenantHeader
+// selects for administrators, and the one their credentials name for
+// other clients. It answers 403 to clients selecting another tenant.
+func selectTenant(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	t := r.Header.Get(TenantHeader)
+	if !isAdmin(r) {
+		ct := credentialTenant(r)
+		if t != "" && t != ct {
+			writeError(w, r, http.StatusForbidden, CodeForbidden, "tenant not allowed", nil)
+			return
+		}
+		t = ct
+	}
+	if info, ok := r.Context().Value(requestKey{}).(*requestInfo); ok {
+		info.tenant = t
+	}
+
+	next(w, r)
+}
+
+// tailable function answers 501 to requests tailing the messages of a
+// tenant database, which only the main database is watched for, and
+// reports whether r may tail messages
+func tailable(w http.ResponseWriter, r *http.Request) bool {
+	if tenant(r) == "" {
+		return true
+	}
+	writeError(w, r, http.StatusNotImplemented, CodeNotSupported, "tailing not supported for tenant databases", nil)
+	return false
+}
+
+// openDb function opens a session on the database of the request tenant,
+// restricted to the channels of the request owner under owner scoping.
+// When that fails, the request is answered and false is returned.
+func openDb(w http.ResponseWriter, r *http.Request) (db.MgoDb, bool) {
+	Db := db.MgoDb{}
+	err := Db.InitTenant(tenant(r))
+	if err == nil {
+		if o, ok := scopedOwner(r); ok {
+			Db.Scope(o)
+		}
+		return Db, true
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err == db.ErrUnknownTenant {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "unknown tenant", nil)
+		return Db, false
+	}
+
+	logger(r).Error(err)
+	writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "tenant database unavailable", nil)
+	return Db, false
+}
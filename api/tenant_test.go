@@ -0,0 +1,87 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestTenants(t *testing.T) {
+	const cid = "tenant-1"
+
+	if err := mfdb.SetTenants("acme=acme_test"); err != nil {
+		t.Fatal(err)
+	}
+	defer mfdb.SetTenants("")
+	err := api.SetAPIKeys([]api.APIKey{
+		{ID: "acme", Hash: keyHash("acme-key"), Channels: []string{"*"}, Owner: "acme"},
+		{ID: "beta", Hash: keyHash("beta-key"), Channels: []string{"*"}, Owner: "beta"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.SetAPIKeys(nil)
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	channels := Db.Session.DB("acme_test").C(mfdb.ChannelsCollection)
+	if err := channels.Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer channels.Remove(bson.M{"id": cid})
+
+	cases := []struct {
+		path   string
+		key    string
+		token  string
+		tenant string
+		code   int
+	}{
+		{"/messages", "", "", "acme", http.StatusForbidden},
+		{"/messages", "beta-key", "", "acme", http.StatusForbidden},
+		{"/messages", "beta-key", "", "", http.StatusNotFound},
+		{"/messages", "acme-key", "", "acme", http.StatusOK},
+		{"/messages", "acme-key", "", "", http.StatusOK},
+		{"/messages", "", "admin", "acme", http.StatusOK},
+		{"/messages", "", "admin", "", http.StatusNotFound},
+		{"/messages/stream", "acme-key", "", "", http.StatusNotImplemented},
+	}
+	for i, c := range cases {
+		req, err := http.NewRequest("GET", ts.URL+"/channels/"+cid+c.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.key != "" {
+			req.Header.Set(api.APIKeyHeader, c.key)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		if c.tenant != "" {
+			req.Header.Set(api.TenantHeader, c.tenant)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+	}
+}
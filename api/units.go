@@ -0,0 +1,236 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/units"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// unitsEnabled function answers 501 while the unit registry is off
+func unitsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if !units.Enabled {
+		writeError(w, r, http.StatusNotImplemented, CodeNotSupported, "unit registry not enabled", nil)
+		return false
+	}
+	return true
+}
+
+// getUnits function lists the entries of the unit registry
+func getUnits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !unitsEnabled(w, r) {
+		return
+	}
+
+	es, err := units.List()
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to list units", nil)
+		return
+	}
+
+	res, err := json.Marshal(es)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// getUnit function returns the registry entry of a SenML name
+func getUnit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !unitsEnabled(w, r) {
+		return
+	}
+
+	name := bone.GetValue(r, "name")
+	e, err := units.Get(name)
+	if err == units.ErrNotFound {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "name not registered", map[string]interface{}{"id": name})
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to read unit", nil)
+		return
+	}
+
+	res, err := json.Marshal(e)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// setUnit function creates or replaces the registry entry of a SenML name
+func setUnit(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !unitsEnabled(w, r) {
+		return
+	}
+
+	var e units.Entry
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, "malformed request body", nil)
+		return
+	}
+	e.Name = bone.GetValue(r, "name")
+
+	err := units.Set(e)
+	if err == units.ErrEntry {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to save unit", nil)
+		return
+	}
+
+	res, err := json.Marshal(e)
+	if err != nil {
+		logger(r).Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, string(res))
+}
+
+// removeUnit function deletes the registry entry of a SenML name
+func removeUnit(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+	if !unitsEnabled(w, r) {
+		return
+	}
+
+	name := bone.GetValue(r, "name")
+	err := units.Remove(name)
+	if err == units.ErrNotFound {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "name not registered", map[string]interface{}{"id": name})
+		return
+	}
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to remove unit", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// annotation function returns the catalog annotating the messages
+// returned to r, if it asks for annotations
+func annotation(w http.ResponseWriter, r *http.Request) (units.Catalog, bool) {
+	if r.URL.Query().Get("annotate") != "true" {
+		return nil, true
+	}
+	if !unitsEnabled(w, r) {
+		return nil, false
+	}
+
+	c, err := units.Load()
+	if err != nil {
+		logger(r).Error(err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "failed to read units", nil)
+		return nil, false
+	}
+	return c, true
+}
+
+// annotate function gives em the display name of its name, its
+// registered unit if it has none, and its violations of the registry
+func annotate(em *enrichedMessage, c units.Catalog) {
+	e, vs, ok := c.Check(em.Message)
+	if !ok {
+		return
+	}
+	em.DisplayName, em.Violations = e.DisplayName, vs
+	if em.Unit == "" && em.BaseUnit == "" {
+		em.Unit = e.Unit
+	}
+}
+
+// storedUnits function returns the units values matched by match were
+// stored with in the collections of channel cid, by name. The scan is
+// capped as aggregations are.
+func storedUnits(ctx context.Context, Db db.MgoDb, cid string, st, et float64, match bson.M) (map[string][]string, error) {
+	pipeline := []bson.M{{"$match": match}}
+	if AggregateMaxScan > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": AggregateMaxScan})
+	}
+	pipeline = append(pipeline, bson.M{"$group": bson.M{
+		"_id": bson.M{"name": "$name", "unit": bson.M{"$cond": []interface{}{bson.M{"$gt": []interface{}{"$unit", ""}}, "$unit", "$baseunit"}}},
+	}})
+
+	collections, err := Db.MessageCollections(cid, st, et)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := map[string][]string{}
+	for _, c := range collections {
+		var part []struct {
+			ID struct {
+				Name string `bson:"name"`
+				Unit string `bson:"unit"`
+			} `bson:"_id"`
+		}
+		if err := Db.Aggregate(ctx, c, pipeline).All(&part); err != nil {
+			return nil, err
+		}
+		for _, p := range part {
+			stored[p.ID.Name] = append(stored[p.ID.Name], p.ID.Unit)
+		}
+	}
+	return stored, nil
+}
+
+// mixedUnits function returns the units of the values of channel cid an
+// aggregation would combine, if there are several. Names without
+// registered unit are only compared by the units stored with them.
+func mixedUnits(ctx context.Context, Db db.MgoDb, cid, name string, st, et float64) ([]string, error) {
+	c, err := units.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{"channel": cid, "time": bson.M{"$gt": st, "$lt": et}, "value": bson.M{"$exists": true}}
+	if name != "" {
+		match["name"] = name
+	}
+
+	var stored map[string][]string
+	err = Db.Read(ctx, func() error {
+		var err error
+		stored, err = storedUnits(ctx, Db, cid, st, et, match)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if us := c.Units(stored); len(us) > 1 {
+		return us, nil
+	}
+	return nil, nil
+}
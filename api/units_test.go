@@ -0,0 +1,108 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/units"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestUnits(t *testing.T) {
+	const cid = "units"
+
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+	units.Enabled = true
+	defer func() { units.Enabled = false }()
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	defer Db.C(units.Collection).RemoveAll(nil)
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": cid}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": cid})
+	t1, t2, h := 21.5, 95.0, 40.0
+	msgs := []models.Message{
+		{Channel: cid, Name: "temperature", Time: 1500000000, Value: &t1},
+		{Channel: cid, Name: "temperature", Unit: "Cel", Time: 1500000001, Value: &t2},
+		{Channel: cid, Name: "humidity", Unit: "%RH", Time: 1500000002, Value: &h},
+	}
+	for _, m := range msgs {
+		coll := mfdb.MessageCollection(cid, m.Time)
+		if err := Db.C(coll).Insert(m); err != nil {
+			t.Fatal(err)
+		}
+		defer Db.C(coll).RemoveAll(bson.M{"channel": cid})
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		token  string
+		body   string
+		code   int
+	}{
+		{"PUT", "/units/temperature", "", `{"unit": "Cel"}`, http.StatusForbidden},
+		{"PUT", "/units/temperature", "admin", `{"unit": "Cel", "display_name": "Temperature", "min": -40, "max": 85}`, http.StatusOK},
+		{"PUT", "/units/humidity", "admin", `{"unit": "%RH", "min": 100, "max": 0}`, http.StatusBadRequest},
+		{"PUT", "/units/humidity", "admin", `{"unit": "%RH"}`, http.StatusOK},
+		{"GET", "/units", "", "", http.StatusOK},
+		{"GET", "/units/temperature", "", "", http.StatusOK},
+		{"GET", "/units/pressure", "", "", http.StatusNotFound},
+		{"GET", "/channels/" + cid + "/messages/aggregate?start_time=1400000000&end_time=1500000010", "", "", http.StatusUnprocessableEntity},
+		{"GET", "/channels/" + cid + "/messages/aggregate?start_time=1400000000&end_time=1500000010&name=temperature", "", "", http.StatusOK},
+		{"DELETE", "/units/humidity", "admin", "", http.StatusNoContent},
+		{"DELETE", "/units/humidity", "admin", "", http.StatusNotFound},
+	}
+	for i, c := range cases {
+		req, err := http.NewRequest(c.method, ts.URL+c.path, strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		res.Body.Close()
+		if res.StatusCode != c.code {
+			t.Errorf("case %d: expected status %d got %d", i+1, c.code, res.StatusCode)
+		}
+	}
+
+	res, err := http.Get(ts.URL + "/channels/" + cid + "/messages?annotate=true&start_time=1400000000&end_time=1500000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body []map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	res.Body.Close()
+	if err != nil || len(body) != 2 {
+		t.Fatalf("expected two messages got %v, %v", body, err)
+	}
+	if body[0]["display_name"] != "Temperature" || body[0]["u"] != "Cel" || body[0]["violations"] != nil {
+		t.Errorf("expected annotated message got %v", body[0])
+	}
+	if vs, _ := body[1]["violations"].([]interface{}); len(vs) != 1 || vs[0] != units.ViolationRange {
+		t.Errorf("expected range violation got %v", body[1])
+	}
+}
@@ -0,0 +1,71 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"sync"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+// Label value of series past the cardinality caps.
+const otherLabel = "other"
+
+var (
+	// UsageMaxChannels caps the number of channels usage metrics are
+	// reported for. Others are reported together as "other".
+	UsageMaxChannels = 100
+
+	// UsageMaxOwners caps the number of token owners usage metrics are
+	// reported for. Others are reported together as "other".
+	UsageMaxOwners = 100
+
+	usageMessages = metrics.NewCounterVec("mongo_reader_usage_messages_total",
+		"Messages served, by channel and token owner.", "channel", "owner")
+	usageBytes = metrics.NewCounterVec("mongo_reader_usage_bytes_total",
+		"Response bytes sent, by channel and token owner.", "channel", "owner")
+
+	usageChannels = &labelCap{seen: map[string]bool{}}
+	usageOwners   = &labelCap{seen: map[string]bool{}}
+)
+
+// labelCap admits label values until a maximum number of distinct ones
+type labelCap struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// value returns v if it was seen before or fewer than max values were,
+// and "other" otherwise
+func (c *labelCap) value(v string, max int) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seen[v] {
+		return v
+	}
+	if len(c.seen) >= max {
+		return otherLabel
+	}
+	c.seen[v] = true
+	return v
+}
+
+// recordUsage function counts the messages and bytes served on channel
+// to owner
+func recordUsage(channel, owner string, docs int, bytes int64) {
+	if channel == "" {
+		channel = "none"
+	}
+	channel = usageChannels.value(channel, UsageMaxChannels)
+	owner = usageOwners.value(owner, UsageMaxOwners)
+
+	usageMessages.Add(float64(docs), channel, owner)
+	usageBytes.Add(float64(bytes), channel, owner)
+}
@@ -0,0 +1,86 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestUsageMetrics(t *testing.T) {
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+
+	var Db mfdb.MgoDb
+	Db.Init()
+	defer Db.Close()
+	if err := Db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": "usage"}); err != nil {
+		t.Fatal(err)
+	}
+	defer Db.C(mfdb.ChannelsCollection).Remove(bson.M{"id": "usage"})
+
+	for _, path := range []string{"/channels/usage/messages", "/channels/usage-unknown/messages"} {
+		for _, token := range []string{"admin", ""} {
+			req, err := http.NewRequest("GET", ts.URL+path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", token)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+		}
+	}
+
+	// Metrics of the public port are restricted to administrators
+	res, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status %d got %d", http.StatusForbidden, res.StatusCode)
+	}
+
+	req, err := http.NewRequest("GET", ts.URL+"/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "admin")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, series := range []string{
+		`mongo_reader_usage_bytes_total{channel="usage",owner="admin"}`,
+		`mongo_reader_usage_bytes_total{channel="usage",owner="anonymous"}`,
+	} {
+		if !strings.Contains(string(body), series) {
+			t.Errorf("expected series %s in\n%s", series, body)
+		}
+	}
+	if strings.Contains(string(body), `channel="usage-unknown"`) {
+		t.Errorf("expected no series of unknown channels in\n%s", body)
+	}
+}
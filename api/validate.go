@@ -0,0 +1,173 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-zoo/bone"
+)
+
+// fieldError describes an invalid query parameter
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e fieldError) String() string {
+	return e.Field + " " + e.Message
+}
+
+// validateRoutes function checks the query parameters of the routes of
+// mux against those documented in the operations table before calling
+// their handlers
+func validateRoutes(mux *bone.Mux) {
+	for method, routes := range mux.Routes {
+		if method == "static" {
+			method = "GET"
+		}
+		for _, r := range routes {
+			if op, ok := operations[method+" "+unversioned(r.Path)]; ok && len(op.Params) > 0 {
+				r.Handler = checkQuery(op.Params, r.Handler)
+			}
+		}
+	}
+}
+
+// checkQuery function answers 400 with the errors of every invalid
+// parameter of params instead of calling h
+func checkQuery(params []param, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errs := queryErrors(params, r)
+		if len(errs) == 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			msgs[i] = e.String()
+		}
+		msg := "invalid query parameters: " + strings.Join(msgs, "; ")
+		writeError(w, r, http.StatusBadRequest, CodeInvalidFilter, msg, map[string]interface{}{"fields": errs})
+	})
+}
+
+// queryErrors function returns the errors of the parameters of r, in
+// the order of params
+func queryErrors(params []param, r *http.Request) []fieldError {
+	q := r.URL.Query()
+	errs := []fieldError{}
+	for _, p := range params {
+		s := q.Get(p.Name)
+		if s == "" {
+			if p.Required {
+				errs = append(errs, fieldError{p.Name, "is required"})
+			}
+			continue
+		}
+		if p.Excludes != "" && q.Get(p.Excludes) != "" {
+			errs = append(errs, fieldError{p.Name, "cannot be combined with " + p.Excludes})
+			continue
+		}
+		if msg := checkParam(p, s); msg != "" {
+			errs = append(errs, fieldError{p.Name, msg})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// Both bounds are valid numbers once their parameters are
+	if st, et := q.Get("start_time"), q.Get("end_time"); st != "" && et != "" {
+		s, _ := strconv.ParseFloat(st, 64)
+		e, _ := strconv.ParseFloat(et, 64)
+		if s > e {
+			errs = append(errs, fieldError{"start_time", "must not be after end_time"})
+		}
+	}
+	return errs
+}
+
+// checkParam function returns why s is not a valid value of p, or an
+// empty string
+func checkParam(p param, s string) string {
+	switch p.Type {
+	case "number":
+		if f, err := strconv.ParseFloat(s, 64); err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "must be a number"
+		}
+	case "integer":
+		if _, err := strconv.Atoi(s); err != nil {
+			return "must be an integer"
+		}
+	case "boolean":
+		if s != "true" && s != "false" {
+			return "must be true or false"
+		}
+	}
+
+	if len(p.Enum) > 0 {
+		found := false
+		for _, v := range p.Enum {
+			found = found || v == s
+		}
+		if !found {
+			return "must be one of " + strings.Join(p.Enum, ", ")
+		}
+	}
+
+	if p.Check != nil {
+		return p.Check(s)
+	}
+	return ""
+}
+
+// atLeast function returns a check of numbers not below min
+func atLeast(min float64) func(string) string {
+	return func(s string) string {
+		if f, _ := strconv.ParseFloat(s, 64); f < min {
+			return fmt.Sprintf("must be at least %g", min)
+		}
+		return ""
+	}
+}
+
+// positive function checks that the number s is above zero
+func positive(s string) string {
+	if f, _ := strconv.ParseFloat(s, 64); f <= 0 {
+		return "must be positive"
+	}
+	return ""
+}
+
+// checkLimit function checks that the limit s is positive and within
+// MaxLimit
+func checkLimit(s string) string {
+	n, _ := strconv.Atoi(s)
+	if n <= 0 {
+		return "must be positive"
+	}
+	if max := MaxLimit(); max > 0 && n > max {
+		return fmt.Sprintf("must not exceed %d", max)
+	}
+	return ""
+}
+
+// checkCursor function checks that s is a cursor returned by a read
+func checkCursor(s string) string {
+	if _, err := parseCursor(s); err != nil {
+		return "is malformed"
+	}
+	return ""
+}
@@ -10,9 +10,9 @@ package api
 
 import (
 	"fmt"
-	"log"
 	"os"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/xeipuuv/gojsonschema"
 )
 
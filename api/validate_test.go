@@ -0,0 +1,75 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+)
+
+func TestQueryValidation(t *testing.T) {
+	cases := []struct {
+		path   string
+		fields []string
+	}{
+		{"/channels/1/messages?limit=0", []string{"limit"}},
+		{"/channels/1/messages?limit=ten", []string{"limit"}},
+		{"/channels/1/messages?limit=100000000", []string{"limit"}},
+		{"/channels/1/messages?offset=-1", []string{"offset"}},
+		{"/channels/1/messages?cursor=x", []string{"cursor"}},
+		{"/channels/1/messages?offset=10&cursor=bzoxMA", []string{"cursor"}},
+		{"/channels/1/messages?count=all&meta=yes", []string{"count", "meta"}},
+		{"/channels/1/messages?start_time=NaN&end_time=-1", []string{"start_time", "end_time"}},
+		{"/channels/1/messages?start_time=20&end_time=10", []string{"start_time"}},
+		{"/channels/1/messages?wait=soon", []string{"wait"}},
+		{"/channels/1/messages?wait=2h", []string{"wait"}},
+		{"/channels/1/messages/aggregate?interval=0", []string{"interval"}},
+		{"/channels/1/messages/stream?end_time=now", []string{"end_time"}},
+	}
+
+	for i, c := range cases {
+		res, err := http.Get(ts.URL + c.path)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		var e struct {
+			api.Error
+			Details struct {
+				Fields []struct {
+					Field   string `json:"field"`
+					Message string `json:"message"`
+				} `json:"fields"`
+			} `json:"details"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&e)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("case %d: expected status %d got %d", i+1, http.StatusBadRequest, res.StatusCode)
+		}
+		if e.Code != api.CodeInvalidFilter {
+			t.Errorf("case %d: expected code %s got %s", i+1, api.CodeInvalidFilter, e.Code)
+		}
+		fields := []string{}
+		for _, f := range e.Details.Fields {
+			fields = append(fields, f.Field)
+		}
+		if !reflect.DeepEqual(fields, c.fields) {
+			t.Errorf("case %d: expected invalid fields %v got %v", i+1, c.fields, fields)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+	"golang.org/x/net/websocket"
+)
+
+// getMessageWS function serves the messages matching the filters and then
+// keeps pushing new messages of the channel over a WebSocket
+func getMessageWS(w http.ResponseWriter, r *http.Request) {
+	cid := bone.GetValue(r, "channel_id")
+
+	if !tailable(w, r) {
+		return
+	}
+	Db, ok := openDb(w, r)
+	if !ok {
+		return
+	}
+	defer Db.Close()
+
+	if err := Db.FindChannel(cid); err != nil {
+		channelError(w, r, cid, err)
+		return
+	}
+
+	st, et, err := timeRange(r)
+	if err != nil {
+		badFilter(w, r, err)
+		return
+	}
+
+	s := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			// Server read and write timeouts bound requests, not sockets
+			ws.SetDeadline(time.Time{})
+			serveWS(ws, Db, cid, st, et, redact.Enabled() && isAdmin(r))
+		},
+	}
+	s.ServeHTTP(w, r)
+}
+
+func serveWS(ws *websocket.Conn, Db db.MgoDb, cid string, st, et float64, admin bool) {
+	// Clients are not expected to send anything; reading only detects close.
+	closed := make(chan struct{})
+	go func() {
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+		close(closed)
+	}()
+
+	tailMessages(Db, cid, st, et, "", closed, func(m models.StoredMessage) error {
+		redact.Apply(&m.Message, admin)
+		return websocket.JSON.Send(ws, m)
+	})
+}
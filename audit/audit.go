@@ -0,0 +1,221 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package audit records who read what data, and how much of it.
+//
+// Records are queued in memory and written by a single worker to the
+// configured sink: a file, a MongoDB collection or syslog. Records are
+// dropped, and counted as such, when the queue is full, so that a slow
+// sink never holds requests up.
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"log/syslog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+var (
+	// ErrSink indicates a malformed sink specification.
+	ErrSink = errors.New("audit sink must be file:<path>, mongo:<collection> or syslog:[<network>://<address>]")
+
+	errUnavailable = errors.New("database unavailable")
+
+	mu    sync.Mutex
+	queue chan Record
+	done  chan struct{}
+
+	dropped = metrics.NewCounterVec("mongo_reader_audit_records_dropped_total",
+		"Audit records dropped because the audit queue was full.")
+	failed = metrics.NewCounterVec("mongo_reader_audit_records_failed_total",
+		"Audit records the audit sink failed to write.")
+)
+
+type (
+	// Record struct describes one access to stored data
+	Record struct {
+		Time      time.Time         `json:"time" bson:"time"`
+		RequestID string            `json:"request_id" bson:"request_id"`
+		Subject   string            `json:"subject" bson:"subject"`
+		Admin     bool              `json:"admin,omitempty" bson:"admin,omitempty"`
+		Tenant    string            `json:"tenant,omitempty" bson:"tenant,omitempty"`
+		Method    string            `json:"method" bson:"method"`
+		Path      string            `json:"path" bson:"path"`
+		Channel   string            `json:"channel,omitempty" bson:"channel,omitempty"`
+		Filters   map[string]string `json:"filters,omitempty" bson:"filters,omitempty"`
+		Status    int               `json:"status" bson:"status"`
+		Documents int               `json:"documents" bson:"documents"`
+		Bytes     int64             `json:"bytes" bson:"bytes"`
+	}
+
+	// Sink writes audit records
+	Sink interface {
+		Write(Record) error
+	}
+
+	// fileSink appends records to a file, one JSON document per line
+	fileSink struct {
+		f *os.File
+	}
+
+	// mongoSink inserts records into a collection of the reader database
+	mongoSink struct {
+		collection string
+	}
+
+	// syslogSink sends records to syslog as JSON messages
+	syslogSink struct {
+		w *syslog.Writer
+	}
+)
+
+// Open function creates the sink described by spec. file:<path> appends
+// to a file, mongo:<collection> inserts into a collection of the reader
+// database, syslog: writes to the local syslog daemon and
+// syslog:<network>://<address> to a remote one.
+func Open(spec string) (Sink, error) {
+	i := strings.Index(spec, ":")
+	if i < 0 {
+		return nil, ErrSink
+	}
+	kind, target := spec[:i], spec[i+1:]
+
+	switch kind {
+	case "file":
+		if target == "" {
+			return nil, ErrSink
+		}
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return fileSink{f}, nil
+	case "mongo":
+		if target == "" {
+			return nil, ErrSink
+		}
+		return mongoSink{target}, nil
+	case "syslog":
+		network, addr := "", ""
+		if target != "" {
+			j := strings.Index(target, "://")
+			if j < 0 {
+				return nil, ErrSink
+			}
+			network, addr = target[:j], target[j+3:]
+		}
+		w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_AUTH, "mainflux-mongodb-reader")
+		if err != nil {
+			return nil, err
+		}
+		return syslogSink{w}, nil
+	}
+
+	return nil, ErrSink
+}
+
+func (s fileSink) Write(rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = s.f.Write(append(b, '\n'))
+	return err
+}
+
+func (s mongoSink) Write(rec Record) error {
+	if !db.Connected() {
+		return errUnavailable
+	}
+
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	return Db.C(s.collection).Insert(rec)
+}
+
+func (s syslogSink) Write(rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.w.Info(string(b))
+}
+
+// Start function writes queued records to sink. At most size records
+// wait to be written.
+func Start(sink Sink, size int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	queue = make(chan Record, size)
+	done = make(chan struct{})
+	go func(q chan Record, done chan struct{}) {
+		defer close(done)
+		for rec := range q {
+			if err := sink.Write(rec); err != nil {
+				failed.Inc()
+				log.WithField("module", "audit").Errorf("Can't write audit record: %v", err)
+			}
+		}
+	}(queue, done)
+}
+
+// Stop function stops queueing records and waits until those queued are
+// written
+func Stop() {
+	mu.Lock()
+	q, d := queue, done
+	queue, done = nil, nil
+	if q != nil {
+		close(q)
+	}
+	mu.Unlock()
+
+	if d != nil {
+		<-d
+	}
+}
+
+// Enabled function reports whether records are being written
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return queue != nil
+}
+
+// Log function queues rec for writing. It does nothing until Start is
+// called, and drops rec if the queue is full.
+func Log(rec Record) {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now().UTC()
+	}
+
+	// The queue is closed by Stop under the lock
+	mu.Lock()
+	defer mu.Unlock()
+
+	if queue == nil {
+		return
+	}
+	select {
+	case queue <- rec:
+	default:
+		dropped.Inc()
+	}
+}
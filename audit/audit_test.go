@@ -0,0 +1,114 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package audit_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/audit"
+)
+
+func TestOpen(t *testing.T) {
+	cases := []struct {
+		spec string
+		err  error
+	}{
+		{"", audit.ErrSink},
+		{"kafka:audit", audit.ErrSink},
+		{"file:", audit.ErrSink},
+		{"mongo:", audit.ErrSink},
+		{"syslog:localhost:514", audit.ErrSink},
+		{"mongo:audit", nil},
+	}
+
+	for i, c := range cases {
+		if _, err := audit.Open(c.spec); err != c.err {
+			t.Errorf("case %d: expected error %v got %v", i+1, c.err, err)
+		}
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	sink, err := audit.Open("file:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recs := []audit.Record{
+		{Subject: "admin", Channel: "1", Documents: 10, Bytes: 512},
+		{Subject: "anonymous", Channel: "2", Filters: map[string]string{"start_time": "0"}},
+	}
+	for _, rec := range recs {
+		if err := sink.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != len(recs) {
+		t.Fatalf("expected %d records got %d", len(recs), len(lines))
+	}
+	for i, line := range lines {
+		var rec audit.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("record %d: %s", i+1, err.Error())
+		}
+		if rec.Subject != recs[i].Subject || rec.Channel != recs[i].Channel || rec.Documents != recs[i].Documents {
+			t.Errorf("record %d: expected %+v got %+v", i+1, recs[i], rec)
+		}
+	}
+}
+
+func TestStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	sink, err := audit.Open("file:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit.Start(sink, 100)
+	for i := 0; i < 10; i++ {
+		audit.Log(audit.Record{Subject: "admin"})
+	}
+	audit.Stop()
+	audit.Log(audit.Record{Subject: "late"})
+
+	if audit.Enabled() {
+		t.Errorf("expected audit log to be stopped")
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "\n"); n != 10 {
+		t.Errorf("expected %d records written got %d", 10, n)
+	}
+}
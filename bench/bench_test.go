@@ -0,0 +1,143 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package bench_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	"github.com/mainflux/mainflux-mongodb-reader/bench"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+
+	"gopkg.in/mgo.v2"
+)
+
+const benchDb = "mainflux_bench"
+
+var dataset = bench.Dataset{
+	Channels: 10,
+	Messages: 2880,
+	Step:     30 * time.Second,
+	End:      time.Now().Truncate(time.Minute),
+}
+
+var (
+	seedOnce sync.Once
+	server   *httptest.Server
+	seedErr  error
+)
+
+// setup seeds the server named by MF_BENCH_MONGO once and serves the
+// reader on it
+func setup(b *testing.B) *httptest.Server {
+	url := os.Getenv("MF_BENCH_MONGO")
+	if url == "" {
+		b.Skip("MF_BENCH_MONGO is not set")
+	}
+
+	seedOnce.Do(func() {
+		s, err := mgo.Dial(url)
+		if err != nil {
+			seedErr = err
+			return
+		}
+		if err = s.DB(benchDb).DropDatabase(); err != nil {
+			seedErr = err
+			return
+		}
+
+		mfdb.SetMainSession(s)
+		mfdb.SetMainDb(benchDb)
+		if seedErr = bench.Seed(s, benchDb, dataset); seedErr != nil {
+			return
+		}
+		server = httptest.NewServer(api.HTTPServer())
+	})
+	if seedErr != nil {
+		b.Fatal(seedErr)
+	}
+	return server
+}
+
+func BenchmarkQueries(b *testing.B) {
+	ts := setup(b)
+
+	for _, q := range bench.Queries(dataset, bench.ChannelID(0)) {
+		q := q
+		b.Run(q.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				resp, err := http.Get(ts.URL + q.Path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				n, _ := io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					b.Fatalf("unexpected status %d", resp.StatusCode)
+				}
+				b.SetBytes(n)
+			}
+		})
+	}
+}
+
+func BenchmarkParallelQueries(b *testing.B) {
+	ts := setup(b)
+	queries := bench.Queries(dataset, bench.ChannelID(0))
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			resp, err := http.Get(ts.URL + queries[i%len(queries)].Path)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			i++
+		}
+	})
+}
+
+func TestLoadRun(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		io.WriteString(w, `{"response": "ok"}`)
+	}))
+	defer ts.Close()
+
+	l := bench.Load{URL: ts.URL, Concurrency: 4, Requests: 50}
+
+	res := l.Run(bench.Query{Name: "ok", Path: "/ok"})
+	if res.Requests != 50 || res.Errors != 0 {
+		t.Errorf("expected 50 requests and no errors got %d and %d", res.Requests, res.Errors)
+	}
+	if res.Bytes != 50*int64(len(`{"response": "ok"}`)) {
+		t.Errorf("unexpected response bytes %d", res.Bytes)
+	}
+	if p50, p99 := res.Percentile(50), res.Percentile(99); p50 <= 0 || p50 > p99 {
+		t.Errorf("unexpected percentiles p50 %v p99 %v", p50, p99)
+	}
+
+	res = l.Run(bench.Query{Name: "fail", Path: "/fail"})
+	if res.Errors != 50 {
+		t.Errorf("expected 50 errors got %d", res.Errors)
+	}
+}
@@ -0,0 +1,184 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package bench
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Query is a representative reader request.
+type Query struct {
+	Name string
+	Path string
+}
+
+// Queries function returns the representative queries on channel cid of
+// a dataset
+func Queries(ds Dataset, cid string) []Query {
+	et := ds.End.Unix() + 1
+	hour := fmt.Sprintf("start_time=%d&end_time=%d", et-3600, et)
+	day := fmt.Sprintf("start_time=%d&end_time=%d", et-86400, et)
+	base := "/channels/" + cid
+
+	return []Query{
+		{"messages_last_hour", base + "/messages?" + hour},
+		{"messages_limit_100", base + "/messages?limit=100&" + day},
+		{"messages_by_name", base + "/messages?name=temperature&" + hour},
+		{"aggregate_hourly", base + "/messages/aggregate?interval=3600&fn=avg&" + day},
+		{"aggregate_daily", base + "/messages/aggregate?interval=86400&fn=max&" + day},
+		{"latest", base + "/messages/latest"},
+		{"stats", base + "/stats?" + day},
+	}
+}
+
+// Result holds the measurements of a query.
+type Result struct {
+	Name     string
+	Requests int
+	Errors   int
+	Bytes    int64
+	Elapsed  time.Duration
+
+	latencies durations
+}
+
+// Throughput function returns the requests served per second
+func (res Result) Throughput() float64 {
+	if res.Elapsed <= 0 {
+		return 0
+	}
+	return float64(res.Requests) / res.Elapsed.Seconds()
+}
+
+// Percentile function returns the latency p percent of requests stayed
+// under
+func (res Result) Percentile(p float64) time.Duration {
+	if len(res.latencies) == 0 {
+		return 0
+	}
+	i := int(p / 100 * float64(len(res.latencies)))
+	if i >= len(res.latencies) {
+		i = len(res.latencies) - 1
+	}
+	return res.latencies[i]
+}
+
+// String function formats the result as a report line
+func (res Result) String() string {
+	return fmt.Sprintf("%-20s %8d req %6d err %10.1f req/s  p50 %-10v p95 %-10v p99 %v",
+		res.Name, res.Requests, res.Errors, res.Throughput(),
+		res.Percentile(50), res.Percentile(95), res.Percentile(99))
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+// Load describes a load test of a single query.
+type Load struct {
+	Client      *http.Client
+	URL         string
+	Concurrency int
+	// Duration bounds the test, Requests the requests sent. The test stops
+	// at whichever is reached first; zero means unbounded.
+	Duration time.Duration
+	Requests int
+}
+
+// Run function sends q concurrently until l is exhausted and measures the
+// responses
+func (l Load) Run(q Query) Result {
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	workers := l.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	var deadline time.Time
+	if l.Duration > 0 {
+		deadline = time.Now().Add(l.Duration)
+	}
+
+	var (
+		mu   sync.Mutex
+		sent int
+		wg   sync.WaitGroup
+	)
+	res := Result{Name: q.Name}
+
+	// next reserves a request, false once the load is exhausted
+	next := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if l.Requests > 0 && sent >= l.Requests {
+			return false
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return false
+		}
+		sent++
+		return true
+	}
+
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next() {
+				t := time.Now()
+				n, err := get(client, l.URL+q.Path)
+				d := time.Since(t)
+
+				mu.Lock()
+				res.Requests++
+				res.Bytes += n
+				res.latencies = append(res.latencies, d)
+				if err != nil {
+					res.Errors++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	res.Elapsed = time.Since(start)
+
+	sort.Sort(res.latencies)
+	return res
+}
+
+// get function reads the whole response of url and returns its size
+func get(client *http.Client, url string) (int64, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	n, err := io.Copy(ioutil.Discard, resp.Body)
+	if err != nil {
+		return n, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return n, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return n, nil
+}
@@ -0,0 +1,108 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package bench seeds a database with synthetic SenML messages and
+// measures the throughput and latency of representative reader queries.
+//
+// It backs the benchmarks of this package, run against a MongoDB server
+// named by MF_BENCH_MONGO, and the cmd/loadgen load generator.
+package bench
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Messages inserted by a single bulk write.
+const seedBatch = 1000
+
+// Names of the series of every seeded channel.
+var seriesNames = []string{"temperature", "humidity", "pressure"}
+
+// Dataset describes the synthetic data of a seeded database.
+type Dataset struct {
+	Channels int
+	// Messages per channel and series.
+	Messages int
+	// Time between two messages of a series.
+	Step time.Duration
+	// Time of the last message of every series.
+	End time.Time
+}
+
+// Start function returns the time of the first message of every series
+func (ds Dataset) Start() time.Time {
+	return ds.End.Add(-time.Duration(ds.Messages-1) * ds.Step)
+}
+
+// ChannelID function returns the id of the i-th seeded channel
+func ChannelID(i int) string {
+	return fmt.Sprintf("bench-%04d", i)
+}
+
+// Seed function stores the messages of ds in the database of s, laid out
+// as configured in the db package
+func Seed(s *mgo.Session, database string, ds Dataset) error {
+	d := s.DB(database)
+
+	start := ds.Start()
+	for c := 0; c < ds.Channels; c++ {
+		cid := ChannelID(c)
+		if _, err := d.C(db.ChannelsCollection).Upsert(bson.M{"id": cid}, bson.M{"id": cid}); err != nil {
+			return err
+		}
+
+		batches := map[string][]interface{}{}
+		for i := 0; i < ds.Messages; i++ {
+			t := start.Add(time.Duration(i) * ds.Step)
+			for k, name := range seriesNames {
+				m := message(cid, name, t, float64(i)+float64(k)*100)
+				coll := db.MessageCollection(cid, m.Time)
+				batches[coll] = append(batches[coll], m)
+				if len(batches[coll]) == seedBatch {
+					if err := d.C(coll).Insert(batches[coll]...); err != nil {
+						return err
+					}
+					batches[coll] = nil
+				}
+			}
+		}
+		for coll, batch := range batches {
+			if len(batch) == 0 {
+				continue
+			}
+			if err := d.C(coll).Insert(batch...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// message returns a synthetic reading of series name of channel cid
+func message(cid, name string, t time.Time, x float64) models.Message {
+	v := 20 + 5*math.Sin(x/60)
+	return models.Message{
+		Name:        name,
+		Unit:        "Cel",
+		Time:        float64(t.UnixNano()) / 1e9,
+		Value:       &v,
+		Publisher:   "bench-publisher",
+		Protocol:    "mqtt",
+		Created:     t.UTC().Format(time.RFC3339),
+		ContentType: "application/senml+json",
+		Channel:     cid,
+	}
+}
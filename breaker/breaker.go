@@ -0,0 +1,152 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package breaker implements circuit breakers that stop calls to a failing
+// dependency for a while, instead of letting every caller wait for it.
+package breaker
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Breaker states
+const (
+	Closed   = "closed"
+	Open     = "open"
+	HalfOpen = "half-open"
+)
+
+var (
+	// Threshold is the number of consecutive failures opening a breaker.
+	// Zero disables breakers.
+	Threshold = 5
+	// Cooldown is the time an open breaker rejects calls before letting
+	// a single probe through.
+	Cooldown = 30 * time.Second
+
+	mu       sync.Mutex
+	breakers = map[string]*Breaker{}
+)
+
+// Breaker struct tracks the health of one named dependency
+type Breaker struct {
+	mu       sync.Mutex
+	name     string
+	state    string
+	failures int
+	opened   time.Time
+	probing  bool
+}
+
+// Status struct is a snapshot of a breaker
+type Status struct {
+	Name     string    `json:"name"`
+	State    string    `json:"state"`
+	Failures int       `json:"failures"`
+	Opened   time.Time `json:"opened,omitempty"`
+}
+
+// Get function returns the breaker of name, creating it closed if needed
+func Get(name string) *Breaker {
+	mu.Lock()
+	defer mu.Unlock()
+
+	b, ok := breakers[name]
+	if !ok {
+		b = &Breaker{name: name, state: Closed}
+		breakers[name] = b
+	}
+	return b
+}
+
+// All function returns the status of every breaker, sorted by name
+func All() []Status {
+	mu.Lock()
+	names := make([]string, 0, len(breakers))
+	for name := range breakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	bs := make([]*Breaker, len(names))
+	for i, name := range names {
+		bs[i] = breakers[name]
+	}
+	mu.Unlock()
+
+	ss := []Status{}
+	for _, b := range bs {
+		ss = append(ss, b.Status())
+	}
+	return ss
+}
+
+// Allow function reports whether a call may proceed. Once the cooldown of
+// an open breaker has passed, a single call is let through as a probe.
+func (b *Breaker) Allow() bool {
+	if Threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.opened) < Cooldown {
+			return false
+		}
+		b.state = HalfOpen
+		b.probing = true
+		return true
+	case HalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// Success function records a successful call, closing the breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = Closed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure function records a failed call, opening the breaker when the
+// threshold is reached or the probe of a half-open breaker failed
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == HalfOpen || (Threshold > 0 && b.failures >= Threshold) {
+		b.state = Open
+		b.opened = time.Now()
+	}
+}
+
+// Status function returns a snapshot of the breaker
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Status{Name: b.name, State: b.state, Failures: b.failures}
+	if b.state != Closed {
+		s.Opened = b.opened
+	}
+	return s
+}
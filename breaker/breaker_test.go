@@ -0,0 +1,56 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	Threshold = 2
+	Cooldown = 20 * time.Millisecond
+
+	b := Get("test")
+	if !b.Allow() {
+		t.Fatalf("expected closed breaker to allow calls")
+	}
+
+	b.Failure()
+	if b.Status().State != Closed {
+		t.Errorf("expected breaker to stay closed below threshold")
+	}
+	b.Failure()
+	if b.Status().State != Open || b.Allow() {
+		t.Fatalf("expected breaker to open and reject calls")
+	}
+
+	time.Sleep(Cooldown)
+	if !b.Allow() {
+		t.Fatalf("expected a probe after cooldown")
+	}
+	if b.Allow() {
+		t.Errorf("expected a single probe while half-open")
+	}
+	b.Failure()
+	if b.Status().State != Open {
+		t.Errorf("expected failed probe to reopen breaker")
+	}
+
+	time.Sleep(Cooldown)
+	b.Allow()
+	b.Success()
+	if s := b.Status(); s.State != Closed || s.Failures != 0 || !b.Allow() {
+		t.Errorf("expected successful probe to close breaker got %+v", s)
+	}
+
+	if ss := All(); len(ss) != 1 || ss[0].Name != "test" {
+		t.Errorf("expected one registered breaker got %v", ss)
+	}
+}
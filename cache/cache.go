@@ -0,0 +1,143 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package cache keeps the responses of hot queries for a short time.
+//
+// Entries are keyed on the tenant, channel and normalized query, and on a
+// generation number of the channel. Invalidating a channel bumps its
+// generation, which orphans its entries until they expire, so no store
+// ever has to enumerate keys.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+// Store is where entries are kept
+type Store interface {
+	// Get returns the value of key, and whether it was found.
+	Get(key string) ([]byte, bool, error)
+	// Set stores value under key for ttl.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Incr increments the integer value of key and returns it.
+	Incr(key string) (int64, error)
+}
+
+var (
+	mu    sync.RWMutex
+	store Store
+	ttl   time.Duration
+
+	hits = metrics.NewCounterVec("mongo_reader_cache_hits_total",
+		"Responses served from the query cache.")
+	misses = metrics.NewCounterVec("mongo_reader_cache_misses_total",
+		"Cacheable responses not found in the query cache.")
+	errs = metrics.NewCounterVec("mongo_reader_cache_errors_total",
+		"Failed query cache operations.")
+)
+
+// Configure function makes s keep entries for t. A nil store disables
+// the cache.
+func Configure(s Store, t time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	store, ttl = s, t
+}
+
+// Enabled function reports whether the cache is configured
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return store != nil && ttl > 0
+}
+
+func current() (Store, time.Duration) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return store, ttl
+}
+
+// Key function returns the cache key of the query of path with params on
+// channel of tenant. Parameters are normalized, so their order does not
+// matter. An empty key means the entry cannot be cached.
+func Key(tenant, channel, path string, params url.Values, variant string) string {
+	s, _ := current()
+	if s == nil {
+		return ""
+	}
+
+	b, _, err := s.Get(genKey(tenant, channel))
+	if err != nil {
+		errs.Inc()
+		return ""
+	}
+	gen := string(b)
+	if gen == "" {
+		gen = "0"
+	}
+
+	sum := sha256.Sum256([]byte(path + "?" + params.Encode() + "#" + variant))
+	return "mfr:q:" + tenant + ":" + channel + ":" + gen + ":" + hex.EncodeToString(sum[:16])
+}
+
+// Get function returns the entry of key
+func Get(key string) ([]byte, bool) {
+	s, _ := current()
+	if s == nil || key == "" {
+		return nil, false
+	}
+
+	v, ok, err := s.Get(key)
+	if err != nil {
+		errs.Inc()
+		return nil, false
+	}
+	if !ok {
+		misses.Inc()
+		return nil, false
+	}
+	hits.Inc()
+	return v, true
+}
+
+// Set function stores value under key
+func Set(key string, value []byte) {
+	s, t := current()
+	if s == nil || key == "" {
+		return
+	}
+
+	if err := s.Set(key, value, t); err != nil {
+		errs.Inc()
+	}
+}
+
+// Invalidate function discards the entries of channel of tenant
+func Invalidate(tenant, channel string) {
+	s, _ := current()
+	if s == nil {
+		return
+	}
+
+	if _, err := s.Incr(genKey(tenant, channel)); err != nil {
+		errs.Inc()
+	}
+}
+
+func genKey(tenant, channel string) string {
+	return "mfr:gen:" + tenant + ":" + channel
+}
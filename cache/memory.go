@@ -0,0 +1,132 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package cache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+func init() {
+	metrics.NewGaugeFunc("mongo_reader_cache_memory_bytes", "Bytes held by the in-process query cache.", func() float64 {
+		mu.RLock()
+		m, ok := store.(*Memory)
+		mu.RUnlock()
+		if !ok {
+			return 0
+		}
+		return float64(m.Size())
+	})
+}
+
+type (
+	// Memory struct is an in-process store evicting the least recently
+	// used entries beyond a size in bytes. Counters are never evicted, so
+	// that invalidations are not forgotten.
+	Memory struct {
+		mu       sync.Mutex
+		max      int64
+		size     int64
+		entries  map[string]*list.Element
+		lru      *list.List
+		counters map[string]int64
+	}
+
+	memEntry struct {
+		key     string
+		value   []byte
+		expires time.Time
+	}
+)
+
+// NewMemory function returns an in-process store of at most max bytes
+func NewMemory(max int64) *Memory {
+	return &Memory{
+		max:      max,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+		counters: map[string]int64{},
+	}
+}
+
+// Size function returns the bytes held by m
+func (m *Memory) Size() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.size
+}
+
+// Get function returns the value of key
+func (m *Memory) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n, ok := m.counters[key]; ok {
+		return []byte(strconv.FormatInt(n, 10)), true, nil
+	}
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*memEntry)
+	if !time.Now().Before(e.expires) {
+		m.remove(el)
+		return nil, false, nil
+	}
+	m.lru.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set function stores value under key for ttl, evicting the least
+// recently used entries as needed. Values larger than the store are not
+// kept.
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+	size := entrySize(key, value)
+	if size > m.max {
+		return nil
+	}
+
+	for m.size+size > m.max {
+		m.remove(m.lru.Back())
+	}
+	m.entries[key] = m.lru.PushFront(&memEntry{key, value, time.Now().Add(ttl)})
+	m.size += size
+	return nil
+}
+
+// Incr function increments the counter key
+func (m *Memory) Incr(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[key]++
+	return m.counters[key], nil
+}
+
+func (m *Memory) remove(el *list.Element) {
+	e := m.lru.Remove(el).(*memEntry)
+	delete(m.entries, e.key)
+	m.size -= entrySize(e.key, e.value)
+}
+
+func entrySize(key string, value []byte) int64 {
+	return int64(len(key) + len(value))
+}
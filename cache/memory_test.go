@@ -0,0 +1,59 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/cache"
+)
+
+func TestMemory(t *testing.T) {
+	m := cache.NewMemory(20)
+
+	m.Set("a", []byte("123456789"), time.Minute)
+	m.Set("b", []byte("123456789"), time.Minute)
+	if _, ok, _ := m.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+
+	// b is now the least recently used entry.
+	m.Set("c", []byte("123456789"), time.Minute)
+	if _, ok, _ := m.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok, _ := m.Get("a"); !ok {
+		t.Error("expected a to stay cached")
+	}
+	if s := m.Size(); s != 20 {
+		t.Errorf("expected size 20 got %d", s)
+	}
+
+	m.Set("huge", make([]byte, 100), time.Minute)
+	if _, ok, _ := m.Get("huge"); ok {
+		t.Error("expected an entry larger than the store to be skipped")
+	}
+
+	m.Set("short", []byte("x"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := m.Get("short"); ok {
+		t.Error("expected the entry to expire")
+	}
+
+	for i := 0; i < 3; i++ {
+		m.Incr("gen")
+	}
+	for i := 0; i < 10; i++ {
+		m.Set(string(rune('d'+i)), []byte("123456789"), time.Minute)
+	}
+	if v, ok, _ := m.Get("gen"); !ok || string(v) != "3" {
+		t.Errorf("expected counters to survive eviction got %q", v)
+	}
+}
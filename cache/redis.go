@@ -0,0 +1,221 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Idle connections kept open to Redis.
+	redisIdle = 8
+	// Time limit of a Redis command.
+	redisTimeout = time.Second
+)
+
+// ErrRedisURL indicates a malformed Redis URL.
+var ErrRedisURL = errors.New("Redis URL must be redis://[:<password>@]<host>:<port>[/<db>]")
+
+type (
+	// Redis struct is a store speaking the Redis protocol over a small
+	// pool of connections
+	Redis struct {
+		addr     string
+		password string
+		db       int
+		idle     chan *redisConn
+	}
+
+	redisConn struct {
+		net.Conn
+		r *bufio.Reader
+	}
+
+	// redisError is an error reply of the server
+	redisError string
+)
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// NewRedis function returns the store of the Redis server at rawurl. The
+// connection is checked with a PING.
+func NewRedis(rawurl string) (*Redis, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return nil, ErrRedisURL
+	}
+
+	r := &Redis{addr: u.Host, idle: make(chan *redisConn, redisIdle)}
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		r.addr = net.JoinHostPort(u.Host, "6379")
+	}
+	if u.User != nil {
+		r.password, _ = u.User.Password()
+	}
+	if p := strings.Trim(u.Path, "/"); p != "" {
+		if r.db, err = strconv.Atoi(p); err != nil {
+			return nil, ErrRedisURL
+		}
+	}
+
+	if _, err := r.do("PING"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Get function returns the value of key
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	v, err := r.do("GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	b, ok := v.([]byte)
+	return b, ok, nil
+}
+
+// Set function stores value under key for ttl
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	ms := strconv.FormatInt(int64(ttl/time.Millisecond), 10)
+	_, err := r.do("SET", key, string(value), "PX", ms)
+	return err
+}
+
+// Incr function increments the integer value of key
+func (r *Redis) Incr(key string) (int64, error) {
+	v, err := r.do("INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return 0, redisError("unexpected INCR reply")
+	}
+	return n, nil
+}
+
+// do sends a command and returns its reply: a string for status replies,
+// an int64, a []byte, nil for null replies or a []interface{}
+func (r *Redis) do(args ...string) (interface{}, error) {
+	c, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := c.do(args...)
+	if _, ok := err.(redisError); err != nil && !ok {
+		c.Close()
+		return nil, err
+	}
+
+	select {
+	case r.idle <- c:
+	default:
+		c.Close()
+	}
+	return v, err
+}
+
+// conn returns an idle connection, or a new authenticated one
+func (r *Redis) conn() (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+
+	nc, err := net.DialTimeout("tcp", r.addr, redisTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+
+	if r.password != "" {
+		if _, err := c.do("AUTH", r.password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.do("SELECT", strconv.Itoa(r.db)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	c.SetDeadline(time.Now().Add(redisTimeout))
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(a))+"\r\n"...)
+		buf = append(buf, a...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return readReply(c.r)
+}
+
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		vs := make([]interface{}, n)
+		for i := range vs {
+			if vs[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return vs, nil
+	}
+	return nil, fmt.Errorf("redis: malformed reply %q", line)
+}
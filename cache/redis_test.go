@@ -0,0 +1,139 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package cache_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/cache"
+)
+
+// fakeRedis serves GET, SET, INCR, PING and AUTH from memory
+func fakeRedis(t *testing.T, password string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				r := bufio.NewReader(c)
+				authed := password == ""
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+
+					mu.Lock()
+					switch cmd := strings.ToUpper(args[0]); {
+					case cmd == "AUTH" && args[1] == password:
+						authed = true
+						io.WriteString(c, "+OK\r\n")
+					case !authed:
+						io.WriteString(c, "-NOAUTH Authentication required.\r\n")
+					case cmd == "PING":
+						io.WriteString(c, "+PONG\r\n")
+					case cmd == "GET":
+						if v, ok := data[args[1]]; ok {
+							fmt.Fprintf(c, "$%d\r\n%s\r\n", len(v), v)
+						} else {
+							io.WriteString(c, "$-1\r\n")
+						}
+					case cmd == "SET":
+						data[args[1]] = args[2]
+						io.WriteString(c, "+OK\r\n")
+					case cmd == "INCR":
+						n, _ := strconv.Atoi(data[args[1]])
+						data[args[1]] = strconv.Itoa(n + 1)
+						fmt.Fprintf(c, ":%d\r\n", n+1)
+					default:
+						io.WriteString(c, "-ERR unknown command\r\n")
+					}
+					mu.Unlock()
+				}
+			}(c)
+		}
+	}()
+
+	return l
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	l := fakeRedis(t, "secret")
+	defer l.Close()
+
+	if _, err := cache.NewRedis("http://" + l.Addr().String()); err != cache.ErrRedisURL {
+		t.Errorf("expected %v got %v", cache.ErrRedisURL, err)
+	}
+	if _, err := cache.NewRedis("redis://" + l.Addr().String()); err == nil {
+		t.Error("expected an error without password")
+	}
+
+	r, err := cache.NewRedis("redis://:secret@" + l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Configure(r, time.Minute)
+	defer cache.Configure(nil, 0)
+
+	params := url.Values{"start_time": {"1"}, "end_time": {"2"}}
+	key := cache.Key("", "7", "/channels/7/messages", params, "")
+	if _, ok := cache.Get(key); ok {
+		t.Fatal("expected an empty cache")
+	}
+	cache.Set(key, []byte(`[{"n": "temp"}]`))
+
+	reordered := url.Values{"end_time": {"2"}, "start_time": {"1"}}
+	if v, ok := cache.Get(cache.Key("", "7", "/channels/7/messages", reordered, "")); !ok || string(v) != `[{"n": "temp"}]` {
+		t.Errorf("expected a cached entry got %q", v)
+	}
+
+	cache.Invalidate("", "7")
+	if _, ok := cache.Get(cache.Key("", "7", "/channels/7/messages", params, "")); ok {
+		t.Error("expected the entry to be invalidated")
+	}
+}
@@ -0,0 +1,222 @@
+/**
+ * Copyright (c) 2017 Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	"github.com/mainflux/mainflux-mongodb-reader/cache"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/ipfilter"
+	"github.com/mainflux/mainflux-mongodb-reader/jwt"
+	"github.com/mainflux/mainflux-mongodb-reader/logging"
+	"github.com/mainflux/mainflux-mongodb-reader/quota"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+	"github.com/mainflux/mainflux-mongodb-reader/registry"
+	"github.com/mainflux/mainflux-mongodb-reader/things"
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+
+	"gopkg.in/mgo.v2"
+)
+
+// Time given to every dependency --check-config connects to
+const checkTimeout = 10 * time.Second
+
+// check is a step of --check-config, skipped unless enabled
+type check struct {
+	name    string
+	enabled bool
+	run     func() error
+}
+
+// checkConfig runs the checks of the options, of the files they name and
+// of the connectivity to the services they point to, writes a report of
+// their outcome to w and tells whether all passed.
+func checkConfig(w io.Writer) bool {
+	checks := []check{
+		{"log level", true, func() error { return logging.SetLevel(opts.LogLevel) }},
+		{"MongoDB log level", true, func() error { return logging.SetMongoLevel(opts.MongoLogLevel) }},
+		{"TLS policy", true, func() error {
+			_, err := tlsutil.ParsePolicy(opts.TLSMin, opts.TLSCiphers, opts.TLSFIPS)
+			return err
+		}},
+		{"storage layout", true, checkLayout},
+		{"read preference", true, func() error {
+			if _, err := db.ParseReadMode(opts.ReadPreference); err != nil {
+				return err
+			}
+			_, err := db.ParseTagSets(opts.ReadTags)
+			return err
+		}},
+		{"read concern", true, func() error {
+			switch opts.ReadConcern {
+			case "", db.ConcernLocal, db.ConcernMajority:
+				return nil
+			}
+			return fmt.Errorf("unknown read concern %s", opts.ReadConcern)
+		}},
+		{"export splitting", true, func() error {
+			if opts.SplitRanges < 1 || opts.SplitParallelism < 1 {
+				return errors.New("--split-ranges and --split-parallelism must be positive")
+			}
+			return nil
+		}},
+		{"tenant databases", opts.TenantDatabases != "", func() error { return db.SetTenants(opts.TenantDatabases) }},
+		{"egress quotas", opts.EgressQuotas != "", func() error { return quota.SetQuotas(quota.Quotas{}, opts.EgressQuotas) }},
+		{"sunset", opts.Sunset != "", func() error {
+			_, err := time.Parse("2006-01-02", opts.Sunset)
+			return err
+		}},
+		{"certificate identities", opts.CertIDs != "", func() error { return api.SetCertIdentities(opts.CertIDs) }},
+		{"IP filter", opts.AllowCIDRs != "" || opts.DenyCIDRs != "" || opts.IPRules != "", checkIPFilter},
+		{"redaction hooks", opts.Redact != "", func() error {
+			_, err := redact.Parse(opts.Redact)
+			return err
+		}},
+		{"API keys", opts.APIKeys != "", func() error { return api.LoadAPIKeys(opts.APIKeys) }},
+		{"HMAC keys", opts.HMACKeys != "", func() error { return api.LoadHMACKeys(opts.HMACKeys) }},
+		{"server certificate", opts.ServerCert != "", func() error {
+			auth, err := tlsutil.ParseClientAuth(opts.ClientAuth)
+			if err != nil {
+				return err
+			}
+			_, _, err = tlsutil.Server(opts.ServerCert, opts.ServerKey, opts.ServerCA, auth)
+			return err
+		}},
+		{"MongoDB", true, pingMongo},
+		{"MongoDB archive", opts.ArchiveURI != "", func() error {
+			info, err := db.ParseURI(opts.ArchiveURI)
+			if err != nil {
+				return err
+			}
+			return ping(info)
+		}},
+		{"query cache", opts.CacheRedis != "", func() error {
+			_, err := cache.NewRedis(opts.CacheRedis)
+			return err
+		}},
+		{"JWKS", opts.JWKSURL != "", func() error {
+			_, err := jwt.NewVerifier(opts.JWKSURL, opts.JWTIssuer, opts.JWTAud)
+			return err
+		}},
+		{"service registry", opts.RegistryURL != "", func() error {
+			_, err := registry.New(opts.RegistryURL, opts.RegistryToken, opts.RegistryInterval)
+			return err
+		}},
+		{"things service", opts.ThingsURL != "", func() error { return things.Init(opts.ThingsURL, opts.ThingsToken) }},
+		{"NATS", opts.NatsHost != "", func() error {
+			c, err := net.DialTimeout("tcp", net.JoinHostPort(opts.NatsHost, opts.NatsPort), checkTimeout)
+			if err != nil {
+				return err
+			}
+			return c.Close()
+		}},
+	}
+
+	failed := 0
+	for _, c := range checks {
+		if !c.enabled {
+			continue
+		}
+		if err := c.run(); err != nil {
+			failed++
+			fmt.Fprintf(w, "FAIL\t%s: %v\n", c.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "ok\t%s\n", c.name)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(w, "%d check(s) failed\n", failed)
+		return false
+	}
+	fmt.Fprintln(w, "Configuration is valid")
+	return true
+}
+
+func checkLayout() error {
+	switch opts.Layout {
+	case db.LayoutSingle, db.LayoutMonthly:
+	case db.LayoutHash:
+		if opts.Partitions <= 0 {
+			return fmt.Errorf("invalid number of partitions %d", opts.Partitions)
+		}
+	default:
+		return fmt.Errorf("unknown storage layout %s", opts.Layout)
+	}
+	return nil
+}
+
+func checkIPFilter() error {
+	if _, err := ipfilter.ParseCIDRs(opts.AllowCIDRs); err != nil {
+		return err
+	}
+	if _, err := ipfilter.ParseCIDRs(opts.DenyCIDRs); err != nil {
+		return err
+	}
+	if opts.IPRules != "" {
+		_, err := ipfilter.ReadFile(opts.IPRules)
+		return err
+	}
+	return nil
+}
+
+// pingMongo connects to MongoDB the way the reader does, with the TLS
+// files and credentials of the options
+func pingMongo() error {
+	info, err := db.ParseURI("mongodb://" + net.JoinHostPort(opts.MongoHost, opts.MongoPort))
+	if opts.MongoURI != "" {
+		info, err = db.ParseURI(opts.MongoURI)
+	}
+	if err != nil {
+		return err
+	}
+
+	var cfg *tls.Config
+	if opts.MongoTLS || opts.MongoTLSCA != "" || opts.MongoTLSCert != "" {
+		key := opts.MongoTLSKey
+		if key == "" {
+			key = opts.MongoTLSCert
+		}
+		if cfg, err = db.ClientTLS(opts.MongoTLSCA, opts.MongoTLSCert, key, opts.MongoInsecure); err != nil {
+			return err
+		}
+		db.UseTLS(info, cfg)
+	}
+
+	if opts.MongoUsername != "" || opts.MongoAuth != "" {
+		creds := db.Credentials{
+			Username:  opts.MongoUsername,
+			Password:  opts.MongoPassword,
+			Source:    opts.MongoSource,
+			Mechanism: opts.MongoAuth,
+		}
+		if err := db.SetCredentials(info, creds, cfg); err != nil {
+			return err
+		}
+	}
+	return ping(info)
+}
+
+// ping dials the MongoDB deployment of info and pings it
+func ping(info *mgo.DialInfo) error {
+	info.Timeout = checkTimeout
+	s, err := mgo.DialWithInfo(info)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return s.Ping()
+}
@@ -0,0 +1,86 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Command loadgen seeds a MongoDB database with synthetic SenML messages
+// and measures the throughput and latency of a running reader.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/bench"
+
+	"gopkg.in/mgo.v2"
+)
+
+const help string = `
+Usage: loadgen [options]
+Options:
+	-url		Reader address
+	-mongo		MongoDB connection string, seeds the database when set
+	-db		MongoDB database
+	-channels	Channels to seed
+	-messages	Messages to seed per channel and series
+	-step		Time between seeded messages
+	-channel	Channel to query, the first seeded one by default
+	-concurrency	Concurrent requests
+	-duration	Duration of the test of each query
+	-requests	Requests sent per query, unbounded if zero
+	-h		Show this help
+`
+
+func main() {
+	url := flag.String("url", "http://localhost:7071", "Reader address.")
+	mongo := flag.String("mongo", "", "MongoDB connection string, seeds the database when set.")
+	database := flag.String("db", "mainflux", "MongoDB database.")
+	channels := flag.Int("channels", 10, "Channels to seed.")
+	messages := flag.Int("messages", 2880, "Messages to seed per channel and series.")
+	step := flag.Duration("step", 30*time.Second, "Time between seeded messages.")
+	channel := flag.String("channel", bench.ChannelID(0), "Channel to query.")
+	concurrency := flag.Int("concurrency", 8, "Concurrent requests.")
+	duration := flag.Duration("duration", 10*time.Second, "Duration of the test of each query.")
+	requests := flag.Int("requests", 0, "Requests sent per query, unbounded if zero.")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, help) }
+	flag.Parse()
+
+	ds := bench.Dataset{
+		Channels: *channels,
+		Messages: *messages,
+		Step:     *step,
+		End:      time.Now().Truncate(time.Minute),
+	}
+
+	if *mongo != "" {
+		s, err := mgo.Dial(*mongo)
+		if err != nil {
+			log.Fatalf("Can't connect to MongoDB: %v\n", err)
+		}
+		start := time.Now()
+		if err := bench.Seed(s, *database, ds); err != nil {
+			log.Fatalf("Can't seed %s: %v\n", *database, err)
+		}
+		s.Close()
+		fmt.Printf("Seeded %d messages in %v\n", ds.Channels*ds.Messages*3, time.Since(start))
+	}
+
+	l := bench.Load{
+		Client:      &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency}},
+		URL:         *url,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Requests:    *requests,
+	}
+	for _, q := range bench.Queries(ds, *channel) {
+		fmt.Println(l.Run(q))
+	}
+}
@@ -0,0 +1,264 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Command mfmongo-reader-cli queries a running reader and prints the
+// messages, aggregations and statistics of a channel as a table, CSV or
+// JSON.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/pkg/client"
+)
+
+const help string = `
+Usage: mfmongo-reader-cli [options] <command> <channel_id> [options]
+Commands:
+	messages	Messages of the channel, in time order when paged
+	latest		Latest message of every name
+	aggregate	Message values aggregated in time buckets
+	stats		Message count, time span and size of the channel
+Options:
+	-url		Reader address (MF_READER_URL)
+	-token		JWT or admin token (MF_READER_TOKEN)
+	-api-key	API key (MF_READER_API_KEY)
+	-key-id		Id of the HMAC key signing requests (MF_READER_KEY_ID)
+	-secret		Secret of the HMAC key signing requests (MF_READER_SECRET)
+	-cert		Client certificate (MF_READER_CERT)
+	-key		Private key of the client certificate, in -cert by default (MF_READER_KEY)
+	-ca		CA certificate of the reader, the system pool by default (MF_READER_CA)
+	-insecure	Don't verify the certificate of the reader
+	-tenant		Tenant queried
+	-tenant-header	Request header selecting the tenant
+	-from		Start of the time range: UNIX time, RFC 3339 time or duration before now, e.g. 24h
+	-to		End of the time range, now by default
+	-limit		Largest number of messages per page
+	-offset		Number of messages skipped
+	-all		Read every page of messages
+	-name		Latest message or aggregation of this name only
+	-fn		Aggregation function: avg, min, max, sum or count
+	-interval	Aggregation bucket width, e.g. 15m
+	-o		Output: table, csv or json
+	-timeout	Time limit of each request
+	-h		Show this help
+
+Secrets are better passed through the environment than the command line.
+`
+
+// Options of the command line
+type options struct {
+	URL          string
+	Token        string
+	APIKey       string
+	KeyID        string
+	Secret       string
+	Cert         string
+	Key          string
+	CA           string
+	Insecure     bool
+	Tenant       string
+	TenantHeader string
+	From         string
+	To           string
+	Limit        int
+	Offset       int
+	All          bool
+	Name         string
+	Fn           string
+	Interval     time.Duration
+	Output       string
+	Timeout      time.Duration
+}
+
+var opts options
+
+func main() {
+	flag.StringVar(&opts.URL, "url", env("MF_READER_URL", "http://localhost:7071"), "Reader address.")
+	flag.StringVar(&opts.Token, "token", env("MF_READER_TOKEN", ""), "JWT or admin token.")
+	flag.StringVar(&opts.APIKey, "api-key", env("MF_READER_API_KEY", ""), "API key.")
+	flag.StringVar(&opts.KeyID, "key-id", env("MF_READER_KEY_ID", ""), "Id of the HMAC key signing requests.")
+	flag.StringVar(&opts.Secret, "secret", env("MF_READER_SECRET", ""), "Secret of the HMAC key signing requests.")
+	flag.StringVar(&opts.Cert, "cert", env("MF_READER_CERT", ""), "Client certificate.")
+	flag.StringVar(&opts.Key, "key", env("MF_READER_KEY", ""), "Private key of the client certificate.")
+	flag.StringVar(&opts.CA, "ca", env("MF_READER_CA", ""), "CA certificate of the reader.")
+	flag.BoolVar(&opts.Insecure, "insecure", false, "Don't verify the certificate of the reader.")
+	flag.StringVar(&opts.Tenant, "tenant", "", "Tenant queried.")
+	flag.StringVar(&opts.TenantHeader, "tenant-header", "X-Tenant-ID", "Request header selecting the tenant.")
+	flag.StringVar(&opts.From, "from", "", "Start of the time range.")
+	flag.StringVar(&opts.To, "to", "", "End of the time range.")
+	flag.IntVar(&opts.Limit, "limit", 0, "Largest number of messages per page.")
+	flag.IntVar(&opts.Offset, "offset", 0, "Number of messages skipped.")
+	flag.BoolVar(&opts.All, "all", false, "Read every page of messages.")
+	flag.StringVar(&opts.Name, "name", "", "Latest message or aggregation of this name only.")
+	flag.StringVar(&opts.Fn, "fn", "", "Aggregation function.")
+	flag.DurationVar(&opts.Interval, "interval", 0, "Aggregation bucket width.")
+	flag.StringVar(&opts.Output, "o", "table", "Output: table, csv or json.")
+	flag.DurationVar(&opts.Timeout, "timeout", 30*time.Second, "Time limit of each request.")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, help) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	// Options may follow the command and the channel too
+	flag.CommandLine.Parse(args[2:])
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(args[0], args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run executes command on channel and prints its result
+func run(command, channel string) error {
+	out, err := newWriter(os.Stdout, opts.Output)
+	if err != nil {
+		return err
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	r, err := timeRange(opts.From, opts.To, time.Now())
+	if err != nil {
+		return err
+	}
+	if opts.Name != "" && command != "latest" && command != "aggregate" {
+		return fmt.Errorf("-name doesn't apply to %s", command)
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "messages":
+		return readMessages(ctx, c, channel, client.Filter{TimeRange: r, Limit: opts.Limit, Offset: opts.Offset}, out)
+	case "latest":
+		msgs, err := c.Latest(ctx, channel, opts.Name)
+		if err != nil {
+			return err
+		}
+		return out.messages(msgs)
+	case "aggregate":
+		a, err := c.Aggregate(ctx, channel, client.AggregateFilter{
+			TimeRange: r,
+			Name:      opts.Name,
+			Fn:        client.Function(opts.Fn),
+			Interval:  opts.Interval,
+		})
+		if err != nil {
+			return err
+		}
+		return out.aggregation(a)
+	case "stats":
+		s, err := c.Stats(ctx, channel, r)
+		if err != nil {
+			return err
+		}
+		return out.stats(s)
+	}
+	return fmt.Errorf("unknown command %s", command)
+}
+
+// newClient returns the client of the reader of the options
+func newClient() (*client.Client, error) {
+	cfg := client.Config{
+		URL:          opts.URL,
+		Token:        opts.Token,
+		APIKey:       opts.APIKey,
+		KeyID:        opts.KeyID,
+		Secret:       opts.Secret,
+		Tenant:       opts.Tenant,
+		TenantHeader: opts.TenantHeader,
+		Timeout:      opts.Timeout,
+	}
+	if opts.Cert != "" || opts.CA != "" || opts.Insecure {
+		key := opts.Key
+		if key == "" {
+			key = opts.Cert
+		}
+		var err error
+		if cfg.TLS, err = db.ClientTLS(opts.CA, opts.Cert, key, opts.Insecure); err != nil {
+			return nil, err
+		}
+	}
+	return client.New(cfg)
+}
+
+// readMessages reads the messages of channel f selects, following the
+// pages of the read with -all
+func readMessages(ctx context.Context, c *client.Client, channel string, f client.Filter, out writer) error {
+	if !opts.All {
+		p, err := c.Messages(ctx, channel, f)
+		if err != nil {
+			return err
+		}
+		return out.messages(p.Messages)
+	}
+
+	all := []models.Message{}
+	it := c.Iterate(ctx, channel, f)
+	for it.Next() {
+		all = append(all, it.Message())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return out.messages(all)
+}
+
+// timeRange returns the time range from, to
+func timeRange(from, to string, now time.Time) (client.TimeRange, error) {
+	var r client.TimeRange
+	var err error
+	if from != "" {
+		if r.Start, err = parseTime(from, now); err != nil {
+			return r, err
+		}
+	}
+	if to != "" {
+		r.End, err = parseTime(to, now)
+	}
+	return r, err
+}
+
+// parseTime returns the time s names: a UNIX time, an RFC 3339 time or a
+// duration before now
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		return client.Time(t), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use a UNIX time, an RFC 3339 time or a duration", s)
+}
+
+// env returns the value of the environment variable name, def if unset
+func env(name, def string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return def
+}
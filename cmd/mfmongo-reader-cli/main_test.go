@@ -0,0 +1,104 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/pkg/client"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	cases := map[string]time.Time{
+		"1499990000.5":         time.Unix(1499990000, 5e8),
+		"2017-07-14T02:40:00Z": now,
+		"1h":                   now.Add(-time.Hour),
+	}
+	for s, expected := range cases {
+		if got, err := parseTime(s, now); err != nil || !got.Equal(expected) {
+			t.Errorf("%s: expected %v got %v, %v", s, expected, got, err)
+		}
+	}
+	if _, err := parseTime("yesterday", now); err == nil {
+		t.Errorf("expected an invalid time to be rejected")
+	}
+}
+
+func TestReadAllPages(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":"unauthorized","message":"missing credentials","request_id":"r1"}`)
+			return
+		}
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("cursor") == "" {
+			fmt.Fprint(w, `{"messages":[{"n":"temp","v":20,"t":1500000000,"publisher":"p"}],"meta":{"has_more":true,"next_cursor":"bzox"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"messages":[{"n":"on","vb":true,"t":1500000001,"publisher":"p"}],"meta":{"has_more":false}}`)
+	}))
+	defer ts.Close()
+
+	opts = options{URL: ts.URL, All: true, Timeout: time.Second}
+	c, err := newClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	ctx, f := context.Background(), client.Filter{Limit: 1}
+	err = readMessages(ctx, c, "c1", f, writer{&out, "csv"})
+	if err == nil || !strings.Contains(err.Error(), "missing credentials (unauthorized), request id r1") {
+		t.Fatalf("expected the error body to be reported got %v", err)
+	}
+
+	opts.APIKey = "secret"
+	if c, err = newClient(); err != nil {
+		t.Fatal(err)
+	}
+	if err := readMessages(ctx, c, "c1", f, writer{&out, "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "time,name,value,unit,publisher,protocol\n" +
+		"2017-07-14T02:40:00Z,temp,20,,p,\n" +
+		"2017-07-14T02:40:01Z,on,true,,p,\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+	if len(queries) != 2 || queries[0] != "limit=1&meta=true" || queries[1] != "cursor=bzox&limit=1&meta=true" {
+		t.Errorf("expected pages to follow the cursor got %v", queries)
+	}
+}
+
+func TestTableOutput(t *testing.T) {
+	v := 1.5
+	var out bytes.Buffer
+	if err := (writer{&out, "table"}).messages([]models.Message{{Name: "temp", Value: &v, Unit: "C", Time: 1500000000}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "TIME                  NAME  VALUE  UNIT  PUBLISHER  PROTOCOL\n" +
+		"2017-07-14T02:40:00Z  temp  1.5    C                \n"
+	if out.String() != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, out.String())
+	}
+
+	if _, err := newWriter(&out, "xml"); err == nil {
+		t.Errorf("expected an unknown output to be rejected")
+	}
+}
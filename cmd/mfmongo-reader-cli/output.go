@@ -0,0 +1,137 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/pkg/client"
+)
+
+// writer prints the results of the commands in an output format
+type writer struct {
+	w      io.Writer
+	format string
+}
+
+var messageColumns = []string{"time", "name", "value", "unit", "publisher", "protocol"}
+
+// newWriter returns the writer of format to w
+func newWriter(w io.Writer, format string) (writer, error) {
+	switch format {
+	case "table", "csv", "json":
+		return writer{w, format}, nil
+	}
+	return writer{}, fmt.Errorf("unknown output %s, use table, csv or json", format)
+}
+
+func (w writer) messages(msgs []models.Message) error {
+	if w.format == "json" {
+		return w.json(msgs)
+	}
+
+	rows := make([][]string, len(msgs))
+	for i, m := range msgs {
+		rows[i] = []string{formatTime(m.Time), m.Name, value(m), m.Unit, m.Publisher, m.Protocol}
+	}
+	return w.rows(messageColumns, rows)
+}
+
+func (w writer) aggregation(a client.Aggregation) error {
+	if w.format == "json" {
+		return w.json(a)
+	}
+
+	rows := make([][]string, len(a.Buckets))
+	for i, b := range a.Buckets {
+		rows[i] = []string{formatTime(b.Time), formatFloat(b.Value), strconv.Itoa(b.Count)}
+	}
+	if err := w.rows([]string{"time", a.Fn, "count"}, rows); err != nil {
+		return err
+	}
+	if a.Truncated && w.format == "table" {
+		_, err := fmt.Fprintln(w.w, "(truncated, narrow the time range or widen the interval)")
+		return err
+	}
+	return nil
+}
+
+func (w writer) stats(s client.Stats) error {
+	if w.format == "json" {
+		return w.json(s)
+	}
+
+	return w.rows([]string{"channel", "count", "oldest", "newest", "bytes"}, [][]string{{
+		s.Channel,
+		strconv.Itoa(s.Count),
+		formatTime(s.Oldest),
+		formatTime(s.Newest),
+		strconv.FormatInt(s.Bytes, 10),
+	}})
+}
+
+func (w writer) json(v interface{}) error {
+	enc := json.NewEncoder(w.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// rows prints the rows under the header as a table or CSV
+func (w writer) rows(header []string, rows [][]string) error {
+	if w.format == "csv" {
+		c := csv.NewWriter(w.w)
+		c.Write(header)
+		c.WriteAll(rows)
+		return c.Error()
+	}
+
+	tw := tabwriter.NewWriter(w.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
+
+// value returns the value of m, of whichever type it has
+func value(m models.Message) string {
+	switch {
+	case m.Value != nil:
+		return formatFloat(*m.Value)
+	case m.BoolValue != nil:
+		return strconv.FormatBool(*m.BoolValue)
+	case m.StringValue != "":
+		return m.StringValue
+	case m.DataValue != "":
+		return m.DataValue
+	case m.Sum != nil:
+		return formatFloat(*m.Sum)
+	}
+	return ""
+}
+
+// formatTime returns the RFC 3339 time of the UNIX time t
+func formatTime(t float64) string {
+	if t == 0 {
+		return ""
+	}
+	return client.Time(t).UTC().Format(time.RFC3339Nano)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
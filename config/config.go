@@ -0,0 +1,125 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package config reads the options of the reader from YAML or TOML
+// files. Nested keys are joined with dashes into option names, so that
+// db: {tls: {ca: ...}} and db-tls-ca: ... both set --db-tls-ca, and lists
+// become comma separated values.
+//
+// Only the subset of both formats options need is understood: mappings
+// or tables, scalars, and lists of scalars.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Load function reads the options in the file at path, in the format
+// its extension names
+func Load(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return ParseYAML(b)
+	case ".toml":
+		return ParseTOML(b)
+	default:
+		return nil, fmt.Errorf("unknown format %q, use .yaml, .yml or .toml", ext)
+	}
+}
+
+// lineError function returns the error of line n of a file
+func lineError(n int, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", n, fmt.Sprintf(format, args...))
+}
+
+// name function converts key, nested in parent, to an option name
+func name(parent, key string) string {
+	key = strings.Replace(strings.Replace(key, "_", "-", -1), ".", "-", -1)
+	if parent == "" {
+		return key
+	}
+	return parent + "-" + key
+}
+
+// stripComment function removes the comment ending line, if any
+func stripComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// scalar function returns the value of the scalar s, unquoted
+func scalar(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+	return s, nil
+}
+
+// list function returns the items of the inline list s, joined with
+// commas
+func list(s string) (string, error) {
+	inner := strings.TrimSpace(s[1 : len(s)-1])
+	if inner == "" {
+		return "", nil
+	}
+
+	items := []string{}
+	for _, item := range strings.Split(inner, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		v, err := scalar(item)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, v)
+	}
+	return strings.Join(items, ","), nil
+}
+
+// value function returns the value of the scalar or inline list s
+func value(s string) (string, error) {
+	if strings.HasPrefix(s, "[") {
+		if !strings.HasSuffix(s, "]") {
+			return "", fmt.Errorf("unterminated list %s", s)
+		}
+		return list(s)
+	}
+	return scalar(s)
+}
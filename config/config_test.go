@@ -0,0 +1,135 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/config"
+)
+
+var expected = map[string]string{
+	"db-uri":        "mongodb://reader:s3cret@db:27017/mainflux",
+	"db-tls":        "true",
+	"db-tls-ca":     "/etc/ssl/mongo.pem",
+	"max-limit":     "5000",
+	"cors-origins":  "https://a.example.com,https://b.example.com",
+	"cors-methods":  "GET,POST",
+	"tenant-header": "X-Tenant # not a comment",
+}
+
+func TestParseYAML(t *testing.T) {
+	doc := `
+# Reader options
+db-uri: "mongodb://reader:s3cret@db:27017/mainflux"
+db:
+  tls: true
+  tls_ca: /etc/ssl/mongo.pem   # CA of the replica set
+max-limit: 5000
+cors:
+  origins:
+  - https://a.example.com
+  - 'https://b.example.com'
+  methods: [GET, POST]
+tenant-header: "X-Tenant # not a comment"
+`
+	values, err := config.ParseYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v got %v", expected, values)
+	}
+
+	for i, doc := range []string{
+		"max-limit 5000",
+		"max-limit: 1\nmax-limit: 2",
+		"- item",
+		"db:\n\ttls: true",
+		"db-uri: \"mongodb://db",
+	} {
+		if _, err := config.ParseYAML([]byte(doc)); err == nil {
+			t.Errorf("case %d: expected an error for %q", i+1, doc)
+		}
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	doc := `
+# Reader options
+db-uri = "mongodb://reader:s3cret@db:27017/mainflux"
+max-limit = 5000
+tenant-header = "X-Tenant # not a comment"
+
+[db]
+tls = true
+tls_ca = '/etc/ssl/mongo.pem' # CA of the replica set
+
+[cors]
+origins = ["https://a.example.com", "https://b.example.com"]
+methods = ["GET", "POST"]
+`
+	values, err := config.ParseTOML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v got %v", expected, values)
+	}
+
+	for i, doc := range []string{
+		"max-limit 5000",
+		"max-limit = 1\nmax-limit = 2",
+		"[[db]]",
+		"db-uri = \"\"\"",
+	} {
+		if _, err := config.ParseTOML([]byte(doc)); err == nil {
+			t.Errorf("case %d: expected an error for %q", i+1, doc)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		file string
+		body string
+		err  bool
+	}{
+		{"reader.yml", "max-limit: 5", false},
+		{"reader.toml", "max-limit = 5", false},
+		{"reader.json", `{"max-limit": 5}`, true},
+	}
+	for i, c := range cases {
+		path := filepath.Join(dir, c.file)
+		if err := ioutil.WriteFile(path, []byte(c.body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		values, err := config.Load(path)
+		if (err != nil) != c.err {
+			t.Errorf("case %d: expected error %v got %v", i+1, c.err, err)
+		}
+		if err == nil && values["max-limit"] != "5" {
+			t.Errorf("case %d: expected max-limit 5 got %q", i+1, values["max-limit"])
+		}
+	}
+
+	if _, err := config.Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,58 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package config
+
+import (
+	"strings"
+)
+
+// ParseTOML function reads the options of a TOML document made of
+// tables, key/value pairs and single line arrays
+func ParseTOML(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	table := ""
+
+	for i, line := range strings.Split(string(data), "\n") {
+		n := i + 1
+		text := strings.TrimSpace(stripComment(line))
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if strings.HasPrefix(text, "[[") || !strings.HasSuffix(text, "]") {
+				return nil, lineError(n, "expected a [table]")
+			}
+			table = name("", strings.TrimSpace(text[1:len(text)-1]))
+			continue
+		}
+
+		parts := strings.SplitN(text, "=", 2)
+		if len(parts) != 2 {
+			return nil, lineError(n, "expected key = value")
+		}
+		k, err := scalar(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, lineError(n, "%v", err)
+		}
+		v := strings.TrimSpace(parts[1])
+		if strings.HasPrefix(v, `"""`) || strings.HasPrefix(v, "'''") {
+			return nil, lineError(n, "multi-line strings aren't supported")
+		}
+
+		k = name(table, k)
+		if _, ok := values[k]; ok {
+			return nil, lineError(n, "%s is set twice", k)
+		}
+		if values[k], err = value(v); err != nil {
+			return nil, lineError(n, "%v", err)
+		}
+	}
+	return values, nil
+}
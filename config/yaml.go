@@ -0,0 +1,101 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A mapping opened by a key without value
+type yamlFrame struct {
+	indent int
+	key    string
+}
+
+// ParseYAML function reads the options of a YAML document made of block
+// mappings, scalars and lists
+func ParseYAML(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	lists := map[string][]string{}
+	stack := []yamlFrame{}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		n := i + 1
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, lineError(n, "tabs can't indent YAML")
+		}
+		indent := len(line) - len(text)
+		item := text == "-" || strings.HasPrefix(text, "- ")
+
+		// List items may be indented as much as their key
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if indent > top.indent || (item && indent == top.indent) {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		parent := ""
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1].key
+		}
+
+		if item {
+			if parent == "" {
+				return nil, lineError(n, "list outside of an option")
+			}
+			v, err := scalar(strings.TrimSpace(text[1:]))
+			if err != nil {
+				return nil, lineError(n, "%v", err)
+			}
+			lists[parent] = append(lists[parent], v)
+			continue
+		}
+
+		var k, v string
+		switch {
+		case strings.Contains(text, ": "):
+			parts := strings.SplitN(text, ": ", 2)
+			k, v = parts[0], strings.TrimSpace(parts[1])
+		case strings.HasSuffix(text, ":"):
+			k = text[:len(text)-1]
+		default:
+			return nil, lineError(n, "expected key: value")
+		}
+		k, err := scalar(strings.TrimSpace(k))
+		if err != nil {
+			return nil, lineError(n, "%v", err)
+		}
+		k = name(parent, k)
+		if _, ok := values[k]; ok {
+			return nil, lineError(n, "%s is set twice", k)
+		}
+		if v == "" {
+			stack = append(stack, yamlFrame{indent, k})
+			continue
+		}
+		if values[k], err = value(v); err != nil {
+			return nil, lineError(n, "%v", err)
+		}
+	}
+
+	for k, l := range lists {
+		if _, ok := values[k]; ok {
+			return nil, fmt.Errorf("%s is set twice", k)
+		}
+		values[k] = strings.Join(l, ",")
+	}
+	return values, nil
+}
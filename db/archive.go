@@ -0,0 +1,75 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	archiveSession *mgo.Session
+	archiveDbName  string
+
+	// ArchiveAfter is the age from which messages are read from the
+	// archive rather than from the main database.
+	ArchiveAfter time.Duration
+)
+
+// store is the part of a time range kept by one database
+type store struct {
+	mdb    *MgoDb
+	st, et float64
+	query  interface{}
+}
+
+// InitArchive function connects to the cold store holding messages older
+// than ArchiveAfter. The database named in info takes precedence over db.
+func InitArchive(info *mgo.DialInfo, db string) error {
+	s, err := mgo.DialWithInfo(info)
+	if err != nil {
+		return err
+	}
+	s.SetMode(mgo.Monotonic, true)
+
+	if info.Database != "" {
+		db = info.Database
+	}
+	archiveSession, archiveDbName = s, db
+
+	return nil
+}
+
+// archiveCutoff returns the time before which messages are archived, or
+// zero when there is no archive
+func archiveCutoff() float64 {
+	if archiveSession == nil || ArchiveAfter <= 0 {
+		return 0
+	}
+	return float64(time.Now().Add(-ArchiveAfter).Unix())
+}
+
+// stores splits a query of messages between st and et among the archive,
+// for the part before the cutoff, and the main database for the rest
+func (mdb *MgoDb) stores(st, et float64, query interface{}) []store {
+	cutoff := archiveCutoff()
+	if mdb.Archive == nil || cutoff <= st {
+		return []store{{mdb, st, et, query}}
+	}
+	if cutoff >= et {
+		return []store{{mdb.Archive, st, et, query}}
+	}
+
+	return []store{
+		{mdb.Archive, st, cutoff, bson.M{"$and": []interface{}{query, bson.M{"time": bson.M{"$lt": cutoff}}}}},
+		{mdb, cutoff, et, bson.M{"$and": []interface{}{query, bson.M{"time": bson.M{"$gte": cutoff}}}}},
+	}
+}
@@ -0,0 +1,62 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestStores(t *testing.T) {
+	hot := &MgoDb{}
+	cold := &MgoDb{}
+	hot.Archive = cold
+
+	archiveSession = &mgo.Session{}
+	ArchiveAfter = time.Hour
+	defer func() { archiveSession, ArchiveAfter = nil, 0 }()
+
+	cutoff := float64(time.Now().Add(-time.Hour).Unix())
+	q := bson.M{"channel": "1"}
+
+	cases := []struct {
+		st, et float64
+		dbs    []*MgoDb
+	}{
+		{cutoff + 60, Latest, []*MgoDb{hot}},
+		{Earliest, cutoff - 60, []*MgoDb{cold}},
+		{Earliest, Latest, []*MgoDb{cold, hot}},
+	}
+
+	for i, c := range cases {
+		ss := hot.stores(c.st, c.et, q)
+		if len(ss) != len(c.dbs) {
+			t.Errorf("case %d: expected %d stores got %d", i+1, len(c.dbs), len(ss))
+			continue
+		}
+		for j := range ss {
+			if ss[j].mdb != c.dbs[j] {
+				t.Errorf("case %d: unexpected store %d", i+1, j)
+			}
+		}
+	}
+
+	ss := hot.stores(Earliest, Latest, q)
+	if ss[0].et != ss[1].st || ss[0].et < cutoff-1 || ss[0].et > cutoff+1 {
+		t.Errorf("expected range split at cutoff %v got %v and %v", cutoff, ss[0].et, ss[1].st)
+	}
+
+	hot.Archive = nil
+	if ss := hot.stores(Earliest, Latest, q); len(ss) != 1 || ss[0].mdb != hot {
+		t.Errorf("expected only the main store without archive")
+	}
+}
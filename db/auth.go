@@ -0,0 +1,151 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/mgo.v2"
+)
+
+// Authentication mechanisms
+const (
+	MechanismSCRAMSHA1   = "SCRAM-SHA-1"
+	MechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	MechanismX509        = "MONGODB-X509"
+)
+
+// Database holding users authenticated by X.509 certificates.
+const externalSource = "$external"
+
+var (
+	// ErrSCRAMSHA256 is returned for SCRAM-SHA-256, which mgo cannot speak.
+	ErrSCRAMSHA256 = errors.New("SCRAM-SHA-256 is not supported by the MongoDB driver, use SCRAM-SHA-1")
+	// ErrMechanism is returned for unknown authentication mechanisms.
+	ErrMechanism = errors.New("authentication mechanism must be SCRAM-SHA-1 or MONGODB-X509")
+
+	errX509Cert = errors.New("MONGODB-X509 authentication requires a client certificate")
+)
+
+// Short names of the subject attributes used in distinguished names
+var attributeNames = map[string]string{
+	"2.5.4.3":                    "CN",
+	"2.5.4.5":                    "SERIALNUMBER",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.8":                    "ST",
+	"2.5.4.9":                    "STREET",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"2.5.4.17":                   "postalCode",
+	"0.9.2342.19200300.100.1.1":  "UID",
+	"0.9.2342.19200300.100.1.25": "DC",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+}
+
+// Credentials struct holds the authentication settings of a connection
+type Credentials struct {
+	Username  string
+	Password  string
+	Source    string
+	Mechanism string
+}
+
+// SetCredentials function makes connections described by info
+// authenticate with creds. MONGODB-X509 users default to the subject of
+// the client certificate of tlsCfg.
+func SetCredentials(info *mgo.DialInfo, creds Credentials, tlsCfg *tls.Config) error {
+	switch creds.Mechanism {
+	case "", MechanismSCRAMSHA1:
+	case MechanismSCRAMSHA256:
+		return ErrSCRAMSHA256
+	case MechanismX509:
+		if creds.Source == "" {
+			creds.Source = externalSource
+		}
+		if creds.Username == "" {
+			if tlsCfg == nil || len(tlsCfg.Certificates) == 0 {
+				return errX509Cert
+			}
+			name, err := certSubject(tlsCfg.Certificates[0])
+			if err != nil {
+				return err
+			}
+			creds.Username = name
+		}
+	default:
+		return ErrMechanism
+	}
+
+	info.Username = creds.Username
+	info.Password = creds.Password
+	info.Mechanism = creds.Mechanism
+	if creds.Source != "" {
+		info.Source = creds.Source
+	}
+	return nil
+}
+
+// certSubject returns the RFC 2253 distinguished name of the subject of
+// cert, which MongoDB uses as the name of X.509 users
+func certSubject(cert tls.Certificate) (string, error) {
+	if len(cert.Certificate) == 0 {
+		return "", errX509Cert
+	}
+	c, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return "", err
+	}
+
+	var rdns pkix.RDNSequence
+	if _, err := asn1.Unmarshal(c.RawSubject, &rdns); err != nil {
+		return "", err
+	}
+	return distinguishedName(rdns), nil
+}
+
+// distinguishedName formats rdns as RFC 2253 requires: most specific
+// attribute first, with special characters escaped
+func distinguishedName(rdns pkix.RDNSequence) string {
+	parts := []string{}
+	for i := len(rdns) - 1; i >= 0; i-- {
+		attrs := []string{}
+		for _, atv := range rdns[i] {
+			name, ok := attributeNames[atv.Type.String()]
+			if !ok {
+				name = atv.Type.String()
+			}
+			attrs = append(attrs, name+"="+escapeDN(fmt.Sprint(atv.Value)))
+		}
+		parts = append(parts, strings.Join(attrs, "+"))
+	}
+	return strings.Join(parts, ",")
+}
+
+func escapeDN(v string) string {
+	var b []byte
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case strings.IndexByte(`,+"\<>;`, c) >= 0,
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(v)-1 && c == ' ':
+			b = append(b, '\\', c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+func TestSetCredentials(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:         "reader, eu",
+			OrganizationalUnit: []string{"IoT"},
+			Organization:       []string{"Mainflux"},
+			Country:            []string{"RS"},
+		},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withCert := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+
+	cases := []struct {
+		creds    Credentials
+		cfg      *tls.Config
+		err      error
+		username string
+		source   string
+	}{
+		{Credentials{"u", "p", "admin", ""}, nil, nil, "u", "admin"},
+		{Credentials{"u", "p", "", MechanismSCRAMSHA1}, nil, nil, "u", ""},
+		{Credentials{"u", "p", "", MechanismSCRAMSHA256}, nil, ErrSCRAMSHA256, "", ""},
+		{Credentials{"u", "p", "", "GSSAPI"}, nil, ErrMechanism, "", ""},
+		{Credentials{"", "", "", MechanismX509}, nil, errX509Cert, "", ""},
+		{Credentials{"", "", "", MechanismX509}, withCert, nil, `CN=reader\, eu,OU=IoT,O=Mainflux,C=RS`, externalSource},
+		{Credentials{"CN=other", "", "", MechanismX509}, withCert, nil, "CN=other", externalSource},
+	}
+
+	for i, c := range cases {
+		info := &mgo.DialInfo{}
+		err := SetCredentials(info, c.creds, c.cfg)
+		if err != c.err {
+			t.Errorf("case %d: expected error %v got %v", i+1, c.err, err)
+			continue
+		}
+		if info.Username != c.username || info.Source != c.source {
+			t.Errorf("case %d: expected user %q on %q got %q on %q", i+1, c.username, c.source, info.Username, info.Source)
+		}
+	}
+}
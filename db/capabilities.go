@@ -0,0 +1,151 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Server features the reader may depend on
+const (
+	FeatureAggregationCursor = "aggregation_cursor"
+	FeatureExplainCommand    = "explain_command"
+	FeatureReadConcern       = "read_concern"
+	FeatureArrayExpressions  = "array_expressions"
+	FeatureReplaceRoot       = "replace_root"
+	FeatureChangeStreams     = "change_streams"
+	FeatureTimeSeries        = "time_series"
+	FeatureDensify           = "densify"
+	FeaturePercentile        = "percentile"
+)
+
+// Capability struct tells whether the server supports a feature and, if
+// not, why
+type Capability struct {
+	Supported bool   `json:"supported"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Capabilities struct describes the probed server
+type Capabilities struct {
+	Version    string                `json:"version"`
+	ReplicaSet string                `json:"replica_set,omitempty"`
+	Features   map[string]Capability `json:"features"`
+}
+
+// Minimum server versions of features
+var featureVersions = map[string][]int{
+	FeatureAggregationCursor: {2, 6},
+	FeatureExplainCommand:    {3, 0},
+	FeatureReadConcern:       {3, 2},
+	FeatureArrayExpressions:  {3, 4},
+	FeatureReplaceRoot:       {3, 4},
+	FeatureChangeStreams:     {3, 6},
+	FeatureTimeSeries:        {5, 0},
+	FeatureDensify:           {5, 1},
+	FeaturePercentile:        {7, 0},
+}
+
+var (
+	capsMu sync.RWMutex
+	caps   *Capabilities
+)
+
+// Probe function detects the version and topology of the server and the
+// features they allow
+func Probe() (Capabilities, error) {
+	s := mainSession.Copy()
+	defer s.Close()
+
+	bi, err := s.BuildInfo()
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	hello := struct {
+		SetName string `bson:"setName"`
+	}{}
+	if err := s.Run(bson.D{{Name: "isMaster", Value: 1}}, &hello); err != nil {
+		return Capabilities{}, err
+	}
+
+	c := capabilities(bi, hello.SetName)
+
+	capsMu.Lock()
+	caps = &c
+	capsMu.Unlock()
+
+	missing := []string{}
+	for f, fc := range c.Features {
+		if !fc.Supported {
+			missing = append(missing, f)
+		}
+	}
+	sort.Strings(missing)
+	log.Printf("MongoDB: server %s, unsupported features: %s\n", c.Version, strings.Join(missing, ", "))
+
+	return c, nil
+}
+
+func capabilities(bi mgo.BuildInfo, setName string) Capabilities {
+	c := Capabilities{
+		Version:    bi.Version,
+		ReplicaSet: setName,
+		Features:   map[string]Capability{},
+	}
+
+	for f, v := range featureVersions {
+		if !bi.VersionAtLeast(v...) {
+			c.Features[f] = Capability{
+				Reason: fmt.Sprintf("requires MongoDB %d.%d or newer, server is %s", v[0], v[1], bi.Version),
+			}
+			continue
+		}
+		c.Features[f] = Capability{Supported: true}
+	}
+
+	if fc := c.Features[FeatureChangeStreams]; fc.Supported && setName == "" {
+		c.Features[FeatureChangeStreams] = Capability{Reason: "requires a replica set or sharded cluster"}
+	}
+
+	return c
+}
+
+// Supports function reports whether the server supports feature. Features
+// are assumed to be supported until the server was probed.
+func Supports(feature string) Capability {
+	capsMu.RLock()
+	defer capsMu.RUnlock()
+
+	if caps == nil {
+		return Capability{Supported: true}
+	}
+	if fc, ok := caps.Features[feature]; ok {
+		return fc
+	}
+	return Capability{Reason: "unknown feature"}
+}
+
+// Probed function returns the result of the last probe, if any
+func Probed() (Capabilities, bool) {
+	capsMu.RLock()
+	defer capsMu.RUnlock()
+
+	if caps == nil {
+		return Capabilities{}, false
+	}
+	return *caps, true
+}
@@ -0,0 +1,64 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"testing"
+
+	"gopkg.in/mgo.v2"
+)
+
+func TestCapabilities(t *testing.T) {
+	cases := []struct {
+		version   []int
+		setName   string
+		supported map[string]bool
+	}{
+		{[]int{3, 2, 12}, "", map[string]bool{
+			FeatureReadConcern:      true,
+			FeatureArrayExpressions: false,
+			FeatureReplaceRoot:      false,
+		}},
+		{[]int{3, 4, 2}, "", map[string]bool{
+			FeatureAggregationCursor: true,
+			FeatureReadConcern:       true,
+			FeatureArrayExpressions:  true,
+			FeatureReplaceRoot:       true,
+			FeatureChangeStreams:     false,
+			FeatureTimeSeries:        false,
+		}},
+		{[]int{3, 6, 0}, "rs0", map[string]bool{
+			FeatureChangeStreams: true,
+			FeatureDensify:       false,
+		}},
+		{[]int{5, 1, 0}, "", map[string]bool{
+			FeatureTimeSeries:    true,
+			FeatureDensify:       true,
+			FeaturePercentile:    false,
+			FeatureChangeStreams: false,
+		}},
+	}
+
+	for i, c := range cases {
+		caps := capabilities(mgo.BuildInfo{VersionArray: c.version}, c.setName)
+		for f, s := range c.supported {
+			fc := caps.Features[f]
+			if fc.Supported != s {
+				t.Errorf("case %d: expected %s supported %v got %v", i+1, f, s, fc.Supported)
+			}
+			if !fc.Supported && fc.Reason == "" {
+				t.Errorf("case %d: expected reason for unsupported %s", i+1, f)
+			}
+		}
+	}
+
+	if !Supports(FeaturePercentile).Supported {
+		t.Errorf("expected features to be assumed supported before probing")
+	}
+}
@@ -0,0 +1,80 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"strings"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Read concern levels
+const (
+	ConcernLocal    = "local"
+	ConcernMajority = "majority"
+)
+
+var (
+	// ReadConcern is the read concern level of message reads. Empty
+	// leaves the server default in place.
+	ReadConcern string
+)
+
+// withReadConcern adds the configured read concern to the command cmd
+func withReadConcern(cmd bson.D) bson.D {
+	if ReadConcern != "" {
+		cmd = append(cmd, bson.DocElem{Name: "readConcern", Value: bson.M{"level": ReadConcern}})
+	}
+	return cmd
+}
+
+// findCommand runs query as a find command carrying the configured read
+// concern, which mgo queries cannot express
+func (mdb *MgoDb) findCommand(ctx context.Context, collection string, query interface{}, sort string, skip, limit int) *mgo.Iter {
+	cmd := findCmd(collection, query, sort, skip, limit)
+	if BatchSize > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "batchSize", Value: BatchSize})
+	}
+	cmd = withReadConcern(withMaxTime(ctx, cmd))
+
+	res := struct {
+		Cursor struct {
+			FirstBatch []bson.Raw `bson:"firstBatch"`
+			ID         int64      `bson:"id"`
+		}
+	}{}
+	err := mdb.Db.Run(cmd, &res)
+
+	return mdb.C(collection).NewIter(mdb.Session, res.Cursor.FirstBatch, res.Cursor.ID, err)
+}
+
+// findCmd returns the find command of query on collection, sorted by sort
+// and skipping skip documents, returning no more than limit when positive
+func findCmd(collection string, query interface{}, sort string, skip, limit int) bson.D {
+	cmd := bson.D{
+		{Name: "find", Value: collection},
+		{Name: "filter", Value: query},
+	}
+	if sort != "" {
+		order := 1
+		if strings.HasPrefix(sort, "-") {
+			sort, order = sort[1:], -1
+		}
+		cmd = append(cmd, bson.DocElem{Name: "sort", Value: bson.D{{Name: sort, Value: order}}})
+	}
+	if skip > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "skip", Value: skip})
+	}
+	if limit > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "limit", Value: limit})
+	}
+	return cmd
+}
@@ -0,0 +1,204 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Server error code of operations that exceeded their maxTimeMS.
+const exceededTimeLimit = 50
+
+var (
+	// QueryTimeout bounds every operation started through Context.
+	// Zero leaves operations bounded only by their parent context.
+	QueryTimeout time.Duration
+
+	// BatchSize is the number of documents fetched per cursor round trip.
+	// Zero leaves the choice to the server.
+	BatchSize int
+
+	// AllowDiskUse lets aggregation stages exceeding the server memory
+	// limit spill to temporary files instead of failing.
+	AllowDiskUse bool
+)
+
+// Context function derives the context of a database operation, bounded
+// by QueryTimeout
+func Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if QueryTimeout > 0 {
+		return context.WithTimeout(parent, QueryTimeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// MaxTime function returns the execution time left to an operation running
+// under ctx, or zero if ctx has no deadline
+func MaxTime(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+
+	d := deadline.Sub(time.Now())
+	if d < time.Millisecond {
+		d = time.Millisecond
+	}
+	return d
+}
+
+// Run function executes op and waits until it returns or ctx is done,
+// whichever comes first. mgo cannot interrupt an operation in flight, so an
+// abandoned operation keeps running until the server stops it once its
+// maxTimeMS is exceeded. Operations on a session must go through the Run
+// method instead, which keeps the session open until they return.
+func Run(ctx context.Context, op func() error) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- op()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pending counts the operations running on the sessions of an MgoDb, so
+// that closing them waits for the operations Run abandoned
+type pending struct {
+	wg sync.WaitGroup
+	n  int32
+}
+
+func (p *pending) add() {
+	atomic.AddInt32(&p.n, 1)
+	p.wg.Add(1)
+}
+
+func (p *pending) done() {
+	atomic.AddInt32(&p.n, -1)
+	p.wg.Done()
+}
+
+func (p *pending) running() bool {
+	return atomic.LoadInt32(&p.n) > 0
+}
+
+// Run function executes op like the Run function. An abandoned op keeps
+// the sessions of mdb open until it returns, Close then closing them.
+func (mdb *MgoDb) Run(ctx context.Context, op func() error) error {
+	ops := mdb.ops
+	if ops == nil {
+		return Run(ctx, op)
+	}
+
+	ops.add()
+	return Run(ctx, func() error {
+		defer ops.done()
+		return op()
+	})
+}
+
+// IsTimeout function reports whether err means that an operation ran out
+// of time, either on the server or while waiting for it
+func IsTimeout(err error) bool {
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	if e, ok := err.(*mgo.QueryError); ok && e.Code == exceededTimeLimit {
+		return true
+	}
+	return false
+}
+
+// Find function starts a query bounded by the deadline of ctx
+func (mdb *MgoDb) Find(ctx context.Context, collection string, query interface{}) *mgo.Query {
+	q := mdb.C(collection).Find(query)
+	if d := MaxTime(ctx); d > 0 {
+		q.SetMaxTime(d)
+	}
+	if BatchSize > 0 {
+		q.Batch(BatchSize)
+	}
+	return q
+}
+
+// Count function counts the documents matching query within the deadline of ctx
+func (mdb *MgoDb) Count(ctx context.Context, collection string, query interface{}) (int, error) {
+	if query == nil {
+		query = bson.M{}
+	}
+
+	cmd := bson.D{{Name: "count", Value: collection}, {Name: "query", Value: query}}
+	cmd = withReadConcern(withMaxTime(ctx, cmd))
+
+	start := time.Now()
+	res := struct{ N int }{}
+	err := mdb.Db.Run(cmd, &res)
+	observe("count", start, 0, err)
+	mdb.slow("count", collection, query, start, res.N)
+	return res.N, err
+}
+
+// Aggregate function runs an aggregation pipeline bounded by the deadline of ctx
+func (mdb *MgoDb) Aggregate(ctx context.Context, collection string, pipeline interface{}) *mgo.Iter {
+	return mdb.aggregate(ctx, collection, pipeline, AllowDiskUse)
+}
+
+// aggregate runs an aggregation pipeline, letting it use temporary files
+// if diskUse is set
+func (mdb *MgoDb) aggregate(ctx context.Context, collection string, pipeline interface{}, diskUse bool) *mgo.Iter {
+	cmd := bson.D{
+		{Name: "aggregate", Value: collection},
+		{Name: "pipeline", Value: pipeline},
+		{Name: "cursor", Value: cursorOptions()},
+	}
+	if diskUse {
+		cmd = append(cmd, bson.DocElem{Name: "allowDiskUse", Value: true})
+	}
+	cmd = withReadConcern(withMaxTime(ctx, cmd))
+
+	res := struct {
+		Cursor struct {
+			FirstBatch []bson.Raw `bson:"firstBatch"`
+			ID         int64      `bson:"id"`
+		}
+	}{}
+	start := time.Now()
+	err := mdb.Db.Run(cmd, &res)
+	observe("aggregate", start, len(res.Cursor.FirstBatch), err)
+	mdb.slow("aggregate", collection, pipeline, start, len(res.Cursor.FirstBatch))
+
+	return mdb.C(collection).NewIter(mdb.Session, res.Cursor.FirstBatch, res.Cursor.ID, err)
+}
+
+// withMaxTime bounds the command cmd by the deadline of ctx
+func withMaxTime(ctx context.Context, cmd bson.D) bson.D {
+	if d := MaxTime(ctx); d > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "maxTimeMS", Value: int64(d / time.Millisecond)})
+	}
+	return cmd
+}
+
+// cursorOptions returns the cursor document of commands opening a cursor
+func cursorOptions() bson.M {
+	if BatchSize > 0 {
+		return bson.M{"batchSize": BatchSize}
+	}
+	return bson.M{}
+}
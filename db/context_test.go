@@ -0,0 +1,110 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestMaxTime(t *testing.T) {
+	if d := MaxTime(context.Background()); d != 0 {
+		t.Errorf("expected no max time without deadline got %s", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if d := MaxTime(ctx); d <= 0 || d > time.Minute {
+		t.Errorf("expected max time within a minute got %s", d)
+	}
+}
+
+func TestRun(t *testing.T) {
+	errOp := errors.New("op failed")
+	if err := Run(context.Background(), func() error { return errOp }); err != errOp {
+		t.Errorf("expected %v got %v", errOp, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	block := make(chan struct{})
+	defer close(block)
+	err := Run(ctx, func() error {
+		<-block
+		return nil
+	})
+	if !IsTimeout(err) {
+		t.Errorf("expected timeout got %v", err)
+	}
+}
+
+func TestRunAbandoned(t *testing.T) {
+	mdb := MgoDb{ops: &pending{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	block := make(chan struct{})
+	err := mdb.Run(ctx, func() error {
+		<-block
+		return nil
+	})
+	if !IsTimeout(err) || !mdb.ops.running() {
+		t.Fatalf("expected abandoned operation running got %v", err)
+	}
+
+	close(block)
+	mdb.ops.wg.Wait()
+	if mdb.ops.running() {
+		t.Errorf("expected no operation running once it returned")
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	cases := []struct {
+		err     error
+		timeout bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, true},
+		{&mgo.QueryError{Code: exceededTimeLimit}, true},
+		{&mgo.QueryError{Code: 11000}, false},
+	}
+
+	for i, c := range cases {
+		if IsTimeout(c.err) != c.timeout {
+			t.Errorf("case %d: expected %v for %v", i+1, c.timeout, c.err)
+		}
+	}
+}
+
+func TestCommandOptions(t *testing.T) {
+	ReadConcern = ""
+	cmd := withReadConcern(withMaxTime(context.Background(), bson.D{{Name: "count", Value: "messages"}}))
+	if len(cmd) != 1 {
+		t.Errorf("expected no options without deadline and read concern got %v", cmd)
+	}
+
+	ReadConcern = ConcernMajority
+	defer func() { ReadConcern = "" }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cmd = withReadConcern(withMaxTime(ctx, bson.D{{Name: "count", Value: "messages"}}))
+	if len(cmd) != 3 || cmd[1].Name != "maxTimeMS" || cmd[2].Name != "readConcern" {
+		t.Fatalf("expected maxTimeMS and readConcern options got %v", cmd)
+	}
+	if rc, ok := cmd[2].Value.(bson.M); !ok || rc["level"] != ConcernMajority {
+		t.Errorf("expected majority read concern got %v", cmd[2].Value)
+	}
+}
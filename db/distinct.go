@@ -0,0 +1,106 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Distinct function makes the iterator collapse the messages agreeing on
+// the fields keys into the first one stored, as MQTT QoS 1 redeliveries
+// store the same message again. Keys must include the time, which keeps
+// duplicates in the same collection. It must be called before Next.
+func (it *MessageIter) Distinct(keys []string) {
+	it.distinct = keys
+}
+
+// DistinctKey function returns the group key of messages agreeing on
+// the fields keys
+func DistinctKey(keys []string) bson.M {
+	id := bson.M{}
+	for _, k := range keys {
+		id[k] = "$" + k
+	}
+	return id
+}
+
+// distinctPipeline returns the pipeline reading the first stored of the
+// messages matching query that agree on keys, sorted by sort, skipping
+// skip of them and returning no more than limit when positive
+func distinctPipeline(query interface{}, keys []string, sort string, skip, limit int) []bson.M {
+	pipeline := []bson.M{
+		{"$match": query},
+		{"$sort": bson.D{{Name: "_id", Value: 1}}},
+		{"$group": bson.M{"_id": DistinctKey(keys), "doc": bson.M{"$first": "$$ROOT"}}},
+		{"$replaceRoot": bson.M{"newRoot": "$doc"}},
+		{"$sort": sortDoc(sort)},
+	}
+	if skip > 0 {
+		pipeline = append(pipeline, bson.M{"$skip": skip})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+	return pipeline
+}
+
+// sortDoc returns the $sort stage document of the find sort, ties and an
+// empty sort being ordered by arrival
+func sortDoc(sort string) bson.D {
+	doc := bson.D{}
+	if sort != "" && sort != "_id" {
+		if strings.HasPrefix(sort, "-") {
+			doc = append(doc, bson.DocElem{Name: sort[1:], Value: -1})
+		} else {
+			doc = append(doc, bson.DocElem{Name: sort, Value: 1})
+		}
+	}
+	return append(doc, bson.DocElem{Name: "_id", Value: 1})
+}
+
+// countDistinct counts the messages of collection matching query, those
+// agreeing on keys counting once
+func (mdb *MgoDb) countDistinct(ctx context.Context, collection string, query interface{}, keys []string) (int, error) {
+	pipeline := []bson.M{
+		{"$match": query},
+		{"$group": bson.M{"_id": DistinctKey(keys)}},
+		{"$group": bson.M{"_id": nil, "n": bson.M{"$sum": 1}}},
+	}
+
+	res := struct{ N int }{}
+	iter := mdb.aggregate(ctx, collection, pipeline, true)
+	iter.Next(&res)
+	return res.N, iter.Close()
+}
+
+// CountDistinct function counts the messages of channel between st and
+// et matching query across all collections holding them, those agreeing
+// on keys counting once
+func (mdb *MgoDb) CountDistinct(ctx context.Context, channel string, st, et float64, query interface{}, keys []string) (int, error) {
+	total := 0
+	for _, s := range mdb.stores(st, et, query) {
+		names, err := s.mdb.MessageCollections(channel, s.st, s.et)
+		if err != nil {
+			return total, err
+		}
+
+		for _, name := range names {
+			n, err := s.mdb.countDistinct(ctx, name, s.query, keys)
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Plan struct is the plan the server picks for the read of a collection
+type Plan struct {
+	Collection string `json:"collection"`
+	Plan       bson.M `json:"plan"`
+}
+
+// Explain function returns the plans of the reads the iterator runs, with
+// its sort, skip, limit and distinct keys, instead of reading messages.
+// Collections holding no more than the messages left to skip are counted
+// as Next does, and are left out. Verbosity is queryPlanner,
+// executionStats or allPlansExecution; aggregations collapsing duplicates
+// are explained at the queryPlanner verbosity.
+func (it *MessageIter) Explain(verbosity string) ([]Plan, error) {
+	plans := []Plan{}
+	if it.err != nil {
+		return plans, it.err
+	}
+
+	skip := it.skip
+	for _, s := range it.segments {
+		if skip > 0 {
+			n, err := it.count(s)
+			if err != nil {
+				return plans, err
+			}
+			if n <= skip {
+				skip -= n
+				continue
+			}
+		}
+
+		var cmd bson.D
+		if len(it.distinct) > 0 {
+			cmd = bson.D{
+				{Name: "aggregate", Value: s.name},
+				{Name: "pipeline", Value: distinctPipeline(s.query, it.distinct, it.sort, skip, it.limit)},
+				{Name: "explain", Value: true},
+				{Name: "allowDiskUse", Value: true},
+			}
+		} else {
+			cmd = bson.D{
+				{Name: "explain", Value: findCmd(s.name, s.query, it.sort, skip, it.limit)},
+				{Name: "verbosity", Value: verbosity},
+			}
+		}
+		skip = 0
+
+		start := time.Now()
+		plan := bson.M{}
+		err := s.mdb.Db.Run(withMaxTime(it.ctx, cmd), &plan)
+		observe("explain", start, 0, err)
+		if err != nil {
+			return plans, err
+		}
+		plans = append(plans, Plan{s.name, plan})
+	}
+	return plans, nil
+}
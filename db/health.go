@@ -0,0 +1,102 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Health statuses
+const (
+	Up   = "up"
+	Down = "down"
+)
+
+// Member states reported by Check
+const (
+	StatePrimary    = "primary"
+	StateSecondary  = "secondary"
+	StateArbiter    = "arbiter"
+	StateStandalone = "standalone"
+	StateMongos     = "mongos"
+	StateOther      = "other"
+)
+
+// Health struct describes the reachability of MongoDB and the state of
+// the member the reader is connected to
+type Health struct {
+	Status     string  `json:"status"`
+	Latency    float64 `json:"latency,omitempty"`
+	ReplicaSet string  `json:"replica_set,omitempty"`
+	State      string  `json:"state,omitempty"`
+	Primary    string  `json:"primary,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// hello holds the isMaster reply fields telling the member state
+type hello struct {
+	IsMaster    bool   `bson:"ismaster"`
+	Secondary   bool   `bson:"secondary"`
+	ArbiterOnly bool   `bson:"arbiterOnly"`
+	SetName     string `bson:"setName"`
+	Primary     string `bson:"primary"`
+	Msg         string `bson:"msg"`
+}
+
+// Check function pings MongoDB and reads the replica set state of the
+// member serving the reader, within the deadline of ctx
+func Check(ctx context.Context) Health {
+	if !Connected() {
+		return Health{Status: Down, Error: "not connected"}
+	}
+
+	// The session is closed by the check itself, which keeps running
+	// once abandoned past the deadline
+	s := mainSession.Copy()
+	start := time.Now()
+	h := hello{}
+	err := Run(ctx, func() error {
+		defer s.Close()
+		if err := s.Ping(); err != nil {
+			return err
+		}
+		return s.Run(bson.D{{Name: "isMaster", Value: 1}}, &h)
+	})
+	if err != nil {
+		return Health{Status: Down, Error: err.Error()}
+	}
+
+	return Health{
+		Status:     Up,
+		Latency:    time.Since(start).Seconds(),
+		ReplicaSet: h.SetName,
+		State:      h.state(),
+		Primary:    h.Primary,
+	}
+}
+
+// state returns the member state an isMaster reply describes
+func (h hello) state() string {
+	switch {
+	case h.Msg == "isdbgrid":
+		return StateMongos
+	case h.SetName == "" && h.IsMaster:
+		return StateStandalone
+	case h.IsMaster:
+		return StatePrimary
+	case h.Secondary:
+		return StateSecondary
+	case h.ArbiterOnly:
+		return StateArbiter
+	}
+	return StateOther
+}
@@ -0,0 +1,31 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import "testing"
+
+func TestHelloState(t *testing.T) {
+	cases := []struct {
+		hello    hello
+		expected string
+	}{
+		{hello{IsMaster: true}, StateStandalone},
+		{hello{IsMaster: true, Msg: "isdbgrid"}, StateMongos},
+		{hello{IsMaster: true, SetName: "rs0"}, StatePrimary},
+		{hello{Secondary: true, SetName: "rs0"}, StateSecondary},
+		{hello{ArbiterOnly: true, SetName: "rs0"}, StateArbiter},
+		{hello{SetName: "rs0"}, StateOther},
+	}
+
+	for i, c := range cases {
+		if s := c.hello.state(); s != c.expected {
+			t.Errorf("case %d: expected state %s got %s", i+1, c.expected, s)
+		}
+	}
+}
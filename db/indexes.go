@@ -0,0 +1,54 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/mgo.v2"
+)
+
+// Compound indexes serving the supported query shapes of the messages
+// collection: time ranges of a channel, optionally narrowed to one name
+// or one publisher.
+var messageIndexes = [][]string{
+	{"channel", "-time"},
+	{"channel", "name", "time"},
+	{"channel", "publisher", "time"},
+}
+
+// EnsureIndexes function creates the missing indexes of the message
+// collections. Indexes are built in the background so that writers are
+// not blocked while a large collection is indexed.
+func EnsureIndexes() error {
+	s := mainSession.Copy()
+	defer s.Close()
+
+	mdb := MgoDb{Session: s, Db: s.DB(DbName)}
+	names, err := mdb.MessageCollections("", Earliest, Latest)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		c := s.DB(DbName).C(name)
+		for _, keys := range messageIndexes {
+			start := time.Now()
+			idx := mgo.Index{Key: keys, Background: true}
+			if err := c.EnsureIndex(idx); err != nil {
+				return err
+			}
+			log.Printf("MongoDB: index (%s) of %s ready in %s\n", strings.Join(keys, ", "), name, time.Since(start))
+		}
+	}
+
+	return nil
+}
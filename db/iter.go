@@ -0,0 +1,163 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+// segment is a query on one collection of one store
+type segment struct {
+	mdb   *MgoDb
+	name  string
+	query interface{}
+}
+
+// MessageIter struct walks the messages of a channel across all the
+// collections and stores holding them, one document at a time
+type MessageIter struct {
+	ctx      context.Context
+	segments []segment
+	sort     string
+	skip     int
+	limit    int
+	distinct []string
+
+	n      int
+	first  *segment
+	iter   *mgo.Iter
+	err    error
+	start  time.Time
+	closed bool
+}
+
+// IterAll function returns an iterator over the results FindAll would
+// return, decoding documents as they are read instead of all at once.
+// Messages older than the archive cutoff are read from the archive first.
+func (mdb *MgoDb) IterAll(ctx context.Context, channel string, st, et float64,
+	query interface{}, sort string, limit int) *MessageIter {
+	it := &MessageIter{ctx: ctx, sort: sort, limit: limit, start: time.Now()}
+
+	for _, s := range mdb.stores(st, et, query) {
+		names, err := s.mdb.MessageCollections(channel, s.st, s.et)
+		if err != nil {
+			it.err = err
+			break
+		}
+		for _, name := range names {
+			it.segments = append(it.segments, segment{s.mdb, name, s.query})
+		}
+	}
+
+	return it
+}
+
+// Skip function makes the iterator pass over the first n messages. It
+// must be called before Next.
+func (it *MessageIter) Skip(n int) {
+	it.skip = n
+}
+
+// Next function decodes the next message into result, returning false
+// once all messages were read or an error occurred
+func (it *MessageIter) Next(result interface{}) bool {
+	for it.err == nil {
+		if it.limit > 0 && it.n >= it.limit {
+			return false
+		}
+
+		if it.iter == nil {
+			if len(it.segments) == 0 {
+				return false
+			}
+			s := it.segments[0]
+			if it.first == nil {
+				it.first = &it.segments[0]
+			}
+			it.segments = it.segments[1:]
+
+			// Collections holding no more than the messages left to skip
+			// are counted instead of read
+			if it.skip > 0 {
+				n, err := it.count(s)
+				if err != nil {
+					it.err = err
+					return false
+				}
+				if n <= it.skip {
+					it.skip -= n
+					continue
+				}
+			}
+			it.iter = it.open(s)
+			it.skip = 0
+		}
+
+		if it.iter.Next(result) {
+			it.n++
+			return true
+		}
+		it.err = it.iter.Close()
+		it.iter = nil
+	}
+
+	return false
+}
+
+// Close function releases the current cursor and returns the first error
+// met while iterating
+func (it *MessageIter) Close() error {
+	if it.iter != nil {
+		if err := it.iter.Close(); it.err == nil {
+			it.err = err
+		}
+		it.iter = nil
+	}
+	if !it.closed {
+		it.closed = true
+		observe("find", it.start, it.n, it.err)
+		if it.first != nil {
+			it.first.mdb.slow("find", it.first.name, it.first.query, it.start, it.n)
+		}
+	}
+	return it.err
+}
+
+func (it *MessageIter) count(s segment) (int, error) {
+	if len(it.distinct) > 0 {
+		return s.mdb.countDistinct(it.ctx, s.name, s.query, it.distinct)
+	}
+	return s.mdb.Count(it.ctx, s.name, s.query)
+}
+
+func (it *MessageIter) open(s segment) *mgo.Iter {
+	n := 0
+	if it.limit > 0 {
+		n = it.limit - it.n
+	}
+
+	if len(it.distinct) > 0 {
+		// Groups of the whole matched range may outgrow the memory limit
+		// of aggregation stages
+		return s.mdb.aggregate(it.ctx, s.name, distinctPipeline(s.query, it.distinct, it.sort, it.skip, n), true)
+	}
+
+	if ReadConcern != "" {
+		return s.mdb.findCommand(it.ctx, s.name, s.query, it.sort, it.skip, n)
+	}
+
+	q := s.mdb.Find(it.ctx, s.name, s.query)
+	if it.sort != "" {
+		q = q.Sort(it.sort)
+	}
+	return q.Skip(it.skip).Limit(n).Iter()
+}
@@ -0,0 +1,210 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Storage layouts of messages
+const (
+	// LayoutSingle keeps all messages in the messages collection.
+	LayoutSingle = "single"
+	// LayoutHash spreads channels over messages_0 ... messages_<N-1>
+	// by the FNV-1a hash of their id.
+	LayoutHash = "hash"
+	// LayoutMonthly keeps the messages of each month in messages_YYYY_MM,
+	// by message time in UTC.
+	LayoutMonthly = "monthly"
+)
+
+var (
+	// MessagesCollection is the collection of messages, or the name prefix
+	// of their collections in partitioned layouts.
+	MessagesCollection = "messages"
+	// ChannelsCollection is the collection of channels.
+	ChannelsCollection = "channels"
+
+	// Layout is the storage layout of messages.
+	Layout = LayoutSingle
+	// Partitions is the number of collections of the hash layout.
+	Partitions = 16
+)
+
+// Any time range, for operations not bound to one.
+const (
+	Earliest = 0
+	Latest   = math.MaxFloat64
+)
+
+// MessageCollections function returns, in chronological order where it
+// applies, the collections that may hold messages of channel stored
+// between st and et. An empty channel stands for all channels. Sessions
+// restricted to an owner get ErrNotOwned for the channels of others.
+func (mdb *MgoDb) MessageCollections(channel string, st, et float64) ([]string, error) {
+	if err := mdb.Allows(channel); err != nil {
+		return nil, err
+	}
+
+	switch Layout {
+	case LayoutHash:
+		if channel == "" {
+			names := []string{}
+			for i := 0; i < Partitions; i++ {
+				names = append(names, fmt.Sprintf("%s_%d", MessagesCollection, i))
+			}
+			return names, nil
+		}
+		return []string{hashPartition(channel)}, nil
+	case LayoutMonthly:
+		names, err := mdb.Db.CollectionNames()
+		if err != nil {
+			return nil, err
+		}
+		return monthlyPartitions(names, st, et), nil
+	}
+
+	return []string{MessagesCollection}, nil
+}
+
+// MessageCollection function returns the collection a message of channel
+// stored at time t belongs to
+func MessageCollection(channel string, t float64) string {
+	switch Layout {
+	case LayoutHash:
+		return hashPartition(channel)
+	case LayoutMonthly:
+		m := time.Unix(int64(t), 0).UTC()
+		return fmt.Sprintf("%s_%04d_%02d", MessagesCollection, m.Year(), int(m.Month()))
+	}
+	return MessagesCollection
+}
+
+func hashPartition(channel string) string {
+	h := fnv.New32a()
+	h.Write([]byte(channel))
+	return fmt.Sprintf("%s_%d", MessagesCollection, h.Sum32()%uint32(Partitions))
+}
+
+// monthlyPartitions selects the month collections overlapping [st, et].
+func monthlyPartitions(names []string, st, et float64) []string {
+	parts := []string{}
+	for _, name := range names {
+		var y, m int
+		if n, err := fmt.Sscanf(name, MessagesCollection+"_%04d_%02d", &y, &m); err != nil || n != 2 ||
+			name != fmt.Sprintf("%s_%04d_%02d", MessagesCollection, y, m) {
+			continue
+		}
+
+		from := time.Date(y, time.Month(m), 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(0, 1, 0)
+		if float64(from.Unix()) <= et && float64(to.Unix()) > st {
+			parts = append(parts, name)
+		}
+	}
+	sort.Strings(parts)
+
+	return parts
+}
+
+// FindAll function runs query, sorted by sort and limited to limit
+// documents when positive, on every collection holding messages of channel
+// between st and et, and appends the results to the slice pointed by
+// result in collection order
+func (mdb *MgoDb) FindAll(ctx context.Context, channel string, st, et float64,
+	query interface{}, sort string, limit int, result interface{}) error {
+	it := mdb.IterAll(ctx, channel, st, et, query, sort, limit)
+
+	rv := reflect.ValueOf(result).Elem()
+	elem := reflect.New(rv.Type().Elem())
+	for it.Next(elem.Interface()) {
+		rv.Set(reflect.Append(rv, elem.Elem()))
+		elem = reflect.New(rv.Type().Elem())
+	}
+
+	return it.Close()
+}
+
+// CountAll function counts the messages of channel between st and et
+// matching query across all collections holding them
+func (mdb *MgoDb) CountAll(ctx context.Context, channel string, st, et float64, query interface{}) (int, error) {
+	total := 0
+	for _, s := range mdb.stores(st, et, query) {
+		names, err := s.mdb.MessageCollections(channel, s.st, s.et)
+		if err != nil {
+			return total, err
+		}
+
+		for _, name := range names {
+			n, err := s.mdb.Count(ctx, name, s.query)
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+	}
+
+	return total, nil
+}
+
+// LastTime function returns the latest time of the messages of channel
+// between st and et matching query across all collections holding them,
+// zero if there are none
+func (mdb *MgoDb) LastTime(ctx context.Context, channel string, st, et float64, query interface{}) (float64, error) {
+	last := 0.0
+	for _, s := range mdb.stores(st, et, query) {
+		names, err := s.mdb.MessageCollections(channel, s.st, s.et)
+		if err != nil {
+			return last, err
+		}
+
+		for _, name := range names {
+			var m struct{ Time float64 }
+			err := s.mdb.Find(ctx, name, s.query).Select(bson.M{"time": 1}).Sort("-time").One(&m)
+			if err == mgo.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return last, err
+			}
+			last = math.Max(last, m.Time)
+		}
+	}
+
+	return last, nil
+}
+
+// RemoveMessages function deletes the messages of channel between st and
+// et matching query across all collections holding them
+func (mdb *MgoDb) RemoveMessages(channel string, st, et float64, query interface{}) (int, error) {
+	names, err := mdb.MessageCollections(channel, st, et)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, name := range names {
+		info, err := mdb.C(name).RemoveAll(query)
+		if err != nil {
+			return removed, err
+		}
+		removed += info.Removed
+	}
+
+	return removed, nil
+}
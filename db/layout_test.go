@@ -0,0 +1,74 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHashPartition(t *testing.T) {
+	p := hashPartition("a1b2")
+	if p != hashPartition("a1b2") {
+		t.Errorf("expected stable partition of a channel")
+	}
+
+	seen := map[string]bool{}
+	for _, c := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		seen[hashPartition(c)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("expected channels spread over partitions got %v", seen)
+	}
+}
+
+func TestMonthlyPartitions(t *testing.T) {
+	names := []string{"messages_2024_06", "messages", "channels", "messages_2024_04", "messages_2024_05", "messages_24_5"}
+	unix := func(y int, m time.Month, d int) float64 {
+		return float64(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix())
+	}
+
+	cases := []struct {
+		st, et float64
+		parts  []string
+	}{
+		{Earliest, Latest, []string{"messages_2024_04", "messages_2024_05", "messages_2024_06"}},
+		{unix(2024, 5, 10), unix(2024, 5, 20), []string{"messages_2024_05"}},
+		{unix(2024, 4, 30), unix(2024, 6, 1), []string{"messages_2024_04", "messages_2024_05", "messages_2024_06"}},
+		{unix(2025, 1, 1), Latest, []string{}},
+	}
+
+	for i, c := range cases {
+		if parts := monthlyPartitions(names, c.st, c.et); !reflect.DeepEqual(parts, c.parts) {
+			t.Errorf("case %d: expected %v got %v", i+1, c.parts, parts)
+		}
+	}
+}
+
+func TestMessageCollection(t *testing.T) {
+	defer func(l string) { Layout = l }(Layout)
+	may := float64(time.Date(2024, 5, 31, 23, 59, 59, 0, time.UTC).Unix())
+
+	cases := []struct {
+		layout   string
+		expected string
+	}{
+		{LayoutSingle, "messages"},
+		{LayoutHash, hashPartition("a1b2")},
+		{LayoutMonthly, "messages_2024_05"},
+	}
+
+	for i, c := range cases {
+		Layout = c.layout
+		if name := MessageCollection("a1b2", may); name != c.expected {
+			t.Errorf("case %d: expected %s got %s", i+1, c.expected, name)
+		}
+	}
+}
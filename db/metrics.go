@@ -0,0 +1,68 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+	"gopkg.in/mgo.v2"
+)
+
+var (
+	opDuration = metrics.NewHistogramVec("mongo_reader_db_operation_duration_seconds",
+		"Time spent in MongoDB operations.", metrics.DefBuckets, "operation")
+	opErrors = metrics.NewCounterVec("mongo_reader_db_operation_errors_total",
+		"MongoDB operations that failed.", "operation")
+	docsReturned = metrics.NewCounterVec("mongo_reader_db_documents_returned_total",
+		"Documents returned by MongoDB operations.", "operation")
+	selectionFailures = metrics.NewCounterVec("mongo_reader_db_server_selection_failures_total",
+		"MongoDB operations that found no server to run on.", "operation")
+)
+
+func init() {
+	stat := func(f func(mgo.Stats) int) func() float64 {
+		return func() float64 { return float64(f(mgo.GetStats())) }
+	}
+
+	metrics.NewGaugeFunc("mongo_reader_db_pool_sockets_alive", "Open MongoDB sockets.",
+		stat(func(s mgo.Stats) int { return s.SocketsAlive }))
+	metrics.NewGaugeFunc("mongo_reader_db_pool_sockets_in_use", "MongoDB sockets checked out by sessions.",
+		stat(func(s mgo.Stats) int { return s.SocketsInUse }))
+	metrics.NewGaugeFunc("mongo_reader_db_pool_limit", "Maximum number of sockets per MongoDB server.",
+		func() float64 { return float64(poolLimit) })
+	metrics.NewGaugeFunc("mongo_reader_db_clusters", "Known MongoDB clusters.",
+		stat(func(s mgo.Stats) int { return s.Clusters }))
+	metrics.NewGaugeFunc("mongo_reader_db_master_connections", "Connections to MongoDB primaries.",
+		stat(func(s mgo.Stats) int { return s.MasterConns }))
+	metrics.NewGaugeFunc("mongo_reader_db_slave_connections", "Connections to MongoDB secondaries.",
+		stat(func(s mgo.Stats) int { return s.SlaveConns }))
+	metrics.NewCounterFunc("mongo_reader_db_sent_ops_total", "Operations sent to MongoDB.",
+		stat(func(s mgo.Stats) int { return s.SentOps }))
+	metrics.NewCounterFunc("mongo_reader_db_received_ops_total", "Replies received from MongoDB.",
+		stat(func(s mgo.Stats) int { return s.ReceivedOps }))
+	metrics.NewCounterFunc("mongo_reader_db_received_docs_total", "Documents received from MongoDB.",
+		stat(func(s mgo.Stats) int { return s.ReceivedDocs }))
+}
+
+// observe records an operation that started at start, returned docs
+// documents and ended with err
+func observe(op string, start time.Time, docs int, err error) {
+	opDuration.Observe(time.Since(start).Seconds(), op)
+	if docs > 0 {
+		docsReturned.Add(float64(docs), op)
+	}
+	if err != nil && err != mgo.ErrNotFound {
+		opErrors.Inc(op)
+		if strings.Contains(err.Error(), "no reachable servers") {
+			selectionFailures.Inc(op)
+		}
+	}
+}
@@ -9,6 +9,8 @@
 package db
 
 import (
+	"sync/atomic"
+
 	"gopkg.in/mgo.v2"
 )
 
@@ -17,6 +19,8 @@ var (
 	mainDb      *mgo.Database
 	// DbName field
 	DbName string
+
+	connected int32
 )
 
 // MgoDb struct
@@ -24,6 +28,15 @@ type MgoDb struct {
 	Session *mgo.Session
 	Db      *mgo.Database
 	Col     *mgo.Collection
+
+	// Archive is the session on the cold store, if any.
+	Archive *MgoDb
+
+	// Owner the session is restricted to, if any.
+	scope *ownerScope
+
+	// Operations running on the sessions, if tracked.
+	ops *pending
 }
 
 // InitMongo function
@@ -33,7 +46,29 @@ func InitMongo(host string, port string, db string) error {
 		mainSession, err = mgo.Dial("mongodb://" + host + ":" + port)
 
 		if err != nil {
-			panic(err)
+			return err
+		}
+
+		mainSession.SetMode(mgo.Monotonic, true)
+		mainDb = mainSession.DB(db)
+		DbName = db
+	}
+
+	return err
+}
+
+// InitMongoWithInfo function connects to MongoDB using parsed connection
+// settings. The database named in info takes precedence over db.
+func InitMongoWithInfo(info *mgo.DialInfo, db string) error {
+	var err error
+	if mainSession == nil {
+		if info.Database != "" {
+			db = info.Database
+		}
+
+		mainSession, err = mgo.DialWithInfo(info)
+		if err != nil {
+			return err
 		}
 
 		mainSession.SetMode(mgo.Monotonic, true)
@@ -48,6 +83,30 @@ func InitMongo(host string, port string, db string) error {
 func SetMainSession(s *mgo.Session) {
 	mainSession = s
 	mainSession.SetMode(mgo.Monotonic, true)
+	SetConnected()
+}
+
+// SetConnected function marks the main session as ready to serve requests
+func SetConnected() {
+	atomic.StoreInt32(&connected, 1)
+}
+
+// Connected function reports whether the main session is ready
+func Connected() bool {
+	return atomic.LoadInt32(&connected) == 1
+}
+
+// Disconnect function closes the sessions on the main database, the
+// archive and the databases of tenants
+func Disconnect() {
+	atomic.StoreInt32(&connected, 0)
+	if mainSession != nil {
+		mainSession.Close()
+	}
+	if archiveSession != nil {
+		archiveSession.Close()
+	}
+	closeTenants()
 }
 
 // SetMainDb function
@@ -60,25 +119,45 @@ func SetMainDb(db string) {
 func (mdb *MgoDb) Init() *mgo.Session {
 	mdb.Session = mainSession.Copy()
 	mdb.Db = mdb.Session.DB(DbName)
+	mdb.ops = &pending{}
+
+	if archiveSession != nil {
+		s := archiveSession.Copy()
+		mdb.Archive = &MgoDb{Session: s, Db: s.DB(archiveDbName)}
+	}
 
 	return mdb.Session
 }
 
 // C function
 func (mdb *MgoDb) C(collection string) *mgo.Collection {
-	mdb.Col = mdb.Session.DB(DbName).C(collection)
+	mdb.Col = mdb.Db.C(collection)
 	return mdb.Col
 }
 
-// Close function
+// Close function closes the sessions, once the operations Run abandoned
+// on them returned
 func (mdb *MgoDb) Close() bool {
+	if ops := mdb.ops; ops != nil && ops.running() {
+		closing := *mdb
+		closing.ops = nil
+		go func() {
+			ops.wg.Wait()
+			closing.Close()
+		}()
+		return true
+	}
+
 	defer mdb.Session.Close()
+	if mdb.Archive != nil {
+		mdb.Archive.Close()
+	}
 	return true
 }
 
 // DropDb function
 func (mdb *MgoDb) DropDb() {
-	err := mdb.Session.DB(DbName).DropDatabase()
+	err := mdb.Db.DropDatabase()
 	if err != nil {
 		panic(err)
 	}
@@ -86,9 +165,9 @@ func (mdb *MgoDb) DropDb() {
 
 // RemoveAll function
 func (mdb *MgoDb) RemoveAll(collection string) bool {
-	mdb.Session.DB(DbName).C(collection).RemoveAll(nil)
+	mdb.Db.C(collection).RemoveAll(nil)
 
-	mdb.Col = mdb.Session.DB(DbName).C(collection)
+	mdb.Col = mdb.Db.C(collection)
 	return true
 }
 
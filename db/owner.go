@@ -0,0 +1,121 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"errors"
+	"sync"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrNotOwned indicates a read of channels out of the owner scope of a
+// session.
+var ErrNotOwned = errors.New("channel not owned")
+
+// OwnerField is the field of channel documents naming their owner.
+var OwnerField = "owner"
+
+// ownerScope holds the owner a session is restricted to, and what is known
+// of the channels it owns
+type ownerScope struct {
+	owner    string
+	channels *mgo.Collection
+
+	mu    sync.Mutex
+	owned map[string]bool
+}
+
+// Scope function restricts the session to the channels of owner. The
+// messages of other channels, and of all channels at once, are then never
+// read: their lookups fail with ErrNotOwned.
+func (mdb *MgoDb) Scope(owner string) {
+	mdb.scope = &ownerScope{
+		owner:    owner,
+		channels: mdb.Db.C(ChannelsCollection),
+		owned:    map[string]bool{},
+	}
+	if mdb.Archive != nil {
+		mdb.Archive.scope = mdb.scope
+	}
+}
+
+// Owner function returns the owner the session is restricted to, empty if
+// it reads every channel
+func (mdb *MgoDb) Owner() string {
+	if mdb.scope == nil {
+		return ""
+	}
+	return mdb.scope.owner
+}
+
+// Allows function returns ErrNotOwned unless the session may read the
+// messages of channel, an empty channel standing for all channels
+func (mdb *MgoDb) Allows(channel string) error {
+	s := mdb.scope
+	if s == nil {
+		return nil
+	}
+	if channel == "" {
+		return ErrNotOwned
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owned, known := s.owned[channel]
+	if !known {
+		n, err := s.channels.Find(bson.M{"id": channel, OwnerField: s.owner}).Count()
+		if err != nil {
+			return err
+		}
+		owned = n > 0
+		s.owned[channel] = owned
+	}
+	if !owned {
+		return ErrNotOwned
+	}
+	return nil
+}
+
+// FindChannel function returns mgo.ErrNotFound unless channel exists and
+// the session may read it, so that channels of other owners can't be told
+// from missing ones
+func (mdb *MgoDb) FindChannel(channel string) error {
+	q := bson.M{"id": channel}
+	if mdb.scope != nil {
+		q[OwnerField] = mdb.scope.owner
+	}
+	return mdb.C(ChannelsCollection).Find(q).One(nil)
+}
+
+// OwnedChannels function returns the ids of the channels of the owner the
+// session is restricted to, in no particular order
+func (mdb *MgoDb) OwnedChannels() ([]string, error) {
+	var docs []struct {
+		ID string `bson:"id"`
+	}
+	if err := mdb.C(ChannelsCollection).Find(bson.M{OwnerField: mdb.Owner()}).Select(bson.M{"id": 1}).All(&docs); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+	if s := mdb.scope; s != nil {
+		s.mu.Lock()
+		for _, id := range ids {
+			s.owned[id] = true
+		}
+		s.mu.Unlock()
+	}
+	return ids, nil
+}
@@ -0,0 +1,96 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+// PoolStats struct describes the connection pool shared by all sessions
+type PoolStats struct {
+	Limit        int `json:"limit"`
+	MasterConns  int `json:"master_conns"`
+	SlaveConns   int `json:"slave_conns"`
+	SocketsAlive int `json:"sockets_alive"`
+	SocketsInUse int `json:"sockets_in_use"`
+	SentOps      int `json:"sent_ops"`
+	ReceivedOps  int `json:"received_ops"`
+}
+
+var poolLimit = 4096
+
+func init() {
+	mgo.SetStats(true)
+}
+
+// SetPoolLimit function bounds the number of sockets opened to each server.
+// Operations wait for a free socket once the limit is reached.
+func SetPoolLimit(limit int) {
+	if limit <= 0 {
+		return
+	}
+	poolLimit = limit
+	mainSession.SetPoolLimit(limit)
+}
+
+// WarmPool function opens min sockets up front so that the first burst of
+// requests does not pay for connection setup. mgo keeps released sockets
+// open, so they stay in the pool afterwards.
+func WarmPool(min int) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		last error
+	)
+
+	sessions := make([]*mgo.Session, min)
+	for i := range sessions {
+		sessions[i] = mainSession.Copy()
+		wg.Add(1)
+		go func(s *mgo.Session) {
+			defer wg.Done()
+			if err := s.Ping(); err != nil {
+				mu.Lock()
+				last = err
+				mu.Unlock()
+			}
+		}(sessions[i])
+	}
+	wg.Wait()
+
+	for _, s := range sessions {
+		s.Close()
+	}
+
+	return last
+}
+
+// Pool function returns the current state of the connection pool
+func Pool() PoolStats {
+	s := mgo.GetStats()
+	return PoolStats{
+		Limit:        poolLimit,
+		MasterConns:  s.MasterConns,
+		SlaveConns:   s.SlaveConns,
+		SocketsAlive: s.SocketsAlive,
+		SocketsInUse: s.SocketsInUse,
+		SentOps:      s.SentOps,
+		ReceivedOps:  s.ReceivedOps,
+	}
+}
+
+// SetSocketTimeout function bounds a single network round trip to MongoDB
+func SetSocketTimeout(d time.Duration) {
+	if d > 0 {
+		mainSession.SetSocketTimeout(d)
+	}
+}
@@ -0,0 +1,68 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var readModes = map[string]mgo.Mode{
+	"primary":            mgo.Primary,
+	"primaryPreferred":   mgo.PrimaryPreferred,
+	"secondary":          mgo.Secondary,
+	"secondaryPreferred": mgo.SecondaryPreferred,
+	"nearest":            mgo.Nearest,
+	"monotonic":          mgo.Monotonic,
+}
+
+// ParseReadMode function maps a read preference name to an mgo mode
+func ParseReadMode(name string) (mgo.Mode, error) {
+	m, ok := readModes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown read preference %q", name)
+	}
+	return m, nil
+}
+
+// ParseTagSets function parses read preference tag sets written as
+// "k1:v1,k2:v2;k3:v3". Sets are separated by semicolons and tried in order.
+func ParseTagSets(s string) ([]bson.D, error) {
+	sets := []bson.D{}
+	if s == "" {
+		return sets, nil
+	}
+
+	for _, set := range strings.Split(s, ";") {
+		tags := bson.D{}
+		for _, tag := range strings.Split(set, ",") {
+			if tag == "" {
+				continue
+			}
+			kv := strings.SplitN(tag, ":", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return nil, fmt.Errorf("malformed read preference tag %q", tag)
+			}
+			tags = append(tags, bson.DocElem{Name: kv[0], Value: kv[1]})
+		}
+		sets = append(sets, tags)
+	}
+
+	return sets, nil
+}
+
+// SetReadPreference function routes reads of all sessions to the members
+// selected by mode and, for non-primary modes, by the first matching tag set
+func SetReadPreference(mode mgo.Mode, tagSets []bson.D) {
+	mainSession.SetMode(mode, true)
+	mainSession.SelectServers(tagSets...)
+}
@@ -0,0 +1,53 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestParseReadMode(t *testing.T) {
+	if m, err := ParseReadMode("secondaryPreferred"); err != nil || m != mgo.SecondaryPreferred {
+		t.Errorf("expected secondaryPreferred got %v %v", m, err)
+	}
+	if _, err := ParseReadMode("anywhere"); err == nil {
+		t.Errorf("expected error for unknown read preference")
+	}
+}
+
+func TestParseTagSets(t *testing.T) {
+	cases := []struct {
+		in   string
+		sets []bson.D
+		err  bool
+	}{
+		{"", []bson.D{}, false},
+		{"use:analytics", []bson.D{{{Name: "use", Value: "analytics"}}}, false},
+		{"dc:east,use:reports;dc:west", []bson.D{
+			{{Name: "dc", Value: "east"}, {Name: "use", Value: "reports"}},
+			{{Name: "dc", Value: "west"}},
+		}, false},
+		{"dc", nil, true},
+	}
+
+	for i, c := range cases {
+		sets, err := ParseTagSets(c.in)
+		if (err != nil) != c.err {
+			t.Errorf("case %d: unexpected error %v", i+1, err)
+			continue
+		}
+		if !c.err && !reflect.DeepEqual(sets, c.sets) {
+			t.Errorf("case %d: expected %v got %v", i+1, c.sets, sets)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+var (
+	// RetryAttempts is the number of times a read is tried before its
+	// error is returned.
+	RetryAttempts = 3
+	// RetryBackoff is the base delay between attempts, doubled after each
+	// of them and randomized over [0, delay) to spread retries.
+	RetryBackoff = 100 * time.Millisecond
+	// RetryMaxBackoff caps the delay between attempts.
+	RetryMaxBackoff = 2 * time.Second
+
+	// Server error codes of a node that is unreachable, shutting down or
+	// no longer primary.
+	transientCodes = map[int]bool{
+		6:     true, // HostUnreachable
+		7:     true, // HostNotFound
+		89:    true, // NetworkTimeout
+		91:    true, // ShutdownInProgress
+		189:   true, // PrimarySteppedDown
+		9001:  true, // SocketException
+		10107: true, // NotMaster
+		11600: true, // InterruptedAtShutdown
+		11602: true, // InterruptedDueToReplStateChange
+		13435: true, // NotMasterNoSlaveOk
+		13436: true, // NotMasterOrSecondary
+	}
+
+	// Messages of mgo errors that carry no code.
+	transientMessages = []string{
+		"no reachable servers",
+		"not master",
+		"node is recovering",
+		"Closed explicitly",
+	}
+)
+
+// IsTransient function reports whether err is caused by a network failure
+// or a replica set election, so that the operation may succeed if retried
+func IsTransient(err error) bool {
+	if err == nil || err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	if err == io.EOF {
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	if e, ok := err.(*mgo.QueryError); ok && transientCodes[e.Code] {
+		return true
+	}
+	if e, ok := err.(*mgo.LastError); ok && transientCodes[e.Code] {
+		return true
+	}
+
+	for _, m := range transientMessages {
+		if strings.Contains(err.Error(), m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read function runs the read operation op like Run, retrying it with
+// jittered exponential backoff while it fails with a transient error.
+// The session is refreshed between attempts to drop broken sockets.
+func (mdb *MgoDb) Read(ctx context.Context, op func() error) error {
+	return retry(ctx, mdb.Run, op, mdb.Session.Refresh)
+}
+
+func retry(ctx context.Context, run func(context.Context, func() error) error, op func() error, reset func()) error {
+	delay := RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := run(ctx, op)
+		if err == nil || !IsTransient(err) || attempt >= RetryAttempts {
+			return err
+		}
+
+		var wait time.Duration
+		if delay > 0 {
+			wait = time.Duration(rand.Int63n(int64(delay)))
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		reset()
+		if delay *= 2; delay > RetryMaxBackoff {
+			delay = RetryMaxBackoff
+		}
+	}
+}
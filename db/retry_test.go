@@ -0,0 +1,73 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"gopkg.in/mgo.v2"
+)
+
+func TestIsTransient(t *testing.T) {
+	cases := []struct {
+		err       error
+		transient bool
+	}{
+		{nil, false},
+		{io.EOF, true},
+		{errors.New("no reachable servers"), true},
+		{&mgo.QueryError{Code: 10107, Message: "not master"}, true},
+		{&mgo.QueryError{Code: exceededTimeLimit}, false},
+		{mgo.ErrNotFound, false},
+		{context.DeadlineExceeded, false},
+	}
+
+	for i, c := range cases {
+		if IsTransient(c.err) != c.transient {
+			t.Errorf("case %d: expected %v for %v", i+1, c.transient, c.err)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	RetryBackoff = 0
+
+	calls, resets := 0, 0
+	err := retry(context.Background(), Run, func() error {
+		calls++
+		if calls < 2 {
+			return io.EOF
+		}
+		return nil
+	}, func() { resets++ })
+	if err != nil || calls != 2 || resets != 1 {
+		t.Errorf("expected success on second attempt got %v after %d calls and %d resets", err, calls, resets)
+	}
+
+	calls = 0
+	err = retry(context.Background(), Run, func() error {
+		calls++
+		return io.EOF
+	}, func() {})
+	if err != io.EOF || calls != RetryAttempts {
+		t.Errorf("expected %d attempts got %d ending with %v", RetryAttempts, calls, err)
+	}
+
+	calls = 0
+	err = retry(context.Background(), Run, func() error {
+		calls++
+		return mgo.ErrNotFound
+	}, func() {})
+	if err != mgo.ErrNotFound || calls != 1 {
+		t.Errorf("expected permanent error after one attempt got %v after %d", err, calls)
+	}
+}
@@ -0,0 +1,182 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SlowQueryThreshold is the duration from which operations are logged as
+// slow, along with their filter and plan. Zero disables the slow query log.
+var SlowQueryThreshold time.Duration
+
+// Slots of the explains of slow queries running at once, so that a slow
+// database isn't loaded further with one explain per query.
+var explainSlots = make(chan struct{}, 2)
+
+// planSummary struct is the part of an explain result worth logging
+type planSummary struct {
+	Stage         string
+	Index         string
+	DocsExamined  int
+	KeysExamined  int
+	ExecutionTime int
+}
+
+// slow function logs the operation op on collection if it ran longer than
+// SlowQueryThreshold. Filters are logged without their values, and find
+// and count operations are explained on a copy of the session, in the
+// background, to report how many documents the server examined. They are
+// logged without a plan while all explain slots are taken.
+func (mdb *MgoDb) slow(op, collection string, query interface{}, start time.Time, docs int) {
+	d := time.Since(start)
+	if SlowQueryThreshold <= 0 || d < SlowQueryThreshold {
+		return
+	}
+
+	filter, err := json.Marshal(sanitize(query))
+	if err != nil {
+		filter = []byte(`"?"`)
+	}
+	entry := log.WithFields(log.Fields{
+		"operation":     op,
+		"collection":    collection,
+		"filter":        string(filter),
+		"duration":      d.Seconds(),
+		"docs_returned": docs,
+	})
+
+	var cmd bson.D
+	switch op {
+	case "find":
+		cmd = bson.D{{Name: "find", Value: collection}, {Name: "filter", Value: query}}
+	case "count":
+		cmd = bson.D{{Name: "count", Value: collection}, {Name: "query", Value: query}}
+	}
+	if cmd == nil || !Supports(FeatureExplainCommand).Supported {
+		entry.Warn("slow query")
+		return
+	}
+
+	select {
+	case explainSlots <- struct{}{}:
+	default:
+		entry.Warn("slow query")
+		return
+	}
+
+	s := mdb.Session.Copy()
+	name := mdb.Db.Name
+	go func() {
+		defer func() { <-explainSlots }()
+		defer s.Close()
+
+		ctx, cancel := Context(context.Background())
+		defer cancel()
+
+		plan := bson.M{}
+		err := s.DB(name).Run(withMaxTime(ctx, bson.D{
+			{Name: "explain", Value: cmd},
+			{Name: "verbosity", Value: "executionStats"},
+		}), &plan)
+		if err != nil {
+			entry.WithField("explain_error", err.Error()).Warn("slow query")
+			return
+		}
+
+		p := summarize(plan)
+		entry.WithFields(log.Fields{
+			"plan":           p.Stage,
+			"index":          p.Index,
+			"docs_examined":  p.DocsExamined,
+			"keys_examined":  p.KeysExamined,
+			"execution_time": float64(p.ExecutionTime) / 1000,
+		}).Warn("slow query")
+	}()
+}
+
+// sanitize returns query with every value replaced by "?", keeping field
+// names and operators only
+func sanitize(query interface{}) interface{} {
+	switch q := query.(type) {
+	case nil:
+		return bson.M{}
+	case bson.M:
+		return sanitize(map[string]interface{}(q))
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range q {
+			m[k] = sanitize(v)
+		}
+		return m
+	case bson.D:
+		m := map[string]interface{}{}
+		for _, e := range q {
+			m[e.Name] = sanitize(e.Value)
+		}
+		return m
+	case []bson.M:
+		a := []interface{}{}
+		for _, v := range q {
+			a = append(a, sanitize(v))
+		}
+		return a
+	case []interface{}:
+		a := []interface{}{}
+		for _, v := range q {
+			a = append(a, sanitize(v))
+		}
+		return a
+	default:
+		return "?"
+	}
+}
+
+// summarize extracts the innermost stage of the winning plan, telling a
+// collection scan from an index scan, and the execution counters of an
+// executionStats explain result
+func summarize(plan bson.M) planSummary {
+	p := planSummary{}
+
+	if qp, ok := plan["queryPlanner"].(bson.M); ok {
+		stage, _ := qp["winningPlan"].(bson.M)
+		for stage != nil {
+			p.Stage, _ = stage["stage"].(string)
+			if p.Index == "" {
+				p.Index, _ = stage["indexName"].(string)
+			}
+			stage, _ = stage["inputStage"].(bson.M)
+		}
+	}
+
+	if es, ok := plan["executionStats"].(bson.M); ok {
+		p.DocsExamined = toInt(es["totalDocsExamined"])
+		p.KeysExamined = toInt(es["totalKeysExamined"])
+		p.ExecutionTime = toInt(es["executionTimeMillis"])
+	}
+
+	return p
+}
+
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		query    interface{}
+		expected string
+	}{
+		{nil, `{}`},
+		{bson.M{"channel": "secret"}, `{"channel":"?"}`},
+		{bson.M{"channel": "c", "time": bson.M{"$gt": 1.5, "$lt": 2}},
+			`{"channel":"?","time":{"$gt":"?","$lt":"?"}}`},
+		{bson.M{"$and": []interface{}{bson.M{"name": "t"}, bson.D{{Name: "time", Value: 3}}}},
+			`{"$and":[{"name":"?"},{"time":"?"}]}`},
+		{[]bson.M{{"$match": bson.M{"channel": "c"}}, {"$limit": 10}},
+			`[{"$match":{"channel":"?"}},{"$limit":"?"}]`},
+	}
+
+	for i, c := range cases {
+		res, err := json.Marshal(sanitize(c.query))
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		if string(res) != c.expected {
+			t.Errorf("case %d: expected %s got %s", i+1, c.expected, res)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	plan := bson.M{
+		"queryPlanner": bson.M{
+			"winningPlan": bson.M{
+				"stage": "FETCH",
+				"inputStage": bson.M{
+					"stage":     "IXSCAN",
+					"indexName": "channel_1_time_-1",
+				},
+			},
+		},
+		"executionStats": bson.M{
+			"totalDocsExamined":   120,
+			"totalKeysExamined":   int64(121),
+			"executionTimeMillis": 35,
+		},
+	}
+
+	expected := planSummary{"IXSCAN", "channel_1_time_-1", 120, 121, 35}
+	if p := summarize(plan); p != expected {
+		t.Errorf("expected %+v got %+v", expected, p)
+	}
+
+	if p := summarize(bson.M{}); p != (planSummary{}) {
+		t.Errorf("expected empty summary got %+v", p)
+	}
+}
@@ -0,0 +1,178 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	// SplitRanges is the number of sub-ranges time ranges longer than
+	// SplitMinRange are split into by IterSplit. One disables splitting.
+	SplitRanges = 1
+	// SplitParallelism caps the number of sub-ranges read at once.
+	SplitParallelism = 4
+	// SplitMinRange is the shortest time range worth splitting.
+	SplitMinRange = 7 * 24 * time.Hour
+)
+
+// Documents read ahead by each running sub-range.
+const splitBuffer = 1000
+
+type splitDoc struct {
+	raw bson.Raw
+	err error
+}
+
+// SplitIter struct walks the messages of a time range in time order,
+// reading its sub-ranges concurrently
+type SplitIter struct {
+	parts []chan splitDoc
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	err   error
+}
+
+// IterSplit function returns an iterator over the messages of channel
+// matching the query filter builds from a time condition, in time order.
+// Long ranges are split into SplitRanges sub-ranges, read by at most
+// SplitParallelism concurrent queries preferring secondaries, each
+// reading ahead a bounded number of documents.
+func (mdb *MgoDb) IterSplit(ctx context.Context, channel string, st, et float64,
+	filter func(cond bson.M) bson.M) *SplitIter {
+	bounds := splitRange(st, et, float64(time.Now().Unix()))
+
+	it := &SplitIter{stop: make(chan struct{})}
+	for i := 1; i < len(bounds); i++ {
+		it.parts = append(it.parts, make(chan splitDoc, splitBuffer))
+	}
+
+	it.wg.Add(1)
+	go func() {
+		defer it.wg.Done()
+		sem := make(chan struct{}, SplitParallelism)
+		for i, part := range it.parts {
+			select {
+			case sem <- struct{}{}:
+			case <-it.stop:
+				return
+			}
+			// Both may be ready once a sub-range is done
+			select {
+			case <-it.stop:
+				return
+			default:
+			}
+
+			// The first sub-range keeps the exclusive start of the range.
+			cond := bson.M{"$gte": bounds[i], "$lt": bounds[i+1]}
+			if i == 0 {
+				cond = bson.M{"$gt": bounds[i], "$lt": bounds[i+1]}
+			}
+			it.wg.Add(1)
+			go func(part chan splitDoc, st, et float64, cond bson.M) {
+				defer it.wg.Done()
+				defer func() { <-sem }()
+				defer close(part)
+				mdb.readPart(ctx, part, it.stop, len(bounds) > 2, channel, st, et, filter(cond))
+			}(part, bounds[i], bounds[i+1], cond)
+		}
+	}()
+
+	return it
+}
+
+// readPart sends the messages of one sub-range to part until stop
+func (mdb *MgoDb) readPart(ctx context.Context, part chan splitDoc, stop chan struct{},
+	secondary bool, channel string, st, et float64, query interface{}) {
+	sub := &MgoDb{Session: mdb.Session.Copy(), Archive: mdb.Archive, scope: mdb.scope}
+	defer sub.Session.Close()
+	sub.Db = sub.Session.DB(mdb.Db.Name)
+	if secondary {
+		sub.Session.SetMode(mgo.SecondaryPreferred, true)
+	}
+
+	iter := sub.IterAll(ctx, channel, st, et, query, "time", 0)
+	for {
+		var raw bson.Raw
+		if !iter.Next(&raw) {
+			break
+		}
+		select {
+		case part <- splitDoc{raw: raw}:
+		case <-stop:
+			iter.Close()
+			return
+		}
+	}
+	if err := iter.Close(); err != nil {
+		select {
+		case part <- splitDoc{err: err}:
+		case <-stop:
+		}
+	}
+}
+
+// Next function decodes the next message into result, returning false
+// once all messages were read or an error occurred
+func (it *SplitIter) Next(result interface{}) bool {
+	for it.err == nil && len(it.parts) > 0 {
+		d, ok := <-it.parts[0]
+		if !ok {
+			it.parts = it.parts[1:]
+			continue
+		}
+		if d.err != nil {
+			it.err = d.err
+			return false
+		}
+		if it.err = d.raw.Unmarshal(result); it.err != nil {
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+// Close function stops the queries still running, waits for them to
+// return, so that the session of the iterator may be closed, and returns
+// the first error met while iterating
+func (it *SplitIter) Close() error {
+	select {
+	case <-it.stop:
+	default:
+		close(it.stop)
+	}
+	it.wg.Wait()
+	return it.err
+}
+
+// splitRange returns the bounds of the sub-ranges of [st, et]. Ranges
+// reaching past now are split up to now, the last sub-range running on
+// to et.
+func splitRange(st, et, now float64) []float64 {
+	hi := math.Min(et, now)
+	if SplitRanges <= 1 || hi-st < SplitMinRange.Seconds() {
+		return []float64{st, et}
+	}
+
+	bounds := []float64{st}
+	step := (hi - st) / float64(SplitRanges)
+	for i := 1; i < SplitRanges; i++ {
+		bounds = append(bounds, st+float64(i)*step)
+	}
+	return append(bounds, et)
+}
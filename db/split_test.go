@@ -0,0 +1,41 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitRange(t *testing.T) {
+	defer func(n int, d time.Duration) { SplitRanges, SplitMinRange = n, d }(SplitRanges, SplitMinRange)
+	SplitRanges, SplitMinRange = 4, 100*time.Second
+
+	cases := []struct {
+		st, et, now float64
+		expected    []float64
+	}{
+		{0, 400, 1000, []float64{0, 100, 200, 300, 400}},
+		{0, 50, 1000, []float64{0, 50}},
+		{0, Latest, 800, []float64{0, 200, 400, 600, Latest}},
+		{900, Latest, 950, []float64{900, Latest}},
+	}
+
+	for i, c := range cases {
+		if b := splitRange(c.st, c.et, c.now); !reflect.DeepEqual(b, c.expected) {
+			t.Errorf("case %d: expected %v got %v", i+1, c.expected, b)
+		}
+	}
+
+	SplitRanges = 1
+	if b := splitRange(0, 400, 1000); !reflect.DeepEqual(b, []float64{0, 400}) {
+		t.Errorf("expected no split got %v", b)
+	}
+}
@@ -0,0 +1,157 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/mgo.v2"
+)
+
+// ErrUnknownTenant indicates a tenant without a configured database.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// tenantDb is the database of a tenant, either on the main deployment
+// or on its own one, connected to on first use.
+type tenantDb struct {
+	name    string
+	info    *mgo.DialInfo
+	session *mgo.Session
+}
+
+var (
+	tenantsMu sync.Mutex
+	tenants   = map[string]*tenantDb{}
+)
+
+// SetTenants function configures the databases of tenants from a list of
+// tenant=target pairs separated by semicolons. A target is either a
+// database name on the main deployment or a connection string of another
+// one, whose database defaults to the main database name.
+func SetTenants(spec string) error {
+	ts := map[string]*tenantDb{}
+	for _, entry := range strings.Split(spec, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return fmt.Errorf("malformed tenant database %q", entry)
+		}
+
+		t := &tenantDb{name: kv[1]}
+		if strings.HasPrefix(kv[1], scheme) || strings.HasPrefix(kv[1], schemeSRV) {
+			info, err := ParseURI(kv[1])
+			if err != nil {
+				return fmt.Errorf("tenant %s: %v", kv[0], err)
+			}
+			t.name, t.info = info.Database, info
+		}
+		ts[kv[0]] = t
+	}
+
+	tenantsMu.Lock()
+	tenants = ts
+	tenantsMu.Unlock()
+
+	return nil
+}
+
+// IsTenant function reports whether tenant has a configured database
+func IsTenant(tenant string) bool {
+	tenantsMu.Lock()
+	defer tenantsMu.Unlock()
+
+	_, ok := tenants[tenant]
+	return ok
+}
+
+// TenantNames function returns the configured tenants, sorted
+func TenantNames() []string {
+	tenantsMu.Lock()
+	defer tenantsMu.Unlock()
+
+	names := []string{}
+	for t := range tenants {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// closeTenants closes the sessions on the deployments of tenants
+func closeTenants() {
+	tenantsMu.Lock()
+	defer tenantsMu.Unlock()
+
+	for _, t := range tenants {
+		if t.session != nil {
+			t.session.Close()
+			t.session = nil
+		}
+	}
+}
+
+// InitTenant function opens a session on the database of tenant. An empty
+// tenant stands for the main database.
+func (mdb *MgoDb) InitTenant(tenant string) error {
+	if tenant == "" {
+		mdb.Init()
+		return nil
+	}
+
+	tenantsMu.Lock()
+	t, ok := tenants[tenant]
+	var session *mgo.Session
+	if ok && t.session != nil {
+		session = t.session.Copy()
+	}
+	tenantsMu.Unlock()
+	if !ok {
+		return ErrUnknownTenant
+	}
+
+	// Deployments are dialed without the lock held, so that an unreachable
+	// one holds back only the requests of its tenant
+	if t.info != nil && session == nil {
+		s, err := mgo.DialWithInfo(t.info)
+		if err != nil {
+			return err
+		}
+		s.SetMode(mgo.Monotonic, true)
+
+		tenantsMu.Lock()
+		if t.session == nil {
+			t.session = s
+		} else {
+			s.Close()
+		}
+		session = t.session.Copy()
+		tenantsMu.Unlock()
+	}
+
+	name := t.name
+	if name == "" {
+		name = DbName
+	}
+	if session != nil {
+		mdb.Session = session
+	} else {
+		mdb.Session = mainSession.Copy()
+	}
+	mdb.Db = mdb.Session.DB(name)
+	mdb.ops = &pending{}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"testing"
+)
+
+func TestSetTenants(t *testing.T) {
+	defer SetTenants("")
+
+	if err := SetTenants("acme=acme_db; beta=mongodb://db.beta:27017/beta?connect=direct"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if tenants["acme"].name != "acme_db" || tenants["acme"].info != nil {
+		t.Errorf("expected database on main deployment got %+v", tenants["acme"])
+	}
+	if b := tenants["beta"]; b.name != "beta" || b.info == nil || b.info.Addrs[0] != "db.beta:27017" {
+		t.Errorf("expected database on own deployment got %+v", b)
+	}
+	if !IsTenant("acme") || IsTenant("gamma") {
+		t.Errorf("expected acme only to be a tenant")
+	}
+	if names := TenantNames(); len(names) != 2 || names[0] != "acme" || names[1] != "beta" {
+		t.Errorf("expected tenants [acme beta] got %v", names)
+	}
+
+	mdb := MgoDb{}
+	if err := mdb.InitTenant("gamma"); err != ErrUnknownTenant {
+		t.Errorf("expected %v got %v", ErrUnknownTenant, err)
+	}
+
+	for _, spec := range []string{"acme", "=db", "acme=", "beta=mongodb+srv://a.example.com:27017"} {
+		if err := SetTenants(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
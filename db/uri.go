@@ -0,0 +1,219 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package db
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+	"gopkg.in/mgo.v2"
+)
+
+const (
+	scheme    = "mongodb://"
+	schemeSRV = "mongodb+srv://"
+)
+
+var (
+	errScheme  = errors.New("connection string must start with mongodb:// or mongodb+srv://")
+	errSRVHost = errors.New("mongodb+srv connection string must have a single host without port")
+
+	// Resolvers of mongodb+srv connection strings, replaceable in tests.
+	lookupSRV = net.LookupSRV
+	lookupTXT = net.LookupTXT
+
+	// Options understood by the reader itself rather than by mgo.
+	tlsOptions = []string{"tls", "ssl", "tlsInsecure", "tlsAllowInvalidCertificates",
+		"tlsCAFile", "tlsCertificateKeyFile"}
+
+	// Options of other drivers mgo has no use for, found for instance in
+	// the connection strings MongoDB Atlas hands out. They tune writes,
+	// retries and pools mgo manages its own way, so they are dropped.
+	ignoredOptions = []string{"retryWrites", "retryReads", "w", "wtimeoutMS", "journal",
+		"appName", "compressors", "zlibCompressionLevel", "readConcernLevel",
+		"readPreference", "readPreferenceTags", "maxStalenessSeconds", "minPoolSize",
+		"maxIdleTimeMS", "waitQueueTimeoutMS", "socketTimeoutMS", "serverSelectionTimeoutMS",
+		"heartbeatFrequencyMS", "localThresholdMS", "uuidRepresentation", "loadBalanced"}
+
+	// Options a mongodb+srv TXT record is allowed to carry.
+	txtOptions = []string{"authSource", "replicaSet"}
+)
+
+// ParseURI function turns a mongodb:// or mongodb+srv:// connection string
+// into dial settings. Hosts and default options of mongodb+srv strings are
+// resolved from DNS, and TLS, enabled by default for them, is configured
+// through the tls (or ssl), tlsInsecure, tlsCAFile and
+// tlsCertificateKeyFile options. connectTimeoutMS and directConnection
+// are translated to their mgo settings, and the options of other drivers
+// mgo doesn't know are ignored.
+func ParseURI(uri string) (*mgo.DialInfo, error) {
+	srv := strings.HasPrefix(uri, schemeSRV)
+	if !srv && !strings.HasPrefix(uri, scheme) {
+		return nil, errScheme
+	}
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(uri, schemeSRV), scheme)
+
+	var rawOpts string
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, rawOpts = rest[:i], rest[i+1:]
+	}
+	opts, err := url.ParseQuery(rawOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var path string
+	if i := strings.Index(rest, "/"); i >= 0 {
+		rest, path = rest[:i], rest[i:]
+	}
+
+	var userinfo string
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		userinfo, rest = rest[:i+1], rest[i+1:]
+	}
+	hosts := rest
+
+	useTLS := srv
+	if srv {
+		if strings.ContainsAny(hosts, ",:") {
+			return nil, errSRVHost
+		}
+		if hosts, err = resolveSRV(hosts, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, k := range []string{"tls", "ssl"} {
+		if v := opts.Get(k); v != "" {
+			useTLS, _ = strconv.ParseBool(v)
+		}
+	}
+	insecure := false
+	for _, k := range []string{"tlsInsecure", "tlsAllowInvalidCertificates"} {
+		if v := opts.Get(k); v != "" {
+			insecure, _ = strconv.ParseBool(v)
+		}
+	}
+	caFile, certKeyFile := opts.Get("tlsCAFile"), opts.Get("tlsCertificateKeyFile")
+	for _, k := range tlsOptions {
+		opts.Del(k)
+	}
+
+	var timeout time.Duration
+	if v := opts.Get("connectTimeoutMS"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return nil, errors.New("bad value for connectTimeoutMS: " + v)
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	direct, _ := strconv.ParseBool(opts.Get("directConnection"))
+	opts.Del("connectTimeoutMS")
+	opts.Del("directConnection")
+	for _, k := range ignoredOptions {
+		opts.Del(k)
+	}
+
+	plain := scheme + userinfo + hosts + path
+	if len(opts) > 0 {
+		plain += "?" + opts.Encode()
+	}
+	info, err := mgo.ParseURL(plain)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		info.Timeout = timeout
+	}
+	if direct {
+		info.Direct = true
+	}
+
+	var cfg *tls.Config
+	if useTLS {
+		if cfg, err = ClientTLS(caFile, certKeyFile, certKeyFile, insecure); err != nil {
+			return nil, err
+		}
+		UseTLS(info, cfg)
+	}
+
+	creds := Credentials{info.Username, info.Password, info.Source, info.Mechanism}
+	if err := SetCredentials(info, creds, cfg); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// ClientTLS function returns the TLS configuration of connections to
+// MongoDB. Servers are verified against the CAs in caFile only, or the
+// system CAs if it is empty, and the client presents the certificate in
+// certFile and keyFile, which may be the same file, if they are set.
+// The configuration follows the TLS policy of the reader.
+func ClientTLS(caFile, certFile, keyFile string, insecure bool) (*tls.Config, error) {
+	cfg, err := tlsconfig.Client(tlsconfig.Options{
+		CAFile:             caFile,
+		CertFile:           certFile,
+		KeyFile:            keyFile,
+		InsecureSkipVerify: insecure,
+		ExclusiveRootPools: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tlsutil.Apply(cfg)
+	return cfg, nil
+}
+
+// UseTLS function makes connections described by info use TLS
+func UseTLS(info *mgo.DialInfo, cfg *tls.Config) {
+	info.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
+		d := &net.Dialer{Timeout: info.Timeout}
+		return tls.DialWithDialer(d, "tcp", addr.String(), cfg)
+	}
+}
+
+// resolveSRV looks up the hosts behind a mongodb+srv name and merges the
+// options of its TXT record into opts, without overriding explicit ones.
+func resolveSRV(name string, opts url.Values) (string, error) {
+	_, records, err := lookupSRV("mongodb", "tcp", name)
+	if err != nil {
+		return "", err
+	}
+
+	hosts := []string{}
+	for _, r := range records {
+		hosts = append(hosts, net.JoinHostPort(strings.TrimSuffix(r.Target, "."), strconv.Itoa(int(r.Port))))
+	}
+
+	// A missing TXT record only means there are no default options.
+	txts, _ := lookupTXT(name)
+	for _, txt := range txts {
+		txtOpts, err := url.ParseQuery(txt)
+		if err != nil {
+			return "", err
+		}
+		for _, k := range txtOptions {
+			if opts.Get(k) == "" && txtOpts.Get(k) != "" {
+				opts.Set(k, txtOpts.Get(k))
+			}
+		}
+	}
+
+	return strings.Join(hosts, ","), nil
+}
@@ -0,0 +1,239 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// Length of the lines of base64 encoded attachments.
+const base64Line = 76
+
+var errMissingRecipients = errors.New("email destination requires recipients")
+
+// Email sends the export result to the recipients of the destination as
+// an attachment. Results larger than MaxAttachment bytes are replaced by a
+// download link in the message body. Recipients must be among the Allowed
+// addresses or domains, an entry "example.com" allowing every address of
+// that domain.
+type Email struct {
+	Host          string
+	Port          string
+	Username      string
+	Password      string
+	From          string
+	PublicURL     string
+	MaxAttachment int64
+	Allowed       []string
+}
+
+// Validate checks that the recipients of d are allowed addresses
+func (e Email) Validate(d Destination) error {
+	_, err := e.recipients(d)
+	return err
+}
+
+// recipients returns the parsed recipients of d, if all are allowed
+func (e Email) recipients(d Destination) ([]*mail.Address, error) {
+	if len(d.Recipients) == 0 {
+		return nil, errMissingRecipients
+	}
+
+	addrs := []*mail.Address{}
+	for _, r := range d.Recipients {
+		a, err := mail.ParseAddress(r)
+		if err != nil || !e.allows(a.Address) {
+			return nil, ErrDestinationNotAllowed
+		}
+		addrs = append(addrs, a)
+	}
+	return addrs, nil
+}
+
+// allows reports whether addr is an allowed recipient
+func (e Email) allows(addr string) bool {
+	domain := addr[strings.LastIndex(addr, "@")+1:]
+	for _, a := range e.Allowed {
+		a = strings.TrimSpace(a)
+		if strings.EqualFold(a, addr) || strings.EqualFold(a, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Deliver emails the result and returns the download link.
+func (e Email) Deliver(j Job, path string) (string, error) {
+	to, err := e.recipients(j.Destination)
+	if err != nil {
+		return "", err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	location := e.PublicURL + DownloadLink(j.ID)
+	attach := e.MaxAttachment <= 0 || fi.Size() <= e.MaxAttachment
+
+	err = e.send(to, func(w io.Writer) error {
+		return e.compose(w, j, to, path, location, attach)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return location, nil
+}
+
+// send sends the message compose writes to the recipients to, the way
+// smtp.SendMail does, streaming it instead of holding it in memory
+func (e Email) send(to []*mail.Address, compose func(io.Writer) error) error {
+	c, err := smtp.Dial(net.JoinHostPort(e.Host, e.Port))
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.Host}); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, a := range to {
+		if err := c.Rcpt(a.Address); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if err := compose(w); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e Email) compose(w io.Writer, j Job, to []*mail.Address, path, location string, attach bool) error {
+	mw := multipart.NewWriter(w)
+
+	names := []string{}
+	for _, a := range to {
+		names = append(names, a.String())
+	}
+	subject := mime.QEncoding.Encode("utf-8", "Mainflux export of channel "+j.Channel)
+
+	fmt.Fprintf(w, "From: %s\r\n", e.From)
+	fmt.Fprintf(w, "To: %s\r\n", strings.Join(names, ", "))
+	fmt.Fprintf(w, "Subject: %s\r\n", subject)
+	fmt.Fprintf(w, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(w, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(w, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	text := fmt.Sprintf("Export %s of channel %s from %v to %v.\r\n",
+		j.ID, j.Channel, j.StartTime, j.EndTime)
+	if attach {
+		text += "The result is attached.\r\n"
+	} else {
+		text += "The result is too large to be attached, download it from:\r\n" + location + "\r\n"
+	}
+
+	pw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return err
+	}
+	io.WriteString(pw, text)
+
+	if attach {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {j.ContentType()},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {`attachment; filename="` + j.Filename() + `"`},
+		})
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		enc := base64.NewEncoder(base64.StdEncoding, &lineWriter{w: pw})
+		if _, err := io.Copy(enc, f); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		io.WriteString(pw, "\r\n")
+	}
+
+	return mw.Close()
+}
+
+// lineWriter breaks what is written to w into lines of base64Line bytes
+type lineWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if lw.col == base64Line {
+			if _, err := io.WriteString(lw.w, "\r\n"); err != nil {
+				return n, err
+			}
+			lw.col = 0
+		}
+
+		k := base64Line - lw.col
+		if k > len(p) {
+			k = len(p)
+		}
+		m, err := lw.w.Write(p[:k])
+		n += m
+		lw.col += m
+		if err != nil {
+			return n, err
+		}
+		p = p[k:]
+	}
+	return n, nil
+}
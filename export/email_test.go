@@ -0,0 +1,76 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmailValidate(t *testing.T) {
+	e := Email{Allowed: []string{"ops@example.com", " example.org"}}
+
+	cases := []struct {
+		recipients []string
+		err        error
+	}{
+		{nil, errMissingRecipients},
+		{[]string{"ops@example.com", "Jo <jo@example.org>"}, nil},
+		{[]string{"dev@example.com"}, ErrDestinationNotAllowed},
+		{[]string{"jo@example.org.evil.com"}, ErrDestinationNotAllowed},
+		{[]string{"jo@example.org\r\nBcc: x@evil.com"}, ErrDestinationNotAllowed},
+	}
+	for i, c := range cases {
+		if err := e.Validate(Destination{Type: "email", Recipients: c.recipients}); err != c.err {
+			t.Errorf("case %d: expected %v got %v", i+1, c.err, err)
+		}
+	}
+}
+
+func TestEmailCompose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	path := filepath.Join(dir, "1")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := Email{From: "reader@example.com"}
+	j := Job{ID: "1", Request: Request{Channel: "c1\r\nBcc: x@evil.com", Format: JSON}}
+	to := []*mail.Address{{Address: "ops@example.com"}}
+	var buf bytes.Buffer
+	if err := e.compose(&buf, j, to, path, "", true); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := buf.String()
+	if header := msg[:strings.Index(msg, "\r\n\r\n")]; strings.Contains(header, "\r\nBcc:") {
+		t.Errorf("expected no injected header got %q", header)
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var lines []string
+	for len(encoded) > base64Line {
+		lines = append(lines, encoded[:base64Line])
+		encoded = encoded[base64Line:]
+	}
+	lines = append(lines, encoded)
+	if !strings.Contains(msg, strings.Join(lines, "\r\n")+"\r\n") {
+		t.Errorf("expected attachment in lines of %d characters", base64Line)
+	}
+}
@@ -0,0 +1,127 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+// Supported export formats.
+const (
+	JSON = "json"
+	CSV  = "csv"
+)
+
+var csvHeader = []string{
+	"time", "channel", "publisher", "protocol", "name", "unit",
+	"value", "string_value", "bool_value", "data_value", "sum",
+}
+
+// encoder writes messages to an export file in a particular format.
+type encoder interface {
+	Encode(models.Message) error
+	Close() error
+}
+
+func newEncoder(format string, w io.Writer) (encoder, error) {
+	switch format {
+	case JSON:
+		return &jsonEncoder{w: w}, nil
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: cw}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// jsonEncoder writes a JSON array, one message at a time.
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(m models.Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	sep := ","
+	if e.count == 0 {
+		sep = "["
+	}
+	e.count++
+
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "]"
+	if e.count == 0 {
+		end = "[]"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(m models.Message) error {
+	return e.w.Write([]string{
+		formatFloat(m.Time),
+		m.Channel,
+		m.Publisher,
+		m.Protocol,
+		m.Name,
+		m.Unit,
+		formatFloatPtr(m.Value),
+		m.StringValue,
+		formatBoolPtr(m.BoolValue),
+		m.DataValue,
+		formatFloatPtr(m.Sum),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatFloatPtr(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return formatFloat(*f)
+}
+
+func formatBoolPtr(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
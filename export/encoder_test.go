@@ -0,0 +1,61 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+func TestEncoders(t *testing.T) {
+	v := 21.5
+	msgs := []models.Message{
+		{Channel: "c1", Publisher: "p1", Name: "temp", Unit: "C", Time: 1500000000, Value: &v},
+		{Channel: "c1", Publisher: "p1", Name: "state", Time: 1500000001.5, StringValue: "on"},
+	}
+
+	cases := []struct {
+		format string
+		msgs   []models.Message
+		out    string
+	}{
+		{JSON, nil, `[]`},
+		{JSON, msgs[:1], `[{"n":"temp","u":"C","t":1500000000,"v":21.5,"publisher":"p1","protocol":"","created":"","content_type":"","channel":"c1"}]`},
+		{CSV, nil, "time,channel,publisher,protocol,name,unit,value,string_value,bool_value,data_value,sum\n"},
+		{CSV, msgs, "time,channel,publisher,protocol,name,unit,value,string_value,bool_value,data_value,sum\n" +
+			"1500000000,c1,p1,,temp,C,21.5,,,,\n" +
+			"1500000001.5,c1,p1,,state,,,on,,,\n"},
+	}
+
+	for i, c := range cases {
+		var buf bytes.Buffer
+		enc, err := newEncoder(c.format, &buf)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		for _, m := range c.msgs {
+			if err := enc.Encode(m); err != nil {
+				t.Fatalf("case %d: %s", i+1, err.Error())
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		if buf.String() != c.out {
+			t.Errorf("case %d: expected %s got %s", i+1, c.out, buf.String())
+		}
+	}
+
+	if _, err := newEncoder("xml", &bytes.Buffer{}); err != ErrUnknownFormat {
+		t.Errorf("expected %v got %v", ErrUnknownFormat, err)
+	}
+}
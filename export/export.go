@@ -0,0 +1,487 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package export runs message exports as background jobs.
+//
+// A job writes the messages matching its filters to a file in the export
+// directory and then hands the file to its destination. Jobs are kept in
+// memory, along with their files, until they expire, and processed by a
+// fixed pool of workers.
+package export
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Job statuses.
+const (
+	Pending    = "pending"
+	Running    = "running"
+	Delivering = "delivering"
+	Done       = "done"
+	Failed     = "failed"
+)
+
+// How many exported messages pass between two progress updates.
+const progressStep = 1000
+
+var (
+	// ErrUnknownFormat indicates an unsupported export format.
+	ErrUnknownFormat = errors.New("unknown export format")
+	// ErrUnknownDestination indicates an unsupported export destination.
+	ErrUnknownDestination = errors.New("unknown export destination")
+	// ErrMissingChannel indicates an export request without a channel.
+	ErrMissingChannel = errors.New("missing channel")
+	// ErrInvalidChannel indicates a channel id with line breaks.
+	ErrInvalidChannel = errors.New("invalid channel")
+	// ErrQueueFull indicates that no more jobs can be accepted right now.
+	ErrQueueFull = errors.New("export queue is full")
+	// ErrNotFound indicates a non-existent job.
+	ErrNotFound = errors.New("export not found")
+	// ErrDestinationNotAllowed indicates a destination the reader may not
+	// deliver to.
+	ErrDestinationNotAllowed = errors.New("export destination not allowed")
+)
+
+type (
+	// Destination describes where the result of a job is delivered.
+	Destination struct {
+		Type string `json:"type"`
+
+		// Object storage ("s3") settings
+		Bucket string `json:"bucket,omitempty"`
+		Prefix string `json:"prefix,omitempty"`
+
+		// Broker replay ("nats") settings
+		Speed float64 `json:"speed,omitempty"`
+
+		// Webhook ("webhook") settings
+		URL    string `json:"url,omitempty"`
+		Attach bool   `json:"attach,omitempty"`
+
+		// Email ("email") settings
+		Recipients []string `json:"recipients,omitempty"`
+	}
+
+	// Request holds the filters and output settings of an export.
+	Request struct {
+		Channel     string      `json:"channel"`
+		StartTime   float64     `json:"start_time"`
+		EndTime     float64     `json:"end_time"`
+		Format      string      `json:"format"`
+		Compression string      `json:"compression,omitempty"`
+		Destination Destination `json:"destination"`
+
+		// Tenant whose database holds the messages.
+		Tenant string `json:"-"`
+		// Owner whose channels only are exported, unless empty.
+		Owner string `json:"-"`
+	}
+
+	// Job is a single export and its progress.
+	Job struct {
+		ID string `json:"id"`
+		Request
+		Status   string     `json:"status"`
+		Exported int        `json:"exported"`
+		Total    int        `json:"total"`
+		Location string     `json:"location,omitempty"`
+		Error    string     `json:"error,omitempty"`
+		Created  time.Time  `json:"created"`
+		Finished *time.Time `json:"finished,omitempty"`
+	}
+
+	// Deliverer hands the file produced by a job over to a destination and
+	// returns the location at which the result can be retrieved.
+	Deliverer interface {
+		Deliver(j Job, path string) (string, error)
+	}
+
+	// Validator is implemented by deliverers checking the destinations of
+	// requests before their jobs are enqueued.
+	Validator interface {
+		Validate(d Destination) error
+	}
+)
+
+var (
+	// Dir is the directory in which export files are written.
+	Dir = os.TempDir()
+
+	// TTL is the time finished jobs and their files are kept. Zero keeps
+	// them until the reader stops.
+	TTL = 24 * time.Hour
+
+	mu      sync.Mutex
+	jobs    = map[string]*Job{}
+	queue   chan string
+	stopped bool
+	workers sync.WaitGroup
+	expiry  chan struct{}
+
+	errStopped = errors.New("export service stopped")
+
+	destinations = map[string]Deliverer{
+		"file": fileDeliverer{},
+	}
+
+	// Destinations whose results stay in the export directory.
+	local = map[string]bool{"file": true, "webhook": true, "email": true}
+)
+
+// Register function makes a destination type available to export requests
+func Register(name string, d Deliverer) {
+	mu.Lock()
+	defer mu.Unlock()
+	destinations[name] = d
+}
+
+// Start function starts a pool of export workers with a bounded job queue,
+// and the expiry of finished jobs if TTL is set
+func Start(n, queueSize int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	queue = make(chan string, queueSize)
+	stopped = false
+	for i := 0; i < n; i++ {
+		workers.Add(1)
+		go work(queue)
+	}
+
+	if TTL <= 0 || expiry != nil {
+		return
+	}
+	expiry = make(chan struct{})
+	interval := time.Minute
+	if TTL < interval {
+		interval = TTL
+	}
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				expire(now)
+			}
+		}
+	}(expiry)
+}
+
+// Stop function stops accepting jobs, fails those still pending and waits
+// until the running ones finish or ctx is done
+func Stop(ctx context.Context) error {
+	mu.Lock()
+	if queue != nil {
+		close(queue)
+		queue = nil
+		stopped = true
+	}
+	if expiry != nil {
+		close(expiry)
+		expiry = nil
+	}
+	mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Create function validates an export request and enqueues it as a new job
+func Create(req Request) (Job, error) {
+	if req.Channel == "" {
+		return Job{}, ErrMissingChannel
+	}
+	if strings.ContainsAny(req.Channel, "\r\n") {
+		return Job{}, ErrInvalidChannel
+	}
+	if req.Format == "" {
+		req.Format = JSON
+	}
+	if req.Format != JSON && req.Format != CSV {
+		return Job{}, ErrUnknownFormat
+	}
+	if req.Compression != "" && req.Compression != Gzip {
+		return Job{}, ErrUnknownCompression
+	}
+	if req.Destination.Type == "" {
+		req.Destination.Type = "file"
+	}
+	if req.EndTime == 0 {
+		req.EndTime = float64(time.Now().Unix())
+	}
+
+	mu.Lock()
+	d, ok := destinations[req.Destination.Type]
+	mu.Unlock()
+	if !ok {
+		return Job{}, ErrUnknownDestination
+	}
+	if v, ok := d.(Validator); ok {
+		if err := v.Validate(req.Destination); err != nil {
+			return Job{}, err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	j := &Job{
+		ID:      newID(),
+		Request: req,
+		Status:  Pending,
+		Created: time.Now().UTC(),
+	}
+
+	// The queue is nil once stopped
+	select {
+	case queue <- j.ID:
+	default:
+		return Job{}, ErrQueueFull
+	}
+	jobs[j.ID] = j
+
+	return *j, nil
+}
+
+// Get function returns a snapshot of the job with the given id
+func Get(id string) (Job, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	j, ok := jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+
+	return *j, nil
+}
+
+// Downloadable function reports whether the result of a job is complete and
+// kept in the export directory, from which the reader can serve it
+func Downloadable(j Job) bool {
+	if j.Status != Done && j.Status != Delivering {
+		return false
+	}
+	return local[j.Destination.Type]
+}
+
+// Path function returns the export file of a finished job
+func Path(id string) string {
+	return filepath.Join(Dir, id)
+}
+
+// Filename function returns the name the result of a job is delivered
+// under
+func (j Job) Filename() string {
+	name := j.ID + "." + j.Format
+	if j.Compression == Gzip {
+		name += ".gz"
+	}
+	return name
+}
+
+// ContentType function returns the media type of the result of a job
+func (j Job) ContentType() string {
+	switch {
+	case j.Compression == Gzip:
+		return "application/gzip"
+	case j.Format == CSV:
+		return "text/csv"
+	}
+	return "application/json"
+}
+
+func work(q chan string) {
+	defer workers.Done()
+
+	for id := range q {
+		mu.Lock()
+		s := stopped
+		mu.Unlock()
+		if s {
+			finish(id, "", errStopped)
+			continue
+		}
+		run(id)
+	}
+}
+
+func run(id string) {
+	update(id, func(j *Job) { j.Status = Running })
+	j, _ := Get(id)
+
+	path := Path(id)
+	err := write(j, path)
+	var location string
+	if err == nil {
+		update(id, func(j *Job) { j.Status = Delivering })
+		mu.Lock()
+		d := destinations[j.Destination.Type]
+		mu.Unlock()
+		j, _ = Get(id)
+		location, err = d.Deliver(j, path)
+	}
+
+	finish(id, location, err)
+}
+
+// expire forgets the jobs finished TTL before now and removes their files
+func expire(now time.Time) {
+	mu.Lock()
+	expired := []string{}
+	for id, j := range jobs {
+		if j.Finished != nil && now.Sub(*j.Finished) >= TTL {
+			delete(jobs, id)
+			expired = append(expired, id)
+		}
+	}
+	mu.Unlock()
+
+	for _, id := range expired {
+		if err := os.Remove(Path(id)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Export %s: can't remove result: %v", id, err)
+		}
+	}
+}
+
+// finish records the outcome of the job with the given id
+func finish(id, location string, err error) {
+	update(id, func(j *Job) {
+		now := time.Now().UTC()
+		j.Finished = &now
+		j.Location = location
+		j.Status = Done
+		if err != nil {
+			log.Printf("Export %s failed: %v", id, err)
+			j.Status = Failed
+			j.Error = err.Error()
+		}
+	})
+}
+
+func write(j Job, path string) error {
+	Db := db.MgoDb{}
+	if err := Db.InitTenant(j.Tenant); err != nil {
+		return err
+	}
+	defer Db.Close()
+	if j.Owner != "" {
+		Db.Scope(j.Owner)
+	}
+
+	filter := bson.M{"channel": j.Channel, "time": bson.M{"$gt": j.StartTime, "$lt": j.EndTime}}
+
+	names, err := Db.MessageCollections(j.Channel, j.StartTime, j.EndTime)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, name := range names {
+		n, err := Db.C(name).Find(filter).Count()
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+	update(j.ID, func(j *Job) { j.Total = total })
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p, err := newPipeline(f, j.Compression)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	enc, err := newEncoder(j.Format, p)
+	if err != nil {
+		return err
+	}
+
+	// Long ranges are read in concurrent time slices, merged in time order.
+	iter := Db.IterSplit(context.Background(), j.Channel, j.StartTime, j.EndTime, func(cond bson.M) bson.M {
+		return bson.M{"channel": j.Channel, "time": cond}
+	})
+	defer iter.Close()
+
+	n := 0
+	var m models.Message
+	for iter.Next(&m) {
+		redact.Apply(&m, false)
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+		m = models.Message{}
+
+		n++
+		if n%progressStep == 0 {
+			update(j.ID, func(j *Job) { j.Exported = n })
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	update(j.ID, func(j *Job) { j.Exported = n })
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := p.Close(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func update(id string, f func(*Job)) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if j, ok := jobs[id]; ok {
+		f(j)
+	}
+}
+
+// fileDeliverer keeps the result in the export directory, from which it
+// is served by the download endpoint.
+type fileDeliverer struct{}
+
+func (fileDeliverer) Deliver(j Job, path string) (string, error) {
+	return DownloadLink(j.ID), nil
+}
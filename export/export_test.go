@@ -0,0 +1,82 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStop(t *testing.T) {
+	Start(0, 1)
+	q := queue
+	j, err := Create(Request{Channel: "stop", EndTime: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Create(Request{Channel: "stop", EndTime: 1}); err != ErrQueueFull {
+		t.Errorf("expected error %v got %v", ErrQueueFull, err)
+	}
+
+	// A worker still reading the queue fails the pending job
+	workers.Add(1)
+	work(q)
+	if j, _ = Get(j.ID); j.Status != Failed || j.Error != errStopped.Error() {
+		t.Errorf("expected job to fail with %v got %s %q", errStopped, j.Status, j.Error)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { Dir = d }(Dir)
+	Dir = dir
+
+	now := time.Now().UTC()
+	old, recent := now.Add(-2*TTL), now.Add(-TTL/2)
+	mu.Lock()
+	jobs["old"] = &Job{ID: "old", Status: Done, Finished: &old}
+	jobs["recent"] = &Job{ID: "recent", Status: Done, Finished: &recent}
+	jobs["running"] = &Job{ID: "running", Status: Running}
+	mu.Unlock()
+	for _, id := range []string{"old", "recent"} {
+		if err := ioutil.WriteFile(Path(id), []byte("[]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expire(now)
+
+	if _, err := Get("old"); err != ErrNotFound {
+		t.Errorf("expected expired job to be gone got %v", err)
+	}
+	if _, err := os.Stat(Path("old")); !os.IsNotExist(err) {
+		t.Errorf("expected result of expired job to be removed got %v", err)
+	}
+	for _, id := range []string{"recent", "running"} {
+		if _, err := Get(id); err != nil {
+			t.Errorf("expected job %s to be kept got %v", id, err)
+		}
+	}
+	if _, err := os.Stat(Path("recent")); err != nil {
+		t.Errorf("expected result of recent job to be kept got %v", err)
+	}
+}
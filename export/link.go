@@ -0,0 +1,67 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Lifetime of download links while jobs don't expire.
+const linkTTL = 24 * time.Hour
+
+// Key signing download links. Jobs live in memory, so links need not
+// outlive the run of the reader that signed them.
+var linkKey = randomBytes(32)
+
+// DownloadLink function returns the path of the download of the result of
+// job id, signed so that it can be followed without credentials until
+// the job expires
+func DownloadLink(id string) string {
+	ttl := TTL
+	if ttl <= 0 {
+		ttl = linkTTL
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return "/exports/" + id + "/download?expires=" + expires + "&sig=" + sign(id, expires)
+}
+
+// VerifyLink function reports whether expires and sig sign an unexpired
+// download link of job id
+func VerifyLink(id, expires, sig string) bool {
+	t, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > t {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(sign(id, expires)))
+}
+
+func sign(id, expires string) string {
+	mac := hmac.New(sha256.New, linkKey)
+	io.WriteString(mac, id+"."+expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newID returns an unguessable job id
+func newID() string {
+	return hex.EncodeToString(randomBytes(16))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,42 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestDownloadLink(t *testing.T) {
+	u, err := url.Parse(DownloadLink("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	cases := []struct {
+		id      string
+		expires string
+		sig     string
+		valid   bool
+	}{
+		{"1", q.Get("expires"), q.Get("sig"), true},
+		{"2", q.Get("expires"), q.Get("sig"), false},
+		{"1", "1", sign("1", "1"), false},
+		{"1", q.Get("expires") + "0", q.Get("sig"), false},
+		{"1", "", "", false},
+	}
+	for i, c := range cases {
+		if VerifyLink(c.id, c.expires, c.sig) != c.valid {
+			t.Errorf("case %d: expected valid %v", i+1, c.valid)
+		}
+	}
+	if newID() == newID() {
+		t.Errorf("expected distinct ids")
+	}
+}
@@ -0,0 +1,106 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/nats-io/go-nats"
+)
+
+var errReplayFormat = errors.New("nats replay requires json format")
+
+// natsMsg mirrors the envelope Mainflux adapters publish on the broker.
+type natsMsg struct {
+	Channel   string `json:"channel"`
+	Publisher string `json:"publisher"`
+	Protocol  string `json:"protocol"`
+	Payload   []byte `json:"payload"`
+}
+
+// NATS replays exported messages onto the Mainflux broker subject
+// channel.<channel_id>, so services subscribed to it can be backfilled.
+// Destination speed 1 keeps the original pace, higher values accelerate it
+// and 0 publishes as fast as possible. The API accepts replays from
+// administrators only.
+type NATS struct {
+	Conn *nats.Conn
+}
+
+// Deliver publishes every message of the export file in time order.
+func (n NATS) Deliver(j Job, path string) (string, error) {
+	if j.Format != JSON {
+		return "", errReplayFormat
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var rd io.Reader = f
+	if j.Compression == Gzip {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return "", err
+		}
+		defer gz.Close()
+		rd = gz
+	}
+
+	subject := "channel." + j.Channel
+	dec := json.NewDecoder(rd)
+	if _, err := dec.Token(); err != nil {
+		return "", err
+	}
+
+	var prev float64
+	for dec.More() {
+		var m models.Message
+		if err := dec.Decode(&m); err != nil {
+			return "", err
+		}
+
+		if j.Destination.Speed > 0 && prev > 0 && m.Time > prev {
+			time.Sleep(time.Duration((m.Time - prev) / j.Destination.Speed * float64(time.Second)))
+		}
+		prev = m.Time
+
+		payload, err := json.Marshal([]models.Message{m})
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(natsMsg{
+			Channel:   m.Channel,
+			Publisher: m.Publisher,
+			Protocol:  m.Protocol,
+			Payload:   payload,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if err := n.Conn.Publish(subject, data); err != nil {
+			return "", err
+		}
+	}
+
+	if err := n.Conn.Flush(); err != nil {
+		return "", err
+	}
+
+	return "nats:" + subject, nil
+}
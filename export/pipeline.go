@@ -0,0 +1,229 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+// Gzip compresses export files.
+const Gzip = "gzip"
+
+// Chunks queued between the encoder and the writer of a job.
+const queuedChunks = 4
+
+var (
+	// ChunkSize is the size of the buffers encoded messages are handed to
+	// the writer in.
+	ChunkSize = 64 << 10
+	// FlushInterval is the period in which buffered output is flushed
+	// to the export file.
+	FlushInterval = 5 * time.Second
+
+	// ErrUnknownCompression indicates an unsupported compression.
+	ErrUnknownCompression = errors.New("unknown export compression")
+
+	memory = newBudget(64 << 20)
+
+	budgetWaits = metrics.NewCounterVec("mongo_reader_export_budget_waits_total",
+		"Export chunks that waited for the export memory budget.")
+)
+
+// SetMemoryBudget function caps the memory the buffers of all running
+// exports take together, to no less than one chunk
+func SetMemoryBudget(n int) {
+	if n < ChunkSize {
+		n = ChunkSize
+	}
+	memory.resize(n)
+}
+
+// budget hands out bytes of a fixed amount of memory
+type budget struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	size int
+	used int
+}
+
+func newBudget(size int) *budget {
+	b := &budget{size: size}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *budget) resize(size int) {
+	b.mu.Lock()
+	b.size = size
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// acquire waits until n bytes are free and takes them. A request larger
+// than the whole budget waits for all of it.
+func (b *budget) acquire(n int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > b.size {
+		n = b.size
+	}
+	if b.used+n > b.size {
+		budgetWaits.Inc()
+	}
+	for b.used+n > b.size {
+		b.cond.Wait()
+	}
+	b.used += n
+	return n
+}
+
+func (b *budget) release(n int) {
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+type chunk struct {
+	data     []byte
+	reserved int
+}
+
+// pipeline is the writer end of an export: encoded messages are gathered
+// in chunks taken from the memory budget, and handed to a goroutine that
+// compresses them and writes them out. The encoder blocks while the
+// writer falls behind, so slow destinations hold reads back instead of
+// filling memory.
+type pipeline struct {
+	chunks chan chunk
+	cur    chunk
+	done   chan struct{}
+	closed bool
+
+	mu  sync.Mutex
+	err error
+}
+
+func newPipeline(w io.Writer, compression string) (*pipeline, error) {
+	var gz *gzip.Writer
+	switch compression {
+	case "":
+	case Gzip:
+		gz = gzip.NewWriter(w)
+	default:
+		return nil, ErrUnknownCompression
+	}
+
+	p := &pipeline{
+		chunks: make(chan chunk, queuedChunks),
+		done:   make(chan struct{}),
+	}
+	if gz != nil {
+		go p.run(gz, gz)
+	} else {
+		go p.run(w, nil)
+	}
+	return p, nil
+}
+
+// Write gathers b into chunks, waiting for the writer when the memory
+// budget or the queue is exhausted
+func (p *pipeline) Write(b []byte) (int, error) {
+	if err := p.failed(); err != nil {
+		return 0, err
+	}
+
+	n := len(b)
+	for len(b) > 0 {
+		if p.cur.data == nil {
+			p.cur.reserved = memory.acquire(ChunkSize)
+			p.cur.data = make([]byte, 0, p.cur.reserved)
+		}
+
+		k := copy(p.cur.data[len(p.cur.data):cap(p.cur.data)], b)
+		p.cur.data = p.cur.data[:len(p.cur.data)+k]
+		b = b[k:]
+		if len(p.cur.data) == cap(p.cur.data) {
+			p.chunks <- p.cur
+			p.cur = chunk{}
+		}
+	}
+
+	return n, nil
+}
+
+// Close hands over the last chunk, waits for everything to be written
+// and returns the first error met
+func (p *pipeline) Close() error {
+	if p.closed {
+		return p.failed()
+	}
+	p.closed = true
+
+	if p.cur.data != nil {
+		p.chunks <- p.cur
+		p.cur = chunk{}
+	}
+	close(p.chunks)
+	<-p.done
+
+	return p.failed()
+}
+
+func (p *pipeline) failed() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func (p *pipeline) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// run writes chunks to out until the pipeline is closed, flushing the
+// compressor every FlushInterval. Chunks are still drained after a
+// failure, so that their memory is given back.
+func (p *pipeline) run(out io.Writer, gz *gzip.Writer) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case c, ok := <-p.chunks:
+			if !ok {
+				if gz != nil && p.failed() == nil {
+					p.fail(gz.Close())
+				}
+				return
+			}
+			if p.failed() == nil {
+				if _, err := out.Write(c.data); err != nil {
+					p.fail(err)
+				}
+			}
+			memory.release(c.reserved)
+		case <-ticker.C:
+			if gz != nil && p.failed() == nil {
+				p.fail(gz.Flush())
+			}
+		}
+	}
+}
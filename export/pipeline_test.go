@@ -0,0 +1,90 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestPipeline(t *testing.T) {
+	defer func(n int) { ChunkSize = n; SetMemoryBudget(64 << 20) }(ChunkSize)
+	ChunkSize = 16
+	SetMemoryBudget(32)
+
+	data := strings.Repeat("0123456789", 100)
+
+	for _, compression := range []string{"", Gzip} {
+		var out bytes.Buffer
+		p, err := newPipeline(&out, compression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(data); i += 7 {
+			end := i + 7
+			if end > len(data) {
+				end = len(data)
+			}
+			if _, err := p.Write([]byte(data[i:end])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Close(); err != nil {
+			t.Errorf("%q: unexpected error closing twice: %v", compression, err)
+		}
+
+		res := out.Bytes()
+		if compression == Gzip {
+			gz, err := gzip.NewReader(&out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res, err = ioutil.ReadAll(gz); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if string(res) != data {
+			t.Errorf("%q: expected %d bytes got %d", compression, len(data), len(res))
+		}
+	}
+
+	if memory.used != 0 {
+		t.Errorf("expected the memory budget back, %d bytes still used", memory.used)
+	}
+
+	if _, err := newPipeline(&bytes.Buffer{}, "zip"); err != ErrUnknownCompression {
+		t.Errorf("expected error %v got %v", ErrUnknownCompression, err)
+	}
+}
+
+func TestPipelineFailure(t *testing.T) {
+	p, err := newPipeline(failingWriter{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Write(make([]byte, 3*ChunkSize))
+	if err := p.Close(); err == nil || err.Error() != "disk full" {
+		t.Errorf("expected the write error got %v", err)
+	}
+	if memory.used != 0 {
+		t.Errorf("expected the memory budget back, %d bytes still used", memory.used)
+	}
+}
@@ -0,0 +1,282 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	minPartSize  = 5 << 20
+	amzTimestamp = "20060102T150405Z"
+	amzDate      = "20060102"
+)
+
+// S3 uploads export results to an S3 compatible object storage (AWS S3,
+// MinIO, ...). Objects are addressed path-style and requests are signed
+// with AWS Signature Version 4. Results larger than PartSize are sent as
+// a multipart upload.
+type S3 struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Prefix    string
+	PartSize  int64
+	Client    *http.Client
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// Deliver uploads the export file and returns the object URL. The bucket
+// and prefix of the destination, when given, override the configured ones.
+func (s S3) Deliver(j Job, path string) (string, error) {
+	bucket, prefix := s.Bucket, s.Prefix
+	if j.Destination.Bucket != "" {
+		bucket = j.Destination.Bucket
+	}
+	if j.Destination.Prefix != "" {
+		prefix = j.Destination.Prefix
+	}
+	if bucket == "" {
+		return "", fmt.Errorf("s3: no bucket configured")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	object := strings.TrimRight(s.Endpoint, "/") + "/" + bucket + "/" + prefix + j.Filename()
+
+	partSize := s.PartSize
+	if partSize < minPartSize {
+		partSize = minPartSize
+	}
+	if fi.Size() <= partSize {
+		res, err := s.do("PUT", object, nil, io.NewSectionReader(f, 0, fi.Size()))
+		if err != nil {
+			return "", err
+		}
+		res.Body.Close()
+		return object, nil
+	}
+
+	return object, s.multipart(object, f, fi.Size(), partSize)
+}
+
+func (s S3) multipart(object string, f *os.File, size, partSize int64) error {
+	res, err := s.do("POST", object, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return err
+	}
+	var initiated initiateMultipartUploadResult
+	err = xml.NewDecoder(res.Body).Decode(&initiated)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	upload := url.Values{"uploadId": {initiated.UploadID}}
+	complete := completeMultipartUpload{}
+	for n, off := 1, int64(0); off < size; n, off = n+1, off+partSize {
+		length := partSize
+		if off+length > size {
+			length = size - off
+		}
+
+		q := url.Values{"uploadId": {initiated.UploadID}, "partNumber": {strconv.Itoa(n)}}
+		res, err := s.do("PUT", object, q, io.NewSectionReader(f, off, length))
+		if err != nil {
+			s.abort(object, upload)
+			return err
+		}
+		res.Body.Close()
+		complete.Parts = append(complete.Parts, completedPart{n, res.Header.Get("ETag")})
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		s.abort(object, upload)
+		return err
+	}
+	res, err = s.do("POST", object, upload, bytes.NewReader(body))
+	if err != nil {
+		s.abort(object, upload)
+		return err
+	}
+	res.Body.Close()
+
+	return nil
+}
+
+func (s S3) abort(object string, upload url.Values) {
+	if res, err := s.do("DELETE", object, upload, nil); err == nil {
+		res.Body.Close()
+	}
+}
+
+// do sends a signed request, treating any non-2xx response as an error.
+func (s S3) do(method, object string, query url.Values, body io.ReadSeeker) (*http.Response, error) {
+	u, err := url.Parse(object)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	h := sha256.New()
+	var length int64
+	if body != nil {
+		if length, err = io.Copy(h, body); err != nil {
+			return nil, err
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	payloadHash := hex.EncodeToString(h.Sum(nil))
+
+	var rd io.Reader
+	if body != nil {
+		rd = body
+	}
+	req, err := http.NewRequest(method, u.String(), rd)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = length
+	s.sign(req, u, payloadHash, time.Now().UTC())
+
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("s3: %s %s: %s: %s", method, u.Path, res.Status, msg)
+	}
+
+	return res, nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request.
+func (s S3) sign(req *http.Request, u *url.URL, payloadHash string, now time.Time) {
+	req.Header.Set("X-Amz-Date", now.Format(amzTimestamp))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signed := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		uriEncode(u.Path, false),
+		u.RawQuery,
+		"host:" + u.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + now.Format(amzTimestamp) + "\n",
+		signed,
+		payloadHash,
+	}, "\n")
+
+	scope := now.Format(amzDate) + "/" + s.Region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + now.Format(amzTimestamp) + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := signingKey(s.SecretKey, now.Format(amzDate), s.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, service)
+	return hmacSHA256(k, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and
+// slashes unless encodeSlash is set, as required by Signature Version 4.
+func uriEncode(s string, encodeSlash bool) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,42 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"encoding/hex"
+	"net/url"
+	"testing"
+)
+
+func TestSigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation.
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("expected signing key %s got %s", want, got)
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	cases := []struct {
+		query url.Values
+		out   string
+	}{
+		{url.Values{}, ""},
+		{url.Values{"uploads": {""}}, "uploads="},
+		{url.Values{"uploadId": {"a/b c"}, "partNumber": {"2"}}, "partNumber=2&uploadId=a%2Fb%20c"},
+	}
+
+	for i, c := range cases {
+		if got := canonicalQuery(c.query); got != c.out {
+			t.Errorf("case %d: expected %s got %s", i+1, c.out, got)
+		}
+	}
+}
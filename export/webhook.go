@@ -0,0 +1,248 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+var errMissingURL = errors.New("webhook destination requires url")
+
+// Networks of private, loopback and link-local addresses, which webhooks
+// reach only when AllowPrivate is set
+var privateNets = parseCIDRs(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10",
+)
+
+// Webhook POSTs a notification with the download link of the result, or
+// the result itself when the destination asks for an attachment, to the
+// URL of the destination. When Secret is set, requests carry the headers
+//
+//	X-Signature-Timestamp: <unix seconds>
+//	X-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Destination URLs must start with one of the Allowed URLs, with the same
+// scheme and host, and resolve to public addresses unless AllowPrivate is
+// set, requests going to the address that was checked. Redirects are not
+// followed. Download links are signed, see DownloadLink.
+//
+// Failed deliveries are retried with exponential backoff for MaxElapsed.
+type Webhook struct {
+	Secret       string
+	PublicURL    string
+	Allowed      []string
+	AllowPrivate bool
+	MaxElapsed   time.Duration
+	Client       *http.Client
+}
+
+// Validate checks that the URL of d is allowed
+func (wh Webhook) Validate(d Destination) error {
+	_, err := wh.resolve(d)
+	return err
+}
+
+// resolve checks that the URL of d is allowed and returns the address
+// requests to it must go to, or nil if any address will do
+func (wh Webhook) resolve(d Destination) (net.IP, error) {
+	if d.URL == "" {
+		return nil, errMissingURL
+	}
+	u, err := url.Parse(d.URL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return nil, ErrDestinationNotAllowed
+	}
+
+	for _, a := range wh.Allowed {
+		au, err := url.Parse(strings.TrimSpace(a))
+		if err != nil || au.Scheme != u.Scheme || !strings.EqualFold(au.Host, u.Host) {
+			continue
+		}
+		if strings.HasPrefix(u.Path, au.Path) {
+			return wh.checkHost(u.Hostname())
+		}
+	}
+	return nil, ErrDestinationNotAllowed
+}
+
+// checkHost refuses hosts resolving to private addresses unless they are
+// allowed, and returns the public address of host the check passed for
+func (wh Webhook) checkHost(host string) (net.IP, error) {
+	if wh.AllowPrivate {
+		return nil, nil
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, ErrDestinationNotAllowed
+	}
+	for _, ip := range ips {
+		for _, n := range privateNets {
+			if n.Contains(ip) {
+				return nil, ErrDestinationNotAllowed
+			}
+		}
+	}
+	return ips[0], nil
+}
+
+type webhookNotification struct {
+	Job      Job    `json:"job"`
+	Download string `json:"download"`
+}
+
+// Deliver sends the result to the webhook and returns the download link.
+func (wh Webhook) Deliver(j Job, path string) (string, error) {
+	if _, err := wh.resolve(j.Destination); err != nil {
+		return "", err
+	}
+
+	location := wh.PublicURL + DownloadLink(j.ID)
+
+	var body []byte
+	contentType := "application/json"
+	if j.Destination.Attach {
+		contentType = j.ContentType()
+	} else {
+		j.Location = location
+		b, err := json.Marshal(webhookNotification{Job: j, Download: location})
+		if err != nil {
+			return "", err
+		}
+		body = b
+	}
+
+	b := backoff.NewExponentialBackOff()
+	if wh.MaxElapsed > 0 {
+		b.MaxElapsedTime = wh.MaxElapsed
+	}
+
+	err := backoff.Retry(func() error {
+		var rd io.ReadSeeker = bytes.NewReader(body)
+		if j.Destination.Attach {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			rd = f
+		}
+		// The host is resolved and checked again by every attempt, and
+		// the request sent to the address checked, so that the name
+		// can't be rebound to a private address in between
+		ip, err := wh.resolve(j.Destination)
+		if err != nil {
+			return err
+		}
+		return wh.post(j.Destination.URL, ip, contentType, rd)
+	}, b)
+
+	return location, err
+}
+
+func (wh Webhook) post(url string, ip net.IP, contentType string, body io.ReadSeeker) error {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	var signature string
+	if wh.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(wh.Secret))
+		io.WriteString(mac, ts+".")
+		if _, err := io.Copy(mac, body); err != nil {
+			return err
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if signature != "" {
+		req.Header.Set("X-Signature-Timestamp", ts)
+		req.Header.Set("X-Signature", signature)
+	}
+
+	c := http.Client{}
+	if wh.Client != nil {
+		c = *wh.Client
+	}
+	c.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	if ip != nil {
+		c.Transport = pinned(c.Transport, ip)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s responded with %s", url, res.Status)
+	}
+
+	return nil
+}
+
+// pinned returns a transport like rt, without proxy, connecting to ip
+// whatever host requests are sent to. TLS still verifies the host of the
+// request.
+func pinned(rt http.RoundTripper, ip net.IP) http.RoundTripper {
+	t := &http.Transport{TLSHandshakeTimeout: 10 * time.Second}
+	if base, ok := rt.(*http.Transport); ok && base.TLSClientConfig != nil {
+		t.TLSClientConfig = base.TLSClientConfig.Clone()
+	}
+	d := &net.Dialer{Timeout: 30 * time.Second}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		return d.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+	}
+	return t
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := []*net.IPNet{}
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
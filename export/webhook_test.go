@@ -0,0 +1,107 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookDeliver(t *testing.T) {
+	secret := "secret"
+	attempts := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(r.Header.Get("X-Signature-Timestamp") + "." + string(body)))
+		if r.Header.Get("X-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	wh := Webhook{Secret: secret, PublicURL: "http://reader", Allowed: []string{ts.URL}, AllowPrivate: true, MaxElapsed: 10 * time.Second}
+	j := Job{ID: "1", Request: Request{Format: JSON, Destination: Destination{Type: "webhook", URL: ts.URL}}}
+
+	location, err := wh.Deliver(j, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	u, err := url.Parse(location)
+	if err != nil || u.Host != "reader" || u.Path != "/exports/1/download" {
+		t.Errorf("unexpected location %s", location)
+	}
+	if q := u.Query(); !VerifyLink("1", q.Get("expires"), q.Get("sig")) {
+		t.Errorf("expected signed location got %s", location)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts got %d", attempts)
+	}
+}
+
+func TestWebhookValidate(t *testing.T) {
+	wh := Webhook{Allowed: []string{"https://hooks.example.com/mainflux/", "http://127.0.0.1:8080/"}}
+
+	cases := []struct {
+		url string
+		err error
+	}{
+		{"", errMissingURL},
+		{"https://hooks.example.com/other", ErrDestinationNotAllowed},
+		{"https://hooks.example.com.evil.com/mainflux/", ErrDestinationNotAllowed},
+		{"http://hooks.example.com/mainflux/", ErrDestinationNotAllowed},
+		{"https://user@hooks.example.com/mainflux/", ErrDestinationNotAllowed},
+		{"ftp://127.0.0.1:8080/", ErrDestinationNotAllowed},
+		{"http://127.0.0.1:8080/hook", ErrDestinationNotAllowed},
+		{"http://169.254.169.254/latest/meta-data", ErrDestinationNotAllowed},
+	}
+	for i, c := range cases {
+		if err := wh.Validate(Destination{Type: "webhook", URL: c.url}); err != c.err {
+			t.Errorf("case %d: expected %v got %v", i+1, c.err, err)
+		}
+	}
+
+	wh.AllowPrivate = true
+	if err := wh.Validate(Destination{Type: "webhook", URL: "http://127.0.0.1:8080/hook"}); err != nil {
+		t.Errorf("expected private address allowed got %v", err)
+	}
+}
+
+func TestWebhookPinned(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	// The name of the URL doesn't resolve: only the pinned address is
+	// dialed
+	u, _ := url.Parse(ts.URL)
+	wh := Webhook{}
+	if err := wh.post("http://unresolvable.invalid:"+u.Port()+"/", net.ParseIP("127.0.0.1"), "application/json", strings.NewReader("{}")); err != nil {
+		t.Errorf("expected request to pinned address got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+//go:build integration
+// +build integration
+
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package integration
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/bench"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/latest"
+	"github.com/mainflux/mainflux-mongodb-reader/pkg/client"
+)
+
+// Daily messages of three series over four months, so that reads span
+// several monthly collections
+var dataset = bench.Dataset{
+	Channels: 2,
+	Messages: 120,
+	Step:     24 * time.Hour,
+	End:      time.Date(2017, 6, 30, 0, 0, 0, 0, time.UTC),
+}
+
+const series = 3
+
+func TestAPI(t *testing.T) {
+	defer func(layout string) { mfdb.Layout = layout }(mfdb.Layout)
+	mfdb.Layout = mfdb.LayoutMonthly
+
+	for _, d := range deployments {
+		d := d
+		t.Run(d.name, func(t *testing.T) {
+			name := database(t, d, "api")
+			if err := bench.Seed(d.session, name, dataset); err != nil {
+				t.Fatalf("Could not seed %s: %s", name, err)
+			}
+			use(d, name)
+			var mdb mfdb.MgoDb
+			mdb.Init()
+			err := latest.Rebuild(&mdb, "")
+			mdb.Close()
+			if err != nil {
+				t.Fatalf("Could not compute latest values: %s", err)
+			}
+
+			c, err := client.New(client.Config{URL: ts.URL, Backoff: 10 * time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tc := range []struct {
+				name string
+				run  func(*testing.T, *client.Client)
+			}{
+				{"Count", testCount},
+				{"Pagination", testPagination},
+				{"TimeRange", testTimeRange},
+				{"Aggregate", testAggregate},
+				{"Latest", testLatest},
+				{"Stats", testStats},
+				{"Errors", testErrors},
+			} {
+				t.Run(tc.name, func(t *testing.T) { tc.run(t, c) })
+			}
+		})
+	}
+}
+
+func unix(t time.Time) float64 {
+	return float64(t.Unix())
+}
+
+func testCount(t *testing.T, c *client.Client) {
+	ctx := context.Background()
+	for _, mode := range []client.CountMode{client.CountExact, client.CountEstimate} {
+		p, err := c.Messages(ctx, bench.ChannelID(0), client.Filter{Limit: 1, Count: mode})
+		if err != nil {
+			t.Fatalf("%s: %s", mode, err)
+		}
+		if p.Meta.Total == nil || *p.Meta.Total != dataset.Messages*series {
+			t.Errorf("%s: expected a total of %d got %v", mode, dataset.Messages*series, p.Meta.Total)
+		}
+		if !p.Meta.HasMore || len(p.Messages) != 1 {
+			t.Errorf("%s: expected a page of one message with more to read got %v", mode, p)
+		}
+	}
+}
+
+func testPagination(t *testing.T, c *client.Client) {
+	ctx := context.Background()
+	ch := bench.ChannelID(1)
+
+	// Pages cross monthly collections in time order
+	n, last := 0, 0.0
+	it := c.Iterate(ctx, ch, client.Filter{Limit: 50})
+	for it.Next() {
+		m := it.Message()
+		if m.Channel != ch {
+			t.Fatalf("got a message of channel %s", m.Channel)
+		}
+		if m.Time < last {
+			t.Fatalf("message %d at %v follows one at %v", n, m.Time, last)
+		}
+		n, last = n+1, m.Time
+	}
+	if err := it.Err(); err != nil || n != dataset.Messages*series {
+		t.Errorf("expected %d messages got %d, %v", dataset.Messages*series, n, err)
+	}
+
+	// A cursor reads the page an offset does
+	prev, err := c.Messages(ctx, ch, client.Filter{Offset: 75, Limit: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byCursor, err := c.Messages(ctx, ch, client.Filter{Cursor: prev.Meta.NextCursor, Limit: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byOffset, err := c.Messages(ctx, ch, client.Filter{Offset: 100, Limit: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byCursor.Messages) != 25 || len(byOffset.Messages) != 25 {
+		t.Fatalf("expected pages of 25 messages got %d and %d", len(byCursor.Messages), len(byOffset.Messages))
+	}
+	for i := range byCursor.Messages {
+		if byCursor.Messages[i].Time != byOffset.Messages[i].Time {
+			t.Errorf("message %d: cursor read %v, offset read %v", i, byCursor.Messages[i].Time, byOffset.Messages[i].Time)
+		}
+	}
+	if byOffset.Meta.Offset != 100 || byOffset.Meta.Limit != 25 {
+		t.Errorf("expected page metadata of offset 100 and limit 25 got %+v", byOffset.Meta)
+	}
+
+	last100, err := c.Messages(ctx, ch, client.Filter{Offset: dataset.Messages*series - 10, Limit: 25})
+	if err != nil || len(last100.Messages) != 10 || last100.Meta.HasMore {
+		t.Errorf("expected a last page of 10 messages got %d, %v", len(last100.Messages), err)
+	}
+}
+
+func testTimeRange(t *testing.T, c *client.Client) {
+	ctx := context.Background()
+	start := dataset.Start()
+
+	// Bounds are exclusive: days 1 to 9 of every series
+	r := client.TimeRange{Start: start, End: start.Add(10 * dataset.Step)}
+	p, err := c.Messages(ctx, bench.ChannelID(0), client.Filter{TimeRange: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Messages) != 9*series {
+		t.Errorf("expected %d messages got %d", 9*series, len(p.Messages))
+	}
+	for _, m := range p.Messages {
+		if m.Time <= unix(r.Start) || m.Time >= unix(r.End) {
+			t.Errorf("message at %v out of the range", m.Time)
+		}
+	}
+}
+
+func testAggregate(t *testing.T, c *client.Client) {
+	ctx := context.Background()
+	week := 7 * 24 * time.Hour
+	results := map[client.Function]client.Aggregation{}
+	for _, fn := range []client.Function{client.Avg, client.Min, client.Max, client.Sum, client.Count} {
+		a, err := c.Aggregate(ctx, bench.ChannelID(0), client.AggregateFilter{Name: "temperature", Fn: fn, Interval: week})
+		if err != nil {
+			t.Fatalf("%s: %s", fn, err)
+		}
+		if a.Fn != string(fn) || a.Interval != week.Seconds() || a.Truncated {
+			t.Errorf("%s: unexpected aggregation %+v", fn, a)
+		}
+		results[fn] = a
+	}
+
+	total := 0
+	count := results[client.Count].Buckets
+	for i, b := range count {
+		if math.Mod(b.Time, week.Seconds()) != 0 {
+			t.Errorf("bucket at %v isn't aligned on the interval", b.Time)
+		}
+		if b.Value != float64(b.Count) {
+			t.Errorf("bucket at %v: expected the count as value got %v", b.Time, b.Value)
+		}
+		total += b.Count
+
+		for _, fn := range []client.Function{client.Avg, client.Min, client.Max, client.Sum} {
+			if len(results[fn].Buckets) != len(count) || results[fn].Buckets[i].Time != b.Time {
+				t.Fatalf("%s: expected the buckets of count", fn)
+			}
+		}
+		avg, min, max := results[client.Avg].Buckets[i].Value, results[client.Min].Buckets[i].Value, results[client.Max].Buckets[i].Value
+		if min > avg || avg > max {
+			t.Errorf("bucket at %v: expected min %v <= avg %v <= max %v", b.Time, min, avg, max)
+		}
+		if sum := results[client.Sum].Buckets[i].Value; math.Abs(sum-avg*float64(b.Count)) > 1e-6 {
+			t.Errorf("bucket at %v: expected sum %v to be avg times count", b.Time, sum)
+		}
+	}
+	if total != dataset.Messages {
+		t.Errorf("expected buckets of %d messages got %d", dataset.Messages, total)
+	}
+}
+
+func testLatest(t *testing.T, c *client.Client) {
+	msgs, err := c.Latest(context.Background(), bench.ChannelID(0), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != series {
+		t.Fatalf("expected the latest of %d series got %d", series, len(msgs))
+	}
+	for _, m := range msgs {
+		if m.Time != unix(dataset.End) {
+			t.Errorf("%s: expected the latest at %v got %v", m.Name, unix(dataset.End), m.Time)
+		}
+	}
+
+	msgs, err = c.Latest(context.Background(), bench.ChannelID(0), "humidity")
+	if err != nil || len(msgs) != 1 || msgs[0].Name != "humidity" {
+		t.Errorf("expected the latest humidity got %v, %v", msgs, err)
+	}
+}
+
+func testStats(t *testing.T, c *client.Client) {
+	s, err := c.Stats(context.Background(), bench.ChannelID(0), client.TimeRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Count != dataset.Messages*series || s.Oldest != unix(dataset.Start()) || s.Newest != unix(dataset.End) || s.Bytes <= 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func testErrors(t *testing.T, c *client.Client) {
+	ctx := context.Background()
+
+	_, err := c.Stats(ctx, "unknown", client.TimeRange{})
+	if e, ok := err.(*client.Error); !ok || e.Status != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown channel got %v", err)
+	}
+
+	_, err = c.Aggregate(ctx, bench.ChannelID(0), client.AggregateFilter{Fn: "median"})
+	if e, ok := err.(*client.Error); !ok || e.Status != http.StatusBadRequest || e.Code != "invalid_filter" {
+		t.Errorf("expected 400 invalid_filter for an unknown function got %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package integration holds the tests of the reader against real MongoDB
+// deployments, a single node and a replica set, started in Docker
+// containers. They seed SenML fixtures and exercise the filters,
+// aggregations and pagination of the repository and of the HTTP API.
+//
+// The tests are built with the integration tag only:
+//
+//	go test -tags integration ./integration/
+package integration
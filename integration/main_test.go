@@ -0,0 +1,158 @@
+//go:build integration
+// +build integration
+
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/ory-am/dockertest.v3"
+)
+
+// MongoDB image the deployments run
+const mongoTag = "3.4"
+
+// deployment is a MongoDB server the tests run against
+type deployment struct {
+	name    string
+	session *mgo.Session
+}
+
+var (
+	deployments []deployment
+	ts          *httptest.Server
+)
+
+func TestMain(m *testing.M) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	single, err := pool.Run("mongo", mongoTag, nil)
+	if err != nil {
+		log.Fatalf("Could not start resource: %s", err)
+	}
+	rs, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "mongo",
+		Tag:        mongoTag,
+		Cmd:        []string{"mongod", "--replSet", "rs0"},
+	})
+	if err != nil {
+		pool.Purge(single)
+		log.Fatalf("Could not start resource: %s", err)
+	}
+
+	for _, d := range []struct {
+		name     string
+		resource *dockertest.Resource
+		replSet  bool
+	}{{"single", single, false}, {"replica set", rs, true}} {
+		var s *mgo.Session
+		if err := pool.Retry(func() error {
+			var err error
+			s, err = connect(d.resource.GetPort("27017/tcp"), d.replSet)
+			return err
+		}); err != nil {
+			log.Fatalf("Could not connect to %s: %s", d.name, err)
+		}
+		deployments = append(deployments, deployment{d.name, s})
+	}
+
+	// The handlers read from the main session, switched by use
+	use(deployments[0], "mainflux_test")
+	ts = httptest.NewServer(api.HTTPServer())
+
+	code := m.Run()
+
+	ts.Close()
+	for _, d := range deployments {
+		d.session.Close()
+	}
+	for _, r := range []*dockertest.Resource{single, rs} {
+		if err := pool.Purge(r); err != nil {
+			log.Fatalf("Could not purge resource: %s", err)
+		}
+	}
+
+	os.Exit(code)
+}
+
+// connect dials the server listening on port, initiating its replica set
+// if replSet is set, and returns once it accepts writes
+func connect(port string, replSet bool) (*mgo.Session, error) {
+	s, err := mgo.DialWithInfo(&mgo.DialInfo{
+		Addrs:   []string{"localhost:" + port},
+		Direct:  true,
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.SetMode(mgo.Monotonic, true)
+
+	var res struct {
+		IsMaster bool `bson:"ismaster"`
+	}
+	if err := s.Run("isMaster", &res); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if res.IsMaster {
+		return s, nil
+	}
+
+	if replSet {
+		// Members are reached from inside the container, and the client
+		// connects directly, so the host of the member doesn't matter
+		cfg := bson.M{"_id": "rs0", "members": []bson.M{{"_id": 0, "host": "localhost:27017"}}}
+		err := s.Run(bson.D{{Name: "replSetInitiate", Value: cfg}}, nil)
+		if err != nil && !alreadyInitialized(err) {
+			s.Close()
+			return nil, err
+		}
+	}
+	s.Close()
+	return nil, errors.New("waiting for a primary")
+}
+
+func alreadyInitialized(err error) bool {
+	e, ok := err.(*mgo.QueryError)
+	// AlreadyInitialized
+	return ok && e.Code == 23
+}
+
+// use makes the reader serve the database of d
+func use(d deployment, database string) {
+	mfdb.SetMainSession(d.session)
+	mfdb.SetMainDb(database)
+}
+
+// database returns the name of a database of the tests of d, dropped
+// first
+func database(t *testing.T, d deployment, suffix string) string {
+	name := fmt.Sprintf("mainflux_it_%s", suffix)
+	if err := d.session.DB(name).DropDatabase(); err != nil {
+		t.Fatalf("Could not drop %s: %s", name, err)
+	}
+	return name
+}
@@ -0,0 +1,67 @@
+//go:build integration
+// +build integration
+
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package integration
+
+import (
+	"testing"
+
+	mfdb "github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/latest"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+	"github.com/mainflux/mainflux-mongodb-reader/repository/repositorytest"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestMongoRepository(t *testing.T) {
+	defer func(layout string) { mfdb.Layout = layout }(mfdb.Layout)
+
+	for _, d := range deployments {
+		for _, layout := range []string{mfdb.LayoutSingle, mfdb.LayoutMonthly, mfdb.LayoutHash} {
+			d, layout := d, layout
+			t.Run(d.name+"/"+layout, func(t *testing.T) {
+				mfdb.Layout = layout
+
+				var mdb mfdb.MgoDb
+				repositorytest.Run(t, func(t *testing.T, channels []string, msgs []models.Message) repository.MessageRepository {
+					name := database(t, d, "repository")
+					seed(t, d, name, channels, msgs)
+
+					use(d, name)
+					mdb.Init()
+					if err := latest.Rebuild(&mdb, ""); err != nil {
+						t.Fatalf("Could not compute latest values: %s", err)
+					}
+					return repository.NewMongo(&mdb)
+				})
+				mdb.Close()
+			})
+		}
+	}
+}
+
+// seed stores channels and msgs in database, in the collections of the
+// storage layout
+func seed(t *testing.T, d deployment, database string, channels []string, msgs []models.Message) {
+	db := d.session.DB(database)
+	for _, id := range channels {
+		if err := db.C(mfdb.ChannelsCollection).Insert(bson.M{"id": id}); err != nil {
+			t.Fatalf("Could not create channel %s: %s", id, err)
+		}
+	}
+	for _, m := range msgs {
+		if err := db.C(mfdb.MessageCollection(m.Channel, m.Time)).Insert(m); err != nil {
+			t.Fatalf("Could not store message: %s", err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package ipfilter restricts the client addresses served by the reader
+// with CIDR allow and deny rules.
+//
+// Deny rules win over allow rules, and when any allow rule is set, only
+// addresses matching one are allowed. Rules come from the configuration
+// and from a rules file, which is reloaded when it changes.
+package ipfilter
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Rules struct holds the networks allowed and denied
+type Rules struct {
+	Allow []*net.IPNet
+	Deny  []*net.IPNet
+}
+
+var (
+	mu sync.RWMutex
+	// rules of the configuration, and of the rules file
+	static, file Rules
+)
+
+// ParseCIDRs function parses a comma separated list of networks. Single
+// addresses stand for networks of that address only.
+func ParseCIDRs(list string) ([]*net.IPNet, error) {
+	nets := []*net.IPNet{}
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		n, err := parseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func parseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network %q", s)
+	}
+	return n, nil
+}
+
+// ReadFile function parses the rules of file, one "allow <network>" or
+// "deny <network>" per line. Empty lines and lines starting with # are
+// skipped.
+func ReadFile(name string) (Rules, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Rules{}, err
+	}
+	defer f.Close()
+
+	r := Rules{}
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return Rules{}, fmt.Errorf("%s:%d: expected allow or deny and a network", name, line)
+		}
+
+		n, err := parseCIDR(fields[1])
+		if err != nil {
+			return Rules{}, fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+		switch fields[0] {
+		case "allow":
+			r.Allow = append(r.Allow, n)
+		case "deny":
+			r.Deny = append(r.Deny, n)
+		default:
+			return Rules{}, fmt.Errorf("%s:%d: expected allow or deny", name, line)
+		}
+	}
+
+	return r, sc.Err()
+}
+
+// Set function replaces the rules of the configuration
+func Set(r Rules) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	static = r
+}
+
+// Watch function loads the rules of file, then reloads them every
+// interval if the file was modified. Rules that fail to load are logged
+// and the previous ones kept.
+func Watch(name string, interval time.Duration) error {
+	r, err := ReadFile(name)
+	if err != nil {
+		return err
+	}
+	setFile(r)
+
+	mod := modTime(name)
+	go func() {
+		for range time.Tick(interval) {
+			m := modTime(name)
+			if !m.After(mod) {
+				continue
+			}
+			mod = m
+
+			r, err := ReadFile(name)
+			if err != nil {
+				log.Errorf("IP filter: Can't reload rules: %v", err)
+				continue
+			}
+			setFile(r)
+			log.Printf("IP filter: Reloaded rules from %s", name)
+		}
+	}()
+
+	return nil
+}
+
+func setFile(r Rules) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	file = r
+}
+
+func modTime(name string) time.Time {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// Allowed function reports whether ip may be served
+func Allowed(ip net.IP) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if contains(static.Deny, ip) || contains(file.Deny, ip) {
+		return false
+	}
+	if len(static.Allow) == 0 && len(file.Allow) == 0 {
+		return true
+	}
+	return contains(static.Allow, ip) || contains(file.Allow, ip)
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package ipfilter_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/ipfilter"
+)
+
+func TestAllowed(t *testing.T) {
+	if _, err := ipfilter.ParseCIDRs("10.0.0.0/33"); err == nil {
+		t.Error("expected an error for an invalid network")
+	}
+
+	allow, err := ipfilter.ParseCIDRs("10.0.0.0/8, 2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deny, err := ipfilter.ParseCIDRs("10.0.0.13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipfilter.Set(ipfilter.Rules{Allow: allow, Deny: deny})
+	defer ipfilter.Set(ipfilter.Rules{})
+
+	cases := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"10.1.2.3", true},
+		{"10.0.0.13", false},
+		{"192.168.1.1", false},
+		{"2001:db8::1", true},
+	}
+	for i, c := range cases {
+		if a := ipfilter.Allowed(net.ParseIP(c.ip)); a != c.allowed {
+			t.Errorf("case %d: expected %v got %v", i+1, c.allowed, a)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipfilter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "rules")
+	if err := ioutil.WriteFile(name, []byte("# office\nallow 192.168.0.0/16\ndeny 192.168.6.6\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ipfilter.Watch(name, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	office, intruder := net.ParseIP("192.168.1.1"), net.ParseIP("192.168.6.6")
+	if !ipfilter.Allowed(office) || ipfilter.Allowed(intruder) {
+		t.Fatal("expected the rules of the file to apply")
+	}
+
+	if err := ioutil.WriteFile(name, []byte("deny 192.168.0.0/16\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	os.Chtimes(name, later, later)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ipfilter.Allowed(office) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ipfilter.Allowed(office) {
+		t.Error("expected the rules to be reloaded")
+	}
+
+	if err := ioutil.WriteFile(name, []byte("permit everyone\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ipfilter.ReadFile(name); err == nil {
+		t.Error("expected an error for a malformed rule")
+	}
+}
@@ -0,0 +1,124 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package jwt
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+// Largest number of cached decisions.
+const maxDecisions = 10000
+
+var (
+	// DecisionTTL is how long the outcome of validating a token is reused
+	// by verifiers created afterwards. Zero disables caching.
+	DecisionTTL = 30 * time.Second
+
+	cacheHits = metrics.NewCounterVec("mongo_reader_jwt_cache_hits_total",
+		"Token validations answered from the decision cache.")
+	cacheMisses = metrics.NewCounterVec("mongo_reader_jwt_cache_misses_total",
+		"Token validations performed.")
+)
+
+type (
+	// decision is the cached outcome of validating a token
+	decision struct {
+		claims  Claims
+		err     error
+		expires time.Time
+	}
+
+	// flight is a validation in progress, shared by the callers
+	// validating the same token meanwhile
+	flight struct {
+		wg sync.WaitGroup
+		d  decision
+	}
+
+	// decisions caches validation outcomes by token hash
+	decisions struct {
+		ttl time.Duration
+
+		mu       sync.Mutex
+		cache    map[[sha256.Size]byte]decision
+		inflight map[[sha256.Size]byte]*flight
+	}
+)
+
+// Verify function returns the claims of token if it is valid. Positive
+// and negative outcomes are cached for DecisionTTL, never beyond the
+// expiry of the token, and concurrent validations of the same token are
+// performed once.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	dc := &v.decisions
+	if dc.ttl <= 0 {
+		return v.verifyToken(token)
+	}
+
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	dc.mu.Lock()
+	if d, ok := dc.cache[key]; ok && now.Before(d.expires) {
+		dc.mu.Unlock()
+		cacheHits.Inc()
+		return d.claims, d.err
+	}
+	if f, ok := dc.inflight[key]; ok {
+		dc.mu.Unlock()
+		f.wg.Wait()
+		cacheHits.Inc()
+		return f.d.claims, f.d.err
+	}
+	f := &flight{}
+	f.wg.Add(1)
+	if dc.inflight == nil {
+		dc.inflight = map[[sha256.Size]byte]*flight{}
+	}
+	dc.inflight[key] = f
+	dc.mu.Unlock()
+
+	cacheMisses.Inc()
+	claims, err := v.verifyToken(token)
+	f.d = decision{claims: claims, err: err, expires: now.Add(dc.ttl)}
+	if exp := time.Unix(claims.ExpiresAt, 0).Add(leeway); err == nil && exp.Before(f.d.expires) {
+		f.d.expires = exp
+	}
+
+	dc.mu.Lock()
+	delete(dc.inflight, key)
+	dc.store(key, f.d, now)
+	dc.mu.Unlock()
+	f.wg.Done()
+
+	return claims, err
+}
+
+// store caches d under key, evicting expired decisions, or all of them,
+// when the cache is full
+func (dc *decisions) store(key [sha256.Size]byte, d decision, now time.Time) {
+	if dc.cache == nil {
+		dc.cache = map[[sha256.Size]byte]decision{}
+	}
+	if len(dc.cache) >= maxDecisions {
+		for k, old := range dc.cache {
+			if !now.Before(old.expires) {
+				delete(dc.cache, k)
+			}
+		}
+	}
+	if len(dc.cache) >= maxDecisions {
+		dc.cache = map[[sha256.Size]byte]decision{}
+	}
+	dc.cache[key] = d
+}
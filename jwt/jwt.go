@@ -0,0 +1,367 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package jwt validates JSON Web Tokens locally, against the keys of a
+// JWKS endpoint.
+//
+// Tokens signed with RS256, RS384, RS512, ES256 or ES384 are accepted.
+// Keys are fetched when the verifier is created, refreshed periodically
+// and refetched, at most once a minute, when a token names an unknown key.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+)
+
+const (
+	// Tolerated clock difference with the token issuer.
+	leeway = time.Minute
+	// Period of JWKS refreshes.
+	refreshInterval = time.Hour
+	// Shortest time between two fetches of the JWKS.
+	minRefresh = time.Minute
+	// Time limit of fetching the JWKS.
+	fetchTimeout = 10 * time.Second
+)
+
+var (
+	// ErrMalformed indicates a token that is not a signed JWT.
+	ErrMalformed = errors.New("malformed token")
+	// ErrAlgorithm indicates a token signed with an unsupported algorithm.
+	ErrAlgorithm = errors.New("unsupported token algorithm")
+	// ErrKey indicates a token signed by a key missing from the JWKS.
+	ErrKey = errors.New("unknown token key")
+	// ErrSignature indicates a token whose signature does not verify.
+	ErrSignature = errors.New("invalid token signature")
+	// ErrExpired indicates a token used outside its validity period.
+	ErrExpired = errors.New("token expired or not valid yet")
+	// ErrIssuer indicates a token of another issuer.
+	ErrIssuer = errors.New("unexpected token issuer")
+	// ErrAudience indicates a token for another audience.
+	ErrAudience = errors.New("unexpected token audience")
+)
+
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+}
+
+type (
+	// Claims struct holds the claims of a valid token. Channels lists the
+	// channels the bearer may read, "*" standing for all of them, and Role
+	// may grant administrative access.
+	Claims struct {
+		Issuer    string   `json:"iss"`
+		Subject   string   `json:"sub"`
+		Audience  audience `json:"aud"`
+		ExpiresAt int64    `json:"exp"`
+		NotBefore int64    `json:"nbf"`
+		Channels  []string `json:"channels"`
+		Role      string   `json:"role"`
+		Owner     string   `json:"owner"`
+	}
+
+	// audience is a single audience or a list of them
+	audience []string
+
+	header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+
+	// Verifier struct validates tokens against the keys of a JWKS
+	// endpoint, and their issuer and audience when they are set
+	Verifier struct {
+		url      string
+		issuer   string
+		audience string
+		http     *http.Client
+
+		mu        sync.RWMutex
+		keys      map[string]crypto.PublicKey
+		fetched   time.Time
+		refreshMu sync.Mutex
+
+		decisions decisions
+	}
+)
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// NewVerifier function fetches the keys of the JWKS at url and returns a
+// verifier of tokens issued by issuer for audience
+func NewVerifier(url, issuer, audience string) (*Verifier, error) {
+	v := &Verifier{
+		url:       url,
+		issuer:    issuer,
+		audience:  audience,
+		http:      tlsutil.HTTPClient(fetchTimeout),
+		decisions: decisions{ttl: DecisionTTL},
+	}
+	if err := v.refresh(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for range time.Tick(refreshInterval) {
+			v.refresh()
+		}
+	}()
+
+	return v, nil
+}
+
+// verifyToken returns the claims of token if it is valid
+func (v *Verifier) verifyToken(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrMalformed
+	}
+
+	var h header
+	if err := decode(parts[0], &h); err != nil {
+		return Claims{}, ErrMalformed
+	}
+	hash, ok := algorithms[h.Alg]
+	if !ok {
+		return Claims{}, ErrAlgorithm
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	key, err := v.key(h.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+	if err := verify(key, h.Alg, hash, parts[0]+"."+parts[1], sig); err != nil {
+		return Claims{}, err
+	}
+
+	var c Claims
+	if err := decode(parts[1], &c); err != nil {
+		return Claims{}, ErrMalformed
+	}
+	return c, v.check(c)
+}
+
+// check validates the registered claims of c
+func (v *Verifier) check(c Claims) error {
+	now := time.Now()
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(leeway)) ||
+		c.NotBefore != 0 && now.Add(leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return ErrExpired
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return ErrIssuer
+	}
+	if v.audience == "" {
+		return nil
+	}
+	for _, a := range c.Audience {
+		if a == v.audience {
+			return nil
+		}
+	}
+	return ErrAudience
+}
+
+// key returns the key named kid, refetching the JWKS once if it is unknown
+func (v *Verifier) key(kid string) (crypto.PublicKey, error) {
+	v.mu.RLock()
+	k, ok := v.keys[kid]
+	stale := time.Since(v.fetched) > minRefresh
+	v.mu.RUnlock()
+
+	if ok {
+		return k, nil
+	}
+	if !stale {
+		return nil, ErrKey
+	}
+
+	// Tokens naming the same unknown key wait for a single refetch.
+	v.refreshMu.Lock()
+	v.mu.RLock()
+	_, ok = v.keys[kid]
+	stale = time.Since(v.fetched) > minRefresh
+	v.mu.RUnlock()
+	if !ok && stale && v.refresh() != nil {
+		v.refreshMu.Unlock()
+		return nil, ErrKey
+	}
+	v.refreshMu.Unlock()
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if k, ok = v.keys[kid]; !ok {
+		return nil, ErrKey
+	}
+	return k, nil
+}
+
+// refresh fetches the keys of the JWKS. Keys not meant for signatures
+// and key types other than RSA and EC are skipped.
+func (v *Verifier) refresh() error {
+	res, err := v.http.Get(v.url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS: unexpected status %s", res.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return fmt.Errorf("JWKS: %v", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.public(); err == nil {
+			keys[k.Kid] = pub
+		}
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetched = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
+
+// public returns the public key k describes
+func (k jwk) public() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) > 4 {
+			return nil, ErrMalformed
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, ErrAlgorithm
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, ErrAlgorithm
+}
+
+// verify checks sig, the signature of signed by key with algorithm alg
+func verify(key crypto.PublicKey, alg string, hash crypto.Hash, signed string, sig []byte) error {
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" {
+			return ErrAlgorithm
+		}
+		if rsa.VerifyPKCS1v15(k, hash, digest, sig) != nil {
+			return ErrSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" {
+			return ErrAlgorithm
+		}
+		if len(sig) != 2*size {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return ErrSignature
+		}
+		return nil
+	}
+	return ErrAlgorithm
+}
+
+func decode(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
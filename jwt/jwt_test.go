@@ -0,0 +1,217 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/jwt"
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+var enc = base64.RawURLEncoding
+
+// sign returns a token of claims signed with key under the name kid
+func sign(t *testing.T, key crypto.Signer, kid string, claims map[string]interface{}) string {
+	alg := "RS256"
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = "ES256"
+	}
+	h, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	c, _ := json.Marshal(claims)
+	signed := enc.EncodeToString(h) + "." + enc.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		s, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = s
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):], rb)
+		copy(sig[64-len(sb):], sb)
+	}
+
+	return signed + "." + enc.EncodeToString(sig)
+}
+
+// jwksServer serves the JWKS of key under the name kid
+func jwksServer(key *rsa.PrivateKey, kid string) *httptest.Server {
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA", "kid": kid,
+			"n": enc.EncodeToString(key.N.Bytes()),
+			"e": enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwks)
+	}))
+}
+
+// counter returns the value of the metric name
+func counter(t *testing.T, name string) int {
+	srv := httptest.NewServer(metrics.Handler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+
+	m := regexp.MustCompile(`(?m)^` + name + ` (\d+)$`).FindSubmatch(body)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(string(m[1]))
+	return n
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa", "use": "sig",
+				"n": enc.EncodeToString(rsaKey.N.Bytes()),
+				"e": enc.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec", "crv": "P-256",
+				"x": enc.EncodeToString(ecKey.X.Bytes()),
+				"y": enc.EncodeToString(ecKey.Y.Bytes()),
+			},
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer srv.Close()
+
+	v, err := jwt.NewVerifier(srv.URL, "https://auth.example.com", "reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+	valid := map[string]interface{}{
+		"iss": "https://auth.example.com", "aud": []string{"reader"}, "sub": "analytics",
+		"exp": now + 60, "channels": []string{"7"},
+	}
+	with := func(k string, val interface{}) map[string]interface{} {
+		c := map[string]interface{}{}
+		for key, v := range valid {
+			c[key] = v
+		}
+		c[k] = val
+		return c
+	}
+
+	cases := []struct {
+		token string
+		err   error
+	}{
+		{sign(t, rsaKey, "rsa", valid), nil},
+		{sign(t, ecKey, "ec", with("aud", "reader")), nil},
+		{sign(t, rsaKey, "rsa", with("exp", now-3600)), jwt.ErrExpired},
+		{sign(t, rsaKey, "rsa", with("nbf", now+3600)), jwt.ErrExpired},
+		{sign(t, rsaKey, "rsa", with("iss", "https://evil.example.com")), jwt.ErrIssuer},
+		{sign(t, rsaKey, "rsa", with("aud", "other")), jwt.ErrAudience},
+		{sign(t, other, "rsa", valid), jwt.ErrSignature},
+		{sign(t, other, "unknown", valid), jwt.ErrKey},
+		{sign(t, ecKey, "rsa", valid), jwt.ErrAlgorithm},
+		{"not.a-token", jwt.ErrMalformed},
+	}
+
+	for i, c := range cases {
+		claims, err := v.Verify(c.token)
+		if err != c.err {
+			t.Errorf("case %d: expected error %v got %v", i+1, c.err, err)
+			continue
+		}
+		if err == nil && (claims.Subject != "analytics" || len(claims.Channels) != 1 || claims.Channels[0] != "7") {
+			t.Errorf("case %d: unexpected claims %+v", i+1, claims)
+		}
+	}
+}
+
+func TestVerifyCached(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := jwksServer(key, "rsa")
+	defer srv.Close()
+
+	v, err := jwt.NewVerifier(srv.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := sign(t, key, "rsa", map[string]interface{}{"sub": "dashboard", "exp": time.Now().Unix() + 60})
+	misses := counter(t, "mongo_reader_jwt_cache_misses_total")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c, err := v.Verify(token); err != nil || c.Subject != "dashboard" {
+				t.Errorf("unexpected result %+v, %v", c, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := v.Verify(token + "x"); err == nil {
+		t.Error("expected an error for a corrupted token")
+	}
+	if n := counter(t, "mongo_reader_jwt_cache_misses_total") - misses; n != 2 {
+		t.Errorf("expected 2 validations got %d", n)
+	}
+}
@@ -0,0 +1,194 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package latest keeps the most recent message of every channel and name
+// in the latest collection, so that last values are read by key instead
+// of sorting message history.
+//
+// The collection is fed by the message stream watcher. A stored value is
+// only replaced by a message with a later time, so messages arriving out
+// of order, and the rebuild from history running alongside the watcher,
+// never regress it. The collection is rebuilt from history when missing.
+package latest
+
+import (
+	"context"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/stream"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Collection holds the latest message of every channel and name.
+const Collection = "latest"
+
+// value is the latest message of a channel and name
+type value struct {
+	Channel string         `bson:"channel"`
+	Name    string         `bson:"name"`
+	Time    float64        `bson:"time"`
+	Message models.Message `bson:"message"`
+}
+
+// Start function keeps the latest collection up to date with new
+// messages, and rebuilds it from history in the background if missing
+func Start() error {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	names, err := Db.Db.CollectionNames()
+	if err != nil {
+		return err
+	}
+	missing := true
+	for _, name := range names {
+		if name == Collection {
+			missing = false
+		}
+	}
+
+	// Updates rely on the unique index to never replace later values.
+	idx := mgo.Index{Key: []string{"channel", "name"}, Unique: true}
+	if err := Db.C(Collection).EnsureIndex(idx); err != nil {
+		return err
+	}
+
+	stream.Observe(func(msgs []models.StoredMessage) {
+		Db := db.MgoDb{}
+		Db.Init()
+		defer Db.Close()
+
+		if err := update(&Db, newest(msgs)); err != nil {
+			log.WithField("module", "latest").Errorf("Can't update latest values: %v", err)
+		}
+	})
+
+	if missing {
+		go func() {
+			Db := db.MgoDb{}
+			Db.Init()
+			defer Db.Close()
+
+			log.WithField("module", "latest").Info("Rebuilding latest values from history")
+			if err := Rebuild(&Db, ""); err != nil {
+				log.WithField("module", "latest").Errorf("Can't rebuild latest values: %v", err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Rebuild function recomputes the latest values of channel from its
+// messages, or of all channels if channel is empty
+func Rebuild(mdb *db.MgoDb, channel string) error {
+	match := bson.M{}
+	if channel != "" {
+		match["channel"] = channel
+		if _, err := mdb.C(Collection).RemoveAll(match); err != nil {
+			return err
+		}
+	}
+
+	names, err := mdb.MessageCollections(channel, db.Earliest, db.Latest)
+	if err != nil {
+		return err
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sort": bson.M{"time": 1}},
+		{"$group": bson.M{"_id": bson.M{"channel": "$channel", "name": "$name"}, "message": bson.M{"$last": "$$ROOT"}}},
+	}
+	for _, name := range names {
+		res := []struct {
+			Message models.Message `bson:"message"`
+		}{}
+		if err := mdb.Aggregate(context.Background(), name, pipeline).All(&res); err != nil {
+			return err
+		}
+
+		msgs := []models.Message{}
+		for _, r := range res {
+			msgs = append(msgs, r.Message)
+		}
+		if err := update(mdb, msgs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get function returns the latest messages of channel, one per name, or
+// only the one named name unless empty. Sessions restricted to an owner
+// get db.ErrNotOwned for the channels of others.
+func Get(mdb *db.MgoDb, channel, name string) ([]models.Message, error) {
+	if err := mdb.Allows(channel); err != nil {
+		return nil, err
+	}
+
+	query := bson.M{"channel": channel}
+	if name != "" {
+		query["name"] = name
+	}
+
+	values := []value{}
+	if err := mdb.C(Collection).Find(query).Sort("name").All(&values); err != nil {
+		return nil, err
+	}
+
+	msgs := []models.Message{}
+	for _, v := range values {
+		msgs = append(msgs, v.Message)
+	}
+	return msgs, nil
+}
+
+// update stores msgs as the latest values of their channel and name,
+// unless later ones are stored
+func update(mdb *db.MgoDb, msgs []models.Message) error {
+	c := mdb.C(Collection)
+	for _, m := range msgs {
+		selector := bson.M{"channel": m.Channel, "name": m.Name, "time": bson.M{"$lte": m.Time}}
+		_, err := c.Upsert(selector, value{m.Channel, m.Name, m.Time, m})
+		// A later value makes the upsert insert a duplicate key.
+		if err != nil && !mdb.IsDup(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// newest returns the latest of msgs for each channel and name
+func newest(msgs []models.StoredMessage) []models.Message {
+	type key struct{ channel, name string }
+
+	latest := map[key]models.Message{}
+	order := []key{}
+	for _, m := range msgs {
+		k := key{m.Channel, m.Name}
+		prev, ok := latest[k]
+		if !ok {
+			order = append(order, k)
+		}
+		if !ok || m.Time >= prev.Time {
+			latest[k] = m.Message
+		}
+	}
+
+	res := []models.Message{}
+	for _, k := range order {
+		res = append(res, latest[k])
+	}
+	return res
+}
@@ -0,0 +1,44 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package latest
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+func stored(channel, name string, t float64) models.StoredMessage {
+	return models.StoredMessage{Message: models.Message{Channel: channel, Name: name, Time: t}}
+}
+
+func TestNewest(t *testing.T) {
+	msgs := newest([]models.StoredMessage{
+		stored("a", "temperature", 10),
+		stored("a", "humidity", 11),
+		stored("a", "temperature", 12),
+		stored("b", "temperature", 9),
+		stored("a", "temperature", 8),
+	})
+
+	expected := []models.Message{
+		{Channel: "a", Name: "temperature", Time: 12},
+		{Channel: "a", Name: "humidity", Time: 11},
+		{Channel: "b", Name: "temperature", Time: 9},
+	}
+	if len(msgs) != len(expected) {
+		t.Fatalf("expected %d messages got %d", len(expected), len(msgs))
+	}
+	for i, m := range msgs {
+		e := expected[i]
+		if m.Channel != e.Channel || m.Name != e.Name || m.Time != e.Time {
+			t.Errorf("message %d: expected %s/%s at %v got %s/%s at %v", i+1, e.Channel, e.Name, e.Time, m.Channel, m.Name, m.Time)
+		}
+	}
+}
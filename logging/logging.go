@@ -0,0 +1,115 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package logging sets the verbosity of the reader log and of the MongoDB
+// driver log, both of which can be changed while the reader runs.
+package logging
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/mgo.v2"
+)
+
+// Verbosity of the MongoDB driver log.
+const (
+	MongoOff   = "off"
+	MongoInfo  = "info"
+	MongoDebug = "debug"
+)
+
+var (
+	// ErrLevel is returned for unknown log levels.
+	ErrLevel = errors.New("unknown log level")
+
+	mu         sync.Mutex
+	level      = log.InfoLevel
+	mongoLevel = MongoOff
+	base       = log.InfoLevel
+)
+
+// Levels struct describes the current verbosity of both logs
+type Levels struct {
+	Level string `json:"level"`
+	Mongo string `json:"mongo"`
+}
+
+// mongoLogger forwards driver messages to the reader log
+type mongoLogger struct{}
+
+func (mongoLogger) Output(calldepth int, s string) error {
+	log.WithField("module", "mongo").Debug(strings.TrimSpace(s))
+	return nil
+}
+
+// Get function returns the current log levels
+func Get() Levels {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return Levels{level.String(), mongoLevel}
+}
+
+// SetLevel function sets the level of the reader log: debug, info,
+// warning, error, fatal or panic
+func SetLevel(name string) error {
+	l, err := log.ParseLevel(name)
+	if err != nil {
+		return ErrLevel
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	level, base = l, l
+	log.SetLevel(l)
+	return nil
+}
+
+// SetMongoLevel function sets the verbosity of the MongoDB driver log:
+// off, info for connection events or debug for every operation. Driver
+// messages are logged at debug level, so the reader log must be at debug
+// level too for them to show.
+func SetMongoLevel(name string) error {
+	switch name {
+	case MongoOff:
+		mgo.SetLogger(nil)
+		mgo.SetDebug(false)
+	case MongoInfo:
+		mgo.SetLogger(mongoLogger{})
+		mgo.SetDebug(false)
+	case MongoDebug:
+		mgo.SetLogger(mongoLogger{})
+		mgo.SetDebug(true)
+	default:
+		return ErrLevel
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	mongoLevel = name
+	return nil
+}
+
+// ToggleDebug function switches the reader log between debug level and
+// the level last set, and returns the new level
+func ToggleDebug() string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	level = log.DebugLevel
+	if log.GetLevel() == log.DebugLevel {
+		level = base
+	}
+	log.SetLevel(level)
+	return level.String()
+}
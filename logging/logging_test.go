@@ -0,0 +1,55 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package logging_test
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/logging"
+)
+
+func TestSetLevel(t *testing.T) {
+	cases := []struct {
+		level    string
+		mongo    string
+		err      error
+		expected logging.Levels
+	}{
+		{"debug", "debug", nil, logging.Levels{"debug", "debug"}},
+		{"warn", "info", nil, logging.Levels{"warning", "info"}},
+		{"verbose", "off", logging.ErrLevel, logging.Levels{"warning", "off"}},
+		{"info", "all", logging.ErrLevel, logging.Levels{"info", "off"}},
+	}
+
+	for i, c := range cases {
+		err := logging.SetLevel(c.level)
+		if mErr := logging.SetMongoLevel(c.mongo); err == nil {
+			err = mErr
+		}
+		if err != c.err {
+			t.Errorf("case %d: expected error %v got %v", i+1, c.err, err)
+		}
+		if l := logging.Get(); l != c.expected {
+			t.Errorf("case %d: expected levels %v got %v", i+1, c.expected, l)
+		}
+	}
+}
+
+func TestToggleDebug(t *testing.T) {
+	if err := logging.SetLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []string{"debug", "error", "debug"} {
+		if l := logging.ToggleDebug(); l != expected {
+			t.Errorf("toggle %d: expected level %s got %s", i+1, expected, l)
+		}
+	}
+	logging.SetLevel("info")
+}
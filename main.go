@@ -9,17 +9,50 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"github.com/fatih/color"
-	"log"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/mainflux/mainflux-mongodb-reader/api"
+	"github.com/mainflux/mainflux-mongodb-reader/audit"
+	"github.com/mainflux/mainflux-mongodb-reader/breaker"
+	"github.com/mainflux/mainflux-mongodb-reader/cache"
+	"github.com/mainflux/mainflux-mongodb-reader/config"
 	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/export"
+	"github.com/mainflux/mainflux-mongodb-reader/ipfilter"
+	"github.com/mainflux/mainflux-mongodb-reader/jwt"
+	"github.com/mainflux/mainflux-mongodb-reader/latest"
+	"github.com/mainflux/mainflux-mongodb-reader/logging"
+	"github.com/mainflux/mainflux-mongodb-reader/quota"
+	"github.com/mainflux/mainflux-mongodb-reader/ratelimit"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+	"github.com/mainflux/mainflux-mongodb-reader/registry"
+	"github.com/mainflux/mainflux-mongodb-reader/retention"
+	"github.com/mainflux/mainflux-mongodb-reader/rollup"
+	"github.com/mainflux/mainflux-mongodb-reader/sentry"
+	"github.com/mainflux/mainflux-mongodb-reader/stream"
+	"github.com/mainflux/mainflux-mongodb-reader/things"
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+	"github.com/mainflux/mainflux-mongodb-reader/units"
+	"github.com/mainflux/mainflux-mongodb-reader/version"
 
 	"github.com/cenkalti/backoff"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 )
 
 const (
@@ -31,7 +64,182 @@ Options:
 	-m, --nats	MongoDB host
 	-q, --nport	MongoDB port
 	-d, --db	MongoDB database
-	-h, --help	Prints this message end exits`
+	--db-uri	MongoDB connection string, mongodb:// or mongodb+srv://
+	--db-tls	Connect to MongoDB over TLS
+	--db-tls-ca	CA file verifying MongoDB servers, system CAs if empty
+	--db-tls-cert	Client certificate file presented to MongoDB
+	--db-tls-key	Private key file of the client certificate, the certificate file if empty
+	--db-tls-insecure	Skip verification of MongoDB server certificates
+	--db-username	MongoDB user, the client certificate subject for MONGODB-X509 if empty
+	--db-password	MongoDB password
+	--db-auth-source	Database of the MongoDB user
+	--db-auth-mechanism	SCRAM-SHA-1 or MONGODB-X509, SCRAM-SHA-256 is not supported by the driver
+	--read-preference	primary, primaryPreferred, secondary, secondaryPreferred, nearest or monotonic
+	--read-tags	Read preference tag sets, e.g. "use:analytics;dc:east"
+	--max-pool-size	Maximum number of connections to each MongoDB server
+	--min-pool-size	Number of connections opened at startup
+	--socket-timeout	Time limit of a single MongoDB network round trip
+	--retry-attempts	Number of tries of a read failing on network errors or failovers
+	--retry-backoff	Base delay between read retries, doubled after each
+	--retry-max-backoff	Longest delay between read retries
+	--breaker-threshold	Consecutive failures failing an endpoint fast, 0 disables
+	--breaker-cooldown	Time an endpoint fails fast before probing MongoDB again
+	--rate-limit	Data requests per second allowed to each caller, 0 disables
+	--rate-burst	Data requests each caller may make at once
+	--max-in-flight	Concurrent data requests of each caller, 0 disables
+	--max-queries	Database queries run at once across all callers, 0 disables
+	--max-queued	Queries waiting for a slot before new ones are shed with 503
+	--queue-timeout	Longest wait of a query for a slot
+	--ensure-indexes	Create missing message indexes at startup
+	--messages-collection	Collection of SenML messages, or name prefix of partitioned collections
+	--channels-collection	Collection of channels
+	--layout	Message storage layout: single, hash (messages_<n>) or monthly (messages_YYYY_MM)
+	--partitions	Number of collections of the hash layout
+	--read-concern	Read concern of message reads: local or majority, server default if empty
+	--allow-disk-use	Let aggregations exceeding server memory limits use temporary files
+	--aggregate-max-scan	Messages read by an aggregation from each collection, 0 for no cap
+	--aggregate-max-buckets	Buckets an aggregation may return, 0 for no cap
+	--distinct-max-range	Longest time range of a message read with distinct_on, 0 for no cap
+	--max-limit	Largest limit of a message read, 0 for no cap
+	--max-response-bytes	Largest response of message reads and aggregations, 0 for no cap
+	--etags	Answer conditional message reads with 304 when unchanged, at the cost of a count per read
+	--unit-registry	Keep a registry of the units, display names and value ranges of SenML names, served at /units, annotating message reads and guarding aggregations against mixed units
+	--max-wait	Longest wait of a long-polling message read, 0 disables long polling
+	--batch-size	Documents fetched per MongoDB cursor round trip, 0 for the server default
+	--split-ranges	Time slices of long export ranges read concurrently, 1 disables splitting
+	--split-parallelism	Time slices read at once
+	--split-min-range	Shortest export range split into time slices
+	--tenant-databases	Databases of tenants, e.g. "acme=acme_db;beta=mongodb://db.beta/beta"
+	--tenant-header	Request header selecting the tenant; only administrators select tenants other than their own
+	--owner-scoping	Restrict data requests to the channels of the owner their credentials name
+	--require-auth	Answer 401 to data requests without a verified API key, JWT, signature or client certificate
+	--owner-field	Field of channel documents naming their owner
+	--egress-daily-bytes	Response bytes served to a tenant per day, 0 for no quota
+	--egress-daily-docs	Messages served to a tenant per day, 0 for no quota
+	--egress-monthly-bytes	Response bytes served to a tenant per month, 0 for no quota
+	--egress-monthly-docs	Messages served to a tenant per month, 0 for no quota
+	--egress-quotas	Egress quotas of tenants, e.g. "acme=daily_bytes:1000000000,monthly_documents:5000000"
+	--egress-flush-interval	Interval between writes of egress counters to MongoDB
+	--archive-uri	Connection string of a cold store for old messages
+	--archive-after	Age from which messages are read from the cold store
+	--log-level	Log level: debug, info, warning or error; SIGHUP toggles debug unless --config is set
+	--mongo-log-level	MongoDB driver log: off, info or debug, shown at log level debug
+	--slow-query-threshold	Duration from which queries are logged as slow, 0 disables
+	--audit-sink	Audit log of data access: file:<path>, mongo:<collection> or syslog:[<network>://<address>]
+	--swagger-ui	Serve the Swagger UI at /swagger/, browsing the specification at /swagger.json
+	--legacy-paths	Keep serving the data endpoints at their unversioned paths, besides /v1, with Deprecation headers
+	--sunset	Removal date of the unversioned paths announced in Sunset headers, e.g. 2027-01-01
+	--admin-addr	Internal address serving health, metrics, pprof and administrative endpoints instead of the public port, e.g. localhost:7072; without it, metrics, which label usage by channel ID and caller, are only served to administrators
+	--debug-addr	Internal address serving pprof and expvar, e.g. localhost:6060, disabled if empty
+	--sentry-dsn	Sentry DSN errors and panics are reported to, disabled if empty
+	--registry-url	Service registry the reader registers with: consul://<host>:<port>, consuls:// over TLS, or an http(s) URL registrations are posted to
+	--registry-token	Access token of the service registry
+	--registry-name	Service name registered
+	--registry-address	Address registered, reachable by the registry and UIs, defaults to the host name
+	--registry-tags	Comma separated tags registered
+	--registry-interval	Interval between registrations and Consul health checks
+	--usage-max-channels	Channels usage metrics are reported for, others count as "other"
+	--usage-max-owners	Token owners usage metrics are reported for, others count as "other"
+	--cache-redis	Redis URL enabling the query cache, e.g. redis://:password@redis:6379/0
+	--cache-memory	Bytes of the in-process query cache used without Redis, 0 disables
+	--cache-ttl	Time responses stay in the query cache
+	--query-timeout	Default time limit of database queries
+	--admin-token	Token granting access to administrative endpoints
+	--retention	Default message retention period, 0 keeps messages forever
+	--retention-interval	Period of retention enforcement
+	--rollup-interval	Period of message rollups serving aggregations, 0 disables rollups
+	--rollup-delay	Time allowed to late messages before their period is rolled up
+	--rollup-backfill	How far back messages are first rolled up
+	--export-dir	Directory for export job results
+	--export-workers	Number of concurrent export jobs
+	--export-memory	Bytes of buffers all running export jobs may hold
+	--export-flush-interval	Period in which compressed exports are flushed to their file
+	--export-ttl	Time finished export jobs and their results are kept, 0 keeps them until the reader stops
+	--public-url	Public base URL of the reader, used in download links
+	--webhook-secret	Secret for signing webhook deliveries
+	--webhook-allowlist	Comma-separated URLs enabling the "webhook" export destination for URLs starting with them
+	--webhook-allow-private	Deliver webhooks to private, loopback and link-local addresses
+	--smtp-host	SMTP host enabling, with --smtp-allowed-recipients, the "email" export destination
+	--smtp-port	SMTP port
+	--smtp-username	SMTP username
+	--smtp-password	SMTP password
+	--smtp-from	Sender address of export emails
+	--smtp-allowed-recipients	Comma-separated addresses and domains exports may be emailed to, e.g. "ops@example.com,example.org"
+	--smtp-max-attachment	Largest export sent as attachment, in bytes
+	--nats-host	NATS host enabling the "nats" replay destination
+	--nats-port	NATS port
+	--things-url	URL of the Mainflux things service enabling enrich=true labels of messages, e.g. http://things:8182
+	--things-token	Token authorizing lookups in the things service
+	--things-cache-ttl	Time looked up channels and things are reused
+	--s3-endpoint	S3 endpoint enabling the "s3" export destination
+	--s3-region	S3 region
+	--s3-access-key	S3 access key
+	--s3-secret-key	S3 secret key
+	--s3-bucket	Default S3 bucket
+	--s3-prefix	Default S3 object key prefix
+	--read-header-timeout	Time limit of reading request headers
+	--read-timeout	Time limit of reading a whole request, 0 for none; cuts streamed responses
+	--write-timeout	Time limit of writing a response, 0 for none; cuts streams and long downloads
+	--idle-timeout	Time an idle keep-alive connection is kept open
+	--max-header-bytes	Largest request header size
+	--http2	Serve HTTP/2 over HTTPS
+	--server-cert	Certificate file enabling HTTPS
+	--server-key	Private key file of the certificate
+	--server-ca	CA file verifying client certificates
+	--cert-reload-interval	Period of checks for renewed certificate files, 0 disables
+	--client-auth	Client certificates: none, request, require, verify-if-given or require-and-verify
+	--allow-cidrs	Comma separated networks allowed to connect, all if empty
+	--deny-cidrs	Comma separated networks denied to connect
+	--ip-rules-file	File of "allow <network>" and "deny <network>" lines, reloaded on change
+	--cors-origins	Comma separated origins of browser applications allowed to query the API, "*" for any
+	--cors-methods	Comma separated methods allowed to cross-origin requests
+	--cors-headers	Comma separated request headers allowed to cross-origin requests, those asked for if empty
+	--cors-max-age	Time browsers may cache preflight responses
+	--cors-credentials	Let cross-origin requests send cookies and authorization, not allowed with the "*" origin
+	--redact	Hooks run on returned messages, e.g. "mask:ssn,patient/*;drop:raw;decrypt:/run/secrets/key:secret_*"
+	--api-keys	JSON keystore of API keys: [{"id": ..., "sha256": ..., "channels": [...]}]
+	--hmac-keys	JSON keystore of request signing keys: [{"id": ..., "secret": ..., "channels": [...]}]
+	--jwks-url	JWKS endpoint enabling local validation of bearer JWTs
+	--jwt-issuer	Required issuer of JWTs
+	--jwt-audience	Required audience of JWTs
+	--jwt-admin-role	JWT role claim granting administrative access, empty disables
+	--jwt-cache-ttl	Period validation outcomes of a JWT are reused, 0 disables
+	--cert-identities	Identities of client certificate names, e.g. "ops.example.com=admin;gw-7=thing:7"
+	--tls-min-version	Lowest TLS version of HTTPS and outbound connections: 1.0, 1.1 or 1.2
+	--tls-ciphers	Comma separated cipher suites of HTTPS and outbound connections, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+	--tls-fips	Restrict TLS to the FIPS profile: TLS 1.2, ECDHE with AES-GCM and NIST curves
+	--shutdown-timeout	Time in-flight requests and streams are given to end on SIGTERM or SIGINT
+	--config	YAML (.yaml, .yml) or TOML (.toml) file of options
+	--check-config	Check the options, the files they name and the connection to MongoDB and other services, print a report and exit, non-zero on failure
+	-h, --help	Prints this message end exits
+
+Every option can also be set through an environment variable named
+MF_MONGO_READER_<OPTION>, e.g. MF_MONGO_READER_DB_URI for --db-uri.
+Short options use MF_MONGO_READER_HTTP_HOST (-a), MF_MONGO_READER_HTTP_PORT (-p),
+MF_MONGO_READER_DB_HOST (-m), MF_MONGO_READER_DB_PORT (-q) and MF_MONGO_READER_DB (-d).
+Secrets (--db-uri, --db-password, --archive-uri, --admin-token, --webhook-secret,
+--smtp-password, --s3-access-key, --s3-secret-key, --sentry-dsn, --registry-token,
+--things-token and --cache-redis) can instead
+be read from the file named by the variable suffixed with _FILE, e.g.
+MF_MONGO_READER_DB_PASSWORD_FILE=/run/secrets/db_password.
+
+Options can also be kept in the file named by --config or MF_MONGO_READER_CONFIG,
+by their long name or nested by their dash separated words, e.g.
+
+	db:
+	  uri: mongodb://db:27017
+	  tls: true
+	cors-origins: [https://dash.example.com]
+
+Short options use the names of their environment variables, e.g. http-host
+for -a. Lists are joined with commas.
+Command line options take precedence over the environment, and the
+environment over the configuration file.
+
+On SIGHUP the configuration file is read again and the log levels, rate
+limits, --max-limit, CORS options and --allow-cidrs/--deny-cidrs it sets
+are applied without a restart, keeping streaming clients connected. The
+other options need a restart.`
 )
 
 type (
@@ -42,53 +250,1093 @@ type (
 		MongoHost     string
 		MongoPort     string
 		MongoDatabase string
+		MongoURI      string
+		MongoTLS      bool
+		MongoTLSCA    string
+		MongoTLSCert  string
+		MongoTLSKey   string
+		MongoInsecure bool
+		MongoUsername string
+		MongoPassword string
+		MongoSource   string
+		MongoAuth     string
+		QueryTimeout  time.Duration
+		CacheRedis    string
+		CacheMemory   int64
+		CacheTTL      time.Duration
+		SlowQuery     time.Duration
+
+		ReadPreference string
+		ReadTags       string
+		ReadConcern    string
+
+		MaxPoolSize   int
+		MinPoolSize   int
+		SocketTimeout time.Duration
+
+		RetryAttempts   int
+		RetryBackoff    time.Duration
+		RetryMaxBackoff time.Duration
+
+		BreakerThreshold int
+		BreakerCooldown  time.Duration
+
+		RateLimit   float64
+		RateBurst   int
+		MaxInFlight int
+		MaxQueries  int
+		MaxQueued   int
+		QueueWait   time.Duration
+
+		EnsureIndexes bool
+
+		MessagesCollection string
+		ChannelsCollection string
+
+		Layout     string
+		Partitions int
+
+		BatchSize           int
+		AllowDiskUse        bool
+		AggregateMaxScan    int
+		AggregateMaxBuckets int
+		DistinctMaxRange    time.Duration
+		MaxLimit            int
+		MaxResponseBytes    int
+		ETags               bool
+		UnitRegistry        bool
+		MaxWait             time.Duration
+
+		SplitRanges      int
+		SplitParallelism int
+		SplitMinRange    time.Duration
+
+		TenantDatabases string
+		TenantHeader    string
+		OwnerScoping    bool
+		OwnerField      string
+		RequireAuth     bool
+
+		EgressDailyBytes    int64
+		EgressDailyDocs     int64
+		EgressMonthlyBytes  int64
+		EgressMonthlyDocs   int64
+		EgressQuotas        string
+		EgressFlushInterval time.Duration
+
+		ArchiveURI   string
+		ArchiveAfter time.Duration
+
+		LogLevel      string
+		MongoLogLevel string
+
+		AdminToken string
+		AuditSink  string
+		AdminAddr  string
+		SwaggerUI  bool
+		LegacyPath bool
+		Sunset     string
+		DebugAddr  string
+		SentryDSN  string
+
+		RegistryURL      string
+		RegistryToken    string
+		RegistryName     string
+		RegistryAddress  string
+		RegistryTags     string
+		RegistryInterval time.Duration
 
-		Help bool
+		UsageMaxChannels int
+		UsageMaxOwners   int
+
+		Retention         time.Duration
+		RetentionInterval time.Duration
+
+		RollupInterval time.Duration
+		RollupDelay    time.Duration
+		RollupBackfill time.Duration
+
+		ExportDir     string
+		ExportWorkers int
+		ExportMemory  int
+		ExportFlush   time.Duration
+		ExportTTL     time.Duration
+
+		PublicURL           string
+		WebhookSecret       string
+		WebhookAllowlist    string
+		WebhookAllowPrivate bool
+
+		SMTPHost          string
+		SMTPPort          string
+		SMTPUsername      string
+		SMTPPassword      string
+		SMTPFrom          string
+		SMTPMaxAttachment int64
+		SMTPAllowed       string
+
+		NatsHost string
+		NatsPort string
+
+		ThingsURL      string
+		ThingsToken    string
+		ThingsCacheTTL time.Duration
+
+		S3Endpoint  string
+		S3Region    string
+		S3AccessKey string
+		S3SecretKey string
+		S3Bucket    string
+		S3Prefix    string
+
+		ReadHeaderTimeout time.Duration
+		ReadTimeout       time.Duration
+		WriteTimeout      time.Duration
+		IdleTimeout       time.Duration
+		MaxHeaderBytes    int
+		HTTP2             bool
+		ShutdownTimeout   time.Duration
+
+		ServerCert string
+		ServerKey  string
+		ServerCA   string
+		ClientAuth string
+		CertReload time.Duration
+		CertIDs    string
+		AllowCIDRs string
+		DenyCIDRs  string
+		IPRules    string
+		Redact     string
+		APIKeys    string
+		HMACKeys   string
+		JWKSURL    string
+		JWTIssuer  string
+		JWTAud     string
+		JWTCache   time.Duration
+		JWTAdmin   string
+		TLSMin     string
+		TLSCiphers string
+		TLSFIPS    bool
+
+		CORSOrigins     string
+		CORSMethods     string
+		CORSHeaders     string
+		CORSMaxAge      time.Duration
+		CORSCredentials bool
+
+		Config      string
+		CheckConfig bool
+		Help        bool
 	}
 )
 
 var (
 	opts Opts
+
+	// Environment variables of the short options. Long options are read
+	// from MF_MONGO_READER_<NAME>, with dashes replaced by underscores.
+	envNames = map[string]string{
+		"a": "MF_MONGO_READER_HTTP_HOST",
+		"p": "MF_MONGO_READER_HTTP_PORT",
+		"m": "MF_MONGO_READER_DB_HOST",
+		"q": "MF_MONGO_READER_DB_PORT",
+		"d": "MF_MONGO_READER_DB",
+	}
+
+	// Options whose environment variable may name a file holding the
+	// value, through the _FILE suffix
+	secretFlags = map[string]bool{
+		"db-uri":         true,
+		"db-password":    true,
+		"archive-uri":    true,
+		"admin-token":    true,
+		"webhook-secret": true,
+		"smtp-password":  true,
+		"s3-access-key":  true,
+		"s3-secret-key":  true,
+		"sentry-dsn":     true,
+		"registry-token": true,
+		"things-token":   true,
+		"cache-redis":    true,
+	}
+
+	mongoInfo   *mgo.DialInfo
+	archiveInfo *mgo.DialInfo
 )
 
+// loadEnv sets every flag present in the environment. It runs before
+// flag.Parse so that the command line takes precedence.
+func loadEnv() {
+	flag.VisitAll(func(f *flag.Flag) {
+		name, ok := envNames[f.Name]
+		if !ok {
+			name = "MF_MONGO_READER_" + strings.ToUpper(strings.Replace(f.Name, "-", "_", -1))
+		}
+		if len(f.Name) == 1 && !ok {
+			return
+		}
+
+		v, ok := os.LookupEnv(name)
+		if file, isFile := os.LookupEnv(name + "_FILE"); isFile && secretFlags[f.Name] {
+			if ok {
+				log.Fatalf("Both %s and %s_FILE are set\n", name, name)
+			}
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				log.Fatalf("Invalid %s_FILE: %v\n", name, err)
+			}
+			v, ok = strings.TrimRight(string(b), "\r\n"), true
+		}
+
+		if ok {
+			if err := flag.Set(f.Name, v); err != nil {
+				log.Fatalf("Invalid %s: %v\n", name, err)
+			}
+		}
+	})
+}
+
+// configPath returns the configuration file named on the command line or
+// in the environment
+func configPath() string {
+	args := os.Args[1:]
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		switch {
+		case a == "-config" || a == "--config":
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+		case strings.HasPrefix(a, "-config="):
+			return strings.TrimPrefix(a, "-config=")
+		case strings.HasPrefix(a, "--config="):
+			return strings.TrimPrefix(a, "--config=")
+		}
+	}
+	return os.Getenv("MF_MONGO_READER_CONFIG")
+}
+
+// loadConfig sets every flag present in the configuration file. It runs
+// before loadEnv so that the environment and the command line take
+// precedence.
+func loadConfig() {
+	path := configPath()
+	if path == "" {
+		return
+	}
+	values, err := config.Load(path)
+	if err != nil {
+		log.Fatalf("Config: %s: %v\n", path, err)
+	}
+
+	// Short options are named after their environment variables
+	aliases := map[string]string{}
+	for short, env := range envNames {
+		long := strings.ToLower(strings.Replace(strings.TrimPrefix(env, "MF_MONGO_READER_"), "_", "-", -1))
+		aliases[long] = short
+	}
+
+	for name, v := range values {
+		f := name
+		if short, ok := aliases[name]; ok {
+			f = short
+		}
+		if len(name) == 1 || name == "config" || flag.Lookup(f) == nil {
+			log.Fatalf("Config: %s: unknown option %s\n", path, name)
+		}
+		if err := flag.Set(f, v); err != nil {
+			log.Fatalf("Config: %s: invalid %s: %v\n", path, name, err)
+		}
+	}
+}
+
+// Credentials of connection strings, masked in logs
+var uriPassword = regexp.MustCompile(`(://[^:/@]*:)[^@]*@`)
+
+// logConfig logs the options differing from their defaults, with secrets
+// masked
+func logConfig() {
+	fields := log.Fields{}
+	flag.VisitAll(func(f *flag.Flag) {
+		v := f.Value.String()
+		if v == f.DefValue || f.Name == "h" || f.Name == "help" {
+			return
+		}
+		switch {
+		case secretFlags[f.Name]:
+			v = "****"
+		default:
+			v = uriPassword.ReplaceAllString(v, "${1}****@")
+		}
+		fields[f.Name] = v
+	})
+	log.WithFields(fields).Info("Effective configuration")
+}
+
 func tryMongoInit() error {
 	var err error
 
-	log.Print("Connecting to MongoDB... ")
+	log.Print("MongoDB: connecting")
+	if mongoInfo != nil {
+		return db.InitMongoWithInfo(mongoInfo, opts.MongoDatabase)
+	}
 	err = db.InitMongo(opts.MongoHost, opts.MongoPort, opts.MongoDatabase)
 	return err
 }
 
+// connectMongo retries connecting to MongoDB until it succeeds, then
+// configures the session and starts the background jobs reading from it.
+func connectMongo(readMode mgo.Mode, readTags []bson.D) {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 0
+	backoff.RetryNotify(tryMongoInit, b, func(err error, d time.Duration) {
+		log.Printf("MongoDB: Can't connect: %v, retrying in %s\n", err, d)
+	})
+	log.Print("MongoDB: connected")
+
+	db.SetReadPreference(readMode, readTags)
+	db.SetPoolLimit(opts.MaxPoolSize)
+	db.SetSocketTimeout(opts.SocketTimeout)
+	if err := db.WarmPool(opts.MinPoolSize); err != nil {
+		log.Printf("MongoDB: Can't open initial connections: %v\n", err)
+	}
+	if _, err := db.Probe(); err != nil {
+		log.Printf("MongoDB: Can't probe server capabilities: %v\n", err)
+	}
+	if db.ReadConcern != "" && !db.Supports(db.FeatureReadConcern).Supported {
+		log.Printf("MongoDB: %s, ignoring read concern\n", db.Supports(db.FeatureReadConcern).Reason)
+		db.ReadConcern = ""
+	}
+	if opts.EnsureIndexes {
+		if err := db.EnsureIndexes(); err != nil {
+			log.Printf("MongoDB: Can't create indexes: %v\n", err)
+		}
+	}
+	if archiveInfo != nil {
+		if err := db.InitArchive(archiveInfo, opts.MongoDatabase); err != nil {
+			log.Printf("MongoDB: Can't connect to archive: %v\n", err)
+		}
+	}
+	db.SetConnected()
+
+	// Watch for new messages to feed live tails
+	stream.Start()
+
+	// Keep the latest value of every channel and name
+	if err := latest.Start(); err != nil {
+		log.Printf("MongoDB: Can't maintain latest values: %v\n", err)
+	}
+
+	// Remove expired messages
+	retention.Start(opts.Retention, opts.RetentionInterval)
+
+	// Persist the egress of tenants
+	if quota.Enabled() {
+		quota.Start(opts.EgressFlushInterval)
+	}
+
+	// Summarize messages for aggregations
+	if opts.RollupInterval > 0 {
+		rollup.Delay = opts.RollupDelay
+		rollup.Backfill = opts.RollupBackfill
+		rollup.Start(opts.RollupInterval)
+	}
+}
+
 func main() {
+	log.SetFormatter(&log.JSONFormatter{
+		FieldMap: log.FieldMap{log.FieldKeyTime: "timestamp"},
+	})
+
 	flag.StringVar(&opts.HTTPHost, "a", "localhost", "HTTP server address.")
 	flag.StringVar(&opts.HTTPPort, "p", "7071", "HTTP server port.")
 	flag.StringVar(&opts.MongoHost, "m", "localhost", "MongoDB host.")
 	flag.StringVar(&opts.MongoPort, "q", "27017", "MongoDB port.")
 	flag.StringVar(&opts.MongoDatabase, "d", "mainflux", "MongoDB database name.")
+	flag.StringVar(&opts.MongoURI, "db-uri", "", "MongoDB connection string, overrides host and port.")
+	flag.BoolVar(&opts.MongoTLS, "db-tls", false, "Connect to MongoDB over TLS.")
+	flag.StringVar(&opts.MongoTLSCA, "db-tls-ca", "", "CA file verifying MongoDB servers.")
+	flag.StringVar(&opts.MongoTLSCert, "db-tls-cert", "", "Client certificate file presented to MongoDB.")
+	flag.StringVar(&opts.MongoTLSKey, "db-tls-key", "", "Private key file of the client certificate.")
+	flag.BoolVar(&opts.MongoInsecure, "db-tls-insecure", false, "Skip verification of MongoDB server certificates.")
+	flag.StringVar(&opts.MongoUsername, "db-username", "", "MongoDB user.")
+	flag.StringVar(&opts.MongoPassword, "db-password", "", "MongoDB password.")
+	flag.StringVar(&opts.MongoSource, "db-auth-source", "", "Database of the MongoDB user.")
+	flag.StringVar(&opts.MongoAuth, "db-auth-mechanism", "", "MongoDB authentication mechanism.")
+	flag.StringVar(&opts.AdminToken, "admin-token", "", "Token granting access to administrative endpoints.")
+	flag.StringVar(&opts.AuditSink, "audit-sink", "", "Audit log of data access.")
+	flag.StringVar(&opts.AdminAddr, "admin-addr", "", "Internal address serving operational endpoints.")
+	flag.BoolVar(&opts.SwaggerUI, "swagger-ui", false, "Serve the Swagger UI at /swagger/.")
+	flag.BoolVar(&opts.LegacyPath, "legacy-paths", true, "Serve the data endpoints at their unversioned paths.")
+	flag.StringVar(&opts.Sunset, "sunset", "", "Removal date of the unversioned paths.")
+	flag.StringVar(&opts.DebugAddr, "debug-addr", "", "Internal address serving pprof and expvar.")
+	flag.StringVar(&opts.SentryDSN, "sentry-dsn", "", "Sentry DSN errors and panics are reported to.")
+	flag.StringVar(&opts.RegistryURL, "registry-url", "", "Service registry the reader registers with.")
+	flag.StringVar(&opts.RegistryToken, "registry-token", "", "Access token of the service registry.")
+	flag.StringVar(&opts.RegistryName, "registry-name", "mainflux-mongodb-reader", "Service name registered.")
+	flag.StringVar(&opts.RegistryAddress, "registry-address", "", "Address registered.")
+	flag.StringVar(&opts.RegistryTags, "registry-tags", "", "Comma separated tags registered.")
+	flag.DurationVar(&opts.RegistryInterval, "registry-interval", 10*time.Second, "Interval between registrations.")
+	flag.IntVar(&opts.UsageMaxChannels, "usage-max-channels", 100, "Channels usage metrics are reported for.")
+	flag.IntVar(&opts.UsageMaxOwners, "usage-max-owners", 100, "Token owners usage metrics are reported for.")
+	flag.DurationVar(&opts.Retention, "retention", 0, "Default message retention period.")
+	flag.DurationVar(&opts.RetentionInterval, "retention-interval", time.Hour, "Period of retention enforcement.")
+	flag.DurationVar(&opts.RollupInterval, "rollup-interval", 0, "Period of message rollups.")
+	flag.DurationVar(&opts.RollupDelay, "rollup-delay", time.Minute, "Time allowed to late messages before rollup.")
+	flag.DurationVar(&opts.RollupBackfill, "rollup-backfill", 30*24*time.Hour, "How far back messages are first rolled up.")
+	flag.StringVar(&opts.ExportDir, "export-dir", os.TempDir(), "Directory for export job results.")
+	flag.IntVar(&opts.ExportWorkers, "export-workers", 2, "Number of concurrent export jobs.")
+	flag.IntVar(&opts.ExportMemory, "export-memory", 64<<20, "Bytes of buffers of running export jobs.")
+	flag.DurationVar(&opts.ExportFlush, "export-flush-interval", 5*time.Second, "Period of flushes of compressed exports.")
+	flag.DurationVar(&opts.ExportTTL, "export-ttl", 24*time.Hour, "Time finished export jobs are kept.")
+	flag.StringVar(&opts.PublicURL, "public-url", "", "Public base URL of the reader.")
+	flag.StringVar(&opts.WebhookSecret, "webhook-secret", "", "Secret for signing webhook deliveries.")
+	flag.StringVar(&opts.WebhookAllowlist, "webhook-allowlist", "", "Comma-separated URLs webhook destinations must start with.")
+	flag.BoolVar(&opts.WebhookAllowPrivate, "webhook-allow-private", false, "Deliver webhooks to private addresses.")
+	flag.StringVar(&opts.SMTPHost, "smtp-host", "", "SMTP host.")
+	flag.StringVar(&opts.SMTPPort, "smtp-port", "25", "SMTP port.")
+	flag.StringVar(&opts.SMTPUsername, "smtp-username", "", "SMTP username.")
+	flag.StringVar(&opts.SMTPPassword, "smtp-password", "", "SMTP password.")
+	flag.StringVar(&opts.SMTPFrom, "smtp-from", "reader@mainflux.io", "Sender address of export emails.")
+	flag.StringVar(&opts.SMTPAllowed, "smtp-allowed-recipients", "", "Addresses and domains exports may be emailed to.")
+	flag.Int64Var(&opts.SMTPMaxAttachment, "smtp-max-attachment", 10<<20, "Largest export sent as attachment, in bytes.")
+	flag.StringVar(&opts.NatsHost, "nats-host", "", "NATS host.")
+	flag.StringVar(&opts.NatsPort, "nats-port", "4222", "NATS port.")
+	flag.StringVar(&opts.ThingsURL, "things-url", "", "URL of the Mainflux things service.")
+	flag.StringVar(&opts.ThingsToken, "things-token", "", "Token authorizing lookups in the things service.")
+	flag.DurationVar(&opts.ThingsCacheTTL, "things-cache-ttl", 5*time.Minute, "Time looked up channels and things are reused.")
+	flag.StringVar(&opts.S3Endpoint, "s3-endpoint", "", "S3 endpoint, e.g. https://s3.amazonaws.com.")
+	flag.StringVar(&opts.S3Region, "s3-region", "us-east-1", "S3 region.")
+	flag.StringVar(&opts.S3AccessKey, "s3-access-key", "", "S3 access key.")
+	flag.StringVar(&opts.S3SecretKey, "s3-secret-key", "", "S3 secret key.")
+	flag.StringVar(&opts.S3Bucket, "s3-bucket", "", "Default S3 bucket.")
+	flag.StringVar(&opts.S3Prefix, "s3-prefix", "", "Default S3 object key prefix.")
+	flag.StringVar(&opts.ReadPreference, "read-preference", "monotonic", "MongoDB read preference.")
+	flag.StringVar(&opts.ReadTags, "read-tags", "", "MongoDB read preference tag sets.")
+	flag.IntVar(&opts.MaxPoolSize, "max-pool-size", 4096, "Maximum number of connections to each MongoDB server.")
+	flag.IntVar(&opts.MinPoolSize, "min-pool-size", 0, "Number of MongoDB connections opened at startup.")
+	flag.DurationVar(&opts.SocketTimeout, "socket-timeout", time.Minute, "Time limit of a MongoDB network round trip.")
+	flag.IntVar(&opts.RetryAttempts, "retry-attempts", 3, "Number of tries of a transiently failing read.")
+	flag.DurationVar(&opts.RetryBackoff, "retry-backoff", 100*time.Millisecond, "Base delay between read retries.")
+	flag.DurationVar(&opts.RetryMaxBackoff, "retry-max-backoff", 2*time.Second, "Longest delay between read retries.")
+	flag.IntVar(&opts.BreakerThreshold, "breaker-threshold", 5, "Consecutive failures opening an endpoint circuit breaker.")
+	flag.DurationVar(&opts.BreakerCooldown, "breaker-cooldown", 30*time.Second, "Time an open circuit breaker rejects requests.")
+	flag.Float64Var(&opts.RateLimit, "rate-limit", 0, "Data requests per second allowed to each caller.")
+	flag.IntVar(&opts.RateBurst, "rate-burst", 10, "Data requests each caller may make at once.")
+	flag.IntVar(&opts.MaxInFlight, "max-in-flight", 0, "Concurrent data requests of each caller.")
+	flag.IntVar(&opts.MaxQueries, "max-queries", 0, "Database queries run at once across all callers.")
+	flag.IntVar(&opts.MaxQueued, "max-queued", 100, "Queries waiting for a slot.")
+	flag.DurationVar(&opts.QueueWait, "queue-timeout", 5*time.Second, "Longest wait of a query for a slot.")
+	flag.BoolVar(&opts.EnsureIndexes, "ensure-indexes", false, "Create missing message indexes at startup.")
+	flag.StringVar(&opts.MessagesCollection, "messages-collection", "messages", "Collection of SenML messages.")
+	flag.StringVar(&opts.ChannelsCollection, "channels-collection", "channels", "Collection of channels.")
+	flag.StringVar(&opts.Layout, "layout", db.LayoutSingle, "Message storage layout.")
+	flag.IntVar(&opts.Partitions, "partitions", 16, "Number of collections of the hash layout.")
+	flag.StringVar(&opts.ReadConcern, "read-concern", "", "MongoDB read concern level of message reads.")
+	flag.IntVar(&opts.BatchSize, "batch-size", 0, "Documents fetched per MongoDB cursor round trip.")
+	flag.BoolVar(&opts.AllowDiskUse, "allow-disk-use", false, "Let aggregations use temporary files.")
+	flag.IntVar(&opts.AggregateMaxScan, "aggregate-max-scan", 1000000, "Messages read by an aggregation from each collection.")
+	flag.IntVar(&opts.AggregateMaxBuckets, "aggregate-max-buckets", 10000, "Buckets an aggregation may return.")
+	flag.DurationVar(&opts.DistinctMaxRange, "distinct-max-range", 24*time.Hour, "Longest time range of a message read with distinct_on.")
+	flag.IntVar(&opts.MaxLimit, "max-limit", 10000, "Largest limit of a message read.")
+	flag.IntVar(&opts.MaxResponseBytes, "max-response-bytes", 0, "Largest response of message reads and aggregations.")
+	flag.BoolVar(&opts.ETags, "etags", false, "Answer conditional message reads with 304 when unchanged.")
+	flag.BoolVar(&opts.UnitRegistry, "unit-registry", false, "Keep a registry of the units of SenML names.")
+	flag.DurationVar(&opts.MaxWait, "max-wait", time.Minute, "Longest wait of a long-polling message read.")
+	flag.IntVar(&opts.SplitRanges, "split-ranges", 1, "Time slices of long export ranges.")
+	flag.IntVar(&opts.SplitParallelism, "split-parallelism", 4, "Time slices read at once.")
+	flag.DurationVar(&opts.SplitMinRange, "split-min-range", 7*24*time.Hour, "Shortest export range split into time slices.")
+	flag.StringVar(&opts.TenantDatabases, "tenant-databases", "", "Databases of tenants.")
+	flag.StringVar(&opts.TenantHeader, "tenant-header", "X-Tenant-ID", "Request header selecting the tenant.")
+	flag.BoolVar(&opts.OwnerScoping, "owner-scoping", false, "Restrict data requests to the channels of their owner.")
+	flag.StringVar(&opts.OwnerField, "owner-field", "owner", "Field of channel documents naming their owner.")
+	flag.BoolVar(&opts.RequireAuth, "require-auth", false, "Answer 401 to data requests without verified credentials.")
+	flag.Int64Var(&opts.EgressDailyBytes, "egress-daily-bytes", 0, "Response bytes served to a tenant per day.")
+	flag.Int64Var(&opts.EgressDailyDocs, "egress-daily-docs", 0, "Messages served to a tenant per day.")
+	flag.Int64Var(&opts.EgressMonthlyBytes, "egress-monthly-bytes", 0, "Response bytes served to a tenant per month.")
+	flag.Int64Var(&opts.EgressMonthlyDocs, "egress-monthly-docs", 0, "Messages served to a tenant per month.")
+	flag.StringVar(&opts.EgressQuotas, "egress-quotas", "", "Egress quotas of tenants.")
+	flag.DurationVar(&opts.EgressFlushInterval, "egress-flush-interval", 10*time.Second, "Interval between writes of egress counters.")
+	flag.StringVar(&opts.ArchiveURI, "archive-uri", "", "MongoDB connection string of the cold store.")
+	flag.DurationVar(&opts.ArchiveAfter, "archive-after", 30*24*time.Hour, "Age of messages read from the cold store.")
+	flag.StringVar(&opts.LogLevel, "log-level", "info", "Log level: debug, info, warning or error.")
+	flag.StringVar(&opts.MongoLogLevel, "mongo-log-level", logging.MongoOff, "MongoDB driver log: off, info or debug.")
+	flag.StringVar(&opts.CacheRedis, "cache-redis", "", "Redis URL enabling the query cache.")
+	flag.Int64Var(&opts.CacheMemory, "cache-memory", 0, "Bytes of the in-process query cache.")
+	flag.DurationVar(&opts.CacheTTL, "cache-ttl", 5*time.Second, "Time responses stay in the query cache.")
+	flag.DurationVar(&opts.QueryTimeout, "query-timeout", 30*time.Second, "Default time limit of database queries.")
+	flag.DurationVar(&opts.SlowQuery, "slow-query-threshold", 0, "Duration from which queries are logged as slow.")
+	flag.DurationVar(&opts.ReadHeaderTimeout, "read-header-timeout", 10*time.Second, "Time limit of reading request headers.")
+	flag.DurationVar(&opts.ReadTimeout, "read-timeout", 0, "Time limit of reading a whole request.")
+	flag.DurationVar(&opts.WriteTimeout, "write-timeout", 0, "Time limit of writing a response.")
+	flag.DurationVar(&opts.IdleTimeout, "idle-timeout", 2*time.Minute, "Time an idle keep-alive connection is kept open.")
+	flag.IntVar(&opts.MaxHeaderBytes, "max-header-bytes", 64<<10, "Largest request header size.")
+	flag.BoolVar(&opts.HTTP2, "http2", true, "Serve HTTP/2 over HTTPS.")
+	flag.DurationVar(&opts.ShutdownTimeout, "shutdown-timeout", 30*time.Second, "Time in-flight requests are given to end on shutdown.")
+	flag.StringVar(&opts.ServerCert, "server-cert", "", "Certificate file enabling HTTPS.")
+	flag.StringVar(&opts.ServerKey, "server-key", "", "Private key file of the certificate.")
+	flag.StringVar(&opts.ServerCA, "server-ca", "", "CA file verifying client certificates.")
+	flag.StringVar(&opts.ClientAuth, "client-auth", "none", "Client certificate authentication mode.")
+	flag.DurationVar(&opts.CertReload, "cert-reload-interval", time.Minute, "Period of checks for renewed certificate files.")
+	flag.StringVar(&opts.CertIDs, "cert-identities", "", "Identities of client certificate names.")
+	flag.StringVar(&opts.AllowCIDRs, "allow-cidrs", "", "Comma separated networks allowed to connect.")
+	flag.StringVar(&opts.DenyCIDRs, "deny-cidrs", "", "Comma separated networks denied to connect.")
+	flag.StringVar(&opts.IPRules, "ip-rules-file", "", "File of IP allow and deny rules.")
+	flag.StringVar(&opts.Redact, "redact", "", "Redaction hooks run on returned messages.")
+	flag.StringVar(&opts.APIKeys, "api-keys", "", "JSON keystore of API keys.")
+	flag.StringVar(&opts.HMACKeys, "hmac-keys", "", "JSON keystore of request signing keys.")
+	flag.StringVar(&opts.JWKSURL, "jwks-url", "", "JWKS endpoint validating bearer JWTs.")
+	flag.StringVar(&opts.JWTIssuer, "jwt-issuer", "", "Required issuer of JWTs.")
+	flag.StringVar(&opts.JWTAud, "jwt-audience", "", "Required audience of JWTs.")
+	flag.StringVar(&opts.JWTAdmin, "jwt-admin-role", "admin", "JWT role claim granting administrative access.")
+	flag.DurationVar(&opts.JWTCache, "jwt-cache-ttl", 30*time.Second, "Period validation outcomes of a JWT are reused.")
+	flag.StringVar(&opts.TLSMin, "tls-min-version", "", "Lowest TLS version.")
+	flag.StringVar(&opts.TLSCiphers, "tls-ciphers", "", "Comma separated TLS cipher suites.")
+	flag.BoolVar(&opts.TLSFIPS, "tls-fips", false, "Restrict TLS to the FIPS profile.")
+	flag.StringVar(&opts.CORSOrigins, "cors-origins", "", "Comma separated origins allowed to query the API.")
+	flag.StringVar(&opts.CORSMethods, "cors-methods", "GET,POST,PUT,DELETE", "Comma separated methods allowed to cross-origin requests.")
+	flag.StringVar(&opts.CORSHeaders, "cors-headers", "", "Comma separated request headers allowed to cross-origin requests.")
+	flag.DurationVar(&opts.CORSMaxAge, "cors-max-age", 10*time.Minute, "Time browsers may cache preflight responses.")
+	flag.BoolVar(&opts.CORSCredentials, "cors-credentials", false, "Let cross-origin requests send credentials.")
+	flag.StringVar(&opts.Config, "config", "", "YAML or TOML file of options.")
+	flag.BoolVar(&opts.CheckConfig, "check-config", false, "Check the configuration and exit.")
 	flag.BoolVar(&opts.Help, "h", false, "Show help.")
 	flag.BoolVar(&opts.Help, "help", false, "Show help.")
 
+	loadConfig()
+	loadEnv()
 	flag.Parse()
 
 	if opts.Help {
 		fmt.Printf("%s\n", help)
 		os.Exit(0)
 	}
+	if opts.CheckConfig {
+		if !checkConfig(os.Stdout) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if err := applyReloadable(); err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	go handleHangup()
+	logConfig()
+
+	tlsPolicy, err := tlsutil.ParsePolicy(opts.TLSMin, opts.TLSCiphers, opts.TLSFIPS)
+	if err != nil {
+		log.Fatalf("TLS: %v\n", err)
+	}
+	tlsutil.SetPolicy(tlsPolicy)
+
+	if opts.SentryDSN != "" {
+		if err := sentry.Init(opts.SentryDSN); err != nil {
+			log.Fatalf("%v\n", err)
+		}
+	}
 
 	// MongoDb
-	// Connect to MongoDB
-	if err := backoff.Retry(tryMongoInit, backoff.NewExponentialBackOff()); err != nil {
-		log.Fatalf("MongoDd: Can't connect: %v\n", err)
-	} else {
-		log.Println("OK")
+	if opts.MongoURI != "" {
+		info, err := db.ParseURI(opts.MongoURI)
+		if err != nil {
+			log.Fatalf("MongoDB: Invalid connection string: %v\n", err)
+		}
+		mongoInfo = info
+	}
+
+	var mongoTLS *tls.Config
+	if opts.MongoTLS || opts.MongoTLSCA != "" || opts.MongoTLSCert != "" {
+		if opts.MongoTLSKey == "" {
+			opts.MongoTLSKey = opts.MongoTLSCert
+		}
+		cfg, err := db.ClientTLS(opts.MongoTLSCA, opts.MongoTLSCert, opts.MongoTLSKey, opts.MongoInsecure)
+		if err != nil {
+			log.Fatalf("MongoDB: %v\n", err)
+		}
+		db.UseTLS(dialInfo(), cfg)
+		mongoTLS = cfg
+	}
+
+	if opts.MongoUsername != "" || opts.MongoAuth != "" {
+		creds := db.Credentials{
+			Username:  opts.MongoUsername,
+			Password:  opts.MongoPassword,
+			Source:    opts.MongoSource,
+			Mechanism: opts.MongoAuth,
+		}
+		if err := db.SetCredentials(dialInfo(), creds, mongoTLS); err != nil {
+			log.Fatalf("MongoDB: %v\n", err)
+		}
+	}
+
+	if opts.ArchiveURI != "" {
+		info, err := db.ParseURI(opts.ArchiveURI)
+		if err != nil {
+			log.Fatalf("MongoDB: Invalid archive connection string: %v\n", err)
+		}
+		archiveInfo = info
+		db.ArchiveAfter = opts.ArchiveAfter
+	}
+
+	switch opts.Layout {
+	case db.LayoutSingle, db.LayoutMonthly:
+	case db.LayoutHash:
+		if opts.Partitions <= 0 {
+			log.Fatalf("Invalid number of partitions: %d\n", opts.Partitions)
+		}
+	default:
+		log.Fatalf("Unknown storage layout: %s\n", opts.Layout)
+	}
+	db.Layout = opts.Layout
+	db.MessagesCollection = opts.MessagesCollection
+	db.ChannelsCollection = opts.ChannelsCollection
+	db.Partitions = opts.Partitions
+
+	switch opts.ReadConcern {
+	case "", db.ConcernLocal, db.ConcernMajority:
+		db.ReadConcern = opts.ReadConcern
+	default:
+		log.Fatalf("Unknown read concern: %s\n", opts.ReadConcern)
+	}
+
+	if err := db.SetTenants(opts.TenantDatabases); err != nil {
+		log.Fatalf("MongoDB: %v\n", err)
+	}
+	db.OwnerField = opts.OwnerField
+
+	egress := quota.Quotas{
+		DailyBytes:   opts.EgressDailyBytes,
+		DailyDocs:    opts.EgressDailyDocs,
+		MonthlyBytes: opts.EgressMonthlyBytes,
+		MonthlyDocs:  opts.EgressMonthlyDocs,
+	}
+	if err := quota.SetQuotas(egress, opts.EgressQuotas); err != nil {
+		log.Fatalf("Quotas: %v\n", err)
+	}
+
+	readMode, err := db.ParseReadMode(opts.ReadPreference)
+	if err != nil {
+		log.Fatalf("MongoDB: %v\n", err)
+	}
+	readTags, err := db.ParseTagSets(opts.ReadTags)
+	if err != nil {
+		log.Fatalf("MongoDB: %v\n", err)
+	}
+
+	db.QueryTimeout = opts.QueryTimeout
+	db.SlowQueryThreshold = opts.SlowQuery
+	db.RetryAttempts = opts.RetryAttempts
+	db.RetryBackoff = opts.RetryBackoff
+	db.RetryMaxBackoff = opts.RetryMaxBackoff
+	if opts.CacheRedis != "" {
+		r, err := cache.NewRedis(opts.CacheRedis)
+		if err != nil {
+			log.Fatalf("Cache: %v\n", err)
+		}
+		cache.Configure(r, opts.CacheTTL)
+	} else if opts.CacheMemory > 0 {
+		cache.Configure(cache.NewMemory(opts.CacheMemory), opts.CacheTTL)
+	}
+
+	db.BatchSize = opts.BatchSize
+	db.AllowDiskUse = opts.AllowDiskUse
+	if opts.SplitRanges < 1 || opts.SplitParallelism < 1 {
+		log.Fatalf("MongoDB: --split-ranges and --split-parallelism must be positive\n")
+	}
+	db.SplitRanges = opts.SplitRanges
+	db.SplitParallelism = opts.SplitParallelism
+	db.SplitMinRange = opts.SplitMinRange
+
+	// Connect to MongoDB in the background; requests needing it are
+	// answered with 503 until the connection is up.
+	go connectMongo(readMode, readTags)
+
+	// Run export jobs in the background
+	export.Dir = opts.ExportDir
+	if opts.WebhookAllowlist != "" {
+		export.Register("webhook", export.Webhook{
+			Secret:       opts.WebhookSecret,
+			PublicURL:    opts.PublicURL,
+			Allowed:      strings.Split(opts.WebhookAllowlist, ","),
+			AllowPrivate: opts.WebhookAllowPrivate,
+			Client:       tlsutil.HTTPClient(0),
+		})
+	}
+	if opts.SMTPHost != "" && opts.SMTPAllowed != "" {
+		export.Register("email", export.Email{
+			Host:          opts.SMTPHost,
+			Port:          opts.SMTPPort,
+			Username:      opts.SMTPUsername,
+			Password:      opts.SMTPPassword,
+			From:          opts.SMTPFrom,
+			PublicURL:     opts.PublicURL,
+			MaxAttachment: opts.SMTPMaxAttachment,
+			Allowed:       strings.Split(opts.SMTPAllowed, ","),
+		})
+	}
+	if opts.NatsHost != "" {
+		api.NatsInit(opts.NatsHost, opts.NatsPort)
+		export.Register("nats", export.NATS{Conn: api.NatsConn})
+	}
+	things.TTL = opts.ThingsCacheTTL
+	if err := things.Init(opts.ThingsURL, opts.ThingsToken); err != nil {
+		log.Fatalf("Things: %v\n", err)
+	}
+	if opts.S3Endpoint != "" {
+		export.Register("s3", export.S3{
+			Endpoint:  opts.S3Endpoint,
+			Region:    opts.S3Region,
+			AccessKey: opts.S3AccessKey,
+			SecretKey: opts.S3SecretKey,
+			Bucket:    opts.S3Bucket,
+			Prefix:    opts.S3Prefix,
+			Client:    tlsutil.HTTPClient(0),
+		})
+	}
+	export.SetMemoryBudget(opts.ExportMemory)
+	export.FlushInterval = opts.ExportFlush
+	export.TTL = opts.ExportTTL
+	export.Start(opts.ExportWorkers, 100)
+
+	if opts.AuditSink != "" {
+		sink, err := audit.Open(opts.AuditSink)
+		if err != nil {
+			log.Fatalf("Audit: %v\n", err)
+		}
+		audit.Start(sink, 1000)
+	}
+
+	breaker.Threshold = opts.BreakerThreshold
+	breaker.Cooldown = opts.BreakerCooldown
+	ratelimit.MaxQueries = opts.MaxQueries
+	ratelimit.MaxQueued = opts.MaxQueued
+	ratelimit.QueueTimeout = opts.QueueWait
+	api.AdminToken = opts.AdminToken
+	api.TenantHeader = opts.TenantHeader
+	api.OwnerScoping = opts.OwnerScoping
+	api.RequireAuth = opts.RequireAuth
+	api.AggregateMaxScan = opts.AggregateMaxScan
+	api.AggregateMaxBuckets = opts.AggregateMaxBuckets
+	api.DistinctMaxRange = opts.DistinctMaxRange
+	api.MaxResponseBytes = opts.MaxResponseBytes
+	api.ETags = opts.ETags
+	units.Enabled = opts.UnitRegistry
+	api.MaxWait = opts.MaxWait
+	api.SwaggerUI = opts.SwaggerUI
+	api.LegacyPaths = opts.LegacyPath
+	if opts.Sunset != "" {
+		sunset, err := time.Parse("2006-01-02", opts.Sunset)
+		if err != nil {
+			log.Fatalf("Sunset: %v\n", err)
+		}
+		api.Sunset = sunset
+	}
+	api.UsageMaxChannels = opts.UsageMaxChannels
+	api.UsageMaxOwners = opts.UsageMaxOwners
+	if err := api.SetCertIdentities(opts.CertIDs); err != nil {
+		log.Fatalf("TLS: %v\n", err)
+	}
+	if opts.IPRules != "" {
+		if err := ipfilter.Watch(opts.IPRules, 10*time.Second); err != nil {
+			log.Fatalf("IP filter: %v\n", err)
+		}
+	}
+	hooks, err := redact.Parse(opts.Redact)
+	if err != nil {
+		log.Fatalf("Redaction: %v\n", err)
+	}
+	for _, h := range hooks {
+		redact.Register(h)
+	}
+	if err := api.LoadAPIKeys(opts.APIKeys); err != nil {
+		log.Fatalf("API keys: %v\n", err)
+	}
+	if err := api.LoadHMACKeys(opts.HMACKeys); err != nil {
+		log.Fatalf("HMAC keys: %v\n", err)
+	}
+	if opts.JWKSURL != "" {
+		jwt.DecisionTTL = opts.JWTCache
+		v, err := jwt.NewVerifier(opts.JWKSURL, opts.JWTIssuer, opts.JWTAud)
+		if err != nil {
+			log.Fatalf("JWT: %v\n", err)
+		}
+		api.JWTVerifier = v
+		api.AdminRole = opts.JWTAdmin
+	}
+
+	// Report optional features through /version
+	for f, on := range map[string]bool{
+		"admin_port":      opts.AdminAddr != "",
+		"api_keys":        opts.APIKeys != "",
+		"archive":         opts.ArchiveURI != "",
+		"audit":           opts.AuditSink != "",
+		"cache":           opts.CacheRedis != "" || opts.CacheMemory > 0,
+		"circuit_breaker": opts.BreakerThreshold > 0,
+		"cors":            opts.CORSOrigins != "",
+		"debug":           opts.DebugAddr != "",
+		"email":           opts.SMTPHost != "" && opts.SMTPAllowed != "",
+		"enrichment":      opts.ThingsURL != "",
+		"etags":           opts.ETags,
+		"hmac_signing":    opts.HMACKeys != "",
+		"ip_filter":       opts.AllowCIDRs != "" || opts.DenyCIDRs != "" || opts.IPRules != "",
+		"jwt":             opts.JWKSURL != "",
+		"long_polling":    opts.MaxWait > 0,
+		"nats":            opts.NatsHost != "",
+		"owner_scoping":   opts.OwnerScoping,
+		"quotas":          opts.EgressDailyBytes > 0 || opts.EgressDailyDocs > 0 || opts.EgressMonthlyBytes > 0 || opts.EgressMonthlyDocs > 0 || opts.EgressQuotas != "",
+		"rate_limit":      opts.RateLimit > 0 || opts.MaxInFlight > 0,
+		"redaction":       opts.Redact != "",
+		"registry":        opts.RegistryURL != "",
+		"require_auth":    opts.RequireAuth,
+		"retention":       opts.Retention > 0,
+		"rollups":         opts.RollupInterval > 0,
+		"s3":              opts.S3Endpoint != "",
+		"sentry":          opts.SentryDSN != "",
+		"slow_query_log":  opts.SlowQuery > 0,
+		"swagger_ui":      opts.SwaggerUI,
+		"tenants":         opts.TenantDatabases != "",
+		"unit_registry":   opts.UnitRegistry,
+		"webhook":         opts.WebhookAllowlist != "",
+	} {
+		if on {
+			version.Enable(f)
+		}
 	}
 
 	// Print banner
 	color.Cyan(banner)
 
-	// Serve HTTP
+	// Serve runtime diagnostics
+	if opts.DebugAddr != "" {
+		go func() {
+			log.Fatal(httpServer(opts.DebugAddr, api.DebugServer(), nil).ListenAndServe())
+		}()
+	}
+
+	// Serve operational endpoints apart from the public API
+	var admin *http.Server
+	if opts.AdminAddr != "" {
+		api.SeparateAdmin = true
+		admin = httpServer(opts.AdminAddr, api.AdminServer(), nil)
+		go func() {
+			if err := admin.ListenAndServe(); err != http.ErrServerClosed {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	// Serve HTTP, or HTTPS when a certificate is configured
 	httpHost := fmt.Sprintf("%s:%s", opts.HTTPHost, opts.HTTPPort)
-	http.ListenAndServe(httpHost, api.HTTPServer())
+	var srv *http.Server
+	if opts.ServerCert == "" {
+		srv = httpServer(httpHost, api.HTTPServer(), nil)
+	} else {
+		clientAuth, err := tlsutil.ParseClientAuth(opts.ClientAuth)
+		if err != nil {
+			log.Fatalf("TLS: %v\n", err)
+		}
+		tlsConfig, certs, err := tlsutil.Server(opts.ServerCert, opts.ServerKey, opts.ServerCA, clientAuth)
+		if err != nil {
+			log.Fatalf("TLS: %v\n", err)
+		}
+		if opts.CertReload > 0 {
+			go certs.Watch(opts.CertReload, nil)
+		}
+		srv = httpServer(httpHost, api.HTTPServer(), tlsConfig)
+	}
+
+	go func() {
+		var err error
+		if srv.TLSConfig == nil {
+			err = srv.ListenAndServe()
+		} else {
+			err = srv.ListenAndServeTLS("", "")
+		}
+		if err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Announce the reader to UIs
+	if opts.RegistryURL != "" {
+		r, err := registry.New(opts.RegistryURL, opts.RegistryToken, opts.RegistryInterval)
+		if err != nil {
+			log.Fatalf("Registry: %v\n", err)
+		}
+		s, err := registryService(srv.TLSConfig != nil)
+		if err != nil {
+			log.Fatalf("Registry: %v\n", err)
+		}
+		registry.Start(r, s, opts.RegistryInterval, func() bool {
+			return db.Connected() && !api.Draining()
+		})
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGTERM, syscall.SIGINT)
+	log.Printf("Received %s, shutting down", <-c)
+	shutdown(srv, admin)
+}
+
+// shutdown stops accepting connections and waits, up to the shutdown
+// timeout, for in-flight requests and streaming sessions to end before
+// stopping background jobs and closing MongoDB sessions. The admin server,
+// if any, keeps reporting the reader as not ready until then.
+func shutdown(srv, admin *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	defer cancel()
+
+	api.Drain()
+	registry.Stop()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("HTTP: Can't drain requests: %v\n", err)
+	}
+	if err := api.WaitStreams(ctx); err != nil {
+		log.Printf("HTTP: Can't drain streaming sessions: %v\n", err)
+	}
+
+	if admin != nil {
+		if err := admin.Shutdown(ctx); err != nil {
+			log.Printf("HTTP: Can't drain admin requests: %v\n", err)
+		}
+	}
+
+	stream.Stop()
+	retention.Stop()
+	rollup.Stop()
+	quota.Stop()
+	if err := export.Stop(ctx); err != nil {
+		log.Printf("Export: Can't finish running exports: %v\n", err)
+	}
+	audit.Stop()
+	db.Disconnect()
+	log.Print("Shut down")
+}
+
+// registryService returns the registration of the reader: its address,
+// the readiness endpoint the registry checks and, as metadata, its build
+// and the databases it serves channels from.
+func registryService(https bool) (registry.Service, error) {
+	addr := opts.RegistryAddress
+	if addr == "" {
+		h, err := os.Hostname()
+		if err != nil {
+			return registry.Service{}, err
+		}
+		addr = h
+	}
+	port, err := strconv.Atoi(opts.HTTPPort)
+	if err != nil {
+		return registry.Service{}, fmt.Errorf("invalid port %s", opts.HTTPPort)
+	}
+
+	scheme := "http"
+	if https {
+		scheme = "https"
+	}
+	health := fmt.Sprintf("%s://%s/ready", scheme, net.JoinHostPort(addr, opts.HTTPPort))
+	if opts.AdminAddr != "" {
+		// The admin server listens over plain HTTP, on the host of the
+		// reader unless bound to another interface
+		host, adminPort, err := net.SplitHostPort(opts.AdminAddr)
+		if err != nil {
+			return registry.Service{}, err
+		}
+		if host == "" || host == "0.0.0.0" || host == "::" || host == "localhost" || host == "127.0.0.1" {
+			host = addr
+		}
+		health = fmt.Sprintf("http://%s/ready", net.JoinHostPort(host, adminPort))
+	}
+
+	tags := []string{"v" + api.APIVersion}
+	for _, t := range strings.Split(opts.RegistryTags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+
+	v := version.Get()
+	return registry.Service{
+		ID:      fmt.Sprintf("%s-%s-%d", opts.RegistryName, addr, port),
+		Name:    opts.RegistryName,
+		Address: addr,
+		Port:    port,
+		Scheme:  scheme,
+		Tags:    tags,
+		Meta: map[string]string{
+			"version":       v.Version,
+			"api_version":   api.APIVersion,
+			"database":      opts.MongoDatabase,
+			"layout":        opts.Layout,
+			"tenants":       strings.Join(db.TenantNames(), ","),
+			"owner_scoping": strconv.FormatBool(opts.OwnerScoping),
+			"features":      strings.Join(v.Features, ","),
+		},
+		Health: health,
+	}, nil
+}
+
+// httpServer returns a server of h on addr, limited as configured so that
+// slow or idle clients can't hold connections forever.
+func httpServer(addr string, h http.Handler, tlsConfig *tls.Config) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+	}
+	if !opts.HTTP2 {
+		// A non-nil empty map turns off the HTTP/2 upgrade of TLS connections
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	return srv
+}
+
+// commaList returns the trimmed, non-empty items of a comma separated list
+func commaList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// dialInfo returns the connection settings of MongoDB, derived from the
+// host and port unless a connection string was given.
+func dialInfo() *mgo.DialInfo {
+	if mongoInfo == nil {
+		info, err := db.ParseURI("mongodb://" + net.JoinHostPort(opts.MongoHost, opts.MongoPort))
+		if err != nil {
+			log.Fatalf("MongoDB: %v\n", err)
+		}
+		mongoInfo = info
+	}
+	return mongoInfo
+}
+
+// handleHangup reloads the configuration file on every SIGHUP, or
+// switches debug logging on and off when there is none.
+func handleHangup() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	for range c {
+		if configPath() == "" {
+			log.Warnf("Log level set to %s", logging.ToggleDebug())
+			continue
+		}
+		if err := reload(); err != nil {
+			log.Errorf("Reload: %v, keeping the previous configuration", err)
+		}
+	}
 }
 
 var banner = `
@@ -0,0 +1,258 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package metrics keeps counters and histograms of the reader and serves
+// them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the default histogram buckets, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w io.Writer)
+}
+
+var (
+	mu         sync.Mutex
+	collectors = map[string]collector{}
+)
+
+func register(name string, c collector) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := collectors[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	collectors[name] = c
+}
+
+// Handler function serves all registered metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		names := make([]string, 0, len(collectors))
+		for name := range collectors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		cs := make([]collector, len(names))
+		for i, name := range names {
+			cs[i] = collectors[name]
+		}
+		mu.Unlock()
+
+		var buf bytes.Buffer
+		for _, c := range cs {
+			c.write(&buf)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	})
+}
+
+// series holds the values of a metric for one combination of labels.
+type series struct {
+	labels []string
+	value  float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type vec struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	labels []string
+	series map[string]*series
+}
+
+func (v *vec) get(values []string) *series {
+	if len(values) != len(v.labels) {
+		panic("metrics: wrong number of label values of " + v.name)
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labels: append([]string{}, values...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by their labels.
+func (v *vec) sorted() []*series {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ss := make([]*series, len(keys))
+	for i, k := range keys {
+		ss[i] = v.series[k]
+	}
+	return ss
+}
+
+func (v *vec) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, kind)
+}
+
+// CounterVec struct is a monotonically increasing value per label set
+type CounterVec struct {
+	vec
+}
+
+// NewCounterVec function registers a counter partitioned by labels
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec{name: name, help: help, labels: labels, series: map[string]*series{}}}
+	register(name, c)
+	return c
+}
+
+// Add function increases the counter of the label values by d
+func (c *CounterVec) Add(d float64, values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.get(values).value += d
+}
+
+// Inc function increases the counter of the label values by one
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(w, "counter")
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labelPairs(c.labels, s.labels, "", ""), formatFloat(s.value))
+	}
+}
+
+// HistogramVec struct counts observations in buckets per label set
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+// NewHistogramVec function registers a histogram partitioned by labels.
+// Buckets are upper bounds in increasing order.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{vec{name: name, help: help, labels: labels, series: map[string]*series{}}, buckets}
+	register(name, h)
+	return h
+}
+
+// Observe function records v for the label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.get(values)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w, "histogram")
+	for _, s := range h.sorted() {
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelPairs(h.labels, s.labels, "le", formatFloat(b)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelPairs(h.labels, s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labelPairs(h.labels, s.labels, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelPairs(h.labels, s.labels, "", ""), s.count)
+	}
+}
+
+// labelPairs formats labels as {name="value",...}, with an optional
+// extra label appended.
+func labelPairs(names, values []string, extraName, extraValue string) string {
+	pairs := []string{}
+	for i, n := range names {
+		pairs = append(pairs, n+`="`+escape(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// funcMetric reports the value returned by a function at scrape time
+type funcMetric struct {
+	name string
+	help string
+	kind string
+	f    func() float64
+}
+
+// NewGaugeFunc function registers a gauge whose value is read from f
+func NewGaugeFunc(name, help string, f func() float64) {
+	register(name, &funcMetric{name, help, "gauge", f})
+}
+
+// NewCounterFunc function registers a counter whose value is read from f,
+// which must never decrease
+func NewCounterFunc(name, help string, f func() float64) {
+	register(name, &funcMetric{name, help, "counter", f})
+}
+
+func (m *funcMetric) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", m.name, m.help, m.name, m.kind, m.name, formatFloat(m.f()))
+}
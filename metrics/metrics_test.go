@@ -0,0 +1,63 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	c := NewCounterVec("test_requests_total", "Requests.", "method", "path")
+	c.Inc("GET", "/a")
+	c.Add(2, "GET", `/"b"`)
+
+	h := NewHistogramVec("test_duration_seconds", "Durations.", []float64{0.1, 1}, "op")
+	h.Observe(0.05, "find")
+	h.Observe(0.5, "find")
+
+	NewGaugeFunc("test_in_use", "In use.", func() float64 { return 3 })
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	expected := `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{op="find",le="0.1"} 1
+test_duration_seconds_bucket{op="find",le="1"} 2
+test_duration_seconds_bucket{op="find",le="+Inf"} 2
+test_duration_seconds_sum{op="find"} 0.55
+test_duration_seconds_count{op="find"} 2
+# HELP test_in_use In use.
+# TYPE test_in_use gauge
+test_in_use 3
+# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",path="/\"b\""} 2
+test_requests_total{method="GET",path="/a"} 1
+`
+	if body := rec.Body.String(); !strings.Contains(body, expected) {
+		t.Errorf("expected\n%s\ngot\n%s", expected, body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %s", ct)
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	for _, name := range []string{"go_goroutines", "go_memstats_alloc_bytes", "go_gc_cycles_total"} {
+		if !strings.Contains(rec.Body.String(), "\n"+name+" ") {
+			t.Errorf("expected metric %s in\n%s", name, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// How long memory statistics are reused, since reading them stops the world.
+const memStatsTTL = time.Second
+
+var (
+	memMu   sync.Mutex
+	mem     runtime.MemStats
+	memRead time.Time
+)
+
+func init() {
+	NewGaugeFunc("go_goroutines", "Number of goroutines.",
+		func() float64 { return float64(runtime.NumGoroutine()) })
+
+	stat := func(f func(*runtime.MemStats) uint64) func() float64 {
+		return func() float64 { return float64(f(memStats())) }
+	}
+
+	NewGaugeFunc("go_memstats_alloc_bytes", "Bytes of allocated heap objects.",
+		stat(func(m *runtime.MemStats) uint64 { return m.Alloc }))
+	NewGaugeFunc("go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.",
+		stat(func(m *runtime.MemStats) uint64 { return m.HeapInuse }))
+	NewGaugeFunc("go_memstats_heap_objects", "Number of allocated heap objects.",
+		stat(func(m *runtime.MemStats) uint64 { return m.HeapObjects }))
+	NewGaugeFunc("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.",
+		stat(func(m *runtime.MemStats) uint64 { return m.Sys }))
+	NewGaugeFunc("go_memstats_next_gc_bytes", "Heap size targeted by the next garbage collection.",
+		stat(func(m *runtime.MemStats) uint64 { return m.NextGC }))
+	NewCounterFunc("go_gc_cycles_total", "Completed garbage collection cycles.",
+		stat(func(m *runtime.MemStats) uint64 { return uint64(m.NumGC) }))
+	NewCounterFunc("go_gc_pause_seconds_total", "Time the program was stopped by garbage collection.",
+		func() float64 { return float64(memStats().PauseTotalNs) / float64(time.Second) })
+}
+
+// memStats returns runtime memory statistics at most memStatsTTL old
+func memStats() *runtime.MemStats {
+	memMu.Lock()
+	defer memMu.Unlock()
+
+	if time.Since(memRead) > memStatsTTL {
+		runtime.ReadMemStats(&mem)
+		memRead = time.Now()
+	}
+	m := mem
+	return &m
+}
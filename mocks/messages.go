@@ -0,0 +1,278 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package mocks provides in-memory implementations of the interfaces of
+// the reader, so that tests of the services embedding it, and its own
+// transport tests, need no MongoDB server.
+package mocks
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+var _ repository.MessageRepository = (*MessageRepository)(nil)
+
+// MessageRepository struct keeps messages in memory and reads them with
+// the semantics of the MongoDB repository. It is safe for concurrent use.
+type MessageRepository struct {
+	mu       sync.RWMutex
+	channels map[string]bool
+	msgs     []models.Message
+}
+
+// NewMessageRepository function returns an empty repository
+func NewMessageRepository() *MessageRepository {
+	return &MessageRepository{channels: map[string]bool{}}
+}
+
+// CreateChannels method creates channels holding no message yet
+func (r *MessageRepository) CreateChannels(ids ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		r.channels[id] = true
+	}
+}
+
+// Save method stores msgs, creating their channels if needed
+func (r *MessageRepository) Save(msgs ...models.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range msgs {
+		r.channels[m.Channel] = true
+		r.msgs = append(r.msgs, m)
+	}
+}
+
+// Messages method returns the messages q selects, in time order and then
+// in the order they were saved
+func (r *MessageRepository) Messages(ctx context.Context, q repository.Query) ([]models.Message, error) {
+	msgs, err := r.distinct(q)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Stable(byTime(msgs))
+	if q.Offset >= len(msgs) {
+		return []models.Message{}, nil
+	}
+	msgs = msgs[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(msgs) {
+		msgs = msgs[:q.Limit]
+	}
+	return msgs, nil
+}
+
+// Iter method returns an iterator over the messages q selects, in time
+// order if q has an offset or limit, and otherwise in the order they
+// were saved
+func (r *MessageRepository) Iter(ctx context.Context, q repository.Query) (repository.Iterator, error) {
+	if q.Offset > 0 || q.Limit > 0 {
+		msgs, err := r.Messages(ctx, q)
+		return &iterator{msgs: msgs}, err
+	}
+	msgs, err := r.distinct(q)
+	return &iterator{msgs: msgs}, err
+}
+
+// Count method returns the number of messages q selects
+func (r *MessageRepository) Count(ctx context.Context, q repository.Query) (int, error) {
+	msgs, err := r.distinct(q)
+	return len(msgs), err
+}
+
+// Latest method returns the latest message of every name of channel, the
+// last saved of those stored at the same time
+func (r *MessageRepository) Latest(ctx context.Context, channel, name string) ([]models.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	latest := map[string]models.Message{}
+	for _, m := range r.msgs {
+		if m.Channel != channel || (name != "" && m.Name != name) {
+			continue
+		}
+		if prev, ok := latest[m.Name]; !ok || m.Time >= prev.Time {
+			latest[m.Name] = m
+		}
+	}
+
+	names := []string{}
+	for n := range latest {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	msgs := []models.Message{}
+	for _, n := range names {
+		msgs = append(msgs, latest[n])
+	}
+	return msgs, nil
+}
+
+// Aggregate method groups the messages with a numeric value q selects in
+// buckets of interval seconds
+func (r *MessageRepository) Aggregate(ctx context.Context, q repository.Query, interval float64) ([]repository.Bucket, error) {
+	msgs, err := r.selected(q)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := map[float64]*repository.Bucket{}
+	for _, m := range msgs {
+		if m.Value == nil {
+			continue
+		}
+		v := *m.Value
+		t := m.Time - math.Mod(m.Time, interval)
+		b, ok := buckets[t]
+		if !ok {
+			buckets[t] = &repository.Bucket{Time: t, Count: 1, Sum: v, Min: v, Max: v}
+			continue
+		}
+		b.Count++
+		b.Sum += v
+		b.Min = math.Min(b.Min, v)
+		b.Max = math.Max(b.Max, v)
+	}
+
+	res := []repository.Bucket{}
+	for _, b := range buckets {
+		res = append(res, *b)
+	}
+	sort.Sort(bucketsByTime(res))
+	return res, nil
+}
+
+// Stats method returns the number and time span of the messages of
+// channel
+func (r *MessageRepository) Stats(ctx context.Context, channel string) (repository.Stats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.channels[channel] {
+		return repository.Stats{}, repository.ErrChannelNotFound
+	}
+
+	s := repository.Stats{}
+	for _, m := range r.msgs {
+		if m.Channel != channel {
+			continue
+		}
+		if s.Count == 0 || m.Time < s.Oldest {
+			s.Oldest = m.Time
+		}
+		if s.Count == 0 || m.Time > s.Newest {
+			s.Newest = m.Time
+		}
+		s.Count++
+	}
+	return s, nil
+}
+
+// selected returns the messages q selects, regardless of its offset and
+// limit, in the order they were saved
+func (r *MessageRepository) selected(q repository.Query) ([]models.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.channels[q.Channel] {
+		return nil, repository.ErrChannelNotFound
+	}
+
+	msgs := []models.Message{}
+	for _, m := range r.msgs {
+		if m.Channel != q.Channel || m.Time <= q.Start || m.Time >= q.End {
+			continue
+		}
+		if q.Name != "" && m.Name != q.Name {
+			continue
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+// distinct returns the messages q selects, collapsing those agreeing on
+// its distinct fields into the first saved
+func (r *MessageRepository) distinct(q repository.Query) ([]models.Message, error) {
+	msgs, err := r.selected(q)
+	if err != nil || len(q.Distinct) == 0 {
+		return msgs, err
+	}
+
+	seen := map[string]bool{}
+	res := []models.Message{}
+	for _, m := range msgs {
+		b, err := bson.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		doc := bson.M{}
+		if err := bson.Unmarshal(b, doc); err != nil {
+			return nil, err
+		}
+		id := bson.D{}
+		for _, k := range q.Distinct {
+			id = append(id, bson.DocElem{Name: k, Value: doc[k]})
+		}
+		if b, err = bson.Marshal(id); err != nil {
+			return nil, err
+		}
+		if !seen[string(b)] {
+			seen[string(b)] = true
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+// iterator struct walks messages read in memory, encoding each to BSON
+// as the MongoDB repository reads them
+type iterator struct {
+	msgs []models.Message
+	err  error
+}
+
+func (it *iterator) Next(result interface{}) bool {
+	if it.err != nil || len(it.msgs) == 0 {
+		return false
+	}
+	b, err := bson.Marshal(it.msgs[0])
+	if err == nil {
+		err = bson.Unmarshal(b, result)
+	}
+	it.msgs, it.err = it.msgs[1:], err
+	return err == nil
+}
+
+func (it *iterator) Close() error {
+	return it.err
+}
+
+type byTime []models.Message
+
+func (s byTime) Len() int           { return len(s) }
+func (s byTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byTime) Less(i, j int) bool { return s[i].Time < s[j].Time }
+
+type bucketsByTime []repository.Bucket
+
+func (s bucketsByTime) Len() int           { return len(s) }
+func (s bucketsByTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s bucketsByTime) Less(i, j int) bool { return s[i].Time < s[j].Time }
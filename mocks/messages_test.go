@@ -0,0 +1,26 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package mocks
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+	"github.com/mainflux/mainflux-mongodb-reader/repository/repositorytest"
+)
+
+func TestMessageRepository(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T, channels []string, msgs []models.Message) repository.MessageRepository {
+		r := NewMessageRepository()
+		r.CreateChannels(channels...)
+		r.Save(msgs...)
+		return r
+	})
+}
@@ -0,0 +1,348 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package models
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Kinds of the values of message fields
+const (
+	kindString = iota
+	kindFloat
+	kindFloatPtr
+	kindInt
+	kindBoolPtr
+	kindBytes
+)
+
+// BSON element types read by the transcoder
+const (
+	bsonDouble = 0x01
+	bsonString = 0x02
+	bsonBinary = 0x05
+	bsonBool   = 0x08
+	bsonNull   = 0x0A
+	bsonInt32  = 0x10
+	bsonInt64  = 0x12
+)
+
+type messageField struct {
+	bson string
+	json string
+	kind int
+	omit bool
+}
+
+// messageFields lists the fields of Message in JSON encoding order, with
+// their default BSON names.
+var messageFields = [...]messageField{
+	{"xmlname", `"_":`, kindBoolPtr, true},
+	{"basename", `"bn":`, kindString, true},
+	{"basetime", `"bt":`, kindFloat, true},
+	{"baseunit", `"bu":`, kindString, true},
+	{"baseversion", `"bver":`, kindInt, true},
+	{"link", `"l":`, kindString, true},
+	{"name", `"n":`, kindString, true},
+	{"unit", `"u":`, kindString, true},
+	{"time", `"t":`, kindFloat, true},
+	{"updatetime", `"ut":`, kindFloat, true},
+	{"value", `"v":`, kindFloatPtr, true},
+	{"stringvalue", `"vs":`, kindString, true},
+	{"datavalue", `"vd":`, kindString, true},
+	{"boolvalue", `"vb":`, kindBoolPtr, true},
+	{"sum", `"s":`, kindFloatPtr, true},
+	{"publisher", `"publisher":`, kindString, false},
+	{"protocol", `"protocol":`, kindString, false},
+	{"created", `"created":`, kindString, false},
+	{"contenttype", `"content_type":`, kindString, false},
+	{"channel", `"channel":`, kindString, false},
+	{"payload", `"payload":`, kindBytes, true},
+}
+
+// element locates the value of a message field in a BSON document
+type element struct {
+	typ   byte
+	value []byte
+}
+
+// AppendMessageJSON function appends to dst the JSON encoding of the
+// message stored as the BSON document doc, as json.Marshal would encode
+// it once decoded into a Message, without decoding it. It reports false,
+// leaving the decoding to the caller, for documents holding fields of
+// unexpected types or values JSON can't represent.
+func AppendMessageJSON(dst []byte, doc []byte) ([]byte, bool) {
+	var elems [len(messageFields)]element
+	if !scan(doc, &elems) {
+		return dst, false
+	}
+
+	start := len(dst)
+	dst = append(dst, '{')
+	sep := false
+	for i := range messageFields {
+		f, e := &messageFields[i], elems[i]
+		if e.typ == 0 || e.typ == bsonNull {
+			if f.omit {
+				continue
+			}
+			e = element{bsonString, nil}
+		}
+
+		if f.omit && empty(f.kind, e) {
+			continue
+		}
+		if sep {
+			dst = append(dst, ',')
+		}
+		sep = true
+		dst = append(dst, f.json...)
+
+		var ok bool
+		if dst, ok = appendValue(dst, f.kind, e); !ok {
+			return dst[:start], false
+		}
+	}
+
+	return append(dst, '}'), true
+}
+
+// scan finds the elements of doc holding message fields
+func scan(doc []byte, elems *[len(messageFields)]element) bool {
+	if len(doc) < 5 || int(binary.LittleEndian.Uint32(doc)) != len(doc) || doc[len(doc)-1] != 0 {
+		return false
+	}
+
+	for i := 4; i < len(doc)-1; {
+		typ := doc[i]
+		i++
+		k := i
+		for k < len(doc) && doc[k] != 0 {
+			k++
+		}
+		if k >= len(doc) {
+			return false
+		}
+		key := doc[i:k]
+		i = k + 1
+
+		n := size(typ, doc[i:])
+		if n < 0 || i+n > len(doc)-1 {
+			return false
+		}
+		for j := range messageFields {
+			if string(key) == messageFields[j].bson {
+				elems[j] = element{typ, doc[i : i+n]}
+				break
+			}
+		}
+		i += n
+	}
+
+	return true
+}
+
+// size returns the length of a value of BSON type typ at the start of b,
+// or -1 if unknown
+func size(typ byte, b []byte) int {
+	switch typ {
+	case 0x06, bsonNull, 0x7F, 0xFF:
+		return 0
+	case bsonBool:
+		return 1
+	case bsonInt32:
+		return 4
+	case bsonDouble, 0x09, 0x11, bsonInt64:
+		return 8
+	case 0x07:
+		return 12
+	case 0x13:
+		return 16
+	}
+
+	if len(b) < 4 {
+		return -1
+	}
+	n := int(int32(binary.LittleEndian.Uint32(b)))
+	if n < 0 {
+		return -1
+	}
+	switch typ {
+	case bsonString, 0x0D, 0x0E:
+		return 4 + n
+	case 0x03, 0x04, 0x0F:
+		return n
+	case bsonBinary:
+		return 5 + n
+	case 0x0C:
+		return 4 + n + 12
+	}
+	return -1
+}
+
+// empty reports whether e holds the zero value of kind
+func empty(kind int, e element) bool {
+	switch kind {
+	case kindString:
+		return e.typ == bsonString && len(e.value) == 5
+	case kindBytes:
+		return e.typ == bsonBinary && len(e.value) == 5
+	case kindFloat, kindInt:
+		f, ok := number(e)
+		return ok && f == 0
+	}
+	return false
+}
+
+// appendValue appends the JSON encoding of e as a value of kind
+func appendValue(dst []byte, kind int, e element) ([]byte, bool) {
+	switch kind {
+	case kindString:
+		if e.typ != bsonString {
+			return dst, false
+		}
+		if e.value == nil {
+			return append(dst, `""`...), true
+		}
+		if len(e.value) < 5 {
+			return dst, false
+		}
+		return appendString(dst, e.value[4:len(e.value)-1]), true
+	case kindFloat, kindFloatPtr:
+		f, ok := number(e)
+		if !ok || math.IsInf(f, 0) || math.IsNaN(f) {
+			return dst, false
+		}
+		return appendFloat(dst, f), true
+	case kindInt:
+		switch e.typ {
+		case bsonInt32:
+			return strconv.AppendInt(dst, int64(int32(binary.LittleEndian.Uint32(e.value))), 10), true
+		case bsonInt64:
+			return strconv.AppendInt(dst, int64(binary.LittleEndian.Uint64(e.value)), 10), true
+		}
+		return dst, false
+	case kindBoolPtr:
+		if e.typ != bsonBool {
+			return dst, false
+		}
+		return strconv.AppendBool(dst, e.value[0] != 0), true
+	case kindBytes:
+		if e.typ != bsonBinary || e.value[4] != 0 {
+			return dst, false
+		}
+		return appendBase64(dst, e.value[5:]), true
+	}
+	return dst, false
+}
+
+// number returns the numeric value of e as a float64
+func number(e element) (float64, bool) {
+	switch e.typ {
+	case bsonDouble:
+		return math.Float64frombits(binary.LittleEndian.Uint64(e.value)), true
+	case bsonInt32:
+		return float64(int32(binary.LittleEndian.Uint32(e.value))), true
+	case bsonInt64:
+		return float64(int64(binary.LittleEndian.Uint64(e.value))), true
+	}
+	return 0, false
+}
+
+// appendFloat formats f as encoding/json does
+func appendFloat(dst []byte, f float64) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+const hex = "0123456789abcdef"
+
+// appendString quotes s as encoding/json does, escaping HTML characters
+func appendString(dst []byte, s []byte) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		c, n := utf8.DecodeRune(s[i:])
+		if c == utf8.RuneError && n == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += n
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[c&0xF])
+			i += n
+			start = i
+			continue
+		}
+		i += n
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendBase64 appends b in standard base64 within quotes
+func appendBase64(dst []byte, b []byte) []byte {
+	n := base64.StdEncoding.EncodedLen(len(b))
+	dst = append(dst, '"')
+	if cap(dst)-len(dst) < n+1 {
+		grown := make([]byte, len(dst), 2*cap(dst)+n+1)
+		copy(grown, dst)
+		dst = grown
+	}
+	base64.StdEncoding.Encode(dst[len(dst):len(dst)+n], b)
+	dst = dst[:len(dst)+n]
+	return append(dst, '"')
+}
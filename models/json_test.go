@@ -0,0 +1,126 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func float(f float64) *float64 { return &f }
+
+func boolean(b bool) *bool { return &b }
+
+var sample = models.Message{
+	BaseName:    "urn:dev:ow:10e2073a01080063:",
+	Name:        "temperature",
+	Unit:        "Cel",
+	Time:        1276020076.25,
+	Value:       float(23.5),
+	Publisher:   "6c9c6f1e-0f2a-4c0a-9c6d-3a3f1c0f3b52",
+	Protocol:    "mqtt",
+	Created:     "2018-01-10T14:22:01Z",
+	ContentType: "application/senml+json",
+	Channel:     "1",
+}
+
+func TestAppendMessageJSON(t *testing.T) {
+	cases := []interface{}{
+		sample,
+		models.Message{},
+		models.Message{Name: `<a href="x">&</a>`, StringValue: "tab\tquote\"\\ \x01   é \xff"},
+		models.Message{Time: 1e21, UpdateTime: 1e-7, BaseTime: -0.000001, Sum: float(0), BoolValue: boolean(false)},
+		models.Message{BaseVersion: 10, Payload: []byte("binary\x00payload"), DataValue: "ZGF0YQ=="},
+		bson.M{"name": "n", "time": 12, "value": int64(7), "basetime": nil, "_id": bson.NewObjectId(),
+			"extra": bson.M{"nested": []interface{}{1, "a"}}},
+	}
+
+	for i, c := range cases {
+		doc, err := bson.Marshal(c)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		var m models.Message
+		if err := bson.Unmarshal(doc, &m); err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		expected, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+
+		res, ok := models.AppendMessageJSON([]byte("prefix"), doc)
+		if !ok {
+			t.Errorf("case %d: unexpected fallback", i+1)
+			continue
+		}
+		if string(res) != "prefix"+string(expected) {
+			t.Errorf("case %d: expected %s got %s", i+1, expected, res[len("prefix"):])
+		}
+	}
+}
+
+func TestAppendMessageJSONFallback(t *testing.T) {
+	cases := []interface{}{
+		bson.M{"time": "yesterday"},
+		bson.M{"baseversion": 1.5},
+		bson.M{"value": bson.M{"v": 1}},
+	}
+
+	for i, c := range cases {
+		doc, err := bson.Marshal(c)
+		if err != nil {
+			t.Fatalf("case %d: %s", i+1, err.Error())
+		}
+		if res, ok := models.AppendMessageJSON([]byte("prefix"), doc); ok || string(res) != "prefix" {
+			t.Errorf("case %d: expected fallback got %s", i+1, res)
+		}
+	}
+
+	if _, ok := models.AppendMessageJSON(nil, []byte{5, 0, 0}); ok {
+		t.Error("expected fallback for a truncated document")
+	}
+}
+
+func BenchmarkAppendMessageJSON(b *testing.B) {
+	doc, err := bson.Marshal(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := make([]byte, 0, 1024)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf, _ = models.AppendMessageJSON(buf[:0], doc)
+	}
+}
+
+func BenchmarkDecodeMarshal(b *testing.B) {
+	doc, err := bson.Marshal(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var m models.Message
+		if err := bson.Unmarshal(doc, &m); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := json.Marshal(m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -8,7 +8,9 @@
 
 package models
 
-import ()
+import (
+	"gopkg.in/mgo.v2/bson"
+)
 
 type (
 	// Message struct - Mainflux message that flows on the channel.
@@ -81,4 +83,11 @@ type (
 		// Blob
 		Payload []byte `json:"payload,omitempty"`
 	}
+
+	// StoredMessage is a Message together with the ObjectId assigned to it
+	// on insert, which orders messages by arrival.
+	StoredMessage struct {
+		ID      bson.ObjectId `bson:"_id" json:"-"`
+		Message `bson:",inline"`
+	}
 )
@@ -0,0 +1,295 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package client reads messages from a Mainflux MongoDB reader over its
+// HTTP API. It builds the queries from typed filters, iterates over paged
+// reads, follows live streams and retries requests failing for a
+// transient reason.
+//
+// The reader serves no gRPC API, so the client speaks HTTP only.
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request headers of the credentials of the reader
+const (
+	APIKeyHeader             = "X-API-Key"
+	SignatureKeyHeader       = "X-MF-Key-ID"
+	SignatureTimestampHeader = "X-MF-Timestamp"
+	SignatureHeader          = "X-MF-Signature"
+	DefaultTenantHeader      = "X-Tenant-ID"
+)
+
+// Path prefix of the version of the API the client speaks
+const apiPrefix = "/v1"
+
+// Config struct holds the address of a reader and the credentials of the
+// client. Zero values select the defaults.
+type Config struct {
+	// URL is the address of the reader, e.g. http://localhost:7071.
+	URL string
+	// Token is a JWT or the admin token, sent as a bearer token.
+	Token string
+	// APIKey is an API key.
+	APIKey string
+	// KeyID and Secret are the HMAC key signing requests.
+	KeyID  string
+	Secret string
+	// Tenant selects the tenant database, through TenantHeader.
+	Tenant       string
+	TenantHeader string
+	// TLS configures HTTPS connections, e.g. with a client certificate.
+	TLS *tls.Config
+	// Timeout limits every request but streams, 30 seconds by default.
+	Timeout time.Duration
+	// Attempts is the number of times a request failing for a transient
+	// reason is sent, 3 by default.
+	Attempts int
+	// Backoff is the base delay between attempts, doubled after each of
+	// them and randomized over [0, delay), 100ms by default.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between attempts, 5 seconds by default.
+	MaxBackoff time.Duration
+	// HTTPClient sends the requests, one with a transport using TLS by
+	// default.
+	HTTPClient *http.Client
+}
+
+// Client struct reads from a reader. It is safe for concurrent use.
+type Client struct {
+	cfg  Config
+	http *http.Client
+	base string
+}
+
+// Error struct is the error a reader answered a request with
+type Error struct {
+	Status    int                    `json:"-"`
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.RequestID != "" {
+		msg += ", request id " + e.RequestID
+	}
+	return msg
+}
+
+// New function returns the client of the reader of cfg
+func New(cfg Config) (*Client, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid reader address %q", cfg.URL)
+	}
+	if (cfg.KeyID == "") != (cfg.Secret == "") {
+		return nil, errors.New("signing requests needs both a key id and a secret")
+	}
+
+	if cfg.TenantHeader == "" {
+		cfg.TenantHeader = DefaultTenantHeader
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 3
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Second
+	}
+
+	hc := cfg.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     cfg.TLS,
+			MaxIdleConnsPerHost: 16,
+		}}
+	}
+
+	return &Client{
+		cfg:  cfg,
+		http: hc,
+		base: strings.TrimRight(cfg.URL, "/") + apiPrefix,
+	}, nil
+}
+
+// get reads the response to a GET of path with the query q into v
+func (c *Client) get(ctx context.Context, path string, q url.Values, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	res, err := c.send(ctx, path, q, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// send sends a GET of path with the query q and header h, retrying it
+// while it fails for a transient reason, and returns its successful
+// response
+func (c *Client) send(ctx context.Context, path string, q url.Values, h http.Header) (*http.Response, error) {
+	u := c.base + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	delay := c.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range h {
+			req.Header[k] = v
+		}
+		c.authenticate(req)
+
+		res, err := c.http.Do(req.WithContext(ctx))
+		if err == nil && res.StatusCode < 300 {
+			return res, nil
+		}
+
+		var wait time.Duration
+		if err == nil {
+			err = responseError(res)
+			wait = retryAfter(res)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= c.cfg.Attempts || !Transient(err) {
+			return nil, err
+		}
+
+		if d := time.Duration(rand.Int63n(int64(delay))); d > wait {
+			wait = d
+		}
+		if delay *= 2; delay > c.cfg.MaxBackoff {
+			delay = c.cfg.MaxBackoff
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// authenticate sets the credentials of the client on req
+func (c *Client) authenticate(req *http.Request) {
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+	if c.cfg.APIKey != "" {
+		req.Header.Set(APIKeyHeader, c.cfg.APIKey)
+	}
+	if c.cfg.Tenant != "" {
+		req.Header.Set(c.cfg.TenantHeader, c.cfg.Tenant)
+	}
+	if c.cfg.KeyID != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(SignatureKeyHeader, c.cfg.KeyID)
+		req.Header.Set(SignatureTimestampHeader, ts)
+		req.Header.Set(SignatureHeader, Sign(c.cfg.Secret, req.Method, req.URL.RequestURI(), ts, nil))
+	}
+}
+
+// Sign function returns the signature the reader expects of a request
+// signed with secret at the UNIX time timestamp
+func Sign(secret, method, path, timestamp string, body []byte) string {
+	sum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	io.WriteString(mac, method+"\n"+path+"\n"+timestamp+"\n"+hex.EncodeToString(sum[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Transient function reports whether a request failing with err may
+// succeed if sent again: the reader was unreachable, overloaded, rate
+// limiting the client or unavailable
+func Transient(err error) bool {
+	if err == nil || err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		return true
+	}
+	switch e.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// responseError function returns the error of the failed response res,
+// whose body it closes
+func responseError(res *http.Response) error {
+	defer res.Body.Close()
+
+	e := &Error{Status: res.StatusCode}
+	b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err := json.Unmarshal(b, e); err != nil || e.Message == "" {
+		e.Code, e.Message = "", strings.TrimSpace(string(b))
+		if e.Message == "" {
+			e.Message = http.StatusText(res.StatusCode)
+		}
+	}
+	return e
+}
+
+// retryAfter function returns the delay the Retry-After header of res
+// asks for, zero if none
+func retryAfter(res *http.Response) time.Duration {
+	s, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || s < 0 {
+		return 0
+	}
+	return time.Duration(s) * time.Second
+}
+
+// sleep function waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,152 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) (*Client, func()) {
+	ts := httptest.NewServer(h)
+	c, err := New(Config{URL: ts.URL, APIKey: "key", Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, ts.Close
+}
+
+func TestRetries(t *testing.T) {
+	var calls int32
+	c, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"code":"backend_unavailable","message":"database unavailable"}`)
+			return
+		}
+		fmt.Fprint(w, `{"channel":"c1","count":2}`)
+	})
+	defer done()
+
+	s, err := c.Stats(context.Background(), "c1", TimeRange{})
+	if err != nil || s.Count != 2 || calls != 3 {
+		t.Fatalf("expected success on the third attempt got %v, %v after %d", s, err, calls)
+	}
+
+	atomic.StoreInt32(&calls, -10)
+	_, err = c.Stats(context.Background(), "c1", TimeRange{})
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusServiceUnavailable || e.Code != "backend_unavailable" || calls != -7 {
+		t.Errorf("expected the error after 3 attempts got %v after %d", err, calls+10)
+	}
+}
+
+func TestNoRetry(t *testing.T) {
+	var calls int32
+	c, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get(APIKeyHeader) != "key" {
+			t.Errorf("expected the API key to be sent")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":"invalid_filter","message":"invalid query parameters","request_id":"r1"}`)
+	})
+	defer done()
+
+	_, err := c.Messages(context.Background(), "c1", Filter{Limit: -1})
+	expected := "400 Bad Request: invalid query parameters (invalid_filter), request id r1"
+	if err == nil || err.Error() != expected || calls != 1 {
+		t.Errorf("expected %q without retry got %v after %d", expected, err, calls)
+	}
+}
+
+func TestIterate(t *testing.T) {
+	c, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("limit") != "2" || q.Get("start_time") != "1500000000.5" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		switch q.Get("cursor") {
+		case "":
+			fmt.Fprint(w, `{"messages":[{"n":"a"},{"n":"b"}],"meta":{"has_more":true,"next_cursor":"c2"}}`)
+		case "c2":
+			fmt.Fprint(w, `{"messages":[{"n":"c"}],"meta":{"has_more":false}}`)
+		default:
+			t.Errorf("unexpected cursor %s", q.Get("cursor"))
+		}
+	})
+	defer done()
+
+	f := Filter{TimeRange: TimeRange{Start: time.Unix(1500000000, 5e8)}, Limit: 2}
+	it := c.Iterate(context.Background(), "c1", f)
+	names := ""
+	for it.Next() {
+		names += it.Message().Name
+	}
+	if it.Err() != nil || names != "abc" {
+		t.Errorf("expected messages abc got %s, %v", names, it.Err())
+	}
+}
+
+func TestQuality(t *testing.T) {
+	c, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/channels/c1/quality" || r.URL.RawQuery != "interval=60&max=100&name=temperature" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		fmt.Fprint(w, `{"channel":"c1","expected":10,"actual":7,"gap_count":1,"gaps":[{"start":1500000180,"end":1500000420,"duration":240,"missing":3}]}`)
+	})
+	defer done()
+
+	max := 100.0
+	q, err := c.Quality(context.Background(), "c1", QualityFilter{Interval: time.Minute, Name: "temperature", Max: &max})
+	if err != nil || q.Actual != 7 || len(q.Gaps) != 1 || q.Gaps[0].Missing != 3 {
+		t.Errorf("expected report of 7 reports and a gap got %+v, %v", q, err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	var conns int32
+	c, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&conns, 1) {
+		case 1:
+			fmt.Fprint(w, ": comment\n\nid: 1\nevent: message\ndata: {\"n\":\"a\"}\n\n")
+		default:
+			if id := r.Header.Get("Last-Event-ID"); id != "1" {
+				t.Errorf("expected the stream to resume after 1 got %q", id)
+			}
+			fmt.Fprint(w, "id: 2\nevent: message\ndata: {\"n\":\"b\"}\n\n")
+		}
+	})
+	defer done()
+
+	stop := errors.New("stop")
+	names := ""
+	err := c.Stream(context.Background(), "c1", StreamFilter{}, func(e Event) error {
+		names += e.ID + e.Message.Name
+		if e.ID == "2" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || names != "1a2b" {
+		t.Errorf("expected events 1a2b and the handler error got %s, %v", names, err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	expected := "a4f60301f57f4df4ed16ab9cfbd05d481a45f8eaebdb8cce96e415a2764651a8"
+	if s := Sign("secret", "GET", "/v1/channels/c1/messages?meta=true", "1500000000", nil); s != expected {
+		t.Errorf("expected the signature of the reader got %s", s)
+	}
+}
@@ -0,0 +1,171 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Function names an aggregation function
+type Function string
+
+// Aggregation functions
+const (
+	Avg   Function = "avg"
+	Min   Function = "min"
+	Max   Function = "max"
+	Sum   Function = "sum"
+	Count Function = "count"
+)
+
+// CountMode tells how a message read counts the messages it matches
+type CountMode string
+
+// Counting modes of message reads
+const (
+	CountExact    CountMode = "exact"
+	CountEstimate CountMode = "estimate"
+)
+
+// TimeRange struct selects the messages stored between Start and End.
+// Zero times leave the range open.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Filter struct selects a page of the messages of a channel
+type Filter struct {
+	TimeRange
+	// Limit is the largest number of messages returned, all if zero.
+	Limit int
+	// Offset is the number of messages skipped.
+	Offset int
+	// Cursor is the position of the page, from Page.NextCursor. It takes
+	// precedence over Offset.
+	Cursor string
+	// Count asks for the total number of matching messages.
+	Count CountMode
+	// DistinctOn collapses messages agreeing on these fields, which must
+	// include "time", into the first stored.
+	DistinctOn []string
+}
+
+// AggregateFilter struct selects the messages aggregated and the buckets
+// they are aggregated in
+type AggregateFilter struct {
+	TimeRange
+	// Name aggregates only the messages of this name.
+	Name string
+	// Fn is the aggregation function, Avg by default.
+	Fn Function
+	// Interval is the width of the buckets, an hour by default.
+	Interval time.Duration
+}
+
+// QualityFilter struct selects the messages of a quality report, over
+// the last day if Start is zero
+type QualityFilter struct {
+	TimeRange
+	// Interval is the declared reporting interval, required.
+	Interval time.Duration
+	// Name reports only on the messages of this name.
+	Name string
+	// Gaps is the number of longest gaps listed, 10 if zero.
+	Gaps int
+	// Min and Max bound the range of values, instead of the one the
+	// unit registry gives their name.
+	Min *float64
+	Max *float64
+}
+
+// StreamFilter struct selects the messages of a stream
+type StreamFilter struct {
+	TimeRange
+	// LastEventID resumes the stream after the message of this id.
+	LastEventID string
+}
+
+func (r TimeRange) query() url.Values {
+	q := url.Values{}
+	if !r.Start.IsZero() {
+		q.Set("start_time", unixTime(r.Start))
+	}
+	if !r.End.IsZero() {
+		q.Set("end_time", unixTime(r.End))
+	}
+	return q
+}
+
+func (f Filter) query() url.Values {
+	q := f.TimeRange.query()
+	q.Set("meta", "true")
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Cursor != "" {
+		q.Set("cursor", f.Cursor)
+	} else if f.Offset > 0 {
+		q.Set("offset", strconv.Itoa(f.Offset))
+	}
+	if f.Count != "" {
+		q.Set("count", string(f.Count))
+	}
+	if len(f.DistinctOn) > 0 {
+		q.Set("distinct_on", strings.Join(f.DistinctOn, ","))
+	}
+	return q
+}
+
+func (f AggregateFilter) query() url.Values {
+	q := f.TimeRange.query()
+	if f.Name != "" {
+		q.Set("name", f.Name)
+	}
+	if f.Fn != "" {
+		q.Set("fn", string(f.Fn))
+	}
+	if f.Interval > 0 {
+		q.Set("interval", strconv.FormatFloat(f.Interval.Seconds(), 'f', -1, 64))
+	}
+	return q
+}
+
+func (f QualityFilter) query() url.Values {
+	q := f.TimeRange.query()
+	q.Set("interval", strconv.FormatFloat(f.Interval.Seconds(), 'f', -1, 64))
+	if f.Name != "" {
+		q.Set("name", f.Name)
+	}
+	if f.Gaps > 0 {
+		q.Set("gaps", strconv.Itoa(f.Gaps))
+	}
+	if f.Min != nil {
+		q.Set("min", strconv.FormatFloat(*f.Min, 'f', -1, 64))
+	}
+	if f.Max != nil {
+		q.Set("max", strconv.FormatFloat(*f.Max, 'f', -1, 64))
+	}
+	return q
+}
+
+// unixTime function returns the UNIX time of t, in seconds
+func unixTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/float64(time.Second), 'f', -1, 64)
+}
+
+// Time function returns the time of the UNIX time t, in seconds, of a
+// message or a bucket
+func Time(t float64) time.Time {
+	sec := int64(t)
+	return time.Unix(sec, int64((t-float64(sec))*float64(time.Second)))
+}
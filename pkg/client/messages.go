@@ -0,0 +1,214 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+// DefaultPageSize is the number of messages an Iterator reads per request
+// when its filter sets no limit.
+const DefaultPageSize = 1000
+
+type (
+	// Page struct is a page of the messages of a channel
+	Page struct {
+		Messages []models.Message `json:"messages"`
+		Meta     struct {
+			// Total is set when the filter asked for a count.
+			Total      *int   `json:"total,omitempty"`
+			Offset     int    `json:"offset"`
+			Limit      int    `json:"limit"`
+			HasMore    bool   `json:"has_more"`
+			NextCursor string `json:"next_cursor,omitempty"`
+		} `json:"meta"`
+	}
+
+	// Bucket struct is the aggregated value of the messages of a time
+	// bucket, starting at the UNIX time Time
+	Bucket struct {
+		Time  float64 `json:"time"`
+		Value float64 `json:"value"`
+		Count int     `json:"count"`
+	}
+
+	// Aggregation struct is the result of an aggregation
+	Aggregation struct {
+		Channel  string  `json:"channel"`
+		Interval float64 `json:"interval"`
+		Fn       string  `json:"fn"`
+		// Truncated tells that the reader stopped at its scan or bucket
+		// limit.
+		Truncated bool     `json:"truncated"`
+		Buckets   []Bucket `json:"buckets"`
+	}
+
+	// Stats struct is the message count, time span and approximate size
+	// of a channel
+	Stats struct {
+		Channel string  `json:"channel"`
+		Count   int     `json:"count"`
+		Oldest  float64 `json:"oldest"`
+		Newest  float64 `json:"newest"`
+		Bytes   int64   `json:"bytes"`
+	}
+
+	// Gap struct is a silence of a channel longer than its reporting
+	// interval, between the UNIX times Start and End
+	Gap struct {
+		Start    float64 `json:"start"`
+		End      float64 `json:"end"`
+		Duration float64 `json:"duration"`
+		// Missing is the number of reports missed.
+		Missing int `json:"missing"`
+	}
+
+	// Quality struct is the data quality report of a channel over a
+	// window
+	Quality struct {
+		Channel   string  `json:"channel"`
+		Name      string  `json:"name,omitempty"`
+		StartTime float64 `json:"start_time"`
+		EndTime   float64 `json:"end_time"`
+		Interval  float64 `json:"interval"`
+		// Expected is the number of reports at the declared interval,
+		// and Actual the number of distinct timestamps stored.
+		Expected     int     `json:"expected"`
+		Actual       int     `json:"actual"`
+		Completeness float64 `json:"completeness"`
+		Messages     int     `json:"messages"`
+		// Duplicates is the number of messages repeating the name and
+		// time of another, at DuplicateTimes timestamps.
+		Duplicates     int   `json:"duplicates"`
+		DuplicateTimes int   `json:"duplicate_timestamps"`
+		OutOfRange     int   `json:"out_of_range"`
+		GapCount       int   `json:"gap_count"`
+		Gaps           []Gap `json:"gaps"`
+		// Truncated tells that the reader stopped at its scan limit.
+		Truncated bool `json:"truncated"`
+	}
+)
+
+// messagesPath function returns the path of the messages of channel
+func messagesPath(channel string) string {
+	return "/channels/" + url.PathEscape(channel) + "/messages"
+}
+
+// Messages method reads the page of the messages of channel f selects
+func (c *Client) Messages(ctx context.Context, channel string, f Filter) (Page, error) {
+	var p Page
+	err := c.get(ctx, messagesPath(channel), f.query(), &p)
+	return p, err
+}
+
+// Latest method reads the latest message of every name of channel, or of
+// name only if it is set
+func (c *Client) Latest(ctx context.Context, channel, name string) ([]models.Message, error) {
+	q := url.Values{}
+	if name != "" {
+		q.Set("name", name)
+	}
+	msgs := []models.Message{}
+	err := c.get(ctx, messagesPath(channel)+"/latest", q, &msgs)
+	return msgs, err
+}
+
+// Aggregate method aggregates the values of the messages of channel f
+// selects in time buckets
+func (c *Client) Aggregate(ctx context.Context, channel string, f AggregateFilter) (Aggregation, error) {
+	var a Aggregation
+	err := c.get(ctx, messagesPath(channel)+"/aggregate", f.query(), &a)
+	return a, err
+}
+
+// Stats method reads the message count, time span and size of the
+// messages of channel in r
+func (c *Client) Stats(ctx context.Context, channel string, r TimeRange) (Stats, error) {
+	var s Stats
+	err := c.get(ctx, "/channels/"+url.PathEscape(channel)+"/stats", r.query(), &s)
+	return s, err
+}
+
+// Quality method reports on the gaps, duplicates and out of range
+// values of the messages of channel f selects
+func (c *Client) Quality(ctx context.Context, channel string, f QualityFilter) (Quality, error) {
+	var q Quality
+	err := c.get(ctx, "/channels/"+url.PathEscape(channel)+"/quality", f.query(), &q)
+	return q, err
+}
+
+// Iterator struct reads the messages of a channel page by page, in time
+// order:
+//
+//	it := c.Iterate(ctx, channel, client.Filter{})
+//	for it.Next() {
+//		m := it.Message()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type Iterator struct {
+	c       *Client
+	ctx     context.Context
+	channel string
+	filter  Filter
+	page    []models.Message
+	i       int
+	done    bool
+	err     error
+}
+
+// Iterate method returns an iterator over the messages of channel f
+// selects, starting at its offset or cursor. Its limit sets the size of
+// the pages read, DefaultPageSize if zero.
+func (c *Client) Iterate(ctx context.Context, channel string, f Filter) *Iterator {
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	return &Iterator{c: c, ctx: ctx, channel: channel, filter: f, i: -1}
+}
+
+// Next method advances to the next message, reading the next page when
+// the current one is exhausted, and reports whether there is one
+func (it *Iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.i+1 < len(it.page) {
+		it.i++
+		return true
+	}
+
+	for !it.done {
+		p, err := it.c.Messages(it.ctx, it.channel, it.filter)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page, it.i = p.Messages, 0
+		it.done = !p.Meta.HasMore || p.Meta.NextCursor == ""
+		it.filter.Cursor = p.Meta.NextCursor
+		if len(it.page) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Message method returns the current message
+func (it *Iterator) Message() models.Message {
+	return it.page[it.i]
+}
+
+// Err method returns the error that stopped the iteration, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
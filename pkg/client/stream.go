@@ -0,0 +1,128 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+// Event struct is a message received from a stream, with the id it is
+// resumed after
+type Event struct {
+	ID      string
+	Message models.Message
+}
+
+// stopError wraps the error of a stream that must not be resumed: its
+// handler failed or a message can't be decoded
+type stopError struct {
+	err error
+}
+
+func (e stopError) Error() string {
+	return e.err.Error()
+}
+
+// Stream method calls fn with the messages of channel f selects, then
+// with every new one as it is stored, until ctx is done or fn returns an
+// error, which it returns. Dropped connections are opened again and the
+// stream resumed after the last message received, so that none is missed
+// or repeated.
+func (c *Client) Stream(ctx context.Context, channel string, f StreamFilter, fn func(Event) error) error {
+	last := f.LastEventID
+	delay := c.cfg.Backoff
+	for {
+		h := http.Header{}
+		if last != "" {
+			h.Set("Last-Event-ID", last)
+		}
+		res, err := c.send(ctx, messagesPath(channel)+"/stream", f.TimeRange.query(), h)
+		if err != nil {
+			return err
+		}
+
+		received := false
+		err = readEvents(res.Body, func(e Event) error {
+			received, last = true, e.ID
+			if err := fn(e); err != nil {
+				return stopError{err}
+			}
+			return nil
+		})
+		res.Body.Close()
+		if e, ok := err.(stopError); ok {
+			return e.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// The reader closed the stream, e.g. on shutdown, or the
+		// connection broke
+		if received {
+			delay = c.cfg.Backoff
+		}
+		if err := sleep(ctx, time.Duration(rand.Int63n(int64(delay)))); err != nil {
+			return err
+		}
+		if delay *= 2; delay > c.cfg.MaxBackoff {
+			delay = c.cfg.MaxBackoff
+		}
+	}
+}
+
+// readEvents function calls fn with the message events of the
+// server-sent event stream r, until it ends or fn fails
+func readEvents(r io.Reader, fn func(Event) error) error {
+	br := bufio.NewReader(r)
+	var id, event, data string
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line != "" {
+			field, value := line, ""
+			if i := strings.Index(line, ":"); i >= 0 {
+				field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+			}
+			switch field {
+			case "id":
+				id = value
+			case "event":
+				event = value
+			case "data":
+				data += value
+			}
+			continue
+		}
+
+		if data != "" && (event == "" || event == "message") {
+			e := Event{ID: id}
+			if err := json.Unmarshal([]byte(data), &e.Message); err != nil {
+				return stopError{err}
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		event, data = "", ""
+	}
+}
@@ -0,0 +1,391 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package quota counts the bytes and documents served to each tenant and
+// enforces daily and monthly egress quotas on them.
+//
+// Counters are kept in memory and periodically added to the egress
+// collection, one document per tenant and period, so that they survive
+// restarts and add up across the replicas of the reader. A tenant over
+// quota is refused until its period ends; the response crossing the quota
+// is still served in full.
+package quota
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Collection holds the egress counters of tenants.
+const Collection = "egress"
+
+// Names of quotas, as used in override specs and reported in statuses.
+const (
+	DailyBytes   = "daily_bytes"
+	DailyDocs    = "daily_documents"
+	MonthlyBytes = "monthly_bytes"
+	MonthlyDocs  = "monthly_documents"
+)
+
+// Quotas struct holds the egress allowed to a tenant per period. Zero
+// values are unlimited.
+type Quotas struct {
+	DailyBytes   int64
+	DailyDocs    int64
+	MonthlyBytes int64
+	MonthlyDocs  int64
+}
+
+// Usage struct is the egress of a tenant in a period
+type Usage struct {
+	Bytes int64 `bson:"bytes" json:"bytes"`
+	Docs  int64 `bson:"docs" json:"documents"`
+}
+
+// Status struct describes a quota of a tenant: the one it exceeds, or the
+// one closest to exhaustion
+type Status struct {
+	Name      string
+	Limit     int64
+	Remaining int64
+	Reset     time.Time
+}
+
+// counter struct is the egress of a tenant in a period, as last read from
+// the database plus what was served since
+type counter struct {
+	tenant, period string
+
+	load    sync.Once
+	synced  bool
+	flushed Usage
+	pending Usage
+}
+
+var (
+	mu        sync.Mutex
+	defaults  Quotas
+	overrides = map[string]Quotas{}
+	counters  = map[string]*counter{}
+	stop      chan struct{}
+	done      chan struct{}
+)
+
+// SetQuotas function replaces the quotas of every tenant by q, and those
+// of some tenants by the overrides of spec, a list of
+// tenant=name:limit[,name:limit] entries separated by semicolons, such as
+// "acme=daily_bytes:1000000000,monthly_documents:5000000". Overrides start
+// from q.
+func SetQuotas(q Quotas, spec string) error {
+	o := map[string]Quotas{}
+	for _, entry := range strings.Split(spec, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return fmt.Errorf("malformed egress quota %q", entry)
+		}
+
+		tq := q
+		for _, l := range strings.Split(kv[1], ",") {
+			nl := strings.SplitN(strings.TrimSpace(l), ":", 2)
+			if len(nl) != 2 {
+				return fmt.Errorf("malformed egress quota %q", entry)
+			}
+			n, err := strconv.ParseInt(nl[1], 10, 64)
+			if err != nil || n < 0 {
+				return fmt.Errorf("egress quota %s of %s must be a non-negative integer", nl[0], kv[0])
+			}
+			switch nl[0] {
+			case DailyBytes:
+				tq.DailyBytes = n
+			case DailyDocs:
+				tq.DailyDocs = n
+			case MonthlyBytes:
+				tq.MonthlyBytes = n
+			case MonthlyDocs:
+				tq.MonthlyDocs = n
+			default:
+				return fmt.Errorf("unknown egress quota %s of %s", nl[0], kv[0])
+			}
+		}
+		o[kv[0]] = tq
+	}
+
+	mu.Lock()
+	defaults, overrides = q, o
+	mu.Unlock()
+
+	return nil
+}
+
+// Enabled function reports whether any quota is configured, egress being
+// counted only then
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return defaults != Quotas{} || len(overrides) > 0
+}
+
+// Record function adds docs and bytes to the egress of tenant in the
+// current day and month
+func Record(tenant string, docs int, bytes int64) {
+	if !Enabled() || docs == 0 && bytes == 0 {
+		return
+	}
+
+	day, month := periods(time.Now())
+	for _, p := range []string{day, month} {
+		c := lookup(tenant, p)
+		mu.Lock()
+		c.pending.Docs += int64(docs)
+		c.pending.Bytes += bytes
+		mu.Unlock()
+	}
+}
+
+// Check function returns the status of the quotas of tenant, and false if
+// one of them is exceeded. The status is that of the exceeded quota
+// resetting last, or else of the quota closest to exhaustion; its name is
+// empty if tenant has no quota.
+func Check(tenant string) (Status, bool) {
+	mu.Lock()
+	q, ok := overrides[tenant]
+	if !ok {
+		q = defaults
+	}
+	mu.Unlock()
+
+	now := time.Now()
+	day, month := periods(now)
+	nextDay, nextMonth := resets(now)
+
+	var daily, monthly Usage
+	if q.DailyBytes > 0 || q.DailyDocs > 0 {
+		daily = lookup(tenant, day).usage()
+	}
+	if q.MonthlyBytes > 0 || q.MonthlyDocs > 0 {
+		monthly = lookup(tenant, month).usage()
+	}
+
+	var st Status
+	exceeded := false
+	for _, c := range []struct {
+		name  string
+		limit int64
+		used  int64
+		reset time.Time
+	}{
+		{DailyBytes, q.DailyBytes, daily.Bytes, nextDay},
+		{DailyDocs, q.DailyDocs, daily.Docs, nextDay},
+		{MonthlyBytes, q.MonthlyBytes, monthly.Bytes, nextMonth},
+		{MonthlyDocs, q.MonthlyDocs, monthly.Docs, nextMonth},
+	} {
+		if c.limit <= 0 {
+			continue
+		}
+		s := Status{Name: c.name, Limit: c.limit, Remaining: c.limit - c.used, Reset: c.reset}
+		if s.Remaining < 0 {
+			s.Remaining = 0
+		}
+
+		switch over := s.Remaining == 0; {
+		case over && (!exceeded || s.Reset.After(st.Reset)):
+			st, exceeded = s, true
+		case !over && !exceeded && (st.Name == "" || float64(s.Remaining)/float64(s.Limit) < float64(st.Remaining)/float64(st.Limit)):
+			st = s
+		}
+	}
+
+	return st, !exceeded
+}
+
+// Start function adds the egress counted in memory to the egress
+// collection every interval
+func Start(interval time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stop != nil {
+		return
+	}
+	stop, done = make(chan struct{}), make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := Flush(); err != nil {
+					log.Print(err)
+				}
+			}
+		}
+	}(stop, done)
+}
+
+// Stop function stops the periodic flushes and flushes the egress counted
+// since the last one
+func Stop() {
+	mu.Lock()
+	s, d := stop, done
+	stop, done = nil, nil
+	mu.Unlock()
+
+	if s == nil {
+		return
+	}
+	close(s)
+	<-d
+	if err := Flush(); err != nil {
+		log.Print(err)
+	}
+}
+
+// Flush function adds the egress counted in memory to the egress
+// collection and reads back the totals, which include the egress of
+// other replicas. Counters of past periods are forgotten once flushed.
+func Flush() error {
+	mu.Lock()
+	type flush struct {
+		key string
+		c   *counter
+		u   Usage
+	}
+	flushes := []flush{}
+	for key, c := range counters {
+		flushes = append(flushes, flush{key, c, c.pending})
+		c.pending = Usage{}
+	}
+	mu.Unlock()
+
+	if len(flushes) == 0 {
+		return nil
+	}
+
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	day, month := periods(time.Now())
+	var err error
+	for _, f := range flushes {
+		if err != nil {
+			restore(f.c, f.u)
+			continue
+		}
+
+		var res struct {
+			Usage `bson:",inline"`
+		}
+		change := mgo.Change{
+			Update: bson.M{
+				"$inc": bson.M{"bytes": f.u.Bytes, "docs": f.u.Docs},
+				"$set": bson.M{"tenant": f.c.tenant, "period": f.c.period},
+			},
+			Upsert:    true,
+			ReturnNew: true,
+		}
+		if _, err = Db.C(Collection).FindId(f.key).Apply(change, &res); err != nil {
+			restore(f.c, f.u)
+			continue
+		}
+
+		mu.Lock()
+		f.c.flushed, f.c.synced = res.Usage, true
+		if f.c.period != day && f.c.period != month && f.c.pending == (Usage{}) {
+			delete(counters, f.key)
+		}
+		mu.Unlock()
+	}
+
+	return err
+}
+
+// restore adds the egress of a failed flush back to c
+func restore(c *counter, u Usage) {
+	mu.Lock()
+	c.pending.Bytes += u.Bytes
+	c.pending.Docs += u.Docs
+	mu.Unlock()
+}
+
+// lookup returns the counter of tenant in period, reading its total from
+// the database on first use
+func lookup(tenant, period string) *counter {
+	key := tenant + "/" + period
+
+	mu.Lock()
+	c, ok := counters[key]
+	if !ok {
+		c = &counter{tenant: tenant, period: period}
+		counters[key] = c
+	}
+	mu.Unlock()
+
+	c.load.Do(func() {
+		Db := db.MgoDb{}
+		Db.Init()
+		defer Db.Close()
+
+		var res struct {
+			Usage `bson:",inline"`
+		}
+		err := Db.C(Collection).FindId(key).One(&res)
+		if err != nil && err != mgo.ErrNotFound {
+			// Counting from zero until the next flush rather than
+			// refusing requests.
+			log.Print(err)
+			return
+		}
+
+		mu.Lock()
+		if !c.synced {
+			c.flushed, c.synced = res.Usage, true
+		}
+		mu.Unlock()
+	})
+
+	return c
+}
+
+// usage returns the egress of the period of c
+func (c *counter) usage() Usage {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return Usage{Bytes: c.flushed.Bytes + c.pending.Bytes, Docs: c.flushed.Docs + c.pending.Docs}
+}
+
+// periods returns the ids of the day and the month of t, in UTC
+func periods(t time.Time) (string, string) {
+	t = t.UTC()
+	return t.Format("2006-01-02"), t.Format("2006-01")
+}
+
+// resets returns the ends of the day and the month of t, in UTC
+func resets(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, 1), time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+}
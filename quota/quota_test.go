@@ -0,0 +1,87 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package quota
+
+import (
+	"testing"
+	"time"
+)
+
+// preload makes the counter of tenant in period hold u, as if read from
+// the database
+func preload(tenant, period string, u Usage) {
+	c := &counter{tenant: tenant, period: period, flushed: u, synced: true}
+	c.load.Do(func() {})
+	counters[tenant+"/"+period] = c
+}
+
+func TestSetQuotas(t *testing.T) {
+	defer SetQuotas(Quotas{}, "")
+
+	err := SetQuotas(Quotas{DailyBytes: 100}, "acme=daily_documents:10,monthly_bytes:1000; beta=daily_bytes:0")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if q := overrides["acme"]; q != (Quotas{DailyBytes: 100, DailyDocs: 10, MonthlyBytes: 1000}) {
+		t.Errorf("expected acme overrides on top of defaults got %+v", q)
+	}
+	if q := overrides["beta"]; q != (Quotas{}) {
+		t.Errorf("expected beta to be unlimited got %+v", q)
+	}
+	if !Enabled() {
+		t.Error("expected quotas to be enabled")
+	}
+
+	for _, spec := range []string{"acme", "acme=", "acme=daily_bytes", "acme=daily_bytes:-1", "acme=weekly_bytes:1"} {
+		if err := SetQuotas(Quotas{}, spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	defer SetQuotas(Quotas{}, "")
+	defer func() { counters = map[string]*counter{} }()
+
+	if err := SetQuotas(Quotas{DailyBytes: 1000, MonthlyDocs: 100}, "free=daily_bytes:10;open=daily_bytes:0,monthly_documents:0"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	day, month := periods(now)
+	nextDay, nextMonth := resets(now)
+
+	preload("acme", day, Usage{Bytes: 900, Docs: 10})
+	preload("acme", month, Usage{Bytes: 900, Docs: 20})
+	preload("free", day, Usage{Bytes: 5})
+	preload("free", month, Usage{Docs: 100})
+	preload("open", day, Usage{Bytes: 1 << 40})
+	preload("open", month, Usage{Docs: 1 << 40})
+
+	cases := []struct {
+		tenant string
+		status Status
+		ok     bool
+	}{
+		{"acme", Status{DailyBytes, 1000, 100, nextDay}, true},
+		{"free", Status{MonthlyDocs, 100, 0, nextMonth}, false},
+		{"open", Status{}, true},
+	}
+	for _, c := range cases {
+		st, ok := Check(c.tenant)
+		if st != c.status || ok != c.ok {
+			t.Errorf("%s: expected %+v, %v got %+v, %v", c.tenant, c.status, c.ok, st, ok)
+		}
+	}
+
+	// Egress served is counted against the quota at once
+	Record("acme", 1, 100)
+	if st, ok := Check("acme"); ok || st.Name != DailyBytes || st.Remaining != 0 {
+		t.Errorf("expected acme to exceed its daily bytes got %+v, %v", st, ok)
+	}
+}
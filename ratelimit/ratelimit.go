@@ -0,0 +1,111 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package ratelimit limits the request rate and the concurrent requests
+// of each caller, so that a single misbehaving client cannot starve the
+// others, and the queries running at once across all callers, so that
+// traffic spikes are shed rather than slowing the database down for
+// everyone.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Callers idle for this long are forgotten.
+const idleTimeout = 10 * time.Minute
+
+var (
+	// Rate is the number of requests per second a caller is allowed on
+	// average. Zero disables rate limiting.
+	Rate float64
+	// Burst is the number of requests a caller may make at once.
+	Burst = 10
+	// MaxInFlight is the number of requests of a caller served at the
+	// same time. Zero disables the cap.
+	MaxInFlight int
+
+	mu      sync.Mutex
+	callers = map[string]*caller{}
+	swept   time.Time
+)
+
+// SetLimits function replaces Rate, Burst and MaxInFlight while requests
+// are served. Callers keep their tokens and requests in flight.
+func SetLimits(rate float64, burst, maxInFlight int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	Rate, Burst, MaxInFlight = rate, burst, maxInFlight
+}
+
+// caller struct is the token bucket and in-flight count of one caller
+type caller struct {
+	tokens   float64
+	last     time.Time
+	inFlight int
+}
+
+// Acquire function admits a request of the caller key. When it is
+// admitted, release must be called once it is served. Otherwise
+// retryAfter tells when the caller may try again.
+func Acquire(key string) (release func(), retryAfter time.Duration, ok bool) {
+	now := time.Now()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	sweep(now)
+	c, found := callers[key]
+	if !found {
+		c = &caller{tokens: float64(Burst), last: now}
+		callers[key] = c
+	}
+
+	if Rate > 0 {
+		c.tokens = math.Min(float64(Burst), c.tokens+now.Sub(c.last).Seconds()*Rate)
+	}
+	c.last = now
+
+	if MaxInFlight > 0 && c.inFlight >= MaxInFlight {
+		return nil, time.Second, false
+	}
+	if Rate > 0 {
+		if c.tokens < 1 {
+			wait := time.Duration((1 - c.tokens) / Rate * float64(time.Second))
+			return nil, wait, false
+		}
+		c.tokens--
+	}
+
+	c.inFlight++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			mu.Lock()
+			c.inFlight--
+			mu.Unlock()
+		})
+	}, 0, true
+}
+
+// sweep forgets idle callers, at most once per idleTimeout
+func sweep(now time.Time) {
+	if now.Sub(swept) < idleTimeout {
+		return
+	}
+	swept = now
+
+	for key, c := range callers {
+		if c.inFlight == 0 && now.Sub(c.last) > idleTimeout {
+			delete(callers, key)
+		}
+	}
+}
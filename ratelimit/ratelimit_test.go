@@ -0,0 +1,116 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRate(t *testing.T) {
+	Rate, Burst, MaxInFlight = 10, 2, 0
+	defer func() { Rate, Burst = 0, 10 }()
+
+	for i := 0; i < 2; i++ {
+		release, _, ok := Acquire("rate")
+		if !ok {
+			t.Fatalf("request %d: expected burst to be admitted", i+1)
+		}
+		release()
+	}
+
+	_, wait, ok := Acquire("rate")
+	if ok || wait <= 0 || wait > 100*time.Millisecond {
+		t.Fatalf("expected rejection with a wait below 100ms got %v, %v", ok, wait)
+	}
+	if _, _, ok := Acquire("other"); !ok {
+		t.Errorf("expected callers to have separate buckets")
+	}
+
+	time.Sleep(wait)
+	if _, _, ok := Acquire("rate"); !ok {
+		t.Errorf("expected admission once a token is back")
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	Rate, MaxInFlight = 0, 2
+	defer func() { MaxInFlight = 0 }()
+
+	r1, _, ok1 := Acquire("flight")
+	_, _, ok2 := Acquire("flight")
+	if !ok1 || !ok2 {
+		t.Fatal("expected requests below the cap to be admitted")
+	}
+	if _, wait, ok := Acquire("flight"); ok || wait != time.Second {
+		t.Fatalf("expected rejection above the cap")
+	}
+
+	r1()
+	r1()
+	if _, _, ok := Acquire("flight"); !ok {
+		t.Errorf("expected admission once a request is released")
+	}
+	if _, _, ok := Acquire("flight"); ok {
+		t.Errorf("expected a release to count once")
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	SetLimits(0, 10, 1)
+	defer SetLimits(0, 10, 0)
+
+	release, _, ok := Acquire("reload")
+	if !ok {
+		t.Fatal("expected a request below the cap to be admitted")
+	}
+	if _, _, ok := Acquire("reload"); ok {
+		t.Fatal("expected rejection above the cap")
+	}
+
+	SetLimits(0, 10, 2)
+	if _, _, ok := Acquire("reload"); !ok {
+		t.Errorf("expected the raised cap to admit requests in flight")
+	}
+	release()
+}
+
+func TestEnter(t *testing.T) {
+	MaxQueries, MaxQueued, QueueTimeout = 1, 1, 20*time.Millisecond
+	defer func() { MaxQueries = 0 }()
+
+	release, ok := Enter()
+	if !ok {
+		t.Fatal("expected a free slot")
+	}
+
+	waited := make(chan bool)
+	go func() {
+		r, ok := Enter()
+		if ok {
+			r()
+		}
+		waited <- ok
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := Enter(); ok {
+		t.Error("expected shedding past the queue")
+	}
+	release()
+	if !<-waited {
+		t.Error("expected the queued query to get the released slot")
+	}
+
+	release, _ = Enter()
+	defer release()
+	if _, ok := Enter(); ok {
+		t.Error("expected shedding after the queue timeout")
+	}
+}
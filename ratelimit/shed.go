@@ -0,0 +1,94 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+)
+
+var (
+	// MaxQueries is the number of database queries run at the same time
+	// across all callers. Zero disables the limit.
+	MaxQueries int
+	// MaxQueued is the number of queries waiting for a slot. Queries past
+	// it are shed at once.
+	MaxQueued = 100
+	// QueueTimeout is the longest time a query waits for a slot before it
+	// is shed.
+	QueueTimeout = 5 * time.Second
+
+	semMu  sync.Mutex
+	sem    chan struct{}
+	queued int32
+
+	shed = metrics.NewCounterVec("mongo_reader_queries_shed_total",
+		"Queries rejected because every query slot was taken.")
+)
+
+func init() {
+	metrics.NewGaugeFunc("mongo_reader_queries_running", "Queries holding a query slot.", func() float64 {
+		semMu.Lock()
+		defer semMu.Unlock()
+		return float64(len(sem))
+	})
+	metrics.NewGaugeFunc("mongo_reader_queries_queued", "Queries waiting for a query slot.", func() float64 {
+		return float64(atomic.LoadInt32(&queued))
+	})
+}
+
+// slots returns the semaphore of MaxQueries slots
+func slots() chan struct{} {
+	semMu.Lock()
+	defer semMu.Unlock()
+
+	if sem == nil || cap(sem) != MaxQueries {
+		sem = make(chan struct{}, MaxQueries)
+	}
+	return sem
+}
+
+// Enter function takes one of the MaxQueries query slots, waiting at most
+// QueueTimeout behind at most MaxQueued other queries. When it succeeds,
+// release must be called once the query is done.
+func Enter() (release func(), ok bool) {
+	if MaxQueries <= 0 {
+		return func() {}, true
+	}
+
+	s := slots()
+	var once sync.Once
+	release = func() { once.Do(func() { <-s }) }
+
+	select {
+	case s <- struct{}{}:
+		return release, true
+	default:
+	}
+
+	defer atomic.AddInt32(&queued, -1)
+	if int(atomic.AddInt32(&queued, 1)) > MaxQueued {
+		shed.Inc()
+		return nil, false
+	}
+
+	t := time.NewTimer(QueueTimeout)
+	defer t.Stop()
+
+	select {
+	case s <- struct{}{}:
+		return release, true
+	case <-t.C:
+		shed.Inc()
+		return nil, false
+	}
+}
@@ -0,0 +1,233 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package redact post-processes messages before they leave the reader.
+//
+// Hooks run in registration order on every message returned, streamed or
+// exported. The built-in hooks mask values from non-administrators, drop
+// values for everyone and decrypt values stored encrypted. Other hooks
+// can be registered by code embedding the reader.
+package redact
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+// Masked replaces the string values of masked messages.
+const Masked = "***"
+
+// ErrSpec indicates a malformed hook specification.
+var ErrSpec = errors.New("redaction hooks must be mask:<names>, drop:<names> or decrypt:<key file>:<names>")
+
+var (
+	mu    sync.RWMutex
+	hooks []Hook
+)
+
+type (
+	// Hook transforms message m in place. admin tells whether m is
+	// returned to an administrator.
+	Hook interface {
+		Apply(m *models.Message, admin bool)
+	}
+
+	// names matches SenML names against glob patterns, such as "ssn" or
+	// "patient/*"
+	names []string
+
+	// mask hides the values of matching messages from non-administrators
+	mask struct {
+		names names
+	}
+
+	// drop removes the values of matching messages for everyone
+	drop struct {
+		names names
+	}
+
+	// decrypt opens the AES-GCM encrypted string and data values of
+	// matching messages. Encrypted values are the base64 encoding of the
+	// nonce followed by the sealed value.
+	decrypt struct {
+		names names
+		aead  cipher.AEAD
+	}
+)
+
+// Register function appends h to the hooks run on every message
+func Register(h Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	hooks = append(hooks, h)
+}
+
+// Reset function removes every hook
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	hooks = nil
+}
+
+// Enabled function reports whether any hook is registered
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return len(hooks) > 0
+}
+
+// Apply function runs the hooks on m
+func Apply(m *models.Message, admin bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, h := range hooks {
+		h.Apply(m, admin)
+	}
+}
+
+// Parse function returns the built-in hooks of spec, a semicolon
+// separated list of mask:<names>, drop:<names> and
+// decrypt:<key file>:<names>, names being comma separated glob patterns.
+// Key files hold a hex encoded AES key of 16, 24 or 32 bytes.
+func Parse(spec string) ([]Hook, error) {
+	hs := []Hook{}
+	for _, s := range strings.Split(spec, ";") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, ErrSpec
+		}
+
+		switch parts[0] {
+		case "mask":
+			hs = append(hs, mask{parseNames(parts[1])})
+		case "drop":
+			hs = append(hs, drop{parseNames(parts[1])})
+		case "decrypt":
+			i := strings.LastIndex(parts[1], ":")
+			if i <= 0 {
+				return nil, ErrSpec
+			}
+			aead, err := loadKey(parts[1][:i])
+			if err != nil {
+				return nil, err
+			}
+			hs = append(hs, decrypt{parseNames(parts[1][i+1:]), aead})
+		default:
+			return nil, ErrSpec
+		}
+	}
+	return hs, nil
+}
+
+func parseNames(list string) names {
+	ns := names{}
+	for _, n := range strings.Split(list, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
+
+func loadKey(file string) (cipher.AEAD, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: key must be hex encoded", file)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// match reports whether the name of m matches one of the patterns
+func (ns names) match(m *models.Message) bool {
+	name := m.BaseName + m.Name
+	for _, p := range ns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (h mask) Apply(m *models.Message, admin bool) {
+	if admin || !h.names.match(m) {
+		return
+	}
+	clearValues(m)
+	if m.StringValue != "" {
+		m.StringValue = Masked
+	}
+	if m.DataValue != "" {
+		m.DataValue = Masked
+	}
+}
+
+func (h drop) Apply(m *models.Message, admin bool) {
+	if !h.names.match(m) {
+		return
+	}
+	clearValues(m)
+	m.StringValue = ""
+	m.DataValue = ""
+}
+
+// clearValues removes the values that cannot be masked by a placeholder
+func clearValues(m *models.Message) {
+	m.Value = nil
+	m.BoolValue = nil
+	m.Sum = nil
+	m.Payload = nil
+}
+
+func (h decrypt) Apply(m *models.Message, admin bool) {
+	if !h.names.match(m) {
+		return
+	}
+	m.StringValue = h.open(m.StringValue)
+	m.DataValue = h.open(m.DataValue)
+}
+
+// open returns the plaintext of value, or value itself if it is not
+// encrypted with the key of h
+func (h decrypt) open(value string) string {
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(b) < h.aead.NonceSize() {
+		return value
+	}
+	n := h.aead.NonceSize()
+	plain, err := h.aead.Open(nil, b[:n], b[n:], nil)
+	if err != nil {
+		return value
+	}
+	return string(plain)
+}
@@ -0,0 +1,90 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package redact_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/redact"
+)
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"mask", "hide:ssn", "decrypt:ssn", "decrypt:/missing:ssn"} {
+		if _, err := redact.Parse(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+	hs, err := redact.Parse("mask:ssn, patient/*; drop:raw")
+	if err != nil || len(hs) != 2 {
+		t.Errorf("unexpected hooks %v, %v", hs, err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	keyFile := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	sealed := base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte("4711"), nil))
+
+	hs, err := redact.Parse("decrypt:" + keyFile + ":secret; mask:ssn,patient/*; drop:raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range hs {
+		redact.Register(h)
+	}
+	defer redact.Reset()
+
+	v := 36.6
+	cases := []struct {
+		msg      models.Message
+		admin    bool
+		expected string
+		value    bool
+	}{
+		{models.Message{Name: "secret", StringValue: sealed}, false, "4711", false},
+		{models.Message{Name: "secret", StringValue: "plain"}, false, "plain", false},
+		{models.Message{Name: "ssn", StringValue: "123-45-6789"}, false, redact.Masked, false},
+		{models.Message{Name: "ssn", StringValue: "123-45-6789"}, true, "123-45-6789", false},
+		{models.Message{BaseName: "patient/", Name: "temp", Value: &v}, false, "", false},
+		{models.Message{Name: "raw", StringValue: "x"}, true, "", false},
+		{models.Message{Name: "temp", Value: &v}, false, "", true},
+	}
+
+	for i, c := range cases {
+		m := c.msg
+		redact.Apply(&m, c.admin)
+		if m.StringValue != c.expected || (m.Value != nil) != c.value {
+			t.Errorf("case %d: unexpected message %+v", i+1, m)
+		}
+	}
+}
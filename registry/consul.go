@@ -0,0 +1,112 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+)
+
+// Time after which Consul removes a service failing its checks.
+const consulDeregisterAfter = "10m"
+
+type (
+	// consul registers services with the agent at addr
+	consul struct {
+		addr     string
+		token    string
+		interval time.Duration
+		http     *http.Client
+	}
+
+	consulService struct {
+		ID      string
+		Name    string
+		Tags    []string
+		Address string
+		Port    int
+		Meta    map[string]string
+		Check   consulCheck
+	}
+
+	consulCheck struct {
+		HTTP                           string
+		Interval                       string
+		Timeout                        string
+		TLSSkipVerify                  bool
+		DeregisterCriticalServiceAfter string
+	}
+)
+
+func newConsul(addr, token string, interval time.Duration) *consul {
+	return &consul{addr: addr, token: token, interval: interval, http: tlsutil.HTTPClient(requestTimeout)}
+}
+
+// Register function registers s with its readiness endpoint as check.
+// The agent runs the check itself, so ready is not sent.
+func (c *consul) Register(s Service, ready bool) error {
+	cs := consulService{
+		ID:      s.ID,
+		Name:    s.Name,
+		Tags:    s.Tags,
+		Address: s.Address,
+		Port:    s.Port,
+		Meta:    s.Meta,
+		Check: consulCheck{
+			HTTP:     s.Health,
+			Interval: c.interval.String(),
+			Timeout:  requestTimeout.String(),
+			// The agent can't be expected to trust the certificate of
+			// the reader, which serves its clients rather than Consul.
+			TLSSkipVerify:                  s.Scheme == "https",
+			DeregisterCriticalServiceAfter: consulDeregisterAfter,
+		},
+	}
+
+	b, err := json.Marshal(cs)
+	if err != nil {
+		return err
+	}
+	return c.put("/v1/agent/service/register", bytes.NewReader(b))
+}
+
+// Deregister function removes s from the agent
+func (c *consul) Deregister(s Service) error {
+	return c.put("/v1/agent/service/deregister/"+url.PathEscape(s.ID), nil)
+}
+
+func (c *consul) put(path string, body io.Reader) error {
+	req, err := http.NewRequest("PUT", c.addr+path, body)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("Consul answered %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
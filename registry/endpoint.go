@@ -0,0 +1,91 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+)
+
+// Readiness of a registered service, as sent to HTTP registries.
+const (
+	StatusPassing  = "passing"
+	StatusCritical = "critical"
+)
+
+type (
+	// endpoint posts registrations to an HTTP registry
+	endpoint struct {
+		url   string
+		token string
+		http  *http.Client
+	}
+
+	// registration is the body of a registration posted to an HTTP
+	// registry
+	registration struct {
+		Service
+		Status string `json:"status"`
+	}
+)
+
+func newEndpoint(url, token string) *endpoint {
+	return &endpoint{url: url, token: token, http: tlsutil.HTTPClient(requestTimeout)}
+}
+
+// Register function posts s and its status to the registry
+func (e *endpoint) Register(s Service, ready bool) error {
+	reg := registration{Service: s, Status: StatusCritical}
+	if ready {
+		reg.Status = StatusPassing
+	}
+
+	b, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	return e.do("POST", e.url, bytes.NewReader(b))
+}
+
+// Deregister function deletes the registration of s from the registry
+func (e *endpoint) Deregister(s Service) error {
+	return e.do("DELETE", e.url+"/"+url.PathEscape(s.ID), nil)
+}
+
+func (e *endpoint) do(method, url string, body io.Reader) error {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+
+	res, err := e.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 && !(method == "DELETE" && res.StatusCode == http.StatusNotFound) {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("registry answered %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package registry announces the reader to a service registry, so that
+// UIs can discover the readers of a deployment and the databases they
+// serve channels from.
+//
+// The reader registers with a Consul agent, which then polls its
+// readiness endpoint, or with any HTTP endpoint accepting registrations,
+// which is sent the readiness of the reader on every heartbeat. It is
+// deregistered on shutdown.
+package registry
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Time limit of a registry request.
+const requestTimeout = 10 * time.Second
+
+// ErrURL indicates a registry URL of an unknown scheme.
+var ErrURL = errors.New("registry URL must be consul://<host>:<port> or an http(s) URL")
+
+type (
+	// Service struct describes the reader as registered
+	Service struct {
+		ID      string            `json:"id"`
+		Name    string            `json:"name"`
+		Address string            `json:"address"`
+		Port    int               `json:"port"`
+		Scheme  string            `json:"scheme"`
+		Tags    []string          `json:"tags"`
+		Meta    map[string]string `json:"meta"`
+		// URL of the readiness endpoint of the reader.
+		Health string `json:"health_url"`
+	}
+
+	// Registrar is a registry the reader announces itself to
+	Registrar interface {
+		// Register announces s, ready or not. It is called again on
+		// every heartbeat.
+		Register(s Service, ready bool) error
+		// Deregister withdraws s.
+		Deregister(s Service) error
+	}
+)
+
+var (
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+)
+
+// New function returns the registrar of the registry at rawurl, with the
+// access token token, if any. Consul agents are given as
+// consul://<host>:<port>, or consuls:// over TLS. Registrations are posted
+// to other http(s) URLs, and the registrations of the services deleted
+// from their /<id> path. Consul polls the service every interval.
+func New(rawurl, token string, interval time.Duration) (Registrar, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" {
+		return nil, ErrURL
+	}
+
+	switch u.Scheme {
+	case "consul":
+		return newConsul("http://"+u.Host, token, interval), nil
+	case "consuls":
+		return newConsul("https://"+u.Host, token, interval), nil
+	case "http", "https":
+		return newEndpoint(strings.TrimSuffix(rawurl, "/"), token), nil
+	}
+	return nil, ErrURL
+}
+
+// Start function registers s with r, and registers it again every
+// interval until Stop is called, telling r whether ready reports it as
+// ready to serve. Failed registrations are logged and retried on the next
+// heartbeat.
+func Start(r Registrar, s Service, interval time.Duration, ready func() bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stop != nil {
+		return
+	}
+	stop, done = make(chan struct{}), make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		defer func() {
+			if err := r.Deregister(s); err != nil {
+				log.WithField("error", err.Error()).Warn("Can't deregister from the service registry")
+			}
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		registered := false
+		for {
+			if err := r.Register(s, ready()); err != nil {
+				log.WithField("error", err.Error()).Warn("Can't register with the service registry")
+			} else if !registered {
+				registered = true
+				log.WithField("id", s.ID).Info("Registered with the service registry")
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}(stop, done)
+}
+
+// Stop function stops the heartbeats and deregisters the service
+func Stop() {
+	mu.Lock()
+	s, d := stop, done
+	stop, done = nil, nil
+	mu.Unlock()
+
+	if s == nil {
+		return
+	}
+	close(s)
+	<-d
+}
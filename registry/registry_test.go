@@ -0,0 +1,176 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a registry recording the requests it is sent
+type recorder struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   []map[string]interface{}
+	tokens   []string
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	body := map[string]interface{}{}
+	json.NewDecoder(r.Body).Decode(&body)
+	rec.requests = append(rec.requests, r.Method+" "+r.URL.Path)
+	rec.bodies = append(rec.bodies, body)
+	rec.tokens = append(rec.tokens, r.Header.Get("X-Consul-Token")+r.Header.Get("Authorization"))
+}
+
+var service = Service{
+	ID:      "reader-1",
+	Name:    "mainflux-mongodb-reader",
+	Address: "reader.local",
+	Port:    7071,
+	Scheme:  "http",
+	Tags:    []string{"v1"},
+	Meta:    map[string]string{"database": "mainflux"},
+	Health:  "http://reader.local:7071/ready",
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		url  string
+		addr string
+	}{
+		{"consul://localhost:8500", "http://localhost:8500"},
+		{"consuls://consul.example.com", "https://consul.example.com"},
+		{"https://registry.example.com/services/", "https://registry.example.com/services"},
+	}
+	for _, c := range cases {
+		r, err := New(c.url, "", time.Second)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.url, err)
+			continue
+		}
+		switch r := r.(type) {
+		case *consul:
+			if r.addr != c.addr {
+				t.Errorf("%s: expected %s got %s", c.url, c.addr, r.addr)
+			}
+		case *endpoint:
+			if r.url != c.addr {
+				t.Errorf("%s: expected %s got %s", c.url, c.addr, r.url)
+			}
+		}
+	}
+
+	for _, u := range []string{"", "localhost:8500", "consul://", "ftp://registry.example.com"} {
+		if _, err := New(u, "", time.Second); err != ErrURL {
+			t.Errorf("%q: expected %v got %v", u, ErrURL, err)
+		}
+	}
+}
+
+func TestConsul(t *testing.T) {
+	rec := &recorder{}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+
+	c := newConsul(ts.URL, "secret", 15*time.Second)
+	if err := c.Register(service, false); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := c.Deregister(service); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []string{"PUT /v1/agent/service/register", "PUT /v1/agent/service/deregister/reader-1"}
+	if strings.Join(rec.requests, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v got %v", expected, rec.requests)
+	}
+	if rec.tokens[0] != "secret" {
+		t.Errorf("expected token secret got %q", rec.tokens[0])
+	}
+	check, _ := rec.bodies[0]["Check"].(map[string]interface{})
+	if check["HTTP"] != service.Health || check["Interval"] != "15s" {
+		t.Errorf("expected readiness check every 15s got %v", check)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	rec := &recorder{}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+
+	e := newEndpoint(ts.URL+"/services", "secret")
+	if err := e.Register(service, true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := e.Register(service, false); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := e.Deregister(service); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []string{"POST /services", "POST /services", "DELETE /services/reader-1"}
+	if strings.Join(rec.requests, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v got %v", expected, rec.requests)
+	}
+	if rec.tokens[0] != "Bearer secret" {
+		t.Errorf("expected bearer token got %q", rec.tokens[0])
+	}
+	if rec.bodies[0]["status"] != StatusPassing || rec.bodies[1]["status"] != StatusCritical {
+		t.Errorf("expected passing then critical got %v, %v", rec.bodies[0]["status"], rec.bodies[1]["status"])
+	}
+	if rec.bodies[0]["health_url"] != service.Health {
+		t.Errorf("expected health URL %s got %v", service.Health, rec.bodies[0]["health_url"])
+	}
+}
+
+func TestEndpointError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such service", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	e := newEndpoint(ts.URL, "")
+	if err := e.Register(service, true); err == nil {
+		t.Error("expected error of failed registration")
+	}
+	// A registration already gone is as good as deleted
+	if err := e.Deregister(service); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestStart(t *testing.T) {
+	rec := &recorder{}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+
+	Start(newEndpoint(ts.URL, ""), service, 10*time.Millisecond, func() bool { return true })
+	time.Sleep(35 * time.Millisecond)
+	Stop()
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	n := len(rec.requests)
+	if n < 3 || rec.requests[0] != "POST /" || rec.requests[n-1] != "DELETE /reader-1" {
+		t.Errorf("expected heartbeats then deregistration got %v", rec.requests)
+	}
+
+	// Stopping twice is harmless
+	Stop()
+}
@@ -0,0 +1,141 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/api"
+	"github.com/mainflux/mainflux-mongodb-reader/config"
+	"github.com/mainflux/mainflux-mongodb-reader/ipfilter"
+	"github.com/mainflux/mainflux-mongodb-reader/logging"
+	"github.com/mainflux/mainflux-mongodb-reader/ratelimit"
+)
+
+// Options applied again on SIGHUP. The others shape the server, its
+// connections or its background jobs, and need a restart.
+var reloadable = []string{
+	"log-level",
+	"mongo-log-level",
+	"rate-limit",
+	"rate-burst",
+	"max-in-flight",
+	"max-limit",
+	"cors-origins",
+	"cors-methods",
+	"cors-headers",
+	"cors-max-age",
+	"cors-credentials",
+	"allow-cidrs",
+	"deny-cidrs",
+}
+
+// applyReloadable applies the options that may change while requests are
+// served
+func applyReloadable() error {
+	allow, err := ipfilter.ParseCIDRs(opts.AllowCIDRs)
+	if err != nil {
+		return fmt.Errorf("IP filter: %v", err)
+	}
+	deny, err := ipfilter.ParseCIDRs(opts.DenyCIDRs)
+	if err != nil {
+		return fmt.Errorf("IP filter: %v", err)
+	}
+	if err := logging.SetLevel(opts.LogLevel); err != nil {
+		return fmt.Errorf("%v: %s", err, opts.LogLevel)
+	}
+	if err := logging.SetMongoLevel(opts.MongoLogLevel); err != nil {
+		return fmt.Errorf("MongoDB: %v: %s", err, opts.MongoLogLevel)
+	}
+	cors := api.CORS{
+		Origins:     commaList(opts.CORSOrigins),
+		Methods:     commaList(opts.CORSMethods),
+		Headers:     commaList(opts.CORSHeaders),
+		MaxAge:      opts.CORSMaxAge,
+		Credentials: opts.CORSCredentials,
+	}
+	if err := cors.Validate(); err != nil {
+		return fmt.Errorf("CORS: %v", err)
+	}
+
+	ratelimit.SetLimits(opts.RateLimit, opts.RateBurst, opts.MaxInFlight)
+	api.SetMaxLimit(opts.MaxLimit)
+	api.SetCORS(cors)
+	ipfilter.Set(ipfilter.Rules{Allow: allow, Deny: deny})
+	return nil
+}
+
+// reload reads the configuration file again and applies the reloadable
+// options it sets. Options the file no longer sets fall back to the
+// environment or to their defaults, and those given on the command line
+// are kept. On error the previous options stay in effect.
+func reload() error {
+	path := configPath()
+	values, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	previous := map[string]string{}
+	changed := log.Fields{}
+	for _, name := range reloadable {
+		if onCommandLine(name) {
+			continue
+		}
+		f := flag.Lookup(name)
+		v, ok := os.LookupEnv("MF_MONGO_READER_" + strings.ToUpper(strings.Replace(name, "-", "_", -1)))
+		if !ok {
+			if v, ok = values[name]; !ok {
+				v = f.DefValue
+			}
+		}
+
+		previous[name] = f.Value.String()
+		if err := flag.Set(name, v); err != nil {
+			restore(previous)
+			return fmt.Errorf("%s: invalid %s: %v", path, name, err)
+		}
+		if s := f.Value.String(); s != previous[name] {
+			changed[name] = s
+		}
+	}
+
+	if err := applyReloadable(); err != nil {
+		restore(previous)
+		applyReloadable()
+		return err
+	}
+	log.WithFields(changed).Info("Configuration reloaded")
+	return nil
+}
+
+// restore sets the options back to their previous values
+func restore(previous map[string]string) {
+	for name, v := range previous {
+		flag.Set(name, v)
+	}
+}
+
+// onCommandLine tells whether the option name was given as an argument
+func onCommandLine(name string) bool {
+	for _, a := range os.Args[1:] {
+		if a == "--" {
+			break
+		}
+		a = strings.TrimPrefix(strings.TrimPrefix(a, "-"), "-")
+		if a == name || strings.HasPrefix(a, name+"=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/latest"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// mongoRepository reads messages from the collections of the storage
+// layout, and from the archive for messages older than its cutoff
+type mongoRepository struct {
+	mdb *db.MgoDb
+}
+
+// NewMongo function returns the repository of the messages of the
+// database of mdb, whose session it uses until closed
+func NewMongo(mdb *db.MgoDb) MessageRepository {
+	return mongoRepository{mdb}
+}
+
+func (r mongoRepository) Messages(ctx context.Context, q Query) ([]models.Message, error) {
+	if err := r.channel(q.Channel); err != nil {
+		return nil, err
+	}
+
+	msgs := []models.Message{}
+	err := r.mdb.Read(ctx, func() error {
+		msgs = msgs[:0]
+		it := r.mdb.IterAll(ctx, q.Channel, q.Start, q.End, filter(q), "time", q.Limit)
+		it.Skip(q.Offset)
+		it.Distinct(q.Distinct)
+		var m models.Message
+		for it.Next(&m) {
+			msgs = append(msgs, m)
+			m = models.Message{}
+		}
+		return it.Close()
+	})
+	return msgs, err
+}
+
+func (r mongoRepository) Iter(ctx context.Context, q Query) (Iterator, error) {
+	if err := r.channel(q.Channel); err != nil {
+		return nil, err
+	}
+
+	// The first message is read within the retries of the session, so
+	// that failures are reported before a response is committed.
+	it := &mongoIter{}
+	err := r.mdb.Read(ctx, func() error {
+		it.MessageIter = MongoIter(ctx, r.mdb, q)
+		if it.ahead = it.MessageIter.Next(&it.raw); !it.ahead {
+			return it.MessageIter.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+func (r mongoRepository) Count(ctx context.Context, q Query) (int, error) {
+	if err := r.channel(q.Channel); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	err := r.mdb.Read(ctx, func() error {
+		var err error
+		if len(q.Distinct) > 0 {
+			n, err = r.mdb.CountDistinct(ctx, q.Channel, q.Start, q.End, filter(q), q.Distinct)
+			return err
+		}
+		n, err = r.mdb.CountAll(ctx, q.Channel, q.Start, q.End, filter(q))
+		return err
+	})
+	return n, err
+}
+
+func (r mongoRepository) Latest(ctx context.Context, channel, name string) ([]models.Message, error) {
+	var msgs []models.Message
+	err := r.mdb.Read(ctx, func() error {
+		var err error
+		msgs, err = latest.Get(r.mdb, channel, name)
+		return err
+	})
+	return msgs, err
+}
+
+func (r mongoRepository) Aggregate(ctx context.Context, q Query, interval float64) ([]Bucket, error) {
+	if err := r.channel(q.Channel); err != nil {
+		return nil, err
+	}
+
+	match := filter(q)
+	match["value"] = bson.M{"$exists": true}
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":   bson.M{"$subtract": []interface{}{"$time", bson.M{"$mod": []interface{}{"$time", interval}}}},
+			"count": bson.M{"$sum": 1},
+			"sum":   bson.M{"$sum": "$value"},
+			"min":   bson.M{"$min": "$value"},
+			"max":   bson.M{"$max": "$value"},
+		}},
+	}
+
+	merged := map[float64]*Bucket{}
+	err := r.mdb.Read(ctx, func() error {
+		merged = map[float64]*Bucket{}
+		names, err := r.mdb.MessageCollections(q.Channel, q.Start, q.End)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			part := []Bucket{}
+			if err := r.mdb.Aggregate(ctx, name, pipeline).All(&part); err != nil {
+				return err
+			}
+			for i := range part {
+				merge(merged, part[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collect(merged), nil
+}
+
+func (r mongoRepository) Stats(ctx context.Context, channel string) (Stats, error) {
+	if err := r.channel(channel); err != nil {
+		return Stats{}, err
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"channel": channel}},
+		{"$group": bson.M{
+			"_id":    nil,
+			"count":  bson.M{"$sum": 1},
+			"oldest": bson.M{"$min": "$time"},
+			"newest": bson.M{"$max": "$time"},
+		}},
+	}
+
+	var stats Stats
+	err := r.mdb.Read(ctx, func() error {
+		stats = Stats{}
+		names, err := r.mdb.MessageCollections(channel, db.Earliest, db.Latest)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			part := Stats{}
+			iter := r.mdb.Aggregate(ctx, name, pipeline)
+			iter.Next(&part)
+			if err := iter.Close(); err != nil {
+				return err
+			}
+			stats = addStats(stats, part)
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// channel returns ErrChannelNotFound unless channel was created
+func (r mongoRepository) channel(channel string) error {
+	err := r.mdb.FindChannel(channel)
+	if err == mgo.ErrNotFound {
+		return ErrChannelNotFound
+	}
+	return err
+}
+
+// MongoIter function returns the iterator over the messages of the
+// database of mdb that q selects, as the MongoDB repository reads them
+func MongoIter(ctx context.Context, mdb *db.MgoDb, q Query) *db.MessageIter {
+	// Pages are read in time order, so that consecutive pages neither
+	// overlap nor leave gaps
+	sort := ""
+	if q.Limit > 0 || q.Offset > 0 {
+		sort = "time"
+	}
+
+	it := mdb.IterAll(ctx, q.Channel, q.Start, q.End, filter(q), sort, q.Limit)
+	it.Skip(q.Offset)
+	it.Distinct(q.Distinct)
+	return it
+}
+
+// mongoIter replays the message Iter read ahead before those of the
+// cursor
+type mongoIter struct {
+	*db.MessageIter
+	raw   bson.Raw
+	ahead bool
+	err   error
+}
+
+func (it *mongoIter) Next(result interface{}) bool {
+	if it.ahead {
+		it.ahead = false
+		it.err = bson.Unmarshal(it.raw.Data, result)
+		return it.err == nil
+	}
+	return it.err == nil && it.MessageIter.Next(result)
+}
+
+func (it *mongoIter) Close() error {
+	if err := it.MessageIter.Close(); err != nil {
+		return err
+	}
+	return it.err
+}
+
+// filter function returns the MongoDB query of q
+func filter(q Query) bson.M {
+	f := bson.M{"channel": q.Channel, "time": bson.M{"$gt": q.Start, "$lt": q.End}}
+	if q.Name != "" {
+		f["name"] = q.Name
+	}
+	return f
+}
+
+// merge function adds b to the bucket of the same time in buckets
+func merge(buckets map[float64]*Bucket, b Bucket) {
+	m, ok := buckets[b.Time]
+	if !ok {
+		buckets[b.Time] = &b
+		return
+	}
+	if b.Min < m.Min {
+		m.Min = b.Min
+	}
+	if b.Max > m.Max {
+		m.Max = b.Max
+	}
+	m.Count += b.Count
+	m.Sum += b.Sum
+}
+
+// collect function returns buckets in time order
+func collect(buckets map[float64]*Bucket) []Bucket {
+	res := make([]Bucket, 0, len(buckets))
+	for _, b := range buckets {
+		res = append(res, *b)
+	}
+	sort.Sort(byTime(res))
+	return res
+}
+
+type byTime []Bucket
+
+func (s byTime) Len() int           { return len(s) }
+func (s byTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byTime) Less(i, j int) bool { return s[i].Time < s[j].Time }
+
+// addStats function returns the stats of the messages of a and b
+func addStats(a, b Stats) Stats {
+	if b.Count == 0 {
+		return a
+	}
+	if a.Count == 0 || b.Oldest < a.Oldest {
+		a.Oldest = b.Oldest
+	}
+	if b.Newest > a.Newest {
+		a.Newest = b.Newest
+	}
+	a.Count += b.Count
+	return a
+}
@@ -0,0 +1,100 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package repository defines the reads of stored messages the reader
+// serves, apart from the store they are served from: MongoDB in
+// production, or the in-memory repository of package mocks in tests.
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+// ErrChannelNotFound is returned for reads of channels that were never
+// created.
+var ErrChannelNotFound = errors.New("channel not found")
+
+type (
+	// Query struct selects the messages of a channel stored strictly
+	// between Start and End, as UNIX times, like the time range of the
+	// API
+	Query struct {
+		Channel string
+		Start   float64
+		End     float64
+		// Name selects only the messages of this name, if set.
+		Name string
+		// Offset is the number of messages skipped.
+		Offset int
+		// Limit is the largest number of messages returned, all if zero.
+		Limit int
+		// Distinct collapses the messages agreeing on these fields, which
+		// must include the time, into the first one stored, if set.
+		Distinct []string
+	}
+
+	// Iterator walks messages one at a time, decoding each into result
+	// as bson.Unmarshal does, so that bson.Raw documents are left encoded
+	Iterator interface {
+		Next(result interface{}) bool
+		Close() error
+	}
+
+	// Bucket struct sums up the numeric values of the messages stored
+	// from Time for the interval of an aggregation
+	Bucket struct {
+		Time  float64 `bson:"_id"`
+		Count int     `bson:"count"`
+		Sum   float64 `bson:"sum"`
+		Min   float64 `bson:"min"`
+		Max   float64 `bson:"max"`
+	}
+
+	// Stats struct is the number of messages of a channel and the time
+	// span they were stored in
+	Stats struct {
+		Count  int     `bson:"count"`
+		Oldest float64 `bson:"oldest"`
+		Newest float64 `bson:"newest"`
+	}
+
+	// MessageRepository reads the stored messages of channels. Every
+	// method but Latest returns ErrChannelNotFound for unknown channels.
+	MessageRepository interface {
+		// Messages returns the messages q selects, in time order.
+		Messages(ctx context.Context, q Query) ([]models.Message, error)
+
+		// Iter returns an iterator over the messages q selects, in time
+		// order if q has an offset or limit, and otherwise in the order
+		// they are stored. Errors of the first read are returned by Iter
+		// itself, those of the rest by Close.
+		Iter(ctx context.Context, q Query) (Iterator, error)
+
+		// Count returns the number of messages q selects, regardless of
+		// its offset and limit.
+		Count(ctx context.Context, q Query) (int, error)
+
+		// Latest returns the latest message of every name of channel,
+		// sorted by name, or only the one of name if it is set. Unknown
+		// channels have none.
+		Latest(ctx context.Context, channel, name string) ([]models.Message, error)
+
+		// Aggregate groups the messages with a numeric value q selects
+		// in buckets of interval seconds, aligned on multiples of the
+		// interval, and returns them in time order. The offset and limit
+		// of q are ignored.
+		Aggregate(ctx context.Context, q Query, interval float64) ([]Bucket, error)
+
+		// Stats returns the number and time span of all the messages
+		// of channel.
+		Stats(ctx context.Context, channel string) (Stats, error)
+	}
+)
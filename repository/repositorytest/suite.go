@@ -0,0 +1,221 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package repositorytest checks that implementations of
+// repository.MessageRepository read messages with the same semantics.
+package repositorytest
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"github.com/mainflux/mainflux-mongodb-reader/repository"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Seed function returns a repository holding the channels and msgs
+type Seed func(t *testing.T, channels []string, msgs []models.Message) repository.MessageRepository
+
+func message(channel, name string, t float64, v *float64, s string) models.Message {
+	return models.Message{Channel: channel, Name: name, Time: t, Value: v, StringValue: s, Publisher: "p"}
+}
+
+func value(v float64) *float64 {
+	return &v
+}
+
+// Fixtures of the suite: channel c1 with numeric and string messages,
+// c2 with one message and empty with none
+var (
+	Channels = []string{"c1", "c2", "empty"}
+	Messages = []models.Message{
+		message("c1", "temp", 10, value(1), ""),
+		message("c1", "temp", 20, value(2), ""),
+		message("c1", "hum", 20, value(50), ""),
+		message("c1", "temp", 30, value(3), ""),
+		message("c1", "status", 40, nil, "ok"),
+		message("c1", "temp", 50, value(5), ""),
+		message("c2", "temp", 15, value(7), ""),
+	}
+)
+
+// Run function runs the suite against the repository seed returns with
+// the fixtures
+func Run(t *testing.T, seed Seed) {
+	ctx := context.Background()
+	repo := seed(t, Channels, Messages)
+
+	t.Run("Messages", func(t *testing.T) {
+		cases := []struct {
+			desc  string
+			query repository.Query
+			times []float64
+		}{
+			{"whole range", repository.Query{Channel: "c1", End: 100}, []float64{10, 20, 20, 30, 40, 50}},
+			{"exclusive bounds", repository.Query{Channel: "c1", Start: 10, End: 50}, []float64{20, 20, 30, 40}},
+			{"name", repository.Query{Channel: "c1", End: 100, Name: "temp"}, []float64{10, 20, 30, 50}},
+			{"page", repository.Query{Channel: "c1", End: 100, Offset: 2, Limit: 2}, []float64{20, 30}},
+			{"offset past the end", repository.Query{Channel: "c1", End: 100, Offset: 10}, []float64{}},
+			{"other channel", repository.Query{Channel: "c2", End: 100}, []float64{15}},
+			{"empty channel", repository.Query{Channel: "empty", End: 100}, []float64{}},
+			{"distinct", repository.Query{Channel: "c1", End: 100, Distinct: []string{"time"}}, []float64{10, 20, 30, 40, 50}},
+		}
+		for _, tc := range cases {
+			msgs, err := repo.Messages(ctx, tc.query)
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.desc, err)
+				continue
+			}
+			times := []float64{}
+			for _, m := range msgs {
+				if m.Channel != tc.query.Channel {
+					t.Errorf("%s: got a message of channel %s", tc.desc, m.Channel)
+				}
+				times = append(times, m.Time)
+			}
+			if !reflect.DeepEqual(times, tc.times) {
+				t.Errorf("%s: expected times %v got %v", tc.desc, tc.times, times)
+			}
+		}
+	})
+
+	t.Run("Iter", func(t *testing.T) {
+		cases := []struct {
+			desc  string
+			query repository.Query
+			times []float64
+		}{
+			{"whole range", repository.Query{Channel: "c1", End: 100}, []float64{10, 20, 20, 30, 40, 50}},
+			{"page", repository.Query{Channel: "c1", End: 100, Offset: 2, Limit: 2}, []float64{20, 30}},
+			{"distinct page", repository.Query{Channel: "c1", End: 100, Offset: 1, Limit: 2, Distinct: []string{"time"}}, []float64{20, 30}},
+			{"empty channel", repository.Query{Channel: "empty", End: 100}, []float64{}},
+		}
+		for _, tc := range cases {
+			it, err := repo.Iter(ctx, tc.query)
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.desc, err)
+				continue
+			}
+			times := []float64{}
+			var raw bson.Raw
+			for it.Next(&raw) {
+				var m models.Message
+				if err := raw.Unmarshal(&m); err != nil {
+					t.Errorf("%s: can't decode %v", tc.desc, err)
+				}
+				times = append(times, m.Time)
+			}
+			if err := it.Close(); err != nil {
+				t.Errorf("%s: unexpected error %v", tc.desc, err)
+			}
+			// Only pages are read in time order
+			sort.Float64s(times)
+			if !reflect.DeepEqual(times, tc.times) {
+				t.Errorf("%s: expected times %v got %v", tc.desc, tc.times, times)
+			}
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		q := repository.Query{Channel: "c1", Start: 10, End: 100, Offset: 1, Limit: 1}
+		if n, err := repo.Count(ctx, q); err != nil || n != 5 {
+			t.Errorf("expected 5 messages regardless of the page got %d, %v", n, err)
+		}
+		q = repository.Query{Channel: "c1", End: 100, Name: "hum"}
+		if n, err := repo.Count(ctx, q); err != nil || n != 1 {
+			t.Errorf("expected 1 message of the name got %d, %v", n, err)
+		}
+		q = repository.Query{Channel: "c1", End: 100, Distinct: []string{"time"}}
+		if n, err := repo.Count(ctx, q); err != nil || n != 5 {
+			t.Errorf("expected 5 distinct messages got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Latest", func(t *testing.T) {
+		msgs, err := repo.Latest(ctx, "c1", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]float64{}
+		names := []string{}
+		for _, m := range msgs {
+			got[m.Name] = m.Time
+			names = append(names, m.Name)
+		}
+		expected := map[string]float64{"hum": 20, "status": 40, "temp": 50}
+		if !reflect.DeepEqual(got, expected) || !reflect.DeepEqual(names, []string{"hum", "status", "temp"}) {
+			t.Errorf("expected the latest of every name, sorted, got %v", msgs)
+		}
+
+		msgs, err = repo.Latest(ctx, "c1", "temp")
+		if err != nil || len(msgs) != 1 || msgs[0].Time != 50 || *msgs[0].Value != 5 {
+			t.Errorf("expected the latest temp got %v, %v", msgs, err)
+		}
+		if msgs, err := repo.Latest(ctx, "unknown", ""); err != nil || len(msgs) != 0 {
+			t.Errorf("expected no message of an unknown channel got %v, %v", msgs, err)
+		}
+	})
+
+	t.Run("Aggregate", func(t *testing.T) {
+		buckets, err := repo.Aggregate(ctx, repository.Query{Channel: "c1", End: 100}, 20)
+		expected := []repository.Bucket{
+			{Time: 0, Count: 1, Sum: 1, Min: 1, Max: 1},
+			{Time: 20, Count: 3, Sum: 55, Min: 2, Max: 50},
+			{Time: 40, Count: 1, Sum: 5, Min: 5, Max: 5},
+		}
+		if err != nil || !reflect.DeepEqual(buckets, expected) {
+			t.Errorf("expected buckets %v got %v, %v", expected, buckets, err)
+		}
+
+		buckets, err = repo.Aggregate(ctx, repository.Query{Channel: "c1", Start: 10, End: 100, Name: "temp"}, 20)
+		expected = []repository.Bucket{
+			{Time: 20, Count: 2, Sum: 5, Min: 2, Max: 3},
+			{Time: 40, Count: 1, Sum: 5, Min: 5, Max: 5},
+		}
+		if err != nil || !reflect.DeepEqual(buckets, expected) {
+			t.Errorf("expected buckets of temp %v got %v, %v", expected, buckets, err)
+		}
+
+		buckets, err = repo.Aggregate(ctx, repository.Query{Channel: "empty", End: 100}, 20)
+		if err != nil || len(buckets) != 0 {
+			t.Errorf("expected no bucket of an empty channel got %v, %v", buckets, err)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		if s, err := repo.Stats(ctx, "c1"); err != nil || s != (repository.Stats{Count: 6, Oldest: 10, Newest: 50}) {
+			t.Errorf("expected the stats of c1 got %v, %v", s, err)
+		}
+		if s, err := repo.Stats(ctx, "empty"); err != nil || s != (repository.Stats{}) {
+			t.Errorf("expected empty stats got %v, %v", s, err)
+		}
+	})
+
+	t.Run("UnknownChannel", func(t *testing.T) {
+		q := repository.Query{Channel: "unknown", End: 100}
+		if _, err := repo.Messages(ctx, q); err != repository.ErrChannelNotFound {
+			t.Errorf("Messages: expected ErrChannelNotFound got %v", err)
+		}
+		if _, err := repo.Iter(ctx, q); err != repository.ErrChannelNotFound {
+			t.Errorf("Iter: expected ErrChannelNotFound got %v", err)
+		}
+		if _, err := repo.Count(ctx, q); err != repository.ErrChannelNotFound {
+			t.Errorf("Count: expected ErrChannelNotFound got %v", err)
+		}
+		if _, err := repo.Aggregate(ctx, q, 60); err != repository.ErrChannelNotFound {
+			t.Errorf("Aggregate: expected ErrChannelNotFound got %v", err)
+		}
+		if _, err := repo.Stats(ctx, "unknown"); err != repository.ErrChannelNotFound {
+			t.Errorf("Stats: expected ErrChannelNotFound got %v", err)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package retention periodically removes messages older than their
+// channel's retention period.
+//
+// Message time is stored as UNIX seconds rather than as a BSON date, which
+// rules out TTL indexes, so expired messages are removed with range deletes.
+// A default period applies to every channel without an override; overrides
+// are kept in the retention_policies collection.
+package retention
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const policies = "retention_policies"
+
+// Policy overrides the retention period of a channel. A zero period keeps
+// the channel's messages forever.
+type Policy struct {
+	Channel string `bson:"_id" json:"channel"`
+	Period  int64  `bson:"period" json:"period"`
+}
+
+var (
+	mu            sync.Mutex
+	defaultPeriod time.Duration
+	stop          chan struct{}
+)
+
+// Start function enforces retention every interval. A zero default period
+// keeps messages of channels without an override forever.
+func Start(period, interval time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	defaultPeriod = period
+	if stop != nil {
+		return
+	}
+	stop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := Enforce(); err != nil {
+					log.Print(err)
+				}
+			}
+		}
+	}(stop)
+}
+
+// Stop function stops the periodic enforcement
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		stop = nil
+	}
+}
+
+// Default function returns the default retention period
+func Default() time.Duration {
+	mu.Lock()
+	defer mu.Unlock()
+	return defaultPeriod
+}
+
+// Policies function lists all per-channel overrides
+func Policies() ([]Policy, error) {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	ps := []Policy{}
+	err := Db.C(policies).Find(nil).Sort("_id").All(&ps)
+	return ps, err
+}
+
+// SetPolicy function creates or replaces the override of a channel
+func SetPolicy(p Policy) error {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	_, err := Db.C(policies).UpsertId(p.Channel, p)
+	return err
+}
+
+// RemovePolicy function makes the channel fall back to the default period
+func RemovePolicy(channel string) error {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	err := Db.C(policies).RemoveId(channel)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// Enforce function removes all expired messages
+func Enforce() error {
+	ps, err := Policies()
+	if err != nil {
+		return err
+	}
+
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	now := time.Now()
+	overridden := []string{}
+	for _, p := range ps {
+		overridden = append(overridden, p.Channel)
+		if p.Period <= 0 {
+			continue
+		}
+
+		cutoff := float64(now.Unix() - p.Period)
+		removed, err := Db.RemoveMessages(p.Channel, db.Earliest, cutoff, bson.M{"channel": p.Channel, "time": bson.M{"$lt": cutoff}})
+		if err != nil {
+			return err
+		}
+		if removed > 0 {
+			log.Printf("Retention: removed %d messages of channel %s", removed, p.Channel)
+		}
+	}
+
+	period := Default()
+	if period <= 0 {
+		return nil
+	}
+
+	cutoff := float64(now.Add(-period).Unix())
+	removed, err := Db.RemoveMessages("", db.Earliest, cutoff, bson.M{"channel": bson.M{"$nin": overridden}, "time": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		log.Printf("Retention: removed %d messages past the default period", removed)
+	}
+
+	return nil
+}
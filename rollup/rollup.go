@@ -0,0 +1,369 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package rollup maintains per-minute, per-hour and per-day summaries of
+// numeric message values, so that long time ranges are aggregated from a
+// few summaries instead of every message.
+//
+// Summaries hold the count, sum, minimum and maximum of the values of a
+// channel and name over one period. Each resolution is rolled up from the
+// one below it: minutes from messages, hours from minutes and days from
+// hours. A period is rolled up once, Delay after it ends, and a watermark
+// per resolution records how far summaries are complete. Messages stored
+// later than that are only seen by summaries rebuilt through Rebuild.
+package rollup
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	state = "rollup_state"
+
+	// Periods rolled up by a single aggregation, bounding its size.
+	maxPeriods = 1440
+)
+
+// Resolution is the period of the summaries of a collection.
+type Resolution struct {
+	Name    string
+	Seconds float64
+}
+
+// Collection returns the collection of summaries at res
+func (res Resolution) Collection() string {
+	return "rollups_" + res.Name
+}
+
+// Resolutions of the maintained summaries, finest first.
+var Resolutions = []Resolution{
+	{"1m", 60},
+	{"1h", 3600},
+	{"1d", 86400},
+}
+
+// Summary of the values of a channel and name over one period.
+type Summary struct {
+	Channel string  `bson:"channel"`
+	Name    string  `bson:"name"`
+	Time    float64 `bson:"time"`
+	Count   int     `bson:"count"`
+	Sum     float64 `bson:"sum"`
+	Min     float64 `bson:"min"`
+	Max     float64 `bson:"max"`
+}
+
+type watermark struct {
+	Resolution string  `bson:"_id"`
+	Time       float64 `bson:"time"`
+}
+
+var (
+	// Delay is the time allowed to messages to be stored after the end
+	// of their period, before the period is rolled up.
+	Delay = time.Minute
+	// Backfill is how far back summaries are first rolled up.
+	Backfill = 30 * 24 * time.Hour
+
+	mu   sync.Mutex
+	stop chan struct{}
+)
+
+// Start function rolls up ended periods every interval
+func Start(interval time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stop != nil {
+		return
+	}
+	stop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := Run(); err != nil {
+					log.WithField("module", "rollup").Errorf("Can't roll up messages: %v", err)
+				}
+			}
+		}
+	}(stop)
+}
+
+// Stop function stops the periodic rollups
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		stop = nil
+	}
+}
+
+// Run function rolls up every period ended for at least Delay, at every
+// resolution
+func Run() error {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	if err := ensureIndexes(&Db); err != nil {
+		return err
+	}
+
+	end := float64(time.Now().Add(-Delay).Unix())
+	for i, res := range Resolutions {
+		if i > 0 {
+			// Coarser periods wait for the finer summaries they are made of.
+			wm, err := Watermark(&Db, Resolutions[i-1])
+			if err != nil {
+				return err
+			}
+			end = math.Min(end, wm)
+		}
+
+		if err := advance(&Db, i, floor(end, res.Seconds)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// advance rolls up the summaries of resolution i up to end and moves
+// its watermark along
+func advance(mdb *db.MgoDb, i int, end float64) error {
+	res := Resolutions[i]
+	wm, err := Watermark(mdb, res)
+	if err != nil {
+		return err
+	}
+	if wm == 0 {
+		wm = floor(float64(time.Now().Add(-Backfill).Unix()), res.Seconds)
+	}
+
+	for wm < end {
+		to := math.Min(end, wm+maxPeriods*res.Seconds)
+		if err := rollup(mdb, i, nil, wm, to); err != nil {
+			return err
+		}
+
+		wm = to
+		if _, err := mdb.C(state).UpsertId(res.Name, watermark{res.Name, wm}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Watermark function returns the time until which the summaries of res
+// are complete, or zero if none were rolled up
+func Watermark(mdb *db.MgoDb, res Resolution) (float64, error) {
+	wm := watermark{}
+	err := mdb.C(state).FindId(res.Name).One(&wm)
+	if err == mgo.ErrNotFound {
+		return 0, nil
+	}
+	return wm.Time, err
+}
+
+// rollup replaces the summaries of resolution i within [st, et) by those
+// computed from the resolution below, narrowed to the messages matching
+// match
+func rollup(mdb *db.MgoDb, i int, match bson.M, st, et float64) error {
+	res := Resolutions[i]
+	if match == nil {
+		match = bson.M{}
+	}
+	match["time"] = bson.M{"$gte": st, "$lt": et}
+
+	var (
+		sources []string
+		group   bson.M
+	)
+	if i == 0 {
+		match["value"] = bson.M{"$exists": true}
+		names, err := mdb.MessageCollections(channelOf(match), st, et)
+		if err != nil {
+			return err
+		}
+		sources = names
+		group = bson.M{"count": bson.M{"$sum": 1}, "sum": bson.M{"$sum": "$value"},
+			"min": bson.M{"$min": "$value"}, "max": bson.M{"$max": "$value"}}
+	} else {
+		sources = []string{Resolutions[i-1].Collection()}
+		group = bson.M{"count": bson.M{"$sum": "$count"}, "sum": bson.M{"$sum": "$sum"},
+			"min": bson.M{"$min": "$min"}, "max": bson.M{"$max": "$max"}}
+	}
+	group["_id"] = bson.M{"channel": "$channel", "name": "$name", "time": period("$time", res.Seconds)}
+
+	ctx, cancel := db.Context(context.Background())
+	defer cancel()
+
+	merged := map[key]*Summary{}
+	for _, name := range sources {
+		part := []struct {
+			Key     key `bson:"_id"`
+			Summary `bson:",inline"`
+		}{}
+		pipeline := []bson.M{{"$match": match}, {"$group": group}}
+		if err := mdb.Aggregate(ctx, name, pipeline).All(&part); err != nil {
+			return err
+		}
+
+		for _, p := range part {
+			s := p.Summary
+			s.Channel, s.Name, s.Time = p.Key.Channel, p.Key.Name, p.Key.Time
+			merge(merged, p.Key, s)
+		}
+	}
+
+	c := mdb.C(res.Collection())
+	selector := bson.M{"time": bson.M{"$gte": st, "$lt": et}}
+	if ch := channelOf(match); ch != "" {
+		selector["channel"] = ch
+	}
+	if _, err := c.RemoveAll(selector); err != nil {
+		return err
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+
+	b := c.Bulk()
+	b.Unordered()
+	for _, s := range merged {
+		b.Insert(s)
+	}
+	_, err := b.Run()
+	return err
+}
+
+// key identifies the summary of a channel and name over one period
+type key struct {
+	Channel string  `bson:"channel"`
+	Name    string  `bson:"name"`
+	Time    float64 `bson:"time"`
+}
+
+// merge combines partial summaries of the same period computed on
+// different collections
+func merge(merged map[key]*Summary, k key, s Summary) {
+	m, ok := merged[k]
+	if !ok {
+		merged[k] = &s
+		return
+	}
+
+	m.Count += s.Count
+	m.Sum += s.Sum
+	m.Min = math.Min(m.Min, s.Min)
+	m.Max = math.Max(m.Max, s.Max)
+}
+
+// Rebuild function recomputes the summaries of channel overlapping
+// [st, et], after its messages in that range changed
+func Rebuild(mdb *db.MgoDb, channel string, st, et float64) error {
+	for i, res := range Resolutions {
+		wm, err := Watermark(mdb, res)
+		if err != nil {
+			return err
+		}
+
+		from, to := floor(st, res.Seconds), math.Min(wm, floor(et, res.Seconds)+res.Seconds)
+		if from >= to {
+			continue
+		}
+		if err := rollup(mdb, i, bson.M{"channel": channel}, from, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Plan function picks the coarsest resolution whose summaries can serve
+// the aggregation, into buckets of interval seconds, of messages stored
+// from st, and returns it with the time until which its summaries are
+// complete. ok is false when no summaries apply: the interval and st
+// must both be whole numbers of periods of the resolution.
+func Plan(mdb *db.MgoDb, interval, st float64) (res Resolution, until float64, ok bool, err error) {
+	for i := len(Resolutions) - 1; i >= 0; i-- {
+		res = Resolutions[i]
+		if math.Mod(interval, res.Seconds) != 0 || math.Mod(st, res.Seconds) != 0 {
+			continue
+		}
+
+		until, err = Watermark(mdb, res)
+		if err != nil || until > st {
+			return res, until, err == nil, err
+		}
+	}
+
+	return Resolution{}, 0, false, nil
+}
+
+// Pipeline function returns the aggregation of the summaries of channel,
+// narrowed to name unless empty, within [st, et) into buckets of interval
+// seconds. Buckets have the fields of a summary, with their time as _id.
+func Pipeline(channel, name string, st, et, interval float64) []bson.M {
+	match := bson.M{"channel": channel, "time": bson.M{"$gte": st, "$lt": et}}
+	if name != "" {
+		match["name"] = name
+	}
+
+	return []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":   period("$time", interval),
+			"count": bson.M{"$sum": "$count"},
+			"sum":   bson.M{"$sum": "$sum"},
+			"min":   bson.M{"$min": "$min"},
+			"max":   bson.M{"$max": "$max"},
+		}},
+	}
+}
+
+// period returns the expression of the start of the period of field
+func period(field string, seconds float64) bson.M {
+	return bson.M{"$subtract": []interface{}{field, bson.M{"$mod": []interface{}{field, seconds}}}}
+}
+
+func floor(t, seconds float64) float64 {
+	return math.Floor(t/seconds) * seconds
+}
+
+func channelOf(match bson.M) string {
+	ch, _ := match["channel"].(string)
+	return ch
+}
+
+func ensureIndexes(mdb *db.MgoDb) error {
+	for _, res := range Resolutions {
+		idx := mgo.Index{Key: []string{"channel", "name", "time"}, Unique: true, Background: true}
+		if err := mdb.C(res.Collection()).EnsureIndex(idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
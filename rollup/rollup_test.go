@@ -0,0 +1,62 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package rollup
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestMerge(t *testing.T) {
+	k := key{"c", "temperature", 60}
+	merged := map[key]*Summary{}
+
+	merge(merged, k, Summary{Count: 2, Sum: 10, Min: 4, Max: 6})
+	merge(merged, k, Summary{Count: 1, Sum: -3, Min: -3, Max: -3})
+	merge(merged, key{"c", "humidity", 60}, Summary{Count: 1, Sum: 40, Min: 40, Max: 40})
+
+	expected := Summary{Count: 3, Sum: 7, Min: -3, Max: 6}
+	if s := *merged[k]; s != expected {
+		t.Errorf("expected %+v got %+v", expected, s)
+	}
+	if len(merged) != 2 {
+		t.Errorf("expected 2 summaries got %d", len(merged))
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	match := Pipeline("c", "", 0, 3600, 600)[0]["$match"]
+	expected := bson.M{"channel": "c", "time": bson.M{"$gte": 0.0, "$lt": 3600.0}}
+	if !reflect.DeepEqual(match, expected) {
+		t.Errorf("expected match %v got %v", expected, match)
+	}
+
+	match = Pipeline("c", "temperature", 0, 3600, 600)[0]["$match"]
+	if name := match.(bson.M)["name"]; name != "temperature" {
+		t.Errorf("expected match on name temperature got %v", name)
+	}
+}
+
+func TestFloor(t *testing.T) {
+	cases := []struct {
+		t, seconds, expected float64
+	}{
+		{125.5, 60, 120},
+		{3600, 3600, 3600},
+		{86399, 86400, 0},
+	}
+
+	for i, c := range cases {
+		if f := floor(c.t, c.seconds); f != c.expected {
+			t.Errorf("case %d: expected %v got %v", i+1, c.expected, f)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package sentry reports errors to a Sentry server.
+//
+// Errors are reported by a logrus hook, so every entry logged at error
+// level or above becomes an event, tagged with the fields of the entry.
+// Events are sent in the background and dropped when too many wait.
+package sentry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+	"github.com/mainflux/mainflux-mongodb-reader/version"
+)
+
+const (
+	// Number of events waiting to be sent.
+	queueSize = 100
+	// Time limit of sending an event.
+	sendTimeout = 10 * time.Second
+)
+
+// ErrDSN indicates a malformed Sentry DSN.
+var ErrDSN = errors.New("Sentry DSN must be <scheme>://<key>@<host>/<project>")
+
+type (
+	// client sends events to the store endpoint of a project
+	client struct {
+		store  string
+		key    string
+		secret string
+		queue  chan event
+		http   *http.Client
+	}
+
+	event struct {
+		EventID    string            `json:"event_id"`
+		Timestamp  string            `json:"timestamp"`
+		Level      string            `json:"level"`
+		Logger     string            `json:"logger"`
+		Platform   string            `json:"platform"`
+		Message    string            `json:"message"`
+		Release    string            `json:"release,omitempty"`
+		ServerName string            `json:"server_name,omitempty"`
+		Tags       map[string]string `json:"tags,omitempty"`
+		Extra      map[string]string `json:"extra,omitempty"`
+	}
+
+	// hook turns log entries into events
+	hook struct {
+		c *client
+	}
+)
+
+// Init function starts reporting errors logged at error level or above
+// to the project described by dsn
+func Init(dsn string) error {
+	c, err := newClient(dsn)
+	if err != nil {
+		return err
+	}
+
+	go c.run()
+	log.AddHook(hook{c})
+	return nil
+}
+
+func newClient(dsn string) (*client, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.Host == "" {
+		return nil, ErrDSN
+	}
+
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, project := path[:i+1], path[i+1:]
+	if project == "" {
+		return nil, ErrDSN
+	}
+
+	c := &client{
+		store: fmt.Sprintf("%s://%s/%sapi/%s/store/", u.Scheme, u.Host, prefix, project),
+		key:   u.User.Username(),
+		queue: make(chan event, queueSize),
+		http:  tlsutil.HTTPClient(sendTimeout),
+	}
+	c.secret, _ = u.User.Password()
+
+	return c, nil
+}
+
+func (h hook) Levels() []log.Level {
+	return []log.Level{log.ErrorLevel, log.FatalLevel, log.PanicLevel}
+}
+
+// Fire queues the event of entry, or drops it if the queue is full
+func (h hook) Fire(entry *log.Entry) error {
+	e := event{
+		EventID:   eventID(),
+		Timestamp: entry.Time.UTC().Format("2006-01-02T15:04:05"),
+		Level:     entry.Level.String(),
+		Logger:    "mainflux-mongodb-reader",
+		Platform:  "go",
+		Message:   entry.Message,
+		Release:   version.Version,
+		Tags:      map[string]string{},
+		Extra:     map[string]string{},
+	}
+	e.ServerName, _ = os.Hostname()
+	if e.Level == "panic" {
+		e.Level = "fatal"
+	}
+
+	for k, v := range entry.Data {
+		switch k {
+		case "request_id", "channel_id", "method", "path", "module":
+			e.Tags[k] = fmt.Sprint(v)
+		default:
+			e.Extra[k] = fmt.Sprint(v)
+		}
+	}
+
+	select {
+	case h.c.queue <- e:
+	default:
+	}
+	return nil
+}
+
+// run sends queued events. Failures are printed to stderr rather than
+// logged, which would report them again.
+func (c *client) run() {
+	for e := range c.queue {
+		if err := c.send(e); err != nil {
+			fmt.Fprintf(os.Stderr, "Sentry: can't send event: %v\n", err)
+		}
+	}
+}
+
+func (c *client) send(e event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.store, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", c.auth())
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// auth returns the X-Sentry-Auth header value of the client
+func (c *client) auth() string {
+	a := fmt.Sprintf("Sentry sentry_version=7, sentry_client=mainflux-mongodb-reader/%s, sentry_timestamp=%d, sentry_key=%s",
+		version.Version, time.Now().Unix(), c.key)
+	if c.secret != "" {
+		a += ", sentry_secret=" + c.secret
+	}
+	return a
+}
+
+func eventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,300 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package stream watches the messages collection and fans newly stored
+// messages out to per-channel subscribers.
+//
+// The vendored mgo driver has no change stream support, so a single shared
+// watcher polls for messages whose ObjectId is newer than the last one seen.
+// The last seen ObjectId is the resume token; it is persisted after every
+// batch so a restarted reader continues where the previous one stopped.
+package stream
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	checkpoints  = "stream_checkpoints"
+	checkpointID = "messages"
+
+	// Buffered events per subscriber. A subscriber falling further behind
+	// is dropped instead of stalling delivery to everyone else.
+	bufferSize = 256
+
+	// Upper bound of messages fetched in a single poll.
+	batchSize = 1000
+)
+
+// Subscription receives messages stored on a single channel.
+type Subscription struct {
+	// C delivers messages in ObjectId order. It is closed when the
+	// subscription is closed or dropped for being too slow.
+	C <-chan models.StoredMessage
+
+	ch      chan models.StoredMessage
+	channel string
+	m       *manager
+}
+
+// Close stops the delivery of messages to the subscription.
+func (s *Subscription) Close() {
+	s.m.unsubscribe(s)
+}
+
+type manager struct {
+	mu        sync.Mutex
+	subs      map[string]map[*Subscription]bool
+	observers []func([]models.StoredMessage)
+	last      bson.ObjectId
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+var (
+	// PollInterval is the period in which the watcher looks for new messages.
+	PollInterval = time.Second
+
+	defaultManager = newManager()
+)
+
+func newManager() *manager {
+	return &manager{subs: map[string]map[*Subscription]bool{}}
+}
+
+// Start function loads the persisted resume token and starts watching
+func Start() {
+	defaultManager.start()
+}
+
+// Stop function stops watching and closes all subscriptions
+func Stop() {
+	defaultManager.shutdown()
+}
+
+// Subscribe function returns a subscription to new messages of a channel
+func Subscribe(channel string) *Subscription {
+	return defaultManager.subscribe(channel)
+}
+
+// Observe function has f called with every batch of new messages, of all
+// channels, before the batch is checkpointed. f runs on the watcher, so
+// it holds back delivery to subscribers while it runs.
+func Observe(f func([]models.StoredMessage)) {
+	defaultManager.mu.Lock()
+	defer defaultManager.mu.Unlock()
+
+	defaultManager.observers = append(defaultManager.observers, f)
+}
+
+func (m *manager) start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stop != nil {
+		return
+	}
+
+	m.last = loadCheckpoint()
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.watch(m.stop, m.done)
+}
+
+func (m *manager) shutdown() {
+	m.mu.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, subs := range m.subs {
+		for s := range subs {
+			close(s.ch)
+		}
+	}
+	m.subs = map[string]map[*Subscription]bool{}
+}
+
+func (m *manager) subscribe(channel string) *Subscription {
+	ch := make(chan models.StoredMessage, bufferSize)
+	s := &Subscription{C: ch, ch: ch, channel: channel, m: m}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.subs[channel] == nil {
+		m.subs[channel] = map[*Subscription]bool{}
+	}
+	m.subs[channel][s] = true
+
+	return s
+}
+
+func (m *manager) unsubscribe(s *Subscription) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(s)
+}
+
+// remove must be called with the lock held.
+func (m *manager) remove(s *Subscription) {
+	subs, ok := m.subs[s.channel]
+	if !ok || !subs[s] {
+		return
+	}
+
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(m.subs, s.channel)
+	}
+	close(s.ch)
+}
+
+func (m *manager) watch(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			msgs, err := fetch(m.last)
+			if err != nil {
+				log.Print(err)
+				break
+			}
+			if len(msgs) == 0 {
+				break
+			}
+
+			m.dispatch(msgs)
+			m.observe(msgs)
+			m.last = msgs[len(msgs)-1].ID
+			saveCheckpoint(m.last)
+
+			if len(msgs) < batchSize {
+				break
+			}
+		}
+	}
+}
+
+// dispatch delivers messages to the subscribers of their channels.
+func (m *manager) dispatch(msgs []models.StoredMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, msg := range msgs {
+		for s := range m.subs[msg.Channel] {
+			select {
+			case s.ch <- msg:
+			default:
+				m.remove(s)
+			}
+		}
+	}
+}
+
+// observe passes messages to the observers.
+func (m *manager) observe(msgs []models.StoredMessage) {
+	m.mu.Lock()
+	observers := m.observers
+	m.mu.Unlock()
+
+	for _, f := range observers {
+		f(msgs)
+	}
+}
+
+func fetch(after bson.ObjectId) ([]models.StoredMessage, error) {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	names, err := Db.MessageCollections("", db.Earliest, db.Latest)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := []models.StoredMessage{}
+	for _, name := range names {
+		part := []models.StoredMessage{}
+		err := Db.C(name).Find(bson.M{"_id": bson.M{"$gt": after}}).
+			Sort("_id").Limit(batchSize).All(&part)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, part...)
+	}
+
+	// Keep the oldest messages of all partitions, so that none is skipped
+	// when the next poll resumes after the last one returned.
+	if len(names) > 1 {
+		sort.Sort(byID(msgs))
+		if len(msgs) > batchSize {
+			msgs = msgs[:batchSize]
+		}
+	}
+
+	return msgs, nil
+}
+
+type byID []models.StoredMessage
+
+func (s byID) Len() int           { return len(s) }
+func (s byID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+
+func loadCheckpoint() bson.ObjectId {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	cp := struct {
+		Last bson.ObjectId `bson:"last"`
+	}{}
+	if err := Db.C(checkpoints).FindId(checkpointID).One(&cp); err != nil {
+		if err != mgo.ErrNotFound {
+			log.Print(err)
+		}
+		return bson.NewObjectIdWithTime(time.Now())
+	}
+
+	return cp.Last
+}
+
+func saveCheckpoint(last bson.ObjectId) {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	if _, err := Db.C(checkpoints).UpsertId(checkpointID, bson.M{"$set": bson.M{"last": last}}); err != nil {
+		log.Print(err)
+	}
+}
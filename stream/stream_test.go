@@ -0,0 +1,67 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func message(channel string) models.StoredMessage {
+	return models.StoredMessage{
+		ID:      bson.NewObjectId(),
+		Message: models.Message{Channel: channel},
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	m := newManager()
+	a := m.subscribe("a")
+	b := m.subscribe("b")
+
+	m.dispatch([]models.StoredMessage{message("a"), message("b"), message("a")})
+
+	cases := []struct {
+		sub  *Subscription
+		want int
+	}{
+		{a, 2},
+		{b, 1},
+	}
+
+	for i, c := range cases {
+		if got := len(c.sub.C); got != c.want {
+			t.Errorf("case %d: expected %d messages got %d", i+1, c.want, got)
+		}
+	}
+}
+
+func TestDispatchDropsSlowSubscriber(t *testing.T) {
+	m := newManager()
+	s := m.subscribe("a")
+
+	msgs := []models.StoredMessage{}
+	for i := 0; i <= bufferSize; i++ {
+		msgs = append(msgs, message("a"))
+	}
+	m.dispatch(msgs)
+
+	n := 0
+	for range s.C {
+		n++
+	}
+	if n != bufferSize {
+		t.Errorf("expected %d buffered messages got %d", bufferSize, n)
+	}
+
+	// Closing a dropped subscription must be a no-op.
+	s.Close()
+}
@@ -0,0 +1,246 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package things looks up the names and metadata of channels and things
+// in the Mainflux things service, so that responses can label the IDs
+// they carry.
+//
+// Lookups are cached for TTL, including those of entities the service
+// doesn't know, and concurrent lookups of the same entity are sent once.
+package things
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/metrics"
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+)
+
+const (
+	// Time limit of a lookup.
+	requestTimeout = 5 * time.Second
+	// Largest number of cached entities.
+	maxEntries = 10000
+	// Time failed lookups are remembered, so that an unavailable service
+	// isn't asked again by every request.
+	failureTTL = 5 * time.Second
+)
+
+// Kinds of entities.
+const (
+	KindChannel = "channels"
+	KindThing   = "things"
+)
+
+var (
+	// ErrURL indicates a things service URL that isn't http(s).
+	ErrURL = errors.New("things service URL must be an http(s) URL")
+	// ErrDisabled indicates lookups without a configured service.
+	ErrDisabled = errors.New("things service not configured")
+
+	// TTL is how long looked up entities are reused.
+	TTL = 5 * time.Minute
+
+	lookups = metrics.NewCounterVec("mongo_reader_things_lookups_total",
+		"Lookups of channels and things, by outcome.", "outcome")
+
+	svc *service
+)
+
+type (
+	// Entity struct is the label of a channel or thing
+	Entity struct {
+		ID       string      `json:"id"`
+		Name     string      `json:"name,omitempty"`
+		Metadata interface{} `json:"metadata,omitempty"`
+	}
+
+	// entry is a cached lookup
+	entry struct {
+		e       Entity
+		found   bool
+		err     error
+		expires time.Time
+	}
+
+	// flight is a lookup in progress, shared by the callers looking up
+	// the same entity meanwhile
+	flight struct {
+		wg sync.WaitGroup
+		en entry
+	}
+
+	// service looks entities up at url with token
+	service struct {
+		url   string
+		token string
+		http  *http.Client
+
+		mu       sync.Mutex
+		cache    map[string]entry
+		inflight map[string]*flight
+	}
+)
+
+// Init function enables lookups in the things service at rawurl,
+// authorized by token. An empty rawurl disables them.
+func Init(rawurl, token string) error {
+	if rawurl == "" {
+		svc = nil
+		return nil
+	}
+
+	s, err := newService(rawurl, token)
+	if err != nil {
+		return err
+	}
+	svc = s
+	return nil
+}
+
+func newService(rawurl, token string) (*service, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, ErrURL
+	}
+	return &service{
+		url:   strings.TrimSuffix(rawurl, "/"),
+		token: token,
+		http:  tlsutil.HTTPClient(requestTimeout),
+	}, nil
+}
+
+// Enabled function reports whether lookups are configured
+func Enabled() bool {
+	return svc != nil
+}
+
+// Channel function returns the label of channel id, and whether the
+// service knows it
+func Channel(id string) (Entity, bool, error) {
+	if svc == nil {
+		return Entity{}, false, ErrDisabled
+	}
+	return svc.lookup(KindChannel, id)
+}
+
+// Thing function returns the label of thing id, and whether the service
+// knows it
+func Thing(id string) (Entity, bool, error) {
+	if svc == nil {
+		return Entity{}, false, ErrDisabled
+	}
+	return svc.lookup(KindThing, id)
+}
+
+// lookup returns the cached entity of kind id, or fetches it
+func (s *service) lookup(kind, id string) (Entity, bool, error) {
+	key := kind + "/" + id
+	now := time.Now()
+
+	s.mu.Lock()
+	if en, ok := s.cache[key]; ok && now.Before(en.expires) {
+		s.mu.Unlock()
+		lookups.Inc("cached")
+		return en.e, en.found, en.err
+	}
+	if f, ok := s.inflight[key]; ok {
+		s.mu.Unlock()
+		f.wg.Wait()
+		lookups.Inc("cached")
+		return f.en.e, f.en.found, f.en.err
+	}
+	f := &flight{}
+	f.wg.Add(1)
+	if s.inflight == nil {
+		s.inflight = map[string]*flight{}
+	}
+	s.inflight[key] = f
+	s.mu.Unlock()
+
+	e, found, err := s.fetch(kind, id)
+	f.en = entry{e: e, found: found, err: err, expires: now.Add(TTL)}
+	switch {
+	case err != nil:
+		f.en.expires = now.Add(failureTTL)
+		lookups.Inc("error")
+	case found:
+		lookups.Inc("found")
+	default:
+		lookups.Inc("not_found")
+	}
+
+	s.mu.Lock()
+	delete(s.inflight, key)
+	s.store(key, f.en, now)
+	s.mu.Unlock()
+	f.wg.Done()
+
+	return e, found, err
+}
+
+// store caches en under key, evicting expired entries, or all of them,
+// when the cache is full
+func (s *service) store(key string, en entry, now time.Time) {
+	if s.cache == nil {
+		s.cache = map[string]entry{}
+	}
+	if len(s.cache) >= maxEntries {
+		for k, old := range s.cache {
+			if !now.Before(old.expires) {
+				delete(s.cache, k)
+			}
+		}
+	}
+	if len(s.cache) >= maxEntries {
+		s.cache = map[string]entry{}
+	}
+	s.cache[key] = en
+}
+
+// fetch reads entity kind id from the service
+func (s *service) fetch(kind, id string) (Entity, bool, error) {
+	e := Entity{ID: id}
+
+	req, err := http.NewRequest("GET", s.url+"/"+kind+"/"+url.PathEscape(id), nil)
+	if err != nil {
+		return e, false, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", s.token)
+	}
+
+	res, err := s.http.Do(req)
+	if err != nil {
+		return e, false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusForbidden:
+		// Entities of other users are as unknown as missing ones
+		return e, false, nil
+	default:
+		return e, false, fmt.Errorf("things service answered %s", res.Status)
+	}
+
+	var body Entity
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return e, false, err
+	}
+	e.Name, e.Metadata = body.Name, body.Metadata
+	return e, true, nil
+}
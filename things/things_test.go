@@ -0,0 +1,104 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package things
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInit(t *testing.T) {
+	defer Init("", "")
+
+	for _, u := range []string{"things:8182", "ftp://things", "http://"} {
+		if err := Init(u, ""); err != ErrURL {
+			t.Errorf("%q: expected %v got %v", u, ErrURL, err)
+		}
+	}
+	if err := Init("http://things:8182/", "token"); err != nil || !Enabled() {
+		t.Fatalf("expected lookups enabled got %v", err)
+	}
+	if svc.url != "http://things:8182" {
+		t.Errorf("expected trailing slash trimmed got %s", svc.url)
+	}
+
+	Init("", "")
+	if _, _, err := Channel("1"); Enabled() || err != ErrDisabled {
+		t.Errorf("expected %v got %v", ErrDisabled, err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/channels/1":
+			w.Write([]byte(`{"id": "1", "name": "Greenhouse", "metadata": {"site": "north"}}`))
+		case "/things/2":
+			w.Write([]byte(`{"id": "2", "name": "Thermometer", "metadata": "{\"floor\": 1}"}`))
+		case "/things/3":
+			w.WriteHeader(http.StatusForbidden)
+		case "/things/4":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	s, err := newService(ts.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		kind  string
+		id    string
+		name  string
+		found bool
+		err   bool
+	}{
+		{KindChannel, "1", "Greenhouse", true, false},
+		{KindThing, "2", "Thermometer", true, false},
+		{KindThing, "3", "", false, false},
+		{KindThing, "4", "", false, true},
+		{KindChannel, "5", "", false, false},
+	}
+	for _, c := range cases {
+		e, found, err := s.lookup(c.kind, c.id)
+		if e.ID != c.id || e.Name != c.name || found != c.found || (err != nil) != c.err {
+			t.Errorf("%s/%s: expected %s, %v, error %v got %+v, %v, %v", c.kind, c.id, c.name, c.found, c.err, e, found, err)
+		}
+	}
+
+	// Lookups, failed ones included, are answered from the cache
+	n := atomic.LoadInt32(&calls)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, c := range cases {
+				s.lookup(c.kind, c.id)
+			}
+		}()
+	}
+	wg.Wait()
+	if m := atomic.LoadInt32(&calls); m != n {
+		t.Errorf("expected no further requests got %d", m-n)
+	}
+}
@@ -0,0 +1,175 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package tlsutil
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrVersion indicates an unknown or unsupported TLS version.
+	ErrVersion = errors.New("TLS version must be 1.0, 1.1 or 1.2")
+	// ErrCipher indicates an unknown cipher suite.
+	ErrCipher = errors.New("unknown TLS cipher suite")
+	// ErrFIPS indicates a policy weaker than the FIPS profile allows.
+	ErrFIPS = errors.New("FIPS profile requires TLS 1.2 and AES-GCM cipher suites")
+
+	// TLS versions, by configuration name.
+	versions = map[string]uint16{
+		"1.0": tls.VersionTLS10,
+		"1.1": tls.VersionTLS11,
+		"1.2": tls.VersionTLS12,
+	}
+
+	// Cipher suites, by their IANA name.
+	ciphers = map[string]uint16{
+		"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+		"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+		"TLS_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+		"TLS_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+		"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+		"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+		"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	}
+
+	// Cipher suites and curves of the FIPS profile: ECDHE key exchange
+	// with AES-GCM over NIST curves only.
+	fipsCiphers = []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	}
+	fipsCurves = []tls.CurveID{tls.CurveP384, tls.CurveP256}
+
+	mu     sync.RWMutex
+	policy Policy
+)
+
+// Policy struct restricts the TLS versions and cipher suites of both the
+// listener and outbound connections. Zero values keep the defaults.
+type Policy struct {
+	MinVersion   uint16
+	CipherSuites []uint16
+	Curves       []tls.CurveID
+}
+
+// ParsePolicy function returns the policy allowing TLS versions from
+// minVersion and the comma separated cipher suites in suites. The FIPS
+// profile requires TLS 1.2 and restricts suites to its own when fips is
+// set.
+func ParsePolicy(minVersion, suites string, fips bool) (Policy, error) {
+	p := Policy{}
+
+	if minVersion != "" {
+		v, ok := versions[minVersion]
+		if !ok {
+			return Policy{}, ErrVersion
+		}
+		p.MinVersion = v
+	}
+
+	for _, name := range strings.Split(suites, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		c, ok := ciphers[name]
+		if !ok {
+			return Policy{}, ErrCipher
+		}
+		p.CipherSuites = append(p.CipherSuites, c)
+	}
+
+	if !fips {
+		return p, nil
+	}
+
+	if p.MinVersion != 0 && p.MinVersion < tls.VersionTLS12 {
+		return Policy{}, ErrFIPS
+	}
+	p.MinVersion = tls.VersionTLS12
+	p.Curves = fipsCurves
+	if len(p.CipherSuites) == 0 {
+		p.CipherSuites = fipsCiphers
+		return p, nil
+	}
+	for _, c := range p.CipherSuites {
+		if !contains(fipsCiphers, c) {
+			return Policy{}, ErrFIPS
+		}
+	}
+
+	return p, nil
+}
+
+// SetPolicy function makes p the policy of configurations built from now on
+func SetPolicy(p Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	policy = p
+}
+
+// Apply function restricts cfg to the current policy
+func Apply(cfg *tls.Config) {
+	mu.RLock()
+	p := policy
+	mu.RUnlock()
+
+	if p.MinVersion > cfg.MinVersion {
+		cfg.MinVersion = p.MinVersion
+	}
+	if len(p.CipherSuites) > 0 {
+		cfg.CipherSuites = p.CipherSuites
+	}
+	if len(p.Curves) > 0 {
+		cfg.CurvePreferences = p.Curves
+	}
+}
+
+// HTTPClient function returns an HTTP client whose TLS connections follow
+// the current policy
+func HTTPClient(timeout time.Duration) *http.Client {
+	cfg := &tls.Config{}
+	Apply(cfg)
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			Dial: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).Dial,
+			TLSClientConfig:     cfg,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+func contains(a []uint16, v uint16) bool {
+	for _, x := range a {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package tlsutil
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Reloader struct serves a certificate that is reloaded from its files
+// when they change, without restarting the listener
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewReloader function loads the certificate in certFile and keyFile
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload function loads the certificate files again. The certificate in
+// use is kept if they cannot be loaded.
+func (r *Reloader) Reload() error {
+	mod := r.lastModified()
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = mod
+	r.mu.Unlock()
+
+	return nil
+}
+
+// GetCertificate function returns the current certificate, for use as
+// tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Watch function reloads the certificate every interval if its files
+// were modified, until stop is closed
+func (r *Reloader) Watch(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+
+		r.mu.RLock()
+		changed := r.lastModified().After(r.modTime)
+		r.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			log.Errorf("TLS: Can't reload certificate: %v", err)
+			continue
+		}
+		log.Printf("TLS: Reloaded certificate %s", r.certFile)
+	}
+}
+
+// lastModified returns the latest modification time of the files
+func (r *Reloader) lastModified() time.Time {
+	var mod time.Time
+	for _, f := range []string{r.certFile, r.keyFile} {
+		if fi, err := os.Stat(f); err == nil && fi.ModTime().After(mod) {
+			mod = fi.ModTime()
+		}
+	}
+	return mod
+}
@@ -0,0 +1,68 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package tlsutil builds the TLS configurations of the reader listener
+// on top of the docker/go-connections tlsconfig defaults.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"errors"
+
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// ErrClientAuth indicates an unknown client authentication mode.
+var ErrClientAuth = errors.New("client auth must be none, request, require, verify-if-given or require-and-verify")
+
+// Client authentication modes, by configuration name.
+var clientAuths = map[string]tls.ClientAuthType{
+	"":                   tls.NoClientCert,
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// ParseClientAuth function returns the client authentication mode named
+// name
+func ParseClientAuth(name string) (tls.ClientAuthType, error) {
+	a, ok := clientAuths[name]
+	if !ok {
+		return tls.NoClientCert, ErrClientAuth
+	}
+	return a, nil
+}
+
+// Server function returns the TLS configuration of a listener serving
+// the certificate in certFile and keyFile, and the reloader of that
+// certificate. Client certificates are verified against the CAs in
+// caFile only, when auth verifies them.
+func Server(certFile, keyFile, caFile string, auth tls.ClientAuthType) (*tls.Config, *Reloader, error) {
+	cfg, err := tlsconfig.Server(tlsconfig.Options{
+		CertFile:           certFile,
+		KeyFile:            keyFile,
+		CAFile:             caFile,
+		ClientAuth:         auth,
+		ExclusiveRootPools: true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	Apply(cfg)
+	cfg.Certificates = nil
+	cfg.GetCertificate = r.GetCertificate
+
+	return cfg, r, nil
+}
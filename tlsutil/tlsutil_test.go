@@ -0,0 +1,187 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package tlsutil_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux-mongodb-reader/tlsutil"
+)
+
+// writeCert writes a self-signed certificate for name and its key to dir
+func writeCert(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestParseClientAuth(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected tls.ClientAuthType
+		err      error
+	}{
+		{"", tls.NoClientCert, nil},
+		{"request", tls.RequestClientCert, nil},
+		{"require-and-verify", tls.RequireAndVerifyClientCert, nil},
+		{"always", tls.NoClientCert, tlsutil.ErrClientAuth},
+	}
+
+	for i, c := range cases {
+		a, err := tlsutil.ParseClientAuth(c.name)
+		if err != c.err || a != c.expected {
+			t.Errorf("case %d: expected %v, %v got %v, %v", i+1, c.expected, c.err, a, err)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cert, key := writeCert(t, dir, "reader")
+	ca, _ := writeCert(t, dir, "ca")
+
+	cfg, _, err := tlsutil.Server(cert, key, ca, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GetCertificate == nil || cfg.ClientCAs == nil || cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("unexpected configuration %+v", cfg)
+	}
+
+	if _, _, err := tlsutil.Server(filepath.Join(dir, "missing.crt"), key, "", tls.NoClientCert); err == nil {
+		t.Error("expected an error for a missing certificate")
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cert, key := writeCert(t, dir, "reader")
+	r, err := tlsutil.NewReloader(cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := r.GetCertificate(nil)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.Watch(10*time.Millisecond, stop)
+
+	// Make the new files visibly newer on coarse-grained file systems.
+	writeCert(t, dir, "reader")
+	later := time.Now().Add(time.Second)
+	os.Chtimes(cert, later, later)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if c, _ := r.GetCertificate(nil); c != first {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("certificate was not reloaded")
+}
+
+func TestParsePolicy(t *testing.T) {
+	cases := []struct {
+		min     string
+		ciphers string
+		fips    bool
+		err     error
+		version uint16
+		suites  int
+	}{
+		{"", "", false, nil, 0, 0},
+		{"1.2", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_RSA_WITH_AES_128_CBC_SHA", false, nil, tls.VersionTLS12, 2},
+		{"1.3", "", false, tlsutil.ErrVersion, 0, 0},
+		{"", "TLS_RSA_WITH_RC4_128_SHA", false, tlsutil.ErrCipher, 0, 0},
+		{"", "", true, nil, tls.VersionTLS12, 4},
+		{"1.2", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", true, nil, tls.VersionTLS12, 1},
+		{"1.1", "", true, tlsutil.ErrFIPS, 0, 0},
+		{"", "TLS_RSA_WITH_AES_128_GCM_SHA256", true, tlsutil.ErrFIPS, 0, 0},
+	}
+
+	for i, c := range cases {
+		p, err := tlsutil.ParsePolicy(c.min, c.ciphers, c.fips)
+		if err != c.err {
+			t.Errorf("case %d: expected error %v got %v", i+1, c.err, err)
+			continue
+		}
+		if p.MinVersion != c.version || len(p.CipherSuites) != c.suites {
+			t.Errorf("case %d: unexpected policy %+v", i+1, p)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	p, err := tlsutil.ParsePolicy("", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsutil.SetPolicy(p)
+	defer tlsutil.SetPolicy(tlsutil.Policy{})
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS10}
+	tlsutil.Apply(cfg)
+	if cfg.MinVersion != tls.VersionTLS12 || len(cfg.CipherSuites) != 4 || len(cfg.CurvePreferences) != 2 {
+		t.Errorf("unexpected configuration %+v", cfg)
+	}
+}
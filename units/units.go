@@ -0,0 +1,182 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package units keeps the registry of SenML names: the unit their values
+// are measured in, the name UIs display and the range of sensible values.
+//
+// Entries are kept in the units collection, keyed by SenML name. Message
+// reads check messages against them, and aggregations refuse to combine
+// values measured in different units.
+package units
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/mainflux/mainflux-mongodb-reader/db"
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+	"gopkg.in/mgo.v2"
+)
+
+// Collection of registry entries.
+const Collection = "units"
+
+// Violations of an entry by a message.
+const (
+	ViolationUnit  = "unit"
+	ViolationRange = "range"
+)
+
+var (
+	// Enabled turns the registry on.
+	Enabled bool
+
+	// ErrNotFound indicates a name without an entry.
+	ErrNotFound = errors.New("name not registered")
+	// ErrEntry indicates an entry without a name or with an empty range.
+	ErrEntry = errors.New("entry must have a name and a minimum no greater than its maximum")
+)
+
+type (
+	// Entry struct describes the values of a SenML name
+	Entry struct {
+		Name        string   `bson:"_id" json:"name"`
+		Unit        string   `bson:"unit,omitempty" json:"unit,omitempty"`
+		DisplayName string   `bson:"display_name,omitempty" json:"display_name,omitempty"`
+		Min         *float64 `bson:"min,omitempty" json:"min,omitempty"`
+		Max         *float64 `bson:"max,omitempty" json:"max,omitempty"`
+	}
+
+	// Catalog maps SenML names to their entries
+	Catalog map[string]Entry
+)
+
+// Validate function tells whether e is a well formed entry
+func (e Entry) Validate() error {
+	if e.Name == "" || (e.Min != nil && e.Max != nil && *e.Min > *e.Max) {
+		return ErrEntry
+	}
+	return nil
+}
+
+// List function returns all entries, sorted by name
+func List() ([]Entry, error) {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	es := []Entry{}
+	err := Db.C(Collection).Find(nil).Sort("_id").All(&es)
+	return es, err
+}
+
+// Load function returns the catalog of all entries
+func Load() (Catalog, error) {
+	es, err := List()
+	if err != nil {
+		return nil, err
+	}
+	c := Catalog{}
+	for _, e := range es {
+		c[e.Name] = e
+	}
+	return c, nil
+}
+
+// Get function returns the entry of name
+func Get(name string) (Entry, error) {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	var e Entry
+	err := Db.C(Collection).FindId(name).One(&e)
+	if err == mgo.ErrNotFound {
+		return e, ErrNotFound
+	}
+	return e, err
+}
+
+// Set function creates or replaces an entry
+func Set(e Entry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	_, err := Db.C(Collection).UpsertId(e.Name, e)
+	return err
+}
+
+// Remove function deletes the entry of name
+func Remove(name string) error {
+	Db := db.MgoDb{}
+	Db.Init()
+	defer Db.Close()
+
+	err := Db.C(Collection).RemoveId(name)
+	if err == mgo.ErrNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// unit returns the unit of m, given by itself or its base unit
+func unit(m models.Message) string {
+	if m.Unit != "" {
+		return m.Unit
+	}
+	return m.BaseUnit
+}
+
+// Check function returns the entry of the name of m, if registered, and
+// how m violates it: by a unit other than the registered one, or by a
+// value out of the registered range. Messages without a unit are taken
+// to be in the registered one.
+func (c Catalog) Check(m models.Message) (Entry, []string, bool) {
+	e, ok := c[m.Name]
+	if !ok {
+		return e, nil, false
+	}
+
+	var vs []string
+	if u := unit(m); u != "" && e.Unit != "" && u != e.Unit {
+		vs = append(vs, ViolationUnit)
+	}
+	if v := m.Value; v != nil && ((e.Min != nil && *v < *e.Min) || (e.Max != nil && *v > *e.Max)) {
+		vs = append(vs, ViolationRange)
+	}
+	return e, vs, true
+}
+
+// Units function returns the distinct units of values of the names given
+// with the units they were stored with, sorted. Values stored without
+// unit are in the registered unit of their name, if any.
+func (c Catalog) Units(stored map[string][]string) []string {
+	set := map[string]bool{}
+	for name, us := range stored {
+		for _, u := range us {
+			if u == "" {
+				u = c[name].Unit
+			}
+			if u != "" {
+				set[u] = true
+			}
+		}
+	}
+
+	res := []string{}
+	for u := range set {
+		res = append(res, u)
+	}
+	sort.Strings(res)
+	return res
+}
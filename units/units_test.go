@@ -0,0 +1,89 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package units
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/models"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		entry Entry
+		err   error
+	}{
+		{Entry{Name: "temperature", Unit: "Cel", Min: float(-40), Max: float(85)}, nil},
+		{Entry{Name: "humidity", Min: float(0)}, nil},
+		{Entry{Unit: "Cel"}, ErrEntry},
+		{Entry{Name: "temperature", Min: float(85), Max: float(-40)}, ErrEntry},
+	}
+	for i, c := range cases {
+		if err := c.entry.Validate(); err != c.err {
+			t.Errorf("case %d: expected %v got %v", i+1, c.err, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	c := Catalog{
+		"temperature": {Name: "temperature", Unit: "Cel", DisplayName: "Temperature", Min: float(-40), Max: float(85)},
+		"humidity":    {Name: "humidity", Unit: "%RH", Max: float(100)},
+	}
+
+	cases := []struct {
+		msg        models.Message
+		registered bool
+		violations []string
+	}{
+		{models.Message{Name: "temperature", Unit: "Cel", Value: float(21)}, true, nil},
+		{models.Message{Name: "temperature", Value: float(21)}, true, nil},
+		{models.Message{Name: "temperature", BaseUnit: "K", Value: float(294)}, true, []string{ViolationUnit, ViolationRange}},
+		{models.Message{Name: "temperature", Unit: "Cel", Value: float(-41)}, true, []string{ViolationRange}},
+		{models.Message{Name: "humidity", Unit: "%RH", StringValue: "high"}, true, nil},
+		{models.Message{Name: "pressure", Unit: "Pa", Value: float(1e5)}, false, nil},
+	}
+	for i, cs := range cases {
+		e, vs, ok := c.Check(cs.msg)
+		if ok != cs.registered || !reflect.DeepEqual(vs, cs.violations) {
+			t.Errorf("case %d: expected %v, %v got %v, %v", i+1, cs.registered, cs.violations, ok, vs)
+		}
+		if ok && e.Name != cs.msg.Name {
+			t.Errorf("case %d: expected entry of %s got %+v", i+1, cs.msg.Name, e)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	c := Catalog{
+		"temperature": {Name: "temperature", Unit: "Cel"},
+		"humidity":    {Name: "humidity", Unit: "%RH"},
+	}
+
+	cases := []struct {
+		stored map[string][]string
+		units  []string
+	}{
+		{map[string][]string{"temperature": {"", "Cel"}}, []string{"Cel"}},
+		{map[string][]string{"temperature": {"Cel", "K"}}, []string{"Cel", "K"}},
+		{map[string][]string{"temperature": {""}, "humidity": {""}}, []string{"%RH", "Cel"}},
+		{map[string][]string{"pressure": {""}, "temperature": {"Cel"}}, []string{"Cel"}},
+		{map[string][]string{}, []string{}},
+	}
+	for i, cs := range cases {
+		if us := c.Units(cs.stored); !reflect.DeepEqual(us, cs.units) {
+			t.Errorf("case %d: expected %v got %v", i+1, cs.units, us)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+// Package version describes the running build. Version, Commit and
+// BuildDate are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/mainflux/mainflux-mongodb-reader/version.Version=1.2.0 \
+//		-X github.com/mainflux/mainflux-mongodb-reader/version.Commit=$(git rev-parse HEAD) \
+//		-X github.com/mainflux/mainflux-mongodb-reader/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+var (
+	// Version is the release of the build.
+	Version = "dev"
+	// Commit is the git commit the build was made from.
+	Commit = "unknown"
+	// BuildDate is when the build was made.
+	BuildDate = "unknown"
+
+	mu       sync.Mutex
+	features = map[string]bool{}
+)
+
+// Info struct describes the running build
+type Info struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	BuildDate string   `json:"build_date"`
+	GoVersion string   `json:"go_version"`
+	Features  []string `json:"features"`
+}
+
+// Enable function records that the optional feature is turned on
+func Enable(feature string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	features[feature] = true
+}
+
+// Get function returns the description of the running build
+func Get() Info {
+	mu.Lock()
+	defer mu.Unlock()
+
+	fs := []string{}
+	for f := range features {
+		fs = append(fs, f)
+	}
+	sort.Strings(fs)
+
+	return Info{Version, Commit, BuildDate, runtime.Version(), fs}
+}
@@ -0,0 +1,31 @@
+/**
+ * Copyright (c) Mainflux
+ *
+ * Mainflux server is licensed under an Apache license, version 2.0.
+ * All rights not explicitly granted in the Apache license, version 2.0 are reserved.
+ * See the included LICENSE file for more details.
+ */
+
+package version_test
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/mainflux/mainflux-mongodb-reader/version"
+)
+
+func TestGet(t *testing.T) {
+	version.Enable("nats")
+	version.Enable("audit")
+	version.Enable("nats")
+
+	info := version.Get()
+	if info.Version != "dev" || info.GoVersion != runtime.Version() {
+		t.Errorf("unexpected build info %+v", info)
+	}
+	if expected := []string{"audit", "nats"}; !reflect.DeepEqual(info.Features, expected) {
+		t.Errorf("expected features %v got %v", expected, info.Features)
+	}
+}